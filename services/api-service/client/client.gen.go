@@ -0,0 +1,517 @@
+// Code generated by cmd/apigen from internal/api/openapi.json. DO NOT EDIT.
+
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// AggregateRow là schema AggregateRow của API
+type AggregateRow struct {
+	Bucket    time.Time `json:"bucket"`
+	Comments  int64     `json:"comments"`
+	Likes     int64     `json:"likes"`
+	Platform  string    `json:"platform"`
+	Posts     int64     `json:"posts"`
+	Sentiment string    `json:"sentiment"`
+	Shares    int64     `json:"shares"`
+	Topic     string    `json:"topic"`
+}
+
+// AuthorGraphResponse là schema AuthorGraphResponse của API
+type AuthorGraphResponse struct {
+	Baselines map[string]float64 `json:"baselines"`
+	Edges     []GraphEdge        `json:"edges"`
+	Nodes     []GraphNode        `json:"nodes"`
+	Topic     string             `json:"topic,omitempty"`
+}
+
+// AuthorInfluence là schema AuthorInfluence của API
+type AuthorInfluence struct {
+	Consistency        float64 `json:"consistency"`
+	Engagement         int64   `json:"engagement"`
+	Handle             string  `json:"handle"`
+	Platform           string  `json:"platform"`
+	PostCount          int64   `json:"post_count"`
+	Reach              int64   `json:"reach"`
+	RelativeEngagement float64 `json:"relative_engagement"`
+	Score              float64 `json:"score"`
+}
+
+// AuthorProfile là schema AuthorProfile của API
+type AuthorProfile struct {
+	Author          string           `json:"author"`
+	AvgSentiment    float64          `json:"avg_sentiment"`
+	EngagementRate  float64          `json:"engagement_rate"`
+	FirstSeenAt     time.Time        `json:"first_seen_at"`
+	Handle          string           `json:"handle"`
+	LastSeenAt      time.Time        `json:"last_seen_at"`
+	Platform        string           `json:"platform"`
+	PostCount       int64            `json:"post_count"`
+	Topics          map[string]int64 `json:"topics"`
+	TotalComments   int64            `json:"total_comments"`
+	TotalEngagement int64            `json:"total_engagement"`
+	TotalLikes      int64            `json:"total_likes"`
+	TotalShares     int64            `json:"total_shares"`
+}
+
+// AuthorStat là schema AuthorStat của API
+type AuthorStat struct {
+	Author          string  `json:"author"`
+	EngagementRate  float64 `json:"engagement_rate"`
+	Handle          string  `json:"handle"`
+	Platform        string  `json:"platform"`
+	PostCount       int64   `json:"post_count"`
+	TotalEngagement int64   `json:"total_engagement"`
+	TotalLikes      int64   `json:"total_likes"`
+}
+
+// ClusterResponse là schema ClusterResponse của API
+type ClusterResponse struct {
+	CanonicalPostID string         `json:"canonical_post_id"`
+	Engagement      int            `json:"engagement"`
+	Platforms       map[string]int `json:"platforms"`
+	Posts           []Post         `json:"posts"`
+	Size            int            `json:"size"`
+}
+
+// CompareResponse là schema CompareResponse của API
+type CompareResponse struct {
+	Comparison Comparison `json:"comparison"`
+	Today      DayStats   `json:"today"`
+	Yesterday  DayStats   `json:"yesterday"`
+}
+
+// Comparison là schema Comparison của API
+type Comparison struct {
+	EngagementChange int     `json:"engagement_change"`
+	PostsChange      int     `json:"posts_change"`
+	PostsPercent     float64 `json:"posts_percent"`
+}
+
+// ConsumerReplica là schema ConsumerReplica của API
+type ConsumerReplica struct {
+	BatchSize        MetricSummary  `json:"batch_size"`
+	Batches          int64          `json:"batches"`
+	FailedFlushes    int64          `json:"failed_flushes"`
+	FlushMs          MetricSummary  `json:"flush_ms"`
+	Group            string         `json:"group"`
+	Host             string         `json:"host"`
+	LastFlushAt      time.Time      `json:"last_flush_at"`
+	LatencyMs        MetricSummary  `json:"latency_ms"`
+	Partitions       []PartitionLag `json:"partitions"`
+	Processed        int64          `json:"processed"`
+	Stale            bool           `json:"stale"`
+	StartedAt        time.Time      `json:"started_at"`
+	Status           string         `json:"status"`
+	ThroughputPerSec float64        `json:"throughput_per_sec"`
+	TotalLag         int64          `json:"total_lag"`
+	UpdatedAt        time.Time      `json:"updated_at"`
+}
+
+// ConsumersResponse là schema ConsumersResponse của API
+type ConsumersResponse struct {
+	Group            string            `json:"group"`
+	Partitions       []PartitionLag    `json:"partitions"`
+	Replicas         []ConsumerReplica `json:"replicas"`
+	Status           string            `json:"status"`
+	ThroughputPerSec float64           `json:"throughput_per_sec"`
+	TotalLag         int64             `json:"total_lag"`
+}
+
+// DayStats là schema DayStats của API
+type DayStats struct {
+	Engagement int `json:"engagement"`
+	Posts      int `json:"posts"`
+}
+
+// DependencyStatus là schema DependencyStatus của API
+type DependencyStatus struct {
+	Error     string  `json:"error,omitempty"`
+	LatencyMs float64 `json:"latency_ms"`
+	Required  bool    `json:"required"`
+	Status    string  `json:"status"`
+}
+
+// ErrorDetail là schema ErrorDetail của API
+type ErrorDetail struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// ErrorResponse là schema ErrorResponse của API
+type ErrorResponse struct {
+	Error ErrorDetail `json:"error"`
+}
+
+// GraphEdge là schema GraphEdge của API
+type GraphEdge struct {
+	Engagement int64  `json:"engagement,omitempty"`
+	Source     string `json:"source"`
+	Target     string `json:"target"`
+	Weight     int64  `json:"weight"`
+}
+
+// GraphNode là schema GraphNode của API
+type GraphNode struct {
+	Author *AuthorInfluence `json:"author,omitempty"`
+	ID     string           `json:"id"`
+	Label  string           `json:"label"`
+	Type   string           `json:"type"`
+	Weight float64          `json:"weight"`
+}
+
+// HealthReport là schema HealthReport của API
+type HealthReport struct {
+	Dependencies map[string]DependencyStatus `json:"dependencies"`
+	Status       string                      `json:"status"`
+	Time         time.Time                   `json:"time"`
+}
+
+// Insight là schema Insight của API
+type Insight struct {
+	Confidence  float64   `json:"confidence"`
+	Description string    `json:"description"`
+	Timestamp   time.Time `json:"timestamp"`
+	Title       string    `json:"title"`
+	Type        string    `json:"type"`
+}
+
+// InsightsResponse là schema InsightsResponse của API
+type InsightsResponse struct {
+	Insights []Insight `json:"insights"`
+	Message  string    `json:"message,omitempty"`
+	Total    int       `json:"total"`
+}
+
+// MetricSummary là schema MetricSummary của API
+type MetricSummary struct {
+	Avg   float64 `json:"avg"`
+	Count int64   `json:"count"`
+	Max   float64 `json:"max"`
+	Min   float64 `json:"min"`
+	P50   float64 `json:"p50"`
+	P95   float64 `json:"p95"`
+	P99   float64 `json:"p99"`
+}
+
+// PartitionLag là schema PartitionLag của API
+type PartitionLag struct {
+	HighWaterMark int64     `json:"high_water_mark"`
+	Host          string    `json:"host,omitempty"`
+	Lag           int64     `json:"lag"`
+	Offset        int64     `json:"offset"`
+	Partition     int32     `json:"partition"`
+	Topic         string    `json:"topic"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// Post là schema Post của API
+type Post struct {
+	Author          string       `json:"author"`
+	AuthorHandle    string       `json:"author_handle,omitempty"`
+	CanonicalPostID string       `json:"canonical_post_id,omitempty"`
+	CanonicalURL    string       `json:"canonical_url,omitempty"`
+	Comments        int          `json:"comments"`
+	Content         string       `json:"content"`
+	CreatedAt       time.Time    `json:"created_at"`
+	ID              string       `json:"id"`
+	Likes           int          `json:"likes"`
+	Platform        string       `json:"platform"`
+	Sentiment       string       `json:"sentiment"`
+	Shares          int          `json:"shares"`
+	Title           string       `json:"title,omitempty"`
+	Topic           string       `json:"topic"`
+	Topics          []TopicScore `json:"topics,omitempty"`
+	URL             string       `json:"url,omitempty"`
+}
+
+// PostRow là schema PostRow của API
+type PostRow struct {
+	Author          string    `json:"author"`
+	CanonicalPostID string    `json:"canonical_post_id"`
+	CanonicalURL    string    `json:"canonical_url"`
+	Comments        int64     `json:"comments"`
+	Content         string    `json:"content"`
+	CreatedAt       time.Time `json:"created_at"`
+	ID              string    `json:"id"`
+	Likes           int64     `json:"likes"`
+	Platform        string    `json:"platform"`
+	Sentiment       string    `json:"sentiment"`
+	Shares          int64     `json:"shares"`
+	Title           string    `json:"title"`
+	Topic           string    `json:"topic"`
+	URL             string    `json:"url"`
+}
+
+// StatsResponse là schema StatsResponse của API
+type StatsResponse struct {
+	BySentiment map[string]int64 `json:"by_sentiment"`
+	ByTopic     map[string]int64 `json:"by_topic"`
+	TotalPosts  int64            `json:"total_posts"`
+}
+
+// Story là schema Story của API
+type Story struct {
+	CanonicalURL string    `json:"canonical_url"`
+	FirstSeenAt  time.Time `json:"first_seen_at"`
+	ID           int64     `json:"id"`
+	LastSeenAt   time.Time `json:"last_seen_at"`
+}
+
+// StoryPlatform là schema StoryPlatform của API
+type StoryPlatform struct {
+	Comments   int    `json:"comments"`
+	Engagement int    `json:"engagement"`
+	Likes      int    `json:"likes"`
+	Platform   string `json:"platform"`
+	Posts      int    `json:"posts"`
+	Shares     int    `json:"shares"`
+}
+
+// StoryResponse là schema StoryResponse của API
+type StoryResponse struct {
+	Platforms       []StoryPlatform `json:"platforms"`
+	Posts           []Post          `json:"posts"`
+	Story           Story           `json:"story"`
+	TotalEngagement int             `json:"total_engagement"`
+	TotalPosts      int             `json:"total_posts"`
+}
+
+// TaxonomyResponse là schema TaxonomyResponse của API
+type TaxonomyResponse struct {
+	Topics  []TaxonomyTopic `json:"topics"`
+	Version int             `json:"version"`
+}
+
+// TaxonomySubtopic là schema TaxonomySubtopic của API
+type TaxonomySubtopic struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Posts int64  `json:"posts"`
+}
+
+// TaxonomyTopic là schema TaxonomyTopic của API
+type TaxonomyTopic struct {
+	ID        string             `json:"id"`
+	Name      string             `json:"name"`
+	Posts     int64              `json:"posts"`
+	Subtopics []TaxonomySubtopic `json:"subtopics"`
+}
+
+// TopicScore là schema TopicScore của API
+type TopicScore struct {
+	Parent string  `json:"parent,omitempty"`
+	Score  float64 `json:"score"`
+	Topic  string  `json:"topic"`
+}
+
+// TrendingItem là schema TrendingItem của API
+type TrendingItem struct {
+	Hotness string  `json:"hotness"`
+	Post    Post    `json:"post"`
+	Score   float64 `json:"score"`
+}
+
+// TrendingResponse là schema TrendingResponse của API
+type TrendingResponse struct {
+	Total    int            `json:"total"`
+	Trending []TrendingItem `json:"trending"`
+}
+
+// ExportAggregates: Stream post counts and engagement per time bucket, topic, platform and sentiment
+// GET /api/v1/export/aggregates (scope read:analytics)
+// Caller phải Close body
+func (c *Client) ExportAggregates(ctx context.Context, opts *ExportOptions) (io.ReadCloser, error) {
+	return c.stream(ctx, "GET", "/api/v1/export/aggregates"+opts.query())
+}
+
+// ExportPosts: Stream posts matching the filters as CSV, NDJSON or Parquet
+// GET /api/v1/export/posts (scope read:posts)
+// Caller phải Close body
+func (c *Client) ExportPosts(ctx context.Context, opts *ExportOptions) (io.ReadCloser, error) {
+	return c.stream(ctx, "GET", "/api/v1/export/posts"+opts.query())
+}
+
+// GetAuthor: Author profile: activity, engagement, topics and average sentiment
+// GET /api/v1/authors/{platform}/{handle} (scope read:analytics)
+func (c *Client) GetAuthor(ctx context.Context, platform string, handle string) (*AuthorProfile, error) {
+	var out AuthorProfile
+	if _, err := c.do(ctx, "GET", "/api/v1/authors/"+url.PathEscape(platform)+"/"+url.PathEscape(handle), &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetAuthorGraph: Most influential authors with their topics and keywords as a node/edge graph
+// GET /api/v1/graph/authors (scope read:analytics)
+func (c *Client) GetAuthorGraph(ctx context.Context, opts *ListOptions) (*AuthorGraphResponse, error) {
+	var out AuthorGraphResponse
+	if _, err := c.do(ctx, "GET", "/api/v1/graph/authors"+opts.query(), &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetCluster: Cross-posted story cluster (near-duplicates) of a post
+// GET /api/v1/clusters/{id} (scope read:posts)
+func (c *Client) GetCluster(ctx context.Context, id string) (*ClusterResponse, error) {
+	var out ClusterResponse
+	if _, err := c.do(ctx, "GET", "/api/v1/clusters/"+url.PathEscape(id), &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetCompare: Posts and engagement today vs yesterday
+// GET /api/v1/compare (scope read:analytics)
+func (c *Client) GetCompare(ctx context.Context) (*CompareResponse, error) {
+	var out CompareResponse
+	if _, err := c.do(ctx, "GET", "/api/v1/compare", &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetConsumers: Consumer replicas and per-partition lag
+// GET /api/v1/consumers (scope read:analytics)
+func (c *Client) GetConsumers(ctx context.Context) (*ConsumersResponse, error) {
+	var out ConsumersResponse
+	if _, err := c.do(ctx, "GET", "/api/v1/consumers", &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetCrawlers: Last crawl time per source
+// GET /api/v1/crawlers (scope read:analytics)
+func (c *Client) GetCrawlers(ctx context.Context) (map[string]string, error) {
+	var out map[string]string
+	if _, err := c.do(ctx, "GET", "/api/v1/crawlers", &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetHealth: Dependency status (same as /readyz); 503 when PostgreSQL is down
+// GET /api/v1/health
+func (c *Client) GetHealth(ctx context.Context) (*HealthReport, error) {
+	var out HealthReport
+	if _, err := c.do(ctx, "GET", "/api/v1/health", &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetInsights: Insights detected in a time range (default last 24h)
+// GET /api/v1/insights (scope read:analytics)
+// Trả về thêm cursor của trang sau ("" ở trang cuối)
+func (c *Client) GetInsights(ctx context.Context, opts *ListOptions) (*InsightsResponse, string, error) {
+	var out InsightsResponse
+	header, err := c.do(ctx, "GET", "/api/v1/insights"+opts.query(), &out)
+	if err != nil {
+		return nil, "", err
+	}
+	return &out, header.Get("X-Next-Cursor"), nil
+}
+
+// GetOpenAPI: This OpenAPI document
+// GET /api/openapi.json
+func (c *Client) GetOpenAPI(ctx context.Context) (json.RawMessage, error) {
+	var out json.RawMessage
+	if _, err := c.do(ctx, "GET", "/api/openapi.json", &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetRecentPosts: Latest posts (from Redis when unfiltered, otherwise PostgreSQL)
+// GET /api/v1/recent (scope read:posts)
+// Trả về thêm cursor của trang sau ("" ở trang cuối)
+func (c *Client) GetRecentPosts(ctx context.Context, opts *ListOptions) ([]Post, string, error) {
+	var out []Post
+	header, err := c.do(ctx, "GET", "/api/v1/recent"+opts.query(), &out)
+	if err != nil {
+		return nil, "", err
+	}
+	return out, header.Get("X-Next-Cursor"), nil
+}
+
+// GetSentiment: Number of posts per sentiment
+// GET /api/v1/sentiment (scope read:analytics)
+func (c *Client) GetSentiment(ctx context.Context) (map[string]int64, error) {
+	var out map[string]int64
+	if _, err := c.do(ctx, "GET", "/api/v1/sentiment", &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetStats: Total posts with counts by topic and sentiment
+// GET /api/v1/stats (scope read:analytics)
+func (c *Client) GetStats(ctx context.Context) (*StatsResponse, error) {
+	var out StatsResponse
+	if _, err := c.do(ctx, "GET", "/api/v1/stats", &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetStory: Link-based story: platforms and combined engagement
+// GET /api/v1/stories/{id} (scope read:posts)
+func (c *Client) GetStory(ctx context.Context, id int64) (*StoryResponse, error) {
+	var out StoryResponse
+	if _, err := c.do(ctx, "GET", "/api/v1/stories/"+url.PathEscape(strconv.FormatInt(id, 10)), &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetTaxonomy: Topic taxonomy (topics and their subtopics) with number of posts per topic
+// GET /api/v1/taxonomy (scope read:analytics)
+func (c *Client) GetTaxonomy(ctx context.Context) (*TaxonomyResponse, error) {
+	var out TaxonomyResponse
+	if _, err := c.do(ctx, "GET", "/api/v1/taxonomy", &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetTopAuthors: Authors ranked by number of posts, engagement or engagement per post
+// GET /api/v1/authors (scope read:analytics)
+// Trả về thêm cursor của trang sau ("" ở trang cuối)
+func (c *Client) GetTopAuthors(ctx context.Context, opts *ListOptions) ([]AuthorStat, string, error) {
+	var out []AuthorStat
+	header, err := c.do(ctx, "GET", "/api/v1/authors"+opts.query(), &out)
+	if err != nil {
+		return nil, "", err
+	}
+	return out, header.Get("X-Next-Cursor"), nil
+}
+
+// GetTopics: Number of posts per top-level topic (a post counts once for each of its topics)
+// GET /api/v1/topics (scope read:analytics)
+func (c *Client) GetTopics(ctx context.Context) (map[string]int64, error) {
+	var out map[string]int64
+	if _, err := c.do(ctx, "GET", "/api/v1/topics", &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GetTrending: Trending posts in a time range (default last 7 days)
+// GET /api/v1/trending (scope read:posts)
+// Trả về thêm cursor của trang sau ("" ở trang cuối)
+func (c *Client) GetTrending(ctx context.Context, opts *ListOptions) (*TrendingResponse, string, error) {
+	var out TrendingResponse
+	header, err := c.do(ctx, "GET", "/api/v1/trending"+opts.query(), &out)
+	if err != nil {
+		return nil, "", err
+	}
+	return &out, header.Get("X-Next-Cursor"), nil
+}
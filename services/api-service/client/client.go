@@ -0,0 +1,230 @@
+// =====================================================
+// CLIENT - Go client của Social Insight API
+// =====================================================
+// Mô tả: Phần viết tay của client (HTTP, auth, lỗi). Kiểu response
+// và method cho từng endpoint nằm trong client.gen.go, sinh từ
+// internal/api/openapi.json bằng `go generate ./internal/api`
+//
+// Dùng:
+//   c := client.New("http://localhost:8080", os.Getenv("SI_API_KEY"))
+//   stats, err := c.GetStats(ctx)
+//   posts, next, err := c.GetRecentPosts(ctx, &client.ListOptions{Limit: 50, Topic: "ai"})
+//   more, _, err := c.GetRecentPosts(ctx, &client.ListOptions{Limit: 50, Topic: "ai", Cursor: next})
+//   body, err := c.ExportPosts(ctx, &client.ExportOptions{Format: "parquet", Topic: "ai"})
+//   defer body.Close()
+//   var apiErr *client.Error
+//   if errors.As(err, &apiErr) && apiErr.StatusCode == 429 { ... }
+// =====================================================
+
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Client gọi REST API
+type Client struct {
+	BaseURL    string       // Ví dụ http://localhost:8080
+	APIKey     string       // Gửi qua X-API-Key ("" khi AUTH_ENABLED=false)
+	HTTPClient *http.Client // nil = client mặc định timeout 30s
+}
+
+// New tạo Client
+func New(baseURL, apiKey string) *Client {
+	return &Client{
+		BaseURL:    strings.TrimRight(baseURL, "/"),
+		APIKey:     apiKey,
+		HTTPClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Error là lỗi API trả về (body {"error": {code, message}})
+type Error struct {
+	StatusCode int
+	Code       string
+	Message    string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("api error %d %s: %s", e.StatusCode, e.Code, e.Message)
+}
+
+// ListOptions là query params của các endpoint danh sách
+// (zero value = mặc định của server)
+type ListOptions struct {
+	Limit  int
+	Offset int
+	Cursor string // Cursor trang sau, trả về từ lần gọi trước
+
+	From     time.Time
+	To       time.Time
+	Topic    string
+	Platform string
+
+	Sort     string // posts | engagement | engagement_rate (chỉ GetTopAuthors)
+	MinPosts int    // Chỉ GetTopAuthors, GetAuthorGraph
+	Keywords int    // Keywords mỗi tác giả (chỉ GetAuthorGraph; 0 = mặc định của server)
+}
+
+// query trả về "?limit=..." ("" nếu không có params)
+func (o *ListOptions) query() string {
+	if o == nil {
+		return ""
+	}
+	v := url.Values{}
+	if o.Limit > 0 {
+		v.Set("limit", strconv.Itoa(o.Limit))
+	}
+	if o.Offset > 0 {
+		v.Set("offset", strconv.Itoa(o.Offset))
+	}
+	if o.Cursor != "" {
+		v.Set("cursor", o.Cursor)
+	}
+	if !o.From.IsZero() {
+		v.Set("from", o.From.Format(time.RFC3339))
+	}
+	if !o.To.IsZero() {
+		v.Set("to", o.To.Format(time.RFC3339))
+	}
+	if o.Topic != "" {
+		v.Set("topic", o.Topic)
+	}
+	if o.Platform != "" {
+		v.Set("platform", o.Platform)
+	}
+	if o.Sort != "" {
+		v.Set("sort", o.Sort)
+	}
+	if o.MinPosts > 0 {
+		v.Set("min_posts", strconv.Itoa(o.MinPosts))
+	}
+	if o.Keywords > 0 {
+		v.Set("keywords", strconv.Itoa(o.Keywords))
+	}
+	if len(v) == 0 {
+		return ""
+	}
+	return "?" + v.Encode()
+}
+
+// ExportOptions là query params của các endpoint export
+// (zero value = mặc định của server: csv, không lọc).
+// HTTPClient.Timeout tính cả thời gian đọc body: export lớn nên dùng
+// HTTPClient không timeout và giới hạn bằng ctx
+type ExportOptions struct {
+	Format   string // csv | ndjson | parquet
+	Interval string // hour | day | week (chỉ ExportAggregates)
+
+	From     time.Time
+	To       time.Time
+	Topic    string
+	Platform string
+}
+
+// query trả về "?format=..." ("" nếu không có params)
+func (o *ExportOptions) query() string {
+	if o == nil {
+		return ""
+	}
+	v := url.Values{}
+	if o.Format != "" {
+		v.Set("format", o.Format)
+	}
+	if o.Interval != "" {
+		v.Set("interval", o.Interval)
+	}
+	if !o.From.IsZero() {
+		v.Set("from", o.From.Format(time.RFC3339))
+	}
+	if !o.To.IsZero() {
+		v.Set("to", o.To.Format(time.RFC3339))
+	}
+	if o.Topic != "" {
+		v.Set("topic", o.Topic)
+	}
+	if o.Platform != "" {
+		v.Set("platform", o.Platform)
+	}
+	if len(v) == 0 {
+		return ""
+	}
+	return "?" + v.Encode()
+}
+
+// do gửi request, decode body JSON vào out và trả về response headers
+func (c *Client) do(ctx context.Context, method, path string, out interface{}) (http.Header, error) {
+	resp, err := c.send(ctx, method, path, "application/json")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return nil, fmt.Errorf("decode %s %s: %w", method, path, err)
+	}
+	return resp.Header, nil
+}
+
+// stream gửi request và trả về body chưa đọc (file export)
+func (c *Client) stream(ctx context.Context, method, path string) (io.ReadCloser, error) {
+	resp, err := c.send(ctx, method, path, "*/*")
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// send gửi request; status >= 400 thành *Error
+func (c *Client) send(ctx context.Context, method, path, accept string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", accept)
+	if c.APIKey != "" {
+		req.Header.Set("X-API-Key", c.APIKey)
+	}
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 400 {
+		defer resp.Body.Close()
+		return nil, decodeError(resp)
+	}
+	return resp, nil
+}
+
+// decodeError đọc body lỗi; body không phải JSON thì dùng nguyên văn
+func decodeError(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	apiErr := &Error{StatusCode: resp.StatusCode}
+
+	var payload struct {
+		Error struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if json.Unmarshal(body, &payload) == nil && payload.Error.Code != "" {
+		apiErr.Code = payload.Error.Code
+		apiErr.Message = payload.Error.Message
+		return apiErr
+	}
+	apiErr.Message = strings.TrimSpace(string(body))
+	return apiErr
+}
@@ -10,142 +10,388 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
 	"social-insight/config"
+	"social-insight/internal/api"
+	"social-insight/internal/auth"
+	"social-insight/internal/cache"
 	"social-insight/internal/database"
+	"social-insight/internal/export"
+	"social-insight/internal/gql"
+	"social-insight/internal/health"
+	"social-insight/internal/kafka"
+	"social-insight/internal/logger"
+	"social-insight/internal/metrics"
 	"social-insight/internal/models"
+	"social-insight/internal/ratelimit"
 	redisclient "social-insight/internal/redis"
+	"social-insight/internal/server"
+	"social-insight/internal/tracing"
 )
 
 // Server chứa các dependencies
 type Server struct {
 	redis *redisclient.Client
 	db    *database.DB
+
+	// health kiểm tra dependencies; Redis down → degraded mode
+	health *health.Checker
+
+	// consumerGroup là group của consumer cần theo dõi (/api/consumers)
+	consumerGroup string
+
+	// exportTimeout giới hạn thời gian một export (thay cho handler/write timeout)
+	exportTimeout time.Duration
 }
 
+// consumerStaleAfter: replica không ghi snapshot quá thời gian này bị coi là stale
+const consumerStaleAfter = 30 * time.Second
+
+// authUsageFlushInterval là chu kỳ ghi usage counters của API key xuống Postgres
+const authUsageFlushInterval = 30 * time.Second
+
+// headerDegraded báo response được dựng thiếu dependency (giá trị: tên dependency)
+const headerDegraded = "X-Degraded"
+
+// exportFlushRows: số dòng export giữa hai lần flush ra client
+const exportFlushRows = 1000
+
 // =====================================================
-// MIDDLEWARE
+// HELPERS
 // =====================================================
 
-// enableCORS thêm CORS headers cho mọi response
-func enableCORS(next http.HandlerFunc) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+// jsonResponse helper để trả về JSON
+func jsonResponse(w http.ResponseWriter, data interface{}) {
+	server.WriteJSON(w, http.StatusOK, data)
+}
 
-		if r.Method == "OPTIONS" {
-			w.WriteHeader(http.StatusOK)
-			return
-		}
+// internalError log lỗi và trả 500 với message chung
+// (chi tiết lỗi DB không lộ ra client, tra theo request id trong log)
+func internalError(w http.ResponseWriter, r *http.Request, err error) {
+	slog.ErrorContext(r.Context(), "request failed", logger.Err(err))
+	server.WriteError(w, http.StatusInternalServerError, "internal server error")
+}
 
-		next(w, r)
+// cache trả về Redis client gắn context của request
+// nil khi Redis đang down (degraded mode: handler đọc thẳng PostgreSQL)
+func (s *Server) cache(r *http.Request) *redisclient.Client {
+	return s.cacheContext(r.Context())
+}
+
+// cacheContext như cache, cho code không có *http.Request (resolver GraphQL)
+func (s *Server) cacheContext(ctx context.Context) *redisclient.Client {
+	if s.redis == nil || !s.health.Up("redis") {
+		return nil
 	}
+	return s.redis.WithContext(ctx)
 }
 
-// jsonResponse helper để trả về JSON
-func jsonResponse(w http.ResponseWriter, data interface{}) {
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(data)
+// markDegraded báo client response không có dữ liệu từ Redis
+func markDegraded(w http.ResponseWriter) {
+	w.Header().Set(headerDegraded, "redis")
+}
+
+// describeRange mô tả khoảng thời gian của q ("in last 24h" khi to = bây giờ)
+func describeRange(q api.ListQuery) string {
+	if time.Since(q.To) < time.Minute {
+		return "in last " + strings.TrimSuffix(strings.TrimSuffix(q.To.Sub(q.From).Round(time.Hour).String(), "0s"), "0m")
+	}
+	return "from " + q.From.Format(time.RFC3339) + " to " + q.To.Format(time.RFC3339)
 }
 
 // =====================================================
 // HANDLERS
 // =====================================================
 
-// handleHealth kiểm tra health của API
-func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
-	jsonResponse(w, map[string]string{
-		"status": "ok",
-		"time":   time.Now().Format(time.RFC3339),
-	})
+// handleHealthz báo process còn sống (luôn 200), kèm trạng thái dependencies
+func (s *Server) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	jsonResponse(w, s.health.Run(r.Context()))
+}
+
+// handleReadyz trả 503 khi PostgreSQL down
+// Redis/Kafka down chỉ làm status "degraded", API vẫn phục vụ được
+// /api/health dùng chung handler này
+func (s *Server) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	report := s.health.Run(r.Context())
+	server.WriteJSON(w, report.HTTPStatus(), report)
 }
 
 // handleOverallStats trả về thống kê tổng quan
 func (s *Server) handleOverallStats(w http.ResponseWriter, r *http.Request) {
+	stats, degraded, err := s.overallStats(r.Context())
+	if degraded {
+		markDegraded(w)
+	}
+	if err != nil {
+		internalError(w, r, err)
+		return
+	}
+	jsonResponse(w, stats)
+}
+
+// overallStats đọc thống kê tổng quan từ Redis, fallback PostgreSQL
+// (degraded = Redis down; dùng chung với field stats của GraphQL)
+func (s *Server) overallStats(ctx context.Context) (_ api.StatsResponse, degraded bool, err error) {
 	// Thử lấy từ Redis cache trước
-	stats, err := s.redis.GetStats()
-	if err != nil || stats["posts:total"] == 0 {
-		// Fallback: lấy từ PostgreSQL
-		count, _ := s.db.GetPostCount()
-		stats = map[string]int64{"posts:total": count}
-	}
-
-	jsonResponse(w, map[string]interface{}{
-		"total_posts": stats["posts:total"],
-		"by_topic": map[string]int64{
-			"ai":          stats["posts:ai"],
-			"cloud":       stats["posts:cloud"],
-			"devops":      stats["posts:devops"],
-			"programming": stats["posts:programming"],
-			"startup":     stats["posts:startup"],
-		},
-		"by_sentiment": map[string]int64{
+	var stats map[string]int64
+	if rdb := s.cacheContext(ctx); rdb != nil {
+		stats, _ = rdb.GetStats()
+	} else {
+		degraded = true
+	}
+	byTopic := topicCounts(stats)
+	if stats["posts:total"] == 0 || len(byTopic) == 0 {
+		// Fallback: đếm từ PostgreSQL (cả khi consumer chưa ghi counters topic)
+		if stats, err = s.statsFromPostgres(ctx); err != nil {
+			return api.StatsResponse{}, degraded, err
+		}
+		byTopic = topicCounts(stats)
+	}
+
+	return api.StatsResponse{
+		TotalPosts: stats["posts:total"],
+		ByTopic:    byTopic,
+		BySentiment: map[string]int64{
 			"positive": stats["sentiment:positive"],
 			"negative": stats["sentiment:negative"],
 			"neutral":  stats["sentiment:neutral"],
 		},
-	})
+	}, degraded, nil
+}
+
+// topicCounts lấy số posts mỗi topic (posts:{topic}) từ bộ counters
+func topicCounts(stats map[string]int64) map[string]int64 {
+	byTopic := make(map[string]int64)
+	for key, n := range stats {
+		if topic, ok := strings.CutPrefix(key, "posts:"); ok && topic != "total" {
+			byTopic[topic] = n
+		}
+	}
+	return byTopic
+}
+
+// statsFromPostgres dựng cùng bộ counters như Redis (posts:*, sentiment:*)
+func (s *Server) statsFromPostgres(ctx context.Context) (map[string]int64, error) {
+	db := s.db.WithContext(ctx)
+	count, err := db.GetPostCount()
+	if err != nil {
+		return nil, err
+	}
+	topics, err := db.GetStatsByTopic()
+	if err != nil {
+		return nil, err
+	}
+	sentiments, err := db.GetStatsBySentiment()
+	if err != nil {
+		return nil, err
+	}
+
+	stats := map[string]int64{"posts:total": count}
+	for topic, n := range topics {
+		stats["posts:"+topic] = n
+	}
+	for sentiment, n := range sentiments {
+		stats["sentiment:"+sentiment] = n
+	}
+	return stats, nil
 }
 
-// handleTopicStats trả về thống kê theo topic
+// handleTaxonomy trả về cây topic đang dùng kèm số posts mỗi topic
+func (s *Server) handleTaxonomy(w http.ResponseWriter, r *http.Request) {
+	version, topics, err := s.db.WithContext(r.Context()).GetTaxonomy()
+	if err != nil {
+		internalError(w, r, err)
+		return
+	}
+
+	jsonResponse(w, api.BuildTaxonomy(version, topics))
+}
+
+// handleTopicStats trả về số posts mỗi topic cấp 1
 func (s *Server) handleTopicStats(w http.ResponseWriter, r *http.Request) {
-	stats, err := s.db.GetStatsByTopic()
+	stats, err := s.db.WithContext(r.Context()).GetStatsByTopic()
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		internalError(w, r, err)
 		return
 	}
 
-	jsonResponse(w, stats)
+	jsonResponse(w, api.Counts(stats))
 }
 
 // handleSentimentStats trả về thống kê theo sentiment
 func (s *Server) handleSentimentStats(w http.ResponseWriter, r *http.Request) {
-	stats, err := s.db.GetStatsBySentiment()
+	stats, err := s.db.WithContext(r.Context()).GetStatsBySentiment()
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		internalError(w, r, err)
 		return
 	}
 
-	jsonResponse(w, stats)
+	jsonResponse(w, api.Counts(stats))
 }
 
-// handleTopAuthors trả về top tác giả
+// handleTopAuthors trả về top tác giả (mặc định 10, lọc theo list params,
+// xếp theo sort: posts | engagement | engagement_rate)
 func (s *Server) handleTopAuthors(w http.ResponseWriter, r *http.Request) {
-	authors, err := s.db.GetTopAuthors(10)
+	q, err := api.ParseListQuery(r, api.TopAuthorsLimits, time.Now())
+	if err != nil {
+		api.WriteParamError(w, err)
+		return
+	}
+	ranking, err := api.ParseAuthorRanking(r.URL.Query())
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		api.WriteParamError(w, err)
 		return
 	}
 
+	// Lấy thêm 1 phần tử để biết còn trang sau
+	authors, err := s.db.WithContext(r.Context()).GetTopAuthors(q.PostFilter(), ranking, q.Limit+1, q.Offset)
+	if err != nil {
+		internalError(w, r, err)
+		return
+	}
+	if len(authors) > q.Limit {
+		authors = authors[:q.Limit]
+		api.SetNextCursor(w, r, api.OffsetCursor(q.Offset+q.Limit))
+	}
+
 	jsonResponse(w, authors)
 }
 
-// handleRecentPosts trả về posts mới nhất từ Redis
+// handleAuthor trả về hồ sơ tác giả theo platform và handle (không phân biệt hoa thường)
+func (s *Server) handleAuthor(w http.ResponseWriter, r *http.Request) {
+	platform := server.Param(r, "platform")
+	handle := strings.ToLower(server.Param(r, "handle"))
+
+	author, err := s.db.WithContext(r.Context()).GetAuthor(platform, handle)
+	if err != nil {
+		internalError(w, r, err)
+		return
+	}
+	if author == nil {
+		server.WriteError(w, http.StatusNotFound, "author not found")
+		return
+	}
+
+	jsonResponse(w, author)
+}
+
+// handleAuthorGraph trả về đồ thị tác giả – topic/keyword: các tác giả có
+// influence cao nhất (trong topic nếu lọc) cùng topic và keywords của họ
+func (s *Server) handleAuthorGraph(w http.ResponseWriter, r *http.Request) {
+	q, err := api.ParseGraphQuery(r.URL.Query())
+	if err != nil {
+		api.WriteParamError(w, err)
+		return
+	}
+
+	db := s.db.WithContext(r.Context())
+	baselines, err := db.GetPlatformBaselines()
+	if err != nil {
+		internalError(w, r, err)
+		return
+	}
+	candidates, err := db.GetAuthorActivity(q.Filter)
+	if err != nil {
+		internalError(w, r, err)
+		return
+	}
+	authors := api.RankInfluence(candidates, baselines)
+	if len(authors) > q.Limit {
+		authors = authors[:q.Limit]
+	}
+
+	keys := make([]database.AuthorKey, len(authors))
+	for i, a := range authors {
+		keys[i] = database.AuthorKey{Platform: a.Influence.Platform, Handle: a.Influence.Handle}
+	}
+	topics, err := db.GetAuthorTopicEdges(keys, q.Filter.Topic)
+	if err != nil {
+		internalError(w, r, err)
+		return
+	}
+	var keywords []database.AuthorKeywordEdge
+	if q.Keywords > 0 {
+		if keywords, err = db.GetAuthorKeywordEdges(keys, q.Filter.Topic, q.Keywords); err != nil {
+			internalError(w, r, err)
+			return
+		}
+	}
+
+	jsonResponse(w, api.BuildAuthorGraph(q.Filter.Topic, authors, topics, keywords, baselines))
+}
+
+// handleRecentPosts trả về posts mới nhất
+// Trang đầu không filter đọc Redis recent_posts; có filter/cursor,
+// hoặc Redis down/lỗi → đọc từ PostgreSQL
 func (s *Server) handleRecentPosts(w http.ResponseWriter, r *http.Request) {
-	posts, err := s.redis.GetRecentPosts(20)
+	q, err := api.ParseListQuery(r, api.RecentPostsLimits, time.Now())
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		api.WriteParamError(w, err)
 		return
 	}
 
+	// Lấy thêm 1 post để biết còn trang sau
+	var posts []models.Post
+	rdb := s.cache(r)
+	if rdb != nil && !q.Filtered() {
+		posts, err = rdb.GetRecentPosts(int64(q.Limit + 1))
+		if err != nil {
+			slog.WarnContext(r.Context(), "redis recent posts error, using postgres", logger.Err(err))
+			posts = nil
+		}
+	}
+	if posts == nil {
+		if rdb == nil {
+			markDegraded(w)
+		}
+		posts, err = s.db.WithContext(r.Context()).ListPosts(q.PostFilter(), q.After, q.Limit+1, q.Offset)
+		if err != nil {
+			internalError(w, r, err)
+			return
+		}
+	}
+
+	if len(posts) > q.Limit {
+		posts = posts[:q.Limit]
+		last := posts[len(posts)-1]
+		api.SetNextCursor(w, r, api.KeysetCursor(last.CreatedAt, last.ID))
+	}
 	jsonResponse(w, posts)
 }
 
 // handleCrawlers trả về trạng thái last crawl cho các source
 func (s *Server) handleCrawlers(w http.ResponseWriter, r *http.Request) {
-	sources := []string{"hn", "medium", "devto"}
-	result := make(map[string]string)
-	for _, src := range sources {
-		t, err := s.redis.GetLastCrawl(src)
+	status, degraded := s.crawlerStatus(r.Context())
+	if degraded {
+		markDegraded(w)
+	}
+	jsonResponse(w, status)
+}
+
+// crawlerStatus đọc last crawl của các source từ Redis
+// (degraded = Redis down, mọi source "unknown")
+func (s *Server) crawlerStatus(ctx context.Context) (api.CrawlerStatus, bool) {
+	result := make(api.CrawlerStatus)
+	// Thời điểm crawl chỉ lưu trong Redis
+	rdb := s.cacheContext(ctx)
+	for _, src := range api.CrawlerSources {
+		if rdb == nil {
+			result[src] = "unknown"
+			continue
+		}
+		t, err := rdb.GetLastCrawl(src)
 		if err != nil {
 			result[src] = "unknown"
 			continue
@@ -156,22 +402,97 @@ func (s *Server) handleCrawlers(w http.ResponseWriter, r *http.Request) {
 			result[src] = t.Format(time.RFC3339)
 		}
 	}
-	jsonResponse(w, result)
+	return result, rdb == nil
 }
 
-// handleInsights trả về insights phát hiện được
+// handleConsumers trả về trạng thái consumer group: metrics từng replica
+// (throughput, latency, batch size, flush duration) và lag từng partition
+func (s *Server) handleConsumers(w http.ResponseWriter, r *http.Request) {
+	rdb := s.cache(r)
+	if rdb == nil {
+		markDegraded(w)
+		server.WriteError(w, http.StatusServiceUnavailable, "redis not available")
+		return
+	}
+	snapshots, err := rdb.GetConsumerMetrics(s.consumerGroup)
+	if err != nil {
+		internalError(w, r, err)
+		return
+	}
+	lags, err := rdb.GetConsumerLag(s.consumerGroup)
+	if err != nil {
+		internalError(w, r, err)
+		return
+	}
+
+	status := "ok"
+	var throughput float64
+	replicas := make([]api.ConsumerReplica, 0, len(snapshots))
+	for _, raw := range snapshots {
+		var replica api.ConsumerReplica
+		if err := json.Unmarshal([]byte(raw), &replica); err != nil {
+			continue
+		}
+		replica.Stale = time.Since(replica.UpdatedAt) > consumerStaleAfter
+		if replica.Stale {
+			replica.Status = "stale"
+		} else {
+			throughput += replica.ThroughputPerSec
+		}
+		if replica.Status != "ok" {
+			status = "degraded"
+		}
+		replicas = append(replicas, replica)
+	}
+	sort.Slice(replicas, func(i, j int) bool {
+		return replicas[i].Host < replicas[j].Host
+	})
+	if len(replicas) == 0 {
+		status = "down"
+	}
+
+	var totalLag int64
+	partitions := make([]api.PartitionLag, 0, len(lags))
+	for _, raw := range lags {
+		var partition api.PartitionLag
+		if err := json.Unmarshal([]byte(raw), &partition); err != nil {
+			continue
+		}
+		totalLag += partition.Lag
+		partitions = append(partitions, partition)
+	}
+	sort.Slice(partitions, func(i, j int) bool {
+		return partitions[i].Partition < partitions[j].Partition
+	})
+
+	jsonResponse(w, api.ConsumersResponse{
+		Group:            s.consumerGroup,
+		Status:           status,
+		TotalLag:         totalLag,
+		ThroughputPerSec: throughput,
+		Replicas:         replicas,
+		Partitions:       partitions,
+	})
+}
+
+// handleInsights trả về insights phát hiện được (mặc định trong 24h)
 func (s *Server) handleInsights(w http.ResponseWriter, r *http.Request) {
-	// Lấy posts từ 24h trước
-	posts, err := s.db.GetPostsSince(time.Now().Add(-24 * time.Hour))
+	q, err := api.ParseListQuery(r, api.InsightsLimits, time.Now())
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		api.WriteParamError(w, err)
+		return
+	}
+
+	posts, err := s.db.WithContext(r.Context()).GetPosts(q.PostFilter())
+	if err != nil {
+		internalError(w, r, err)
 		return
 	}
 
 	if len(posts) == 0 {
-		jsonResponse(w, map[string]interface{}{
-			"insights": []interface{}{},
-			"message":  "Không đủ dữ liệu để phân tích",
+		jsonResponse(w, api.InsightsResponse{
+			Insights: []api.Insight{},
+			Message:  "Không đủ dữ liệu để phân tích",
 		})
 		return
 	}
@@ -182,24 +503,39 @@ func (s *Server) handleInsights(w http.ResponseWriter, r *http.Request) {
 		topicCounts[post.Topic]++
 	}
 
-	insights := make([]map[string]interface{}, 0)
+	insights := make([]api.Insight, 0)
+	now := time.Now().UTC().Truncate(time.Second)
 
-	// Trending topics
-	for topic, count := range topicCounts {
-		if count > 3 {
-			insights = append(insights, map[string]interface{}{
-				"type":        "trending",
-				"title":       topic + " is trending",
-				"description": fmt.Sprintf("%d mentions in last 24h", count),
-				"confidence":  0.85,
-				"timestamp":   time.Now().Format(time.RFC3339),
+	// Trending topics, nhiều mentions trước (thứ tự cố định để phân trang)
+	topics := make([]string, 0, len(topicCounts))
+	for topic := range topicCounts {
+		topics = append(topics, topic)
+	}
+	sort.Slice(topics, func(i, j int) bool {
+		if topicCounts[topics[i]] != topicCounts[topics[j]] {
+			return topicCounts[topics[i]] > topicCounts[topics[j]]
+		}
+		return topics[i] < topics[j]
+	})
+	for _, topic := range topics {
+		if count := topicCounts[topic]; count > 3 {
+			insights = append(insights, api.Insight{
+				Type:        "trending",
+				Title:       topic + " is trending",
+				Description: fmt.Sprintf("%d mentions %s", count, describeRange(q)),
+				Confidence:  0.85,
+				Timestamp:   now,
 			})
 		}
 	}
 
-	jsonResponse(w, map[string]interface{}{
-		"insights": insights,
-		"total":    len(insights),
+	page, next := api.Page(insights, q)
+	if next != "" {
+		api.SetNextCursor(w, r, next)
+	}
+	jsonResponse(w, api.InsightsResponse{
+		Insights: page,
+		Total:    len(page),
 	})
 }
 
@@ -210,10 +546,10 @@ func (s *Server) handleCompare(w http.ResponseWriter, r *http.Request) {
 	yesterday := today.Add(-24 * time.Hour)
 
 	// Lấy posts hôm nay
-	todayPosts, _ := s.db.GetPostsInRange(today, now)
+	todayPosts, _ := s.db.WithContext(r.Context()).GetPostsInRange(today, now)
 
 	// Lấy posts hôm qua
-	yesterdayPosts, _ := s.db.GetPostsInRange(yesterday, today)
+	yesterdayPosts, _ := s.db.WithContext(r.Context()).GetPostsInRange(yesterday, today)
 
 	// Tính metrics
 	todayCount := len(todayPosts)
@@ -237,107 +573,280 @@ func (s *Server) handleCompare(w http.ResponseWriter, r *http.Request) {
 		yesterdayEngagement += p.Likes + p.Comments + p.Shares
 	}
 
-	jsonResponse(w, map[string]interface{}{
-		"today": map[string]interface{}{
-			"posts":      todayCount,
-			"engagement": todayEngagement,
-		},
-		"yesterday": map[string]interface{}{
-			"posts":      yesterdayCount,
-			"engagement": yesterdayEngagement,
-		},
-		"comparison": map[string]interface{}{
-			"posts_change":      todayCount - yesterdayCount,
-			"posts_percent":     percentChange,
-			"engagement_change": todayEngagement - yesterdayEngagement,
+	jsonResponse(w, api.CompareResponse{
+		Today:     api.DayStats{Posts: todayCount, Engagement: todayEngagement},
+		Yesterday: api.DayStats{Posts: yesterdayCount, Engagement: yesterdayEngagement},
+		Comparison: api.Comparison{
+			PostsChange:      todayCount - yesterdayCount,
+			PostsPercent:     percentChange,
+			EngagementChange: todayEngagement - yesterdayEngagement,
 		},
 	})
 }
 
-// handleTrending trả về trending posts
-func (s *Server) handleTrending(w http.ResponseWriter, r *http.Request) {
-	// Lấy posts từ 7 ngày trước
-	posts, err := s.db.GetPostsSince(time.Now().Add(-7 * 24 * time.Hour))
+// handleCluster trả về story cluster: post gốc và các bài cross-post gần giống
+// GET /api/clusters/{id} - id có thể là post gốc hoặc bất kỳ duplicate nào
+func (s *Server) handleCluster(w http.ResponseWriter, r *http.Request) {
+	id := server.Param(r, "id")
+
+	canonicalID, posts, err := s.db.WithContext(r.Context()).GetPostCluster(id)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		internalError(w, r, err)
 		return
 	}
-
 	if len(posts) == 0 {
-		jsonResponse(w, map[string]interface{}{
-			"trending": []interface{}{},
-		})
+		server.WriteError(w, http.StatusNotFound, "post not found")
 		return
 	}
 
-	// Calculate average engagement
-	totalEngagement := 0
+	platforms := make(map[string]int)
+	engagement := 0
 	for _, p := range posts {
-		totalEngagement += p.Likes + p.Comments + p.Shares
-	}
-	avgEngagement := totalEngagement / len(posts)
-	if avgEngagement == 0 {
-		avgEngagement = 1
+		platforms[p.Platform]++
+		engagement += p.Likes + p.Comments + p.Shares
 	}
 
-	// Simple trending algorithm: recent + highly engaged
-	type TrendingItem struct {
-		Post    models.Post `json:"post"`
-		Score   float64     `json:"score"`
-		Hotness string      `json:"hotness"` // 🔥 level
+	jsonResponse(w, api.ClusterResponse{
+		CanonicalPostID: canonicalID,
+		Size:            len(posts),
+		Platforms:       platforms,
+		Engagement:      engagement,
+		Posts:           posts,
+	})
+}
+
+// handleStory trả về một story: link đã chuẩn hóa, các nền tảng đã thảo luận
+// và engagement cộng dồn
+// GET /api/stories/{id}
+func (s *Server) handleStory(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(server.Param(r, "id"), 10, 64)
+	if err != nil {
+		server.WriteError(w, http.StatusBadRequest, "invalid story id")
+		return
 	}
 
-	trending := make([]TrendingItem, 0)
+	story, posts, err := s.db.WithContext(r.Context()).GetStory(id)
+	if err != nil {
+		internalError(w, r, err)
+		return
+	}
+	if story == nil {
+		server.WriteError(w, http.StatusNotFound, "story not found")
+		return
+	}
 
-	for _, post := range posts {
-		// Recency factor
-		hoursSince := time.Since(post.CreatedAt).Hours()
-		recencyScore := 1.0 / (1.0 + hoursSince/24.0) // Decay over 24 hours
-
-		// Engagement factor
-		engagement := post.Likes + post.Comments*2 + post.Shares*3
-		engagementScore := float64(engagement) / float64(avgEngagement)
-		if engagementScore > 2 {
-			engagementScore = 2 // Cap
+	// Tổng hợp engagement theo nền tảng
+	byPlatform := make(map[string]*models.StoryPlatform)
+	total := models.StoryPlatform{Platform: "all"}
+	for _, p := range posts {
+		sp, ok := byPlatform[p.Platform]
+		if !ok {
+			sp = &models.StoryPlatform{Platform: p.Platform}
+			byPlatform[p.Platform] = sp
 		}
+		for _, agg := range []*models.StoryPlatform{sp, &total} {
+			agg.Posts++
+			agg.Likes += p.Likes
+			agg.Comments += p.Comments
+			agg.Shares += p.Shares
+			agg.Engagement += p.Likes + p.Comments + p.Shares
+		}
+	}
 
-		// Combined score
-		score := (recencyScore * 0.4) + (engagementScore * 0.6)
+	platforms := make([]models.StoryPlatform, 0, len(byPlatform))
+	for _, sp := range byPlatform {
+		platforms = append(platforms, *sp)
+	}
+	sort.Slice(platforms, func(i, j int) bool {
+		return platforms[i].Engagement > platforms[j].Engagement
+	})
 
-		hotness := "🔥"
-		if score > 1.0 {
-			hotness = "🔥🔥"
-		}
-		if score > 1.5 {
-			hotness = "🔥🔥🔥"
-		}
+	jsonResponse(w, api.StoryResponse{
+		Story:           *story,
+		Platforms:       platforms,
+		TotalPosts:      total.Posts,
+		TotalEngagement: total.Engagement,
+		Posts:           posts,
+	})
+}
+
+// handleTrending trả về trending posts
+func (s *Server) handleTrending(w http.ResponseWriter, r *http.Request) {
+	q, err := api.ParseListQuery(r, api.TrendingLimits, time.Now())
+	if err != nil {
+		api.WriteParamError(w, err)
+		return
+	}
+
+	// Mặc định posts của 7 ngày gần nhất
+	posts, err := s.db.WithContext(r.Context()).GetPosts(q.PostFilter())
+	if err != nil {
+		internalError(w, r, err)
+		return
+	}
+
+	trending := api.RankTrending(posts, time.Now())
 
-		trending = append(trending, TrendingItem{
-			Post:    post,
-			Score:   score,
-			Hotness: hotness,
+	// Mặc định top 10
+	page, next := api.Page(trending, q)
+	if next != "" {
+		api.SetNextCursor(w, r, next)
+	}
+	jsonResponse(w, api.TrendingResponse{
+		Trending: page,
+		Total:    len(page),
+	})
+}
+
+// handleExportPosts stream posts theo filter ra CSV/NDJSON/Parquet
+func (s *Server) handleExportPosts(w http.ResponseWriter, r *http.Request) {
+	q, err := api.ParseExportQuery(r, time.Now())
+	if err != nil {
+		api.WriteParamError(w, err)
+		return
+	}
+	streamExport(w, r, "posts", q.Format, s.exportTimeout, func(ctx context.Context, write func(export.PostRow) error) error {
+		return s.db.WithContext(ctx).ExportPosts(q.Filter, func(p models.Post) error {
+			return write(export.NewPostRow(p))
+		})
+	})
+}
+
+// handleExportAggregates stream số liệu gộp theo bucket thời gian,
+// topic, platform và sentiment
+func (s *Server) handleExportAggregates(w http.ResponseWriter, r *http.Request) {
+	q, err := api.ParseExportQuery(r, time.Now())
+	if err != nil {
+		api.WriteParamError(w, err)
+		return
+	}
+	interval, err := api.ParseInterval(r.URL.Query().Get("interval"))
+	if err != nil {
+		api.WriteParamError(w, err)
+		return
+	}
+	streamExport(w, r, "aggregates", q.Format, s.exportTimeout, func(ctx context.Context, write func(export.AggregateRow) error) error {
+		return s.db.WithContext(ctx).ExportAggregates(q.Filter, interval, func(a models.PostAggregate) error {
+			return write(export.NewAggregateRow(a))
 		})
+	})
+}
+
+// streamExport ghi file export ra response trong khi run đọc từ Postgres cursor.
+// Header (Content-Type, Content-Disposition) chỉ gửi khi có byte đầu tiên:
+// lỗi trước đó → 500 JSON; lỗi giữa chừng → log và cắt connection để
+// client không nhận một file cụt trông như hoàn chỉnh
+func streamExport[T export.Row](w http.ResponseWriter, r *http.Request, kind string, format export.Format,
+	timeout time.Duration, run func(ctx context.Context, write func(T) error) error) {
+	ctx, cancel := context.WithTimeout(r.Context(), timeout)
+	defer cancel()
+
+	// Export chạy lâu hơn API_WRITE_TIMEOUT: nới deadline cho riêng request này
+	rc := http.NewResponseController(w)
+	if err := rc.SetWriteDeadline(time.Now().Add(timeout)); err != nil {
+		slog.DebugContext(ctx, "cannot extend write deadline", logger.Err(err))
 	}
 
-	// Sort by score (descending)
-	for i := 0; i < len(trending); i++ {
-		for j := i + 1; j < len(trending); j++ {
-			if trending[j].Score > trending[i].Score {
-				trending[i], trending[j] = trending[j], trending[i]
+	out := &exportResponse{w: w, header: func(h http.Header) {
+		h.Set("Content-Type", format.ContentType())
+		h.Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s-%s.%s"`,
+			kind, time.Now().UTC().Format("20060102"), format))
+	}}
+	ew := export.NewWriter[T](out, format)
+	err := run(ctx, func(row T) error {
+		if err := ew.Write(row); err != nil {
+			return err
+		}
+		if ew.Rows()%exportFlushRows == 0 {
+			if err := ew.Flush(); err != nil {
+				return err
 			}
+			rc.Flush()
 		}
+		return nil
+	})
+	if err == nil {
+		err = ew.Close()
 	}
+	metrics.ExportRows.WithLabelValues(kind, string(format)).Add(float64(ew.Rows()))
 
-	// Return top 10
-	limit := 10
-	if len(trending) < limit {
-		limit = len(trending)
+	if err != nil {
+		if !out.started {
+			internalError(w, r, err)
+			return
+		}
+		slog.ErrorContext(r.Context(), "export aborted", "kind", kind, "format", format,
+			"rows", ew.Rows(), logger.Err(err))
+		panic(http.ErrAbortHandler)
 	}
+	slog.InfoContext(r.Context(), "export done", "kind", kind, "format", format, "rows", ew.Rows())
+}
 
-	jsonResponse(w, map[string]interface{}{
-		"trending": trending[:limit],
-		"total":    limit,
-	})
+// exportResponse ghi body export, gọi header trước byte đầu tiên
+type exportResponse struct {
+	w       http.ResponseWriter
+	header  func(http.Header)
+	started bool
+}
+
+func (e *exportResponse) Write(b []byte) (int, error) {
+	if !e.started {
+		e.started = true
+		e.header(e.w.Header())
+	}
+	return e.w.Write(b)
+}
+
+// registerOperations đăng ký handler cho mọi api.Operations
+// (endpoint export vào nhóm stream, không qua Timeout middleware;
+// thứ tự ngoài → trong: rate limit theo IP, API key, rate limit theo key,
+// response cache)
+// Thiếu handler hoặc thừa handler → lỗi, để spec không lệch khỏi route thật
+func registerOperations(g, stream *server.Group, handlers map[string]http.HandlerFunc,
+	limiter *ratelimit.Limiter, authenticator *auth.Authenticator, responseCache *cache.Cache) error {
+	for _, op := range api.Operations {
+		h, ok := handlers[op.ID]
+		if !ok {
+			return fmt.Errorf("no handler for operation %s (%s %s)", op.ID, op.Method, op.Path)
+		}
+		delete(handlers, op.ID)
+
+		if op.CacheTTL > 0 {
+			h = responseCache.Wrap(op.ID, op.CacheTTL, op.CacheParams(), h)
+		}
+		if op.RateClass != "" {
+			h = limiter.Limit(op.RateClass, h)
+		}
+		if op.Scope != "" {
+			h = authenticator.Require(op.Scope, h)
+			if authenticator.Enabled() {
+				h = limiter.LimitIP(ratelimit.ClassAuth, h)
+			}
+		}
+		group := g
+		if op.Export {
+			group = stream
+		}
+		group.Handle(op.Method, op.Path, h)
+		if op.Alias != "" {
+			group.Handle(op.Method, op.Alias, deprecatedAlias(h))
+		}
+	}
+	for id := range handlers {
+		return fmt.Errorf("handler %s has no entry in api.Operations", id)
+	}
+	return nil
+}
+
+// deprecatedAlias đánh dấu route chưa có version: header Deprecation và
+// Link tới route /api/v1 tương ứng (label metrics riêng để theo dõi migration)
+func deprecatedAlias(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		successor := "/api/v1/" + strings.TrimPrefix(r.URL.Path, "/api/")
+		w.Header().Set("Deprecation", "true")
+		w.Header().Add("Link", "<"+successor+`>; rel="successor-version"`)
+		next(w, r)
+	}
 }
 
 // =====================================================
@@ -345,42 +854,39 @@ func (s *Server) handleTrending(w http.ResponseWriter, r *http.Request) {
 // =====================================================
 
 func main() {
-	fmt.Println("╔════════════════════════════════════════════════════════════╗")
-	fmt.Println("║     SOCIAL INSIGHT - API SERVER                            ║")
-	fmt.Println("║     REST API cho Dashboard                                 ║")
-	fmt.Println("╚════════════════════════════════════════════════════════════╝")
-	fmt.Println()
-
 	// Load configuration
 	if err := config.LoadEnvFile(".env"); err != nil {
-		fmt.Printf("⚠️  Warning: %v\n", err)
+		slog.Warn("cannot load .env", logger.Err(err))
 	}
 	cfg, err := config.Load()
 	if err != nil {
-		fmt.Printf("❌ Config error: %v\n", err)
+		slog.Error("config error", logger.Err(err))
 		os.Exit(1)
 	}
 	if err := cfg.Validate(); err != nil {
-		fmt.Printf("❌ Validation error: %v\n", err)
+		slog.Error("config validation error", logger.Err(err))
 		os.Exit(1)
 	}
+	logger.Setup("api", cfg.LogLevel, cfg.LogFormat)
 	cfg.LogConfig()
 
+	shutdownTracing, err := tracing.Setup(context.Background(), "api", cfg.Tracing())
+	if err != nil {
+		slog.Error("tracing setup error", logger.Err(err))
+		os.Exit(1)
+	}
+	defer tracing.Close(shutdownTracing)
+
 	// Bắt signal
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 
 	// ====== Kết nối Redis ======
-	fmt.Println("📡 Đang kết nối Redis...")
-	redisClient, err := redisclient.NewClient(cfg.RedisAddr)
-	if err != nil {
-		log.Printf("⚠️  Redis không available, một số features sẽ bị giới hạn: %v", err)
-	} else {
-		fmt.Println("✅ Đã kết nối Redis")
-	}
+	// Redis down không chặn khởi động: API chạy degraded mode (đọc PostgreSQL)
+	// và dùng lại Redis khi health check thấy Redis lên lại
+	redisClient := redisclient.Connect(cfg.RedisAddr)
 
 	// ====== Kết nối PostgreSQL ======
-	fmt.Println("📡 Đang kết nối PostgreSQL...")
 	db, err := database.NewDB(database.Config{
 		Host:     cfg.PGHost,
 		Port:     cfg.PGPort,
@@ -389,62 +895,176 @@ func main() {
 		DBName:   cfg.PGDBName,
 	})
 	if err != nil {
-		log.Fatalf("❌ Lỗi PostgreSQL: %v", err)
+		slog.Error("postgres connect error", "host", cfg.PGHost, logger.Err(err))
+		os.Exit(1)
 	}
-	fmt.Println("✅ Đã kết nối PostgreSQL")
+	slog.Info("postgres ready", "host", cfg.PGHost, "db", cfg.PGDBName)
+
+	// ====== Health checks ======
+	// Chỉ PostgreSQL là bắt buộc; Redis/Kafka down → degraded
+	kafkaPinger := kafka.NewPinger(cfg.KafkaBrokers, cfg.KafkaTopic)
+	checker := health.NewChecker(cfg.HealthCheckTimeout)
+	checker.Add("postgres", true, db.Ping)
+	checker.Add("redis", false, redisClient.Ping)
+	checker.Add("kafka", false, kafkaPinger.Ping)
+	health.LogReport(checker.Run(context.Background()))
+
+	watchCtx, stopWatch := context.WithCancel(context.Background())
+	go checker.Watch(watchCtx, cfg.HealthCheckInterval)
 
 	// Tạo server
-	server := &Server{
-		redis: redisClient,
-		db:    db,
+	srv := &Server{
+		redis:         redisClient,
+		db:            db,
+		health:        checker,
+		consumerGroup: cfg.ConsumerGroup,
+		exportTimeout: cfg.APIExportTimeout,
+	}
+
+	// ====== API keys ======
+	// AUTH_ENABLED=false: mọi route mở như trước (dev, dashboard public)
+	authenticator := auth.New(db, cfg.AuthEnabled)
+	go authenticator.Run(watchCtx, authUsageFlushInterval)
+	if !authenticator.Enabled() {
+		slog.Warn("api key authentication disabled (AUTH_ENABLED=false)")
 	}
 
+	// ====== Rate limit ======
+	// Bucket theo API key (hoặc IP) trong Redis, dùng chung giữa replicas
+	limiter := ratelimit.New(cfg.RateLimit(), srv.cache)
+
+	// ====== Response cache ======
+	// Endpoint có CacheTTL đọc Redis trước; consumer làm cache cũ hết
+	// hiệu lực sau mỗi batch. Redis down/cache tắt → chỉ còn ETag/304
+	responseCache := cache.New(func(r *http.Request) cache.Store {
+		if rdb := srv.cache(r); rdb != nil && cfg.APICacheEnabled {
+			return rdb
+		}
+		return nil
+	})
+
 	// ====== Đăng ký routes ======
-	http.HandleFunc("/api/health", enableCORS(server.handleHealth))
-	http.HandleFunc("/api/stats", enableCORS(server.handleOverallStats))
-	http.HandleFunc("/api/topics", enableCORS(server.handleTopicStats))
-	http.HandleFunc("/api/sentiment", enableCORS(server.handleSentimentStats))
-	http.HandleFunc("/api/authors", enableCORS(server.handleTopAuthors))
-	http.HandleFunc("/api/recent", enableCORS(server.handleRecentPosts))
-	http.HandleFunc("/api/crawlers", enableCORS(server.handleCrawlers))
-	http.HandleFunc("/api/insights", enableCORS(server.handleInsights))
-	http.HandleFunc("/api/compare", enableCORS(server.handleCompare))
-	http.HandleFunc("/api/trending", enableCORS(server.handleTrending))
+	// Chain của /api/* (ngoài → trong): request id, metrics, span server,
+	// access log, recover panic, CORS, gzip, timeout handler
+	// (label route/tên span = pattern đăng ký). Endpoint export dùng cùng
+	// chain nhưng không có timeout handler (nó đệm cả response trong memory)
+	router := server.NewRouter()
+	chain := []server.Middleware{
+		server.Plain(logger.RequestIDMiddleware),
+		metrics.Instrument,
+		tracing.Middleware,
+		logger.Middleware,
+		server.Plain(server.Recover),
+		server.Plain(server.CORS(server.CORSConfig{
+			AllowedOrigins: cfg.CORSAllowedOrigins,
+			AllowedHeaders: []string{"Content-Type", "Authorization", auth.HeaderAPIKey, "If-None-Match",
+				"traceparent", "tracestate", logger.HeaderRequestID},
+			ExposedHeaders: []string{logger.HeaderRequestID, headerDegraded,
+				ratelimit.HeaderLimit, ratelimit.HeaderRemaining, ratelimit.HeaderReset, "Retry-After",
+				api.HeaderNextCursor, "Link", "Deprecation", "Content-Disposition", "ETag", cache.HeaderCache},
+		})),
+		server.Plain(server.Compress),
+	}
+	streamRoutes := router.Group(chain...)
+	apiRoutes := router.Group(append(chain, server.Plain(server.Timeout(cfg.APIHandlerTimeout)))...)
+
+	// Route, scope và nhóm rate limit lấy từ api.Operations (nguồn của
+	// OpenAPI spec); scope rỗng = public, class rỗng = không rate limit.
+	// Mỗi route /api/v1/x còn có alias /api/x cho dashboard (deprecated)
+	handlers := map[string]http.HandlerFunc{
+		"GetHealth":      srv.handleReadyz,
+		"GetStats":       srv.handleOverallStats,
+		"GetTopics":      srv.handleTopicStats,
+		"GetTaxonomy":    srv.handleTaxonomy,
+		"GetSentiment":   srv.handleSentimentStats,
+		"GetTopAuthors":  srv.handleTopAuthors,
+		"GetAuthor":      srv.handleAuthor,
+		"GetAuthorGraph": srv.handleAuthorGraph,
+		"GetRecentPosts": srv.handleRecentPosts,
+		"GetCrawlers":    srv.handleCrawlers,
+		"GetConsumers":   srv.handleConsumers,
+		"GetInsights":    srv.handleInsights,
+		"GetCompare":     srv.handleCompare,
+		"GetTrending":    srv.handleTrending,
+		"GetCluster":     srv.handleCluster,
+		"GetStory":       srv.handleStory,
+
+		"ExportPosts":      srv.handleExportPosts,
+		"ExportAggregates": srv.handleExportAggregates,
+	}
+	if err := registerOperations(apiRoutes, streamRoutes, handlers, limiter, authenticator, responseCache); err != nil {
+		slog.Error("route registration error", logger.Err(err))
+		os.Exit(1)
+	}
+	apiRoutes.Handle(http.MethodGet, "/api/openapi.json", api.SpecHandler)
+
+	// GraphQL: một query thay cho nhiều lần gọi REST của dashboard.
+	// Route chỉ cần key hợp lệ, scope kiểm tra theo từng field gốc
+	graphqlHandler, err := gql.NewHandler(gql.Config{
+		Store: func(ctx context.Context) gql.Store { return db.WithContext(ctx) },
+		Stats: func(ctx context.Context) (api.StatsResponse, error) {
+			stats, _, err := srv.overallStats(ctx)
+			return stats, err
+		},
+		Crawlers: func(ctx context.Context) api.CrawlerStatus {
+			status, _ := srv.crawlerStatus(ctx)
+			return status
+		},
+		MaxComplexity: cfg.GraphQLMaxComplexity,
+		MaxDepth:      cfg.GraphQLMaxDepth,
+	})
+	if err != nil {
+		slog.Error("graphql setup error", logger.Err(err))
+		os.Exit(1)
+	}
+	graphqlRoute := authenticator.Authenticate(limiter.Limit(ratelimit.ClassHeavy, graphqlHandler.ServeHTTP))
+	if authenticator.Enabled() {
+		graphqlRoute = limiter.LimitIP(ratelimit.ClassAuth, graphqlRoute)
+	}
+	apiRoutes.Handle(http.MethodGet, "/api/v1/graphql", graphqlRoute)
+	apiRoutes.Handle(http.MethodPost, "/api/v1/graphql", graphqlRoute)
+
+	// /api/* không khớp route → 404 JSON thay vì rơi vào static files
+	router.Mount("/api/", apiRoutes.Wrap("/api/", server.NotFound))
+
+	// Probes cho docker/k8s (không access log)
+	router.Handle(http.MethodGet, "/healthz", srv.handleHealthz)
+	router.Handle(http.MethodGet, "/readyz", srv.handleReadyz)
+
+	// Prometheus metrics
+	router.Mount("/metrics", metrics.Handler())
 
 	// Serve static files cho web dashboard
-	fs := http.FileServer(http.Dir("web"))
-	http.Handle("/", fs)
+	router.Mount("/", http.FileServer(http.Dir("web")))
 
 	// ====== Start server ======
-	fmt.Printf("\n🚀 API Server đang chạy tại http://localhost%s\n", cfg.APIPort)
-	fmt.Println("   Dashboard: http://localhost:8888")
-	fmt.Println("   API Endpoints:")
-	fmt.Println("   - GET /api/health    - Health check")
-	fmt.Println("   - GET /api/stats     - Thống kê tổng quan")
-	fmt.Println("   - GET /api/topics    - Thống kê theo topic")
-	fmt.Println("   - GET /api/sentiment - Thống kê theo sentiment")
-	fmt.Println("   - GET /api/authors   - Top tác giả")
-	fmt.Println("   - GET /api/recent    - Posts mới nhất")
-	fmt.Println("   - GET /api/insights  - Phát hiện insights")
-	fmt.Println("   - GET /api/compare   - So sánh hôm nay vs hôm qua")
-	fmt.Println("   - GET /api/trending  - Top trending posts")
-	fmt.Println("\n   Nhấn Ctrl+C để dừng")
+	httpServer := server.New(cfg.Server(), router)
+	slog.Info("api server listening", "addr", cfg.APIPort, "dashboard", "http://localhost"+cfg.APIPort)
 
 	// Goroutine để chạy server
 	go func() {
-		if err := http.ListenAndServe(cfg.APIPort, nil); err != nil {
-			log.Fatalf("❌ Server error: %v", err)
+		if err := httpServer.ListenAndServe(); err != nil {
+			slog.Error("api server error", logger.Err(err))
+			os.Exit(1)
 		}
 	}()
 
 	// Đợi signal
 	<-sigChan
-	fmt.Println("\n⚠️  Đang shutdown...")
+	slog.Info("shutting down", "timeout", cfg.APIShutdownTimeout)
 
-	if redisClient != nil {
-		redisClient.Close()
+	// Ngừng nhận request mới, chờ request đang chạy xong rồi mới đóng Redis/PostgreSQL
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.APIShutdownTimeout)
+	if err := httpServer.Shutdown(ctx); err != nil {
+		slog.Warn("http shutdown incomplete, closing remaining connections", logger.Err(err))
 	}
+	cancel()
+
+	stopWatch()
+	authenticator.Flush(context.Background())
+	kafkaPinger.Close()
+	redisClient.Close()
 	db.Close()
 
-	fmt.Println("✅ Server đã dừng")
+	slog.Info("api server stopped")
 }
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"social-insight/internal/api"
+	"social-insight/internal/database"
+	"social-insight/internal/health"
+	redisclient "social-insight/internal/redis"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/alicebob/miniredis/v2"
+)
+
+// newHealthServer tạo Server trên miniredis và sqlmock, với health checks
+// như main (chỉ PostgreSQL bắt buộc)
+func newHealthServer(t *testing.T) (*Server, *miniredis.Miniredis, sqlmock.Sqlmock) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	rdb := redisclient.Connect(mr.Addr())
+	t.Cleanup(func() { rdb.Close() })
+
+	conn, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	db := database.NewDBFromConn(conn)
+
+	checker := health.NewChecker(time.Second)
+	checker.Add("postgres", true, db.Ping)
+	checker.Add("redis", false, rdb.Ping)
+	return &Server{redis: rdb, db: db, health: checker}, mr, mock
+}
+
+func TestReadyzDependencies(t *testing.T) {
+	tests := []struct {
+		name       string
+		postgres   error
+		redisDown  bool
+		wantCode   int
+		wantStatus string
+		wantDown   string
+	}{
+		{"healthy", nil, false, http.StatusOK, health.StatusOK, ""},
+		{"redis down", nil, true, http.StatusOK, health.StatusDegraded, "redis"},
+		{"postgres down", errors.New("connection refused"), false, http.StatusServiceUnavailable, health.StatusDown, "postgres"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, mr, mock := newHealthServer(t)
+			mock.ExpectPing().WillReturnError(tt.postgres)
+			if tt.redisDown {
+				mr.Close()
+			}
+
+			w := httptest.NewRecorder()
+			s.handleReadyz(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+			if w.Code != tt.wantCode {
+				t.Fatalf("code = %d, want %d", w.Code, tt.wantCode)
+			}
+			var report health.Report
+			if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+				t.Fatal(err)
+			}
+			if report.Status != tt.wantStatus {
+				t.Errorf("status = %s, want %s", report.Status, tt.wantStatus)
+			}
+			for name, dep := range report.Dependencies {
+				if down := dep.Status == health.StatusDown; down != (name == tt.wantDown) {
+					t.Errorf("%s = %+v", name, dep)
+				}
+			}
+		})
+	}
+}
+
+func TestStatsFallBackWithoutRedis(t *testing.T) {
+	s, mr, mock := newHealthServer(t)
+
+	// Redis còn sống: thống kê đọc từ counters, không chạm PostgreSQL
+	mr.Set("posts:total", "7")
+	mr.HSet(redisclient.TopicCountersKey, "ai", "7")
+	w := httptest.NewRecorder()
+	s.handleOverallStats(w, httptest.NewRequest(http.MethodGet, "/api/stats", nil))
+	if w.Code != http.StatusOK || w.Header().Get(headerDegraded) != "" {
+		t.Fatalf("healthy stats: code %d, degraded %q", w.Code, w.Header().Get(headerDegraded))
+	}
+
+	// Health check thấy Redis down → đọc thẳng PostgreSQL và báo degraded
+	mr.Close()
+	mock.ExpectPing()
+	s.health.Run(context.Background())
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM posts").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+	mock.ExpectQuery("FROM post_topics").WillReturnRows(sqlmock.NewRows([]string{"topic", "count"}).AddRow("ai", 3))
+	mock.ExpectQuery("GROUP BY sentiment").WillReturnRows(sqlmock.NewRows([]string{"sentiment", "count"}).AddRow("negative", 3))
+
+	w = httptest.NewRecorder()
+	s.handleOverallStats(w, httptest.NewRequest(http.MethodGet, "/api/stats", nil))
+	if w.Code != http.StatusOK || w.Header().Get(headerDegraded) != "redis" {
+		t.Fatalf("degraded stats: code %d, degraded %q", w.Code, w.Header().Get(headerDegraded))
+	}
+	var stats api.StatsResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &stats); err != nil {
+		t.Fatal(err)
+	}
+	if stats.TotalPosts != 3 || stats.ByTopic["ai"] != 3 || stats.BySentiment["negative"] != 3 {
+		t.Errorf("stats = %+v", stats)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
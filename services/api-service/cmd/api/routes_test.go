@@ -0,0 +1,109 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"social-insight/internal/api"
+	"social-insight/internal/auth"
+	"social-insight/internal/cache"
+	"social-insight/internal/database"
+	"social-insight/internal/ratelimit"
+	redisclient "social-insight/internal/redis"
+	"social-insight/internal/server"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+// newLimitedRouter đăng ký api.Operations (handler trả 200) với auth bật
+// trên sqlmock, rate limit trong memory: 3 request/IP trước auth,
+// 2 request/client cho nhóm default
+func newLimitedRouter(t *testing.T) (*server.Router, sqlmock.Sqlmock) {
+	t.Helper()
+	conn, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	limiter := ratelimit.New(ratelimit.Config{Rules: map[string]ratelimit.Rule{
+		ratelimit.ClassAuth:    {Limit: 3, Period: time.Minute},
+		ratelimit.ClassDefault: {Limit: 2, Period: time.Minute},
+	}}, func(*http.Request) *redisclient.Client { return nil })
+	responseCache := cache.New(func(*http.Request) cache.Store { return nil })
+
+	handlers := make(map[string]http.HandlerFunc, len(api.Operations))
+	for _, op := range api.Operations {
+		handlers[op.ID] = func(w http.ResponseWriter, r *http.Request) {}
+	}
+	router := server.NewRouter()
+	g := router.Group()
+	if err := registerOperations(g, g, handlers, limiter, auth.New(database.NewDBFromConn(conn), true), responseCache); err != nil {
+		t.Fatal(err)
+	}
+	return router, mock
+}
+
+// expectKey khớp một lần tra API key; id 0 = key không tồn tại
+func expectKey(mock sqlmock.Sqlmock, raw string, id int64) {
+	rows := sqlmock.NewRows([]string{"id", "name", "prefix", "scopes", "request_count", "last_used_at", "created_at", "revoked_at"})
+	if id != 0 {
+		rows.AddRow(id, "dashboard", auth.PrefixOf(raw), "{"+auth.ScopeReadAnalytics+"}", 0, nil, time.Now(), nil)
+	}
+	mock.ExpectQuery("FROM api_keys").WithArgs(auth.HashKey(raw)).WillReturnRows(rows)
+}
+
+func get(router http.Handler, remote, key string) int {
+	r := httptest.NewRequest(http.MethodGet, "/api/v1/topics", nil)
+	r.RemoteAddr = remote
+	r.Header.Set(auth.HeaderAPIKey, key)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, r)
+	return w.Code
+}
+
+func TestBogusKeysLimitedByIP(t *testing.T) {
+	router, mock := newLimitedRouter(t)
+
+	// Mỗi key rác khác nhau là một lần tra Postgres, tới giới hạn của IP
+	bogus := []string{"si_00000001_a", "si_00000002_b", "si_00000003_c", "si_00000004_d"}
+	for _, key := range bogus[:3] {
+		expectKey(mock, key, 0)
+		if code := get(router, "203.0.113.7:1", key); code != http.StatusUnauthorized {
+			t.Fatalf("%s: status %d", key, code)
+		}
+	}
+	// Hết token: 429 trước khi tra key, thiếu key cũng vậy
+	if code := get(router, "203.0.113.7:1", bogus[3]); code != http.StatusTooManyRequests {
+		t.Errorf("over IP limit: status %d", code)
+	}
+	if code := get(router, "203.0.113.7:1", ""); code != http.StatusTooManyRequests {
+		t.Errorf("missing key over IP limit: status %d", code)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestKeyBucketByID(t *testing.T) {
+	router, mock := newLimitedRouter(t)
+
+	// Hai key trùng prefix nhưng khác ID có bucket riêng; IP khác nhau để
+	// không chạm giới hạn theo IP
+	first, second := "si_0a1b2c3d_first", "si_0a1b2c3d_second"
+	expectKey(mock, first, 1)
+	expectKey(mock, second, 2)
+	for i, want := range []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests} {
+		if code := get(router, "198.51.100.1:1", first); code != want {
+			t.Errorf("first key request %d: status %d, want %d", i+1, code, want)
+		}
+	}
+	if code := get(router, "198.51.100.2:1", second); code != http.StatusOK {
+		t.Errorf("second key: status %d", code)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,53 @@
+// =====================================================
+// APIGEN - Sinh openapi.json và Go client
+// =====================================================
+// Mô tả: Ghi internal/api/openapi.json từ api.Spec() rồi sinh
+// client/client.gen.go từ spec đó. Chạy lại sau khi sửa kiểu
+// response hoặc api.Operations; contract test báo lỗi nếu quên
+//
+// Cách chạy (từ services/api-service):
+//   go run ./cmd/apigen
+//   go generate ./internal/api
+// =====================================================
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"social-insight/internal/api"
+)
+
+func main() {
+	specPath := flag.String("spec", "internal/api/openapi.json", "nơi ghi OpenAPI spec")
+	clientPath := flag.String("client", "client/client.gen.go", "nơi ghi Go client")
+	flag.Parse()
+
+	if err := run(*specPath, *clientPath); err != nil {
+		fmt.Fprintln(os.Stderr, "apigen:", err)
+		os.Exit(1)
+	}
+}
+
+func run(specPath, clientPath string) error {
+	spec, err := api.Spec()
+	if err != nil {
+		return fmt.Errorf("build spec: %w", err)
+	}
+	if err := os.WriteFile(specPath, spec, 0o644); err != nil {
+		return err
+	}
+
+	src, err := api.GenerateClient(spec)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(clientPath, src, 0o644); err != nil {
+		return err
+	}
+
+	fmt.Printf("wrote %s and %s\n", specPath, clientPath)
+	return nil
+}
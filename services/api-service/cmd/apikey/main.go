@@ -0,0 +1,173 @@
+// =====================================================
+// APIKEY - Quản lý API keys
+// =====================================================
+// Mô tả: Lệnh admin tạo/liệt kê/thu hồi API key trong PostgreSQL
+// Key đầy đủ chỉ in ra một lần khi tạo, DB chỉ giữ hash
+//
+// Cách chạy:
+//   go run ./cmd/apikey create -name grafana -scopes read:analytics
+//   go run ./cmd/apikey list
+//   go run ./cmd/apikey revoke -key 3f9a12bc   (ID hoặc prefix)
+// =====================================================
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"social-insight/config"
+	"social-insight/internal/auth"
+	"social-insight/internal/database"
+	"social-insight/internal/logger"
+)
+
+const usage = `usage: apikey <command> [flags]
+
+commands:
+  create -name NAME -scopes SCOPE[,SCOPE]   tạo key mới (in key một lần)
+  list                                      liệt kê keys và usage
+  revoke -key ID|PREFIX                     thu hồi key
+`
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	cmd, args := os.Args[1], os.Args[2:]
+
+	// Load config
+	if err := config.LoadEnvFile(".env"); err != nil {
+		slog.Warn("cannot load .env", logger.Err(err))
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		slog.Error("config error", logger.Err(err))
+		os.Exit(1)
+	}
+	// Log ra stderr để stdout chỉ có key/bảng (dễ pipe)
+	logger.SetupWriter(os.Stderr, "apikey", cfg.LogLevel, cfg.LogFormat)
+
+	// ====== Kết nối PostgreSQL ======
+	db, err := database.NewDB(database.Config{
+		Host:     cfg.PGHost,
+		Port:     cfg.PGPort,
+		User:     cfg.PGUser,
+		Password: cfg.PGPassword,
+		DBName:   cfg.PGDBName,
+	})
+	if err != nil {
+		slog.Error("postgres connect error", logger.Err(err))
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	switch cmd {
+	case "create":
+		err = create(db, args)
+	case "list":
+		err = list(db)
+	case "revoke":
+		err = revoke(db, args)
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	if err != nil {
+		slog.Error("apikey "+cmd+" error", logger.Err(err))
+		db.Close()
+		os.Exit(1)
+	}
+}
+
+// create sinh key, lưu hash và in key đầy đủ ra stdout
+func create(db *database.DB, args []string) error {
+	fs := flag.NewFlagSet("create", flag.ExitOnError)
+	name := fs.String("name", "", "Tên gợi nhớ của key (người dùng, hệ thống)")
+	scopes := fs.String("scopes", auth.ScopeReadPosts+","+auth.ScopeReadAnalytics,
+		"Scopes, phân cách bằng dấu phẩy ("+strings.Join(auth.Scopes, ", ")+")")
+	fs.Parse(args)
+
+	if *name == "" {
+		return fmt.Errorf("-name is required")
+	}
+	selected := make([]string, 0)
+	for _, s := range strings.Split(*scopes, ",") {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		if !auth.ValidScope(s) {
+			return fmt.Errorf("unknown scope %q (valid: %s)", s, strings.Join(auth.Scopes, ", "))
+		}
+		selected = append(selected, s)
+	}
+	if len(selected) == 0 {
+		return fmt.Errorf("at least one scope is required")
+	}
+
+	key, prefix, err := auth.GenerateKey()
+	if err != nil {
+		return err
+	}
+	created, err := db.CreateAPIKey(*name, prefix, auth.HashKey(key), selected)
+	if err != nil {
+		return err
+	}
+
+	slog.Info("api key created", "id", created.ID, "name", created.Name,
+		"prefix", created.Prefix, "scopes", strings.Join(created.Scopes, ","))
+	fmt.Fprintln(os.Stderr, "Lưu key này ngay, nó sẽ không được hiển thị lại:")
+	fmt.Println(key)
+	return nil
+}
+
+// list in bảng keys kèm usage counters
+func list(db *database.DB) error {
+	keys, err := db.ListAPIKeys()
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tNAME\tPREFIX\tSCOPES\tREQUESTS\tLAST USED\tCREATED\tSTATUS")
+	for _, k := range keys {
+		lastUsed := "-"
+		if k.LastUsedAt != nil {
+			lastUsed = k.LastUsedAt.Format(time.RFC3339)
+		}
+		status := "active"
+		if k.Revoked() {
+			status = "revoked " + k.RevokedAt.Format(time.RFC3339)
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%d\t%s\t%s\t%s\n", k.ID, k.Name, k.Prefix,
+			strings.Join(k.Scopes, ","), k.RequestCount, lastUsed, k.CreatedAt.Format(time.RFC3339), status)
+	}
+	return tw.Flush()
+}
+
+// revoke thu hồi key theo ID hoặc prefix
+func revoke(db *database.DB, args []string) error {
+	fs := flag.NewFlagSet("revoke", flag.ExitOnError)
+	key := fs.String("key", "", "ID hoặc prefix của key cần thu hồi")
+	fs.Parse(args)
+
+	if *key == "" {
+		return fmt.Errorf("-key is required")
+	}
+	ok, err := db.RevokeAPIKey(*key)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("no active key matches %q", *key)
+	}
+	slog.Info("api key revoked", "key", *key)
+	return nil
+}
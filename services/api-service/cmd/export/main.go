@@ -0,0 +1,176 @@
+// =====================================================
+// EXPORT - Dump posts/aggregates ra file
+// =====================================================
+// Mô tả: Lệnh offline đọc PostgreSQL bằng cursor và ghi CSV, NDJSON
+// hoặc Parquet (cùng định dạng và filter với GET /api/v1/export/*),
+// không qua API nên không bị rate limit/timeout của HTTP
+//
+// Cách chạy:
+//   go run ./cmd/export posts -format parquet -from 2024-01-01 -o posts.parquet
+//   go run ./cmd/export aggregates -interval week -topic ai > ai-weekly.csv
+// =====================================================
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"social-insight/config"
+	"social-insight/internal/api"
+	"social-insight/internal/database"
+	"social-insight/internal/export"
+	"social-insight/internal/logger"
+	"social-insight/internal/models"
+)
+
+const usage = `usage: export posts|aggregates [flags]
+
+flags:
+  -format csv|ndjson|parquet   định dạng file (mặc định csv)
+  -from, -to DATE              RFC3339 hoặc YYYY-MM-DD (mặc định: mọi post)
+  -topic, -platform NAME       lọc theo topic/platform
+  -interval hour|day|week      độ rộng bucket (chỉ aggregates, mặc định day)
+  -o FILE                      file output (mặc định stdout)
+`
+
+func main() {
+	if len(os.Args) < 2 || (os.Args[1] != "posts" && os.Args[1] != "aggregates") {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+	kind := os.Args[1]
+
+	fs := flag.NewFlagSet("export "+kind, flag.ExitOnError)
+	fs.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	format := fs.String("format", "", "csv | ndjson | parquet")
+	from := fs.String("from", "", "lower bound of created_at")
+	to := fs.String("to", "", "upper bound of created_at")
+	topic := fs.String("topic", "", "topic filter")
+	platform := fs.String("platform", "", "platform filter")
+	interval := fs.String("interval", "", "hour | day | week (aggregates)")
+	output := fs.String("o", "", "output file (default stdout)")
+	fs.Parse(os.Args[2:])
+
+	// Load config
+	if err := config.LoadEnvFile(".env"); err != nil {
+		slog.Warn("cannot load .env", logger.Err(err))
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		slog.Error("config error", logger.Err(err))
+		os.Exit(1)
+	}
+	// Log ra stderr để stdout chỉ có dữ liệu export (dễ pipe)
+	logger.SetupWriter(os.Stderr, "export", cfg.LogLevel, cfg.LogFormat)
+
+	// Filter giống query params của API
+	values := url.Values{}
+	for name, v := range map[string]string{"format": *format, "from": *from, "to": *to, "topic": *topic, "platform": *platform} {
+		if v != "" {
+			values.Set(name, v)
+		}
+	}
+	q, err := api.ParseExportValues(values, time.Now())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid flag: %v\n", err)
+		os.Exit(2)
+	}
+	bucket, err := api.ParseInterval(*interval)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid flag: %v\n", err)
+		os.Exit(2)
+	}
+
+	// ====== Kết nối PostgreSQL ======
+	db, err := database.NewDB(database.Config{
+		Host:     cfg.PGHost,
+		Port:     cfg.PGPort,
+		User:     cfg.PGUser,
+		Password: cfg.PGPassword,
+		DBName:   cfg.PGDBName,
+	})
+	if err != nil {
+		slog.Error("postgres connect error", logger.Err(err))
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	// Ctrl-C dừng export (cursor bị huỷ theo context)
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	start := time.Now()
+	var rows int64
+	err = writeOutput(*output, func(w io.Writer) error {
+		var err error
+		switch kind {
+		case "posts":
+			rows, err = run(w, q.Format, func(write func(export.PostRow) error) error {
+				return db.WithContext(ctx).ExportPosts(q.Filter, func(p models.Post) error {
+					return write(export.NewPostRow(p))
+				})
+			})
+		default:
+			rows, err = run(w, q.Format, func(write func(export.AggregateRow) error) error {
+				return db.WithContext(ctx).ExportAggregates(q.Filter, bucket, func(a models.PostAggregate) error {
+					return write(export.NewAggregateRow(a))
+				})
+			})
+		}
+		return err
+	})
+	if err != nil {
+		slog.Error("export failed", "kind", kind, "rows", rows, logger.Err(err))
+		os.Exit(1)
+	}
+	slog.Info("export done", "kind", kind, "format", q.Format, "rows", rows,
+		"output", *output, "took", time.Since(start).Round(time.Millisecond))
+}
+
+// run ghi các dòng do read sinh ra theo format, trả về số dòng đã ghi
+func run[T export.Row](w io.Writer, format export.Format, read func(write func(T) error) error) (int64, error) {
+	ew := export.NewWriter[T](w, format)
+	if err := read(ew.Write); err != nil {
+		return ew.Rows(), err
+	}
+	return ew.Rows(), ew.Close()
+}
+
+// writeOutput mở output (stdout khi path rỗng) có buffer; file chỉ giữ lại
+// khi export thành công
+func writeOutput(path string, fn func(io.Writer) error) error {
+	if path == "" {
+		bw := bufio.NewWriter(os.Stdout)
+		if err := fn(bw); err != nil {
+			return err
+		}
+		return bw.Flush()
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(f)
+	err = fn(bw)
+	if err == nil {
+		err = bw.Flush()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(path)
+	}
+	return err
+}
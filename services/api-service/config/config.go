@@ -9,10 +9,15 @@ package config
 
 import (
 	"fmt"
+	"log/slog"
 	"os"
 	"strconv"
 	"strings"
 	"time"
+
+	"social-insight/internal/ratelimit"
+	"social-insight/internal/server"
+	"social-insight/internal/tracing"
 )
 
 // Config chứa tất cả cấu hình ứng dụng
@@ -33,7 +38,33 @@ type Config struct {
 	PGDBName   string
 
 	// API
-	APIPort string
+	APIPort            string
+	APIReadTimeout     time.Duration // Đọc request (header + body)
+	APIWriteTimeout    time.Duration // Ghi response
+	APIIdleTimeout     time.Duration // Keep-alive
+	APIHandlerTimeout  time.Duration // Handler chạy quá lâu → 503
+	APIShutdownTimeout time.Duration // Chờ request đang chạy khi SIGTERM
+	APIExportTimeout   time.Duration // Thời gian tối đa một export (/api/v1/export/*)
+	APICacheEnabled    bool          // Response cache trong Redis (TTL theo api.Operation.CacheTTL)
+
+	// Auth & CORS
+	AuthEnabled        bool     // Bắt buộc API key cho /api/* (trừ /api/health)
+	CORSAllowedOrigins []string // Origins được gọi API từ browser ("*" = mọi origin)
+
+	// Rate limit (token bucket theo API key/IP, dạng "60/1m", "off" = tắt)
+	RateLimitDefault        string // Endpoint thường
+	RateLimitHeavy          string // Endpoint nạp nhiều posts (trending, insights, compare)
+	RateLimitExport         string // Export file (/api/v1/export/*)
+	RateLimitAuth           string // Theo IP, trước khi tra API key (khi AUTH_ENABLED)
+	RateLimitTrustedProxies string // CIDR/IP reverse proxy được tin X-Forwarded-For
+
+	// GraphQL (/api/v1/graphql): query vượt giới hạn bị từ chối trước khi chạy
+	GraphQLMaxComplexity int // Cost tối đa (field × limit của list cha)
+	GraphQLMaxDepth      int // Số tầng field lồng nhau tối đa
+
+	// Health checks (/healthz, /readyz, degraded mode khi Redis down)
+	HealthCheckTimeout  time.Duration // Timeout mỗi dependency check
+	HealthCheckInterval time.Duration // Chu kỳ kiểm tra nền cho degraded mode
 
 	// Crawler - HackerNews
 	HNCrawlInterval time.Duration
@@ -57,6 +88,15 @@ type Config struct {
 	HTTPClientTimeout time.Duration
 	HTTPMaxRetries    int
 	HTTPRetryDelay    time.Duration
+
+	// Logging
+	LogLevel  string // debug | info | warn | error
+	LogFormat string // text | json
+
+	// Tracing (OpenTelemetry)
+	TracingExporter    string  // none | stdout | otlp
+	TracingEndpoint    string  // OTLP/HTTP endpoint (host:4318 hoặc URL)
+	TracingSampleRatio float64 // Tỉ lệ lấy mẫu trace gốc (0..1)
 }
 
 // Load tải config từ environment variables hoặc default values
@@ -72,29 +112,52 @@ func Load() (*Config, error) {
 
 	cfg := &Config{
 		// Default values (Local development)
-		KafkaBrokers:          kafkaBrokers,
-		KafkaTopic:            getEnv("KAFKA_TOPIC", "raw_posts"),
-		ConsumerGroup:         getEnv("CONSUMER_GROUP", "social_insight_consumer"),
-		RedisAddr:             getEnv("REDIS_ADDR", "localhost:6379"),
-		PGHost:                getEnv("PG_HOST", "localhost"),
-		PGPort:                getEnvInt("PG_PORT", 5432),
-		PGUser:                getEnv("PG_USER", "postgres"),
-		PGPassword:            getEnv("PG_PASSWORD", "postgres123"),
-		PGDBName:              getEnv("PG_DBNAME", "social_insight"),
-		APIPort:               getEnv("API_PORT", ":8888"),
-		HNCrawlInterval:       parseDuration(getEnv("HN_CRAWL_INTERVAL", "5m")),
-		HNStoriesLimit:        getEnvInt("HN_STORIES_LIMIT", 30),
-		DevtoCrawlInterval:    parseDuration(getEnv("DEVTO_CRAWL_INTERVAL", "10m")),
-		DevtoPostsPerTag:      getEnvInt("DEVTO_POSTS_PER_TAG", 6),
-		DevtoTags:             parseStringSlice(getEnv("DEVTO_TAGS", "ai,machine-learning,cloud,devops,startups"), ""),
-		MediumCrawlInterval:   parseDuration(getEnv("MEDIUM_CRAWL_INTERVAL", "10m")),
-		MediumPostsPerTopic:   getEnvInt("MEDIUM_POSTS_PER_TOPIC", 10),
-		MediumTopics:          parseStringSlice(getEnv("MEDIUM_TOPICS", "machine-learning,artificial-intelligence,cloud-computing,devops,startups"), ""),
-		ConsumerBatchSize:     getEnvInt("CONSUMER_BATCH_SIZE", 500),
-		ConsumerFlushInterval: parseDuration(getEnv("CONSUMER_FLUSH_INTERVAL", "2s")),
-		HTTPClientTimeout:     parseDuration(getEnv("HTTP_CLIENT_TIMEOUT", "10s")),
-		HTTPMaxRetries:        getEnvInt("HTTP_MAX_RETRIES", 3),
-		HTTPRetryDelay:        parseDuration(getEnv("HTTP_RETRY_DELAY", "1s")),
+		KafkaBrokers:            kafkaBrokers,
+		KafkaTopic:              getEnv("KAFKA_TOPIC", "raw_posts"),
+		ConsumerGroup:           getEnv("CONSUMER_GROUP", "social_insight_consumer"),
+		RedisAddr:               getEnv("REDIS_ADDR", "localhost:6379"),
+		PGHost:                  getEnv("PG_HOST", "localhost"),
+		PGPort:                  getEnvInt("PG_PORT", 5432),
+		PGUser:                  getEnv("PG_USER", "postgres"),
+		PGPassword:              getEnv("PG_PASSWORD", "postgres123"),
+		PGDBName:                getEnv("PG_DBNAME", "social_insight"),
+		APIPort:                 getEnv("API_PORT", ":8888"),
+		APIReadTimeout:          parseDuration(getEnv("API_READ_TIMEOUT", "10s")),
+		APIWriteTimeout:         parseDuration(getEnv("API_WRITE_TIMEOUT", "30s")),
+		APIIdleTimeout:          parseDuration(getEnv("API_IDLE_TIMEOUT", "60s")),
+		APIHandlerTimeout:       parseDuration(getEnv("API_HANDLER_TIMEOUT", "20s")),
+		APIShutdownTimeout:      parseDuration(getEnv("API_SHUTDOWN_TIMEOUT", "15s")),
+		APIExportTimeout:        parseDuration(getEnv("API_EXPORT_TIMEOUT", "10m")),
+		APICacheEnabled:         getEnvBool("API_CACHE_ENABLED", true),
+		AuthEnabled:             getEnvBool("AUTH_ENABLED", false),
+		CORSAllowedOrigins:      parseStringSlice(getEnv("CORS_ALLOWED_ORIGINS", ""), ","),
+		RateLimitDefault:        getEnv("RATE_LIMIT_DEFAULT", "120/1m"),
+		RateLimitHeavy:          getEnv("RATE_LIMIT_HEAVY", "30/1m"),
+		RateLimitExport:         getEnv("RATE_LIMIT_EXPORT", "6/1m"),
+		RateLimitAuth:           getEnv("RATE_LIMIT_AUTH", "300/1m"),
+		RateLimitTrustedProxies: getEnv("RATE_LIMIT_TRUSTED_PROXIES", ""),
+		GraphQLMaxComplexity:    getEnvInt("GRAPHQL_MAX_COMPLEXITY", 5000),
+		GraphQLMaxDepth:         getEnvInt("GRAPHQL_MAX_DEPTH", 8),
+		HealthCheckTimeout:      parseDuration(getEnv("HEALTH_CHECK_TIMEOUT", "2s")),
+		HealthCheckInterval:     parseDuration(getEnv("HEALTH_CHECK_INTERVAL", "10s")),
+		HNCrawlInterval:         parseDuration(getEnv("HN_CRAWL_INTERVAL", "5m")),
+		HNStoriesLimit:          getEnvInt("HN_STORIES_LIMIT", 30),
+		DevtoCrawlInterval:      parseDuration(getEnv("DEVTO_CRAWL_INTERVAL", "10m")),
+		DevtoPostsPerTag:        getEnvInt("DEVTO_POSTS_PER_TAG", 6),
+		DevtoTags:               parseStringSlice(getEnv("DEVTO_TAGS", "ai,machine-learning,cloud,devops,startups"), ""),
+		MediumCrawlInterval:     parseDuration(getEnv("MEDIUM_CRAWL_INTERVAL", "10m")),
+		MediumPostsPerTopic:     getEnvInt("MEDIUM_POSTS_PER_TOPIC", 10),
+		MediumTopics:            parseStringSlice(getEnv("MEDIUM_TOPICS", "machine-learning,artificial-intelligence,cloud-computing,devops,startups"), ""),
+		ConsumerBatchSize:       getEnvInt("CONSUMER_BATCH_SIZE", 500),
+		ConsumerFlushInterval:   parseDuration(getEnv("CONSUMER_FLUSH_INTERVAL", "2s")),
+		HTTPClientTimeout:       parseDuration(getEnv("HTTP_CLIENT_TIMEOUT", "10s")),
+		HTTPMaxRetries:          getEnvInt("HTTP_MAX_RETRIES", 3),
+		HTTPRetryDelay:          parseDuration(getEnv("HTTP_RETRY_DELAY", "1s")),
+		LogLevel:                getEnv("LOG_LEVEL", "info"),
+		LogFormat:               getEnv("LOG_FORMAT", "text"),
+		TracingExporter:         getEnv("TRACING_EXPORTER", tracing.ExporterNone),
+		TracingEndpoint:         getEnv("TRACING_ENDPOINT", "localhost:4318"),
+		TracingSampleRatio:      getEnvFloat("TRACING_SAMPLE_RATIO", 1.0),
 	}
 
 	return cfg, nil
@@ -120,7 +183,35 @@ func getEnvInt(key string, defaultVal int) int {
 	}
 	val, err := strconv.Atoi(valStr)
 	if err != nil {
-		fmt.Printf("⚠️  Invalid int value for %s: %v, using default: %d\n", key, err, defaultVal)
+		slog.Warn("invalid int value, using default", "key", key, "value", valStr, "default", defaultVal)
+		return defaultVal
+	}
+	return val
+}
+
+// getEnvBool lấy bool environment variable (true/false/1/0)
+func getEnvBool(key string, defaultVal bool) bool {
+	valStr := getEnv(key, "")
+	if valStr == "" {
+		return defaultVal
+	}
+	val, err := strconv.ParseBool(valStr)
+	if err != nil {
+		slog.Warn("invalid bool value, using default", "key", key, "value", valStr, "default", defaultVal)
+		return defaultVal
+	}
+	return val
+}
+
+// getEnvFloat lấy float environment variable
+func getEnvFloat(key string, defaultVal float64) float64 {
+	valStr := getEnv(key, "")
+	if valStr == "" {
+		return defaultVal
+	}
+	val, err := strconv.ParseFloat(valStr, 64)
+	if err != nil {
+		slog.Warn("invalid float value, using default", "key", key, "error", err, "default", defaultVal)
 		return defaultVal
 	}
 	return val
@@ -130,7 +221,7 @@ func getEnvInt(key string, defaultVal int) int {
 func parseDuration(s string) time.Duration {
 	d, err := time.ParseDuration(s)
 	if err != nil {
-		fmt.Printf("⚠️  Invalid duration: %s, using 5s\n", s)
+		slog.Warn("invalid duration, using 5s", "value", s)
 		return 5 * time.Second
 	}
 	return d
@@ -155,22 +246,91 @@ func parseStringSlice(s, separator string) []string {
 	return result
 }
 
-// LogConfig in ra config (với password masked)
+// LogConfig log config (không log password)
 func (c *Config) LogConfig() {
-	fmt.Println("╔════════════════════════════════════════════════════════════╗")
-	fmt.Println("║              APPLICATION CONFIGURATION                     ║")
-	fmt.Println("╠════════════════════════════════════════════════════════════╣")
-	fmt.Printf("║ Kafka Brokers: %s\n", strings.Join(c.KafkaBrokers, ", "))
-	fmt.Printf("║ Kafka Topic: %s\n", c.KafkaTopic)
-	fmt.Printf("║ Redis: %s\n", c.RedisAddr)
-	fmt.Printf("║ PostgreSQL: %s:%d/%s (user: %s)\n", c.PGHost, c.PGPort, c.PGDBName, c.PGUser)
-	fmt.Printf("║ API Port: %s\n", c.APIPort)
-	fmt.Println("║")
-	fmt.Printf("║ HN Crawler: interval=%v, limit=%d\n", c.HNCrawlInterval, c.HNStoriesLimit)
-	fmt.Printf("║ Devto Crawler: interval=%v, %d posts/tag\n", c.DevtoCrawlInterval, c.DevtoPostsPerTag)
-	fmt.Printf("║ Medium Crawler: interval=%v, %d posts/topic\n", c.MediumCrawlInterval, c.MediumPostsPerTopic)
-	fmt.Printf("║ Consumer: batch=%d, flush=%v\n", c.ConsumerBatchSize, c.ConsumerFlushInterval)
-	fmt.Println("╚════════════════════════════════════════════════════════════╝")
+	slog.Info("configuration loaded",
+		slog.Group("kafka",
+			"brokers", strings.Join(c.KafkaBrokers, ","),
+			"topic", c.KafkaTopic,
+			"consumer_group", c.ConsumerGroup),
+		slog.String("redis", c.RedisAddr),
+		slog.Group("postgres",
+			"host", c.PGHost,
+			"port", c.PGPort,
+			"db", c.PGDBName,
+			"user", c.PGUser),
+		slog.Group("api",
+			"port", c.APIPort,
+			"read_timeout", c.APIReadTimeout,
+			"write_timeout", c.APIWriteTimeout,
+			"idle_timeout", c.APIIdleTimeout,
+			"handler_timeout", c.APIHandlerTimeout,
+			"shutdown_timeout", c.APIShutdownTimeout,
+			"export_timeout", c.APIExportTimeout,
+			"cache_enabled", c.APICacheEnabled),
+		slog.Group("auth",
+			"enabled", c.AuthEnabled,
+			"cors_allowed_origins", strings.Join(c.CORSAllowedOrigins, ",")),
+		slog.Group("rate_limit",
+			"default", c.RateLimitDefault,
+			"heavy", c.RateLimitHeavy,
+			"export", c.RateLimitExport,
+			"auth", c.RateLimitAuth,
+			"trusted_proxies", c.RateLimitTrustedProxies),
+		slog.Group("graphql",
+			"max_complexity", c.GraphQLMaxComplexity,
+			"max_depth", c.GraphQLMaxDepth),
+		slog.Group("health",
+			"timeout", c.HealthCheckTimeout,
+			"interval", c.HealthCheckInterval),
+		slog.Group("log",
+			"level", c.LogLevel,
+			"format", c.LogFormat),
+		slog.Group("tracing",
+			"exporter", c.TracingExporter,
+			"endpoint", c.TracingEndpoint,
+			"sample_ratio", c.TracingSampleRatio),
+	)
+}
+
+// Tracing trả về config cho tracing.Setup
+func (c *Config) Tracing() tracing.Config {
+	return tracing.Config{
+		Exporter:    c.TracingExporter,
+		Endpoint:    c.TracingEndpoint,
+		SampleRatio: c.TracingSampleRatio,
+	}
+}
+
+// Server trả về config cho server.New
+func (c *Config) Server() server.Config {
+	return server.Config{
+		Addr:           c.APIPort,
+		ReadTimeout:    c.APIReadTimeout,
+		WriteTimeout:   c.APIWriteTimeout,
+		IdleTimeout:    c.APIIdleTimeout,
+		HandlerTimeout: c.APIHandlerTimeout,
+	}
+}
+
+// RateLimit trả về config cho ratelimit.New
+// (Validate đã kiểm tra cú pháp, rule sai ở đây coi như tắt,
+// danh sách proxy sai coi như rỗng)
+func (c *Config) RateLimit() ratelimit.Config {
+	def, _ := ratelimit.ParseRule(c.RateLimitDefault)
+	heavy, _ := ratelimit.ParseRule(c.RateLimitHeavy)
+	export, _ := ratelimit.ParseRule(c.RateLimitExport)
+	authRule, _ := ratelimit.ParseRule(c.RateLimitAuth)
+	trustedProxies, _ := ratelimit.ParseTrustedProxies(c.RateLimitTrustedProxies)
+	return ratelimit.Config{
+		Rules: map[string]ratelimit.Rule{
+			ratelimit.ClassDefault: def,
+			ratelimit.ClassHeavy:   heavy,
+			ratelimit.ClassExport:  export,
+			ratelimit.ClassAuth:    authRule,
+		},
+		TrustedProxies: trustedProxies,
+	}
 }
 
 // Validate check configuration values
@@ -187,5 +347,39 @@ func (c *Config) Validate() error {
 	if c.PGHost == "" {
 		return fmt.Errorf("postgresql host not configured")
 	}
+	if err := c.Server().Validate(); err != nil {
+		return err
+	}
+	if c.APIShutdownTimeout <= 0 {
+		return fmt.Errorf("api shutdown timeout must be positive")
+	}
+	if c.APIExportTimeout <= 0 {
+		return fmt.Errorf("api export timeout must be positive")
+	}
+	if c.GraphQLMaxComplexity <= 0 || c.GraphQLMaxDepth <= 0 {
+		return fmt.Errorf("graphql max complexity and max depth must be positive")
+	}
+	if c.HealthCheckTimeout <= 0 || c.HealthCheckInterval <= 0 {
+		return fmt.Errorf("health check timeout and interval must be positive")
+	}
+	for _, origin := range c.CORSAllowedOrigins {
+		if origin != "*" && !strings.HasPrefix(origin, "http://") && !strings.HasPrefix(origin, "https://") {
+			return fmt.Errorf("cors origin %q must be * or start with http:// or https://", origin)
+		}
+	}
+	for _, rule := range []string{c.RateLimitDefault, c.RateLimitHeavy, c.RateLimitExport, c.RateLimitAuth} {
+		if _, err := ratelimit.ParseRule(rule); err != nil {
+			return err
+		}
+	}
+	if _, err := ratelimit.ParseTrustedProxies(c.RateLimitTrustedProxies); err != nil {
+		return err
+	}
+	if c.LogFormat != "text" && c.LogFormat != "json" {
+		return fmt.Errorf("log format must be text or json")
+	}
+	if err := c.Tracing().Validate(); err != nil {
+		return err
+	}
 	return nil
 }
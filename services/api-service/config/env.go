@@ -9,6 +9,7 @@ package config
 
 import (
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
 
@@ -25,7 +26,7 @@ func LoadEnvFile(envPath string) error {
 	// Check if file exists
 	if _, err := os.Stat(envPath); err != nil {
 		if os.IsNotExist(err) {
-			fmt.Printf("⚠️  .env file not found at %s, using environment variables\n", envPath)
+			slog.Debug(".env file not found, using environment variables", "path", envPath)
 			return nil // Không phải lỗi - có thể được cấu hình bằng env vars
 		}
 		return fmt.Errorf("cannot access .env file: %w", err)
@@ -36,7 +37,7 @@ func LoadEnvFile(envPath string) error {
 		return fmt.Errorf("error loading .env file: %w", err)
 	}
 
-	fmt.Printf("✅ Loaded environment from %s\n", envPath)
+	slog.Debug("loaded environment", "path", envPath)
 	return nil
 }
 
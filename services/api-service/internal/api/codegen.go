@@ -0,0 +1,327 @@
+// =====================================================
+// CLIENT CODEGEN - Sinh Go client từ openapi.json
+// =====================================================
+// Mô tả: Đọc spec (components.schemas + paths) và sinh
+// client/client.gen.go: một struct cho mỗi schema và một method
+// cho mỗi operation. Chỉ hỗ trợ phần OpenAPI mà Spec() dùng
+// ($ref, object, array, map, kiểu cơ bản, date-time, nullable)
+// =====================================================
+
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"sort"
+	"strings"
+)
+
+// specDoc là phần của OpenAPI document mà generator đọc
+type specDoc struct {
+	Paths      map[string]map[string]specOperation `json:"paths"`
+	Components struct {
+		Schemas map[string]specSchema `json:"schemas"`
+	} `json:"components"`
+}
+
+// specOperation là Operation Object
+type specOperation struct {
+	OperationID   string                  `json:"operationId"`
+	Summary       string                  `json:"summary"`
+	Parameters    []specParameter         `json:"parameters"`
+	Responses     map[string]specResponse `json:"responses"`
+	RequiredScope string                  `json:"x-required-scope"`
+}
+
+// specParameter là Parameter Object (path params; query params = endpoint danh sách)
+type specParameter struct {
+	Name   string     `json:"name"`
+	In     string     `json:"in"`
+	Schema specSchema `json:"schema"`
+}
+
+// specResponse là Response Object
+type specResponse struct {
+	Content map[string]struct {
+		Schema specSchema `json:"schema"`
+	} `json:"content"`
+}
+
+// specSchema là Schema Object
+type specSchema struct {
+	Ref                  string                `json:"$ref"`
+	Type                 string                `json:"type"`
+	Format               string                `json:"format"`
+	Nullable             bool                  `json:"nullable"`
+	Items                *specSchema           `json:"items"`
+	AdditionalProperties *specSchema           `json:"additionalProperties"`
+	Properties           map[string]specSchema `json:"properties"`
+	Required             []string              `json:"required"`
+	AllOf                []specSchema          `json:"allOf"`
+}
+
+// codegen giữ trạng thái khi sinh code
+type codegen struct {
+	imports map[string]bool
+}
+
+// GenerateClient sinh source của package client từ spec
+func GenerateClient(spec []byte) ([]byte, error) {
+	var doc specDoc
+	if err := json.Unmarshal(spec, &doc); err != nil {
+		return nil, fmt.Errorf("parse openapi spec: %w", err)
+	}
+
+	g := &codegen{imports: map[string]bool{"context": true}}
+	var body bytes.Buffer
+
+	// ====== Types ======
+	names := make([]string, 0, len(doc.Components.Schemas))
+	for name := range doc.Components.Schemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		g.writeStruct(&body, name, doc.Components.Schemas[name])
+	}
+
+	// ====== Methods ======
+	type pathOp struct {
+		path, method string
+		op           specOperation
+	}
+	ops := make([]pathOp, 0)
+	for path, item := range doc.Paths {
+		for method, op := range item {
+			ops = append(ops, pathOp{path, strings.ToUpper(method), op})
+		}
+	}
+	sort.Slice(ops, func(i, j int) bool { return ops[i].op.OperationID < ops[j].op.OperationID })
+	for _, o := range ops {
+		if err := g.writeMethod(&body, o.path, o.method, o.op); err != nil {
+			return nil, err
+		}
+	}
+
+	// ====== File ======
+	var out bytes.Buffer
+	out.WriteString("// Code generated by cmd/apigen from internal/api/openapi.json. DO NOT EDIT.\n\n")
+	out.WriteString("package client\n\nimport (\n")
+	imports := make([]string, 0, len(g.imports))
+	for imp := range g.imports {
+		imports = append(imports, imp)
+	}
+	sort.Strings(imports)
+	for _, imp := range imports {
+		fmt.Fprintf(&out, "\t%q\n", imp)
+	}
+	out.WriteString(")\n\n")
+	out.Write(body.Bytes())
+
+	src, err := format.Source(out.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("format generated client: %w", err)
+	}
+	return src, nil
+}
+
+// writeStruct sinh struct cho một schema object
+func (g *codegen) writeStruct(w *bytes.Buffer, name string, s specSchema) {
+	required := make(map[string]bool, len(s.Required))
+	for _, r := range s.Required {
+		required[r] = true
+	}
+	props := make([]string, 0, len(s.Properties))
+	for p := range s.Properties {
+		props = append(props, p)
+	}
+	sort.Strings(props)
+
+	fmt.Fprintf(w, "// %s là schema %s của API\n", name, name)
+	fmt.Fprintf(w, "type %s struct {\n", name)
+	for _, p := range props {
+		tag := p
+		if !required[p] {
+			tag += ",omitempty"
+		}
+		fmt.Fprintf(w, "\t%s %s `json:%q`\n", goName(p), g.goType(s.Properties[p]), tag)
+	}
+	w.WriteString("}\n\n")
+}
+
+// writeMethod sinh method cho một operation
+func (g *codegen) writeMethod(w *bytes.Buffer, path, method string, op specOperation) error {
+	resp, ok := op.Responses["200"]
+	if !ok {
+		return fmt.Errorf("operation %s has no 200 response", op.OperationID)
+	}
+	content, ok := resp.Content["application/json"]
+	if !ok {
+		// Endpoint export: response là file, trả về body cho caller đọc
+		return g.writeExportMethod(w, path, method, op)
+	}
+	schema := content.Schema
+	typ := g.goType(schema)
+
+	// Tham số: ctx + path params; URL ghép từ các đoạn của path
+	args := []string{"ctx context.Context"}
+	urlExpr := fmt.Sprintf("%q", path)
+	// query: có query params (nhận ListOptions); list: có cursor (trả thêm cursor trang sau)
+	query, list := false, false
+	for _, p := range op.Parameters {
+		if p.In == "header" {
+			// If-None-Match: client không gửi conditional request
+			continue
+		}
+		if p.In == "query" {
+			query = true
+			list = list || p.Name == "cursor"
+			continue
+		}
+		arg := goArg(p.Name)
+		value := arg
+		if p.Schema.Type == "integer" {
+			args = append(args, arg+" int64")
+			value = "strconv.FormatInt(" + arg + ", 10)"
+			g.imports["strconv"] = true
+		} else {
+			args = append(args, arg+" string")
+		}
+		g.imports["net/url"] = true
+		urlExpr = strings.Replace(urlExpr, "{"+p.Name+"}", `" + url.PathEscape(`+value+`) + "`, 1)
+	}
+	urlExpr = strings.TrimSuffix(strings.TrimPrefix(urlExpr, `"" + `), ` + ""`)
+	if query {
+		// Endpoint danh sách (hoặc có query params riêng): nhận ListOptions
+		args = append(args, "opts *ListOptions")
+		urlExpr += "+opts.query()"
+	}
+
+	fmt.Fprintf(w, "// %s: %s\n", op.OperationID, op.Summary)
+	if op.RequiredScope != "" {
+		fmt.Fprintf(w, "// %s %s (scope %s)\n", method, path, op.RequiredScope)
+	} else {
+		fmt.Fprintf(w, "// %s %s\n", method, path)
+	}
+
+	// Struct trả về con trỏ, slice/map trả về giá trị
+	result, value := typ, "out"
+	if schema.Ref != "" {
+		result, value = "*"+typ, "&out"
+	}
+	if list {
+		fmt.Fprintf(w, "// Trả về thêm cursor của trang sau (\"\" ở trang cuối)\n")
+		fmt.Fprintf(w, "func (c *Client) %s(%s) (%s, string, error) {\n", op.OperationID, strings.Join(args, ", "), result)
+		fmt.Fprintf(w, "\tvar out %s\n", typ)
+		fmt.Fprintf(w, "\theader, err := c.do(ctx, %q, %s, &out)\n", method, urlExpr)
+		w.WriteString("\tif err != nil {\n\t\treturn nil, \"\", err\n\t}\n")
+		fmt.Fprintf(w, "\treturn %s, header.Get(%q), nil\n}\n\n", value, HeaderNextCursor)
+		return nil
+	}
+	fmt.Fprintf(w, "func (c *Client) %s(%s) (%s, error) {\n", op.OperationID, strings.Join(args, ", "), result)
+	fmt.Fprintf(w, "\tvar out %s\n", typ)
+	fmt.Fprintf(w, "\tif _, err := c.do(ctx, %q, %s, &out); err != nil {\n\t\treturn nil, err\n\t}\n", method, urlExpr)
+	fmt.Fprintf(w, "\treturn %s, nil\n}\n\n", value)
+	return nil
+}
+
+// writeExportMethod sinh method cho endpoint export (không có path params):
+// nhận ExportOptions, trả về body để caller stream và Close
+func (g *codegen) writeExportMethod(w *bytes.Buffer, path, method string, op specOperation) error {
+	for _, p := range op.Parameters {
+		if p.In != "query" {
+			return fmt.Errorf("export operation %s: path params not supported", op.OperationID)
+		}
+	}
+	g.imports["io"] = true
+	fmt.Fprintf(w, "// %s: %s\n", op.OperationID, op.Summary)
+	if op.RequiredScope != "" {
+		fmt.Fprintf(w, "// %s %s (scope %s)\n", method, path, op.RequiredScope)
+	} else {
+		fmt.Fprintf(w, "// %s %s\n", method, path)
+	}
+	w.WriteString("// Caller phải Close body\n")
+	fmt.Fprintf(w, "func (c *Client) %s(ctx context.Context, opts *ExportOptions) (io.ReadCloser, error) {\n", op.OperationID)
+	fmt.Fprintf(w, "\treturn c.stream(ctx, %q, %q+opts.query())\n}\n\n", method, path)
+	return nil
+}
+
+// goType đổi schema thành kiểu Go
+func (g *codegen) goType(s specSchema) string {
+	if len(s.AllOf) == 1 {
+		inner := g.goType(s.AllOf[0])
+		if s.Nullable {
+			return "*" + inner
+		}
+		return inner
+	}
+	if s.Ref != "" {
+		return s.Ref[strings.LastIndex(s.Ref, "/")+1:]
+	}
+
+	var t string
+	switch s.Type {
+	case "string":
+		t = "string"
+		if s.Format == "date-time" {
+			g.imports["time"] = true
+			t = "time.Time"
+		}
+	case "boolean":
+		t = "bool"
+	case "integer":
+		switch s.Format {
+		case "int64":
+			t = "int64"
+		case "int32":
+			t = "int32"
+		default:
+			t = "int"
+		}
+	case "number":
+		t = "float64"
+	case "array":
+		t = "[]" + g.goType(*s.Items)
+	case "object":
+		if s.AdditionalProperties != nil {
+			t = "map[string]" + g.goType(*s.AdditionalProperties)
+		} else {
+			g.imports["encoding/json"] = true
+			t = "json.RawMessage"
+		}
+	default:
+		g.imports["encoding/json"] = true
+		t = "json.RawMessage"
+	}
+	if s.Nullable && !strings.HasPrefix(t, "[]") && !strings.HasPrefix(t, "map[") {
+		return "*" + t
+	}
+	return t
+}
+
+// initialisms viết hoa toàn bộ khi đổi tên field
+var initialisms = map[string]bool{"id": true, "url": true, "api": true, "http": true, "json": true}
+
+// goName đổi snake_case thành tên field Go (canonical_post_id → CanonicalPostID)
+func goName(s string) string {
+	parts := strings.Split(s, "_")
+	for i, p := range parts {
+		if initialisms[p] {
+			parts[i] = strings.ToUpper(p)
+		} else if p != "" {
+			parts[i] = strings.ToUpper(p[:1]) + p[1:]
+		}
+	}
+	return strings.Join(parts, "")
+}
+
+// goArg đổi tên param thành tên biến Go (story_id → storyID)
+func goArg(s string) string {
+	n := goName(s)
+	if strings.ToUpper(n) == n {
+		return strings.ToLower(n)
+	}
+	return strings.ToLower(n[:1]) + n[1:]
+}
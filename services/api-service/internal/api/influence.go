@@ -0,0 +1,183 @@
+// =====================================================
+// INFLUENCE - Điểm ảnh hưởng của tác giả và đồ thị tác giả – topic
+// =====================================================
+// Mô tả: Điểm = 60% engagement mỗi post so với trung bình nền tảng
+// + 25% độ đều đặn (tỉ lệ tuần có post) + 15% độ phủ (số nền tảng
+// có cùng handle). Dùng cho GET /api/v1/graph/authors
+// =====================================================
+
+package api
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"social-insight/internal/database"
+)
+
+// Trọng số và tham số của điểm influence
+const (
+	influenceEngagementWeight  = 0.60
+	influenceConsistencyWeight = 0.25
+	influenceReachWeight       = 0.15
+
+	// influencePriorPosts: engagement được kéo về trung bình nền tảng như
+	// thể tác giả có thêm chừng này posts trung bình (một bài viral
+	// không đưa tác giả mới lên đầu)
+	influencePriorPosts = 3
+
+	// influenceMinWeeks: độ đều đặn tính trên ít nhất chừng này tuần
+	// (tác giả mới có một post không được consistency = 1)
+	influenceMinWeeks = 4
+)
+
+// ScoreInfluence chấm điểm tác giả a; baseline là engagement trung bình
+// mỗi post của nền tảng (≤ 0: coi như tác giả bằng trung bình)
+func ScoreInfluence(a database.AuthorActivity, baseline float64) AuthorInfluence {
+	relative := 1.0
+	if baseline > 0 {
+		shrunk := (float64(a.Engagement) + influencePriorPosts*baseline) / float64(a.PostCount+influencePriorPosts)
+		relative = shrunk / baseline
+	}
+
+	week := 7 * 24 * time.Hour
+	span := int64(a.LastSeenAt.Sub(a.FirstSeenAt)/week) + 1
+	if span < influenceMinWeeks {
+		span = influenceMinWeeks
+	}
+	consistency := math.Min(float64(a.ActiveWeeks)/float64(span), 1)
+
+	reach := 0.0
+	if a.Platforms > 1 {
+		reach = 1 - 1/float64(a.Platforms)
+	}
+
+	// relative/(1+relative): bằng trung bình = 0.5, gấp 3 = 0.75
+	score := influenceEngagementWeight*relative/(1+relative) +
+		influenceConsistencyWeight*consistency +
+		influenceReachWeight*reach
+
+	return AuthorInfluence{
+		Platform:           a.Platform,
+		Handle:             a.Handle,
+		PostCount:          a.PostCount,
+		Engagement:         a.Engagement,
+		Score:              round(score*100, 1),
+		RelativeEngagement: round(relative, 3),
+		Consistency:        round(consistency, 3),
+		Reach:              a.Platforms,
+	}
+}
+
+// RankedAuthor là một tác giả kèm influence
+type RankedAuthor struct {
+	Name      string
+	Influence AuthorInfluence
+}
+
+// RankInfluence chấm điểm authors theo baseline của nền tảng, điểm cao
+// trước (cùng điểm thì theo platform, handle để thứ tự ổn định)
+func RankInfluence(authors []database.AuthorActivity, baselines map[string]float64) []RankedAuthor {
+	ranked := make([]RankedAuthor, len(authors))
+	for i, a := range authors {
+		ranked[i] = RankedAuthor{Name: a.Name, Influence: ScoreInfluence(a, baselines[a.Platform])}
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		a, b := ranked[i].Influence, ranked[j].Influence
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if a.Platform != b.Platform {
+			return a.Platform < b.Platform
+		}
+		return a.Handle < b.Handle
+	})
+	return ranked
+}
+
+// BuildAuthorGraph tạo đồ thị từ authors (đã xếp hạng) và cạnh của họ.
+// Đỉnh tác giả theo thứ tự authors, đỉnh topic/keyword nhiều posts trước
+func BuildAuthorGraph(topic string, authors []RankedAuthor, topics []database.AuthorTopicEdge,
+	keywords []database.AuthorKeywordEdge, baselines map[string]float64) AuthorGraphResponse {
+	graph := AuthorGraphResponse{
+		Topic:     topic,
+		Baselines: baselines,
+		Nodes:     make([]GraphNode, 0, len(authors)),
+		Edges:     make([]GraphEdge, 0, len(topics)+len(keywords)),
+	}
+	if graph.Baselines == nil {
+		graph.Baselines = map[string]float64{}
+	}
+
+	inGraph := make(map[database.AuthorKey]bool, len(authors))
+	for _, a := range authors {
+		influence := a.Influence
+		key := database.AuthorKey{Platform: influence.Platform, Handle: influence.Handle}
+		inGraph[key] = true
+		graph.Nodes = append(graph.Nodes, GraphNode{
+			ID:     authorNodeID(key),
+			Type:   NodeAuthor,
+			Label:  a.Name,
+			Weight: influence.Score,
+			Author: &influence,
+		})
+	}
+
+	// Đỉnh topic/keyword: cộng số posts của các cạnh tới nó
+	weights := make(map[string]float64)
+	targets := make([]GraphNode, 0)
+	target := func(nodeType, label string, posts int64) string {
+		id := nodeType + ":" + label
+		if _, ok := weights[id]; !ok {
+			targets = append(targets, GraphNode{ID: id, Type: nodeType, Label: label})
+		}
+		weights[id] += float64(posts)
+		return id
+	}
+	for _, e := range topics {
+		if inGraph[e.Author] {
+			graph.Edges = append(graph.Edges, GraphEdge{
+				Source:     authorNodeID(e.Author),
+				Target:     target(NodeTopic, e.Topic, e.PostCount),
+				Weight:     e.PostCount,
+				Engagement: e.Engagement,
+			})
+		}
+	}
+	for _, e := range keywords {
+		if inGraph[e.Author] {
+			graph.Edges = append(graph.Edges, GraphEdge{
+				Source: authorNodeID(e.Author),
+				Target: target(NodeKeyword, e.Keyword, e.PostCount),
+				Weight: e.PostCount,
+			})
+		}
+	}
+
+	for i := range targets {
+		targets[i].Weight = weights[targets[i].ID]
+	}
+	sort.SliceStable(targets, func(i, j int) bool {
+		if targets[i].Type != targets[j].Type {
+			return targets[i].Type == NodeTopic
+		}
+		if targets[i].Weight != targets[j].Weight {
+			return targets[i].Weight > targets[j].Weight
+		}
+		return targets[i].Label < targets[j].Label
+	})
+	graph.Nodes = append(graph.Nodes, targets...)
+	return graph
+}
+
+// authorNodeID là id đỉnh của tác giả
+func authorNodeID(k database.AuthorKey) string {
+	return NodeAuthor + ":" + k.Platform + "/" + k.Handle
+}
+
+// round làm tròn x tới digits chữ số thập phân
+func round(x float64, digits int) float64 {
+	p := math.Pow(10, float64(digits))
+	return math.Round(x*p) / p
+}
@@ -0,0 +1,133 @@
+package api
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"social-insight/internal/database"
+)
+
+// activity là tác giả có posts đăng đều mỗi tuần trong weeks tuần
+func activity(handle string, posts, engagement int64, weeks int64, platforms int64) database.AuthorActivity {
+	return database.AuthorActivity{
+		Platform: "hackernews", Handle: handle, Name: handle,
+		PostCount: posts, Engagement: engagement,
+		FirstSeenAt: testNow.Add(-time.Duration(weeks-1) * 7 * 24 * time.Hour), LastSeenAt: testNow,
+		ActiveWeeks: weeks, Platforms: platforms,
+	}
+}
+
+func TestScoreInfluence(t *testing.T) {
+	const baseline = 10
+
+	average := ScoreInfluence(activity("avg", 8, 80, 8, 1), baseline)
+	if average.RelativeEngagement != 1 || average.Consistency != 1 || average.Score != 55 {
+		t.Errorf("average author: %+v", average)
+	}
+
+	// Gấp 3 trung bình nhưng chỉ một bài: bị kéo về gần trung bình
+	viral := ScoreInfluence(activity("viral", 1, 30, 1, 1), baseline)
+	if viral.RelativeEngagement != 1.5 || viral.Consistency != 0.25 {
+		t.Errorf("one viral post: %+v", viral)
+	}
+	steady := ScoreInfluence(activity("steady", 20, 600, 20, 1), baseline)
+	if steady.Score <= viral.Score || steady.Score <= average.Score {
+		t.Errorf("steady %.1f should beat viral %.1f and average %.1f", steady.Score, viral.Score, average.Score)
+	}
+
+	// Cùng handle trên 2 nền tảng
+	if cross := ScoreInfluence(activity("cross", 8, 80, 8, 2), baseline); cross.Score != 62.5 || cross.Reach != 2 {
+		t.Errorf("cross-platform author: %+v", cross)
+	}
+
+	// Nền tảng chưa có engagement: coi như bằng trung bình
+	if none := ScoreInfluence(activity("none", 8, 0, 8, 1), 0); none.RelativeEngagement != 1 {
+		t.Errorf("zero baseline: %+v", none)
+	}
+}
+
+func TestRankInfluence(t *testing.T) {
+	authors := []database.AuthorActivity{
+		activity("b", 8, 80, 8, 1),
+		activity("low", 8, 8, 8, 1),
+		activity("a", 8, 80, 8, 1),
+		activity("high", 8, 800, 8, 1),
+	}
+	ranked := RankInfluence(authors, map[string]float64{"hackernews": 10})
+	var order []string
+	for _, a := range ranked {
+		order = append(order, a.Influence.Handle)
+	}
+	if got := strings.Join(order, ","); got != "high,a,b,low" {
+		t.Errorf("order = %s, want high,a,b,low", got)
+	}
+}
+
+func TestBuildAuthorGraph(t *testing.T) {
+	pg := database.AuthorKey{Platform: "hackernews", Handle: "pg"}
+	ada := database.AuthorKey{Platform: "devto", Handle: "ada"}
+	other := database.AuthorKey{Platform: "devto", Handle: "other"}
+	authors := []RankedAuthor{
+		{Name: "Paul", Influence: AuthorInfluence{Platform: pg.Platform, Handle: pg.Handle, Score: 70}},
+		{Name: "Ada", Influence: AuthorInfluence{Platform: ada.Platform, Handle: ada.Handle, Score: 60}},
+	}
+	topics := []database.AuthorTopicEdge{
+		{Author: pg, Topic: "startup", PostCount: 5, Engagement: 500},
+		{Author: pg, Topic: "ai", PostCount: 2, Engagement: 90},
+		{Author: ada, Topic: "ai", PostCount: 4, Engagement: 40},
+		// Tác giả không nằm trong đồ thị: bỏ cạnh
+		{Author: other, Topic: "cloud", PostCount: 9},
+	}
+	keywords := []database.AuthorKeywordEdge{
+		{Author: pg, Keyword: "rust", PostCount: 1},
+		{Author: ada, Keyword: "rust", PostCount: 2},
+		{Author: ada, Keyword: "llm", PostCount: 3},
+	}
+
+	graph := BuildAuthorGraph("", authors, topics, keywords, nil)
+
+	var ids []string
+	weights := make(map[string]float64)
+	for _, n := range graph.Nodes {
+		ids = append(ids, n.ID)
+		weights[n.ID] = n.Weight
+	}
+	want := []string{"author:hackernews/pg", "author:devto/ada", "topic:ai", "topic:startup", "keyword:llm", "keyword:rust"}
+	if strings.Join(ids, " ") != strings.Join(want, " ") {
+		t.Fatalf("nodes = %v, want %v", ids, want)
+	}
+	if weights["topic:ai"] != 6 || weights["keyword:rust"] != 3 || weights["author:hackernews/pg"] != 70 {
+		t.Errorf("weights = %v", weights)
+	}
+	if graph.Nodes[0].Author == nil || graph.Nodes[0].Label != "Paul" || graph.Nodes[2].Author != nil {
+		t.Errorf("author details: %+v, %+v", graph.Nodes[0], graph.Nodes[2])
+	}
+
+	if len(graph.Edges) != 6 {
+		t.Fatalf("edges = %+v", graph.Edges)
+	}
+	if e := graph.Edges[0]; e.Source != "author:hackernews/pg" || e.Target != "topic:startup" || e.Weight != 5 || e.Engagement != 500 {
+		t.Errorf("first edge = %+v", e)
+	}
+	if graph.Baselines == nil {
+		t.Error("baselines should be an empty map, not null")
+	}
+}
+
+func TestParseGraphQuery(t *testing.T) {
+	q, err := ParseGraphQuery(url.Values{})
+	if err != nil || q.Limit != GraphDefaultAuthors || q.Keywords != GraphDefaultKeywords || q.Filter.MinPosts != GraphDefaultMinPosts {
+		t.Errorf("defaults: got %+v %v", q, err)
+	}
+	q, err = ParseGraphQuery(url.Values{"topic": {"ai"}, "platform": {"devto"}, "limit": {"10"}, "keywords": {"0"}, "min_posts": {"1"}})
+	if err != nil || q.Filter.Topic != "ai" || q.Filter.Platform != "devto" || q.Limit != 10 || q.Keywords != 0 || q.Filter.MinPosts != 1 {
+		t.Errorf("got %+v %v", q, err)
+	}
+	for _, query := range []url.Values{{"topic": {"AI!"}}, {"limit": {"101"}}, {"keywords": {"-1"}}, {"min_posts": {"0"}}} {
+		if _, err := ParseGraphQuery(query); err == nil {
+			t.Errorf("%v should be rejected", query)
+		}
+	}
+}
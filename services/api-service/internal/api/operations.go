@@ -0,0 +1,276 @@
+// =====================================================
+// OPERATIONS - Danh sách endpoint của REST API
+// =====================================================
+// Mô tả: Nguồn duy nhất cho route, scope, nhóm rate limit và
+// kiểu response của từng endpoint. cmd/api đăng ký route theo
+// bảng này, Spec() sinh OpenAPI từ cùng bảng, nên spec không
+// lệch khỏi route thật
+// =====================================================
+
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"social-insight/internal/auth"
+	"social-insight/internal/database"
+	"social-insight/internal/export"
+	"social-insight/internal/health"
+	"social-insight/internal/models"
+	"social-insight/internal/ratelimit"
+)
+
+// Operation mô tả một endpoint
+type Operation struct {
+	// ID là operationId trong spec, cũng là tên method của Go client
+	ID string
+
+	Method  string
+	Path    string // Pattern của router, ví dụ /api/v1/stories/{id}
+	Summary string
+	Tag     string
+
+	// Alias là route cũ chưa có version (dashboard còn dùng), cùng handler;
+	// response có thêm header Deprecation
+	Alias string
+
+	// List != nil: endpoint danh sách, nhận limit/offset/cursor/from/to/
+	// topic/platform theo giới hạn này (xem ParseListQuery)
+	List *ListLimits
+
+	// Export: response là file (?format=csv|ndjson|parquet) mỗi dòng một
+	// Response, stream bằng Postgres cursor; nhận from/to/topic/platform
+	// (xem ParseExportQuery). Route không qua Timeout middleware
+	Export bool
+
+	// Params là path params theo thứ tự xuất hiện trong Path
+	Params []Param
+
+	// Query là query params riêng của endpoint (ngoài List/Export)
+	Query []Param
+
+	// Scope API key cần có ("" = public)
+	Scope string
+
+	// RateClass là nhóm rate limit ("" = không giới hạn)
+	RateClass string
+
+	// CacheTTL > 0: response 200 được cache trong Redis tối đa CacheTTL
+	// (hết hiệu lực sớm hơn khi consumer ghi batch mới) và có ETag/304
+	CacheTTL time.Duration
+
+	// Response là giá trị mẫu của kiểu response 200
+	Response interface{}
+
+	// Errors là các status lỗi riêng của endpoint
+	// (401/403/429/500 được thêm tự động theo Scope/RateClass)
+	Errors []int
+
+	// ErrorBody là kiểu body của Errors khi khác ErrorResponse
+	// (GET /api/health trả HealthReport cả khi 503)
+	ErrorBody interface{}
+}
+
+// Param là một path/query param
+type Param struct {
+	Name        string
+	Type        string // string | integer
+	Description string
+	Enum        []string
+	Default     string
+}
+
+// Operations là mọi endpoint /api/v1/* (trừ /api/openapi.json)
+var Operations = []Operation{
+	{
+		ID: "GetHealth", Method: http.MethodGet, Path: "/api/v1/health", Alias: "/api/health",
+		Tag:       "health",
+		Summary:   "Dependency status (same as /readyz); 503 when PostgreSQL is down",
+		Response:  health.Report{},
+		Errors:    []int{http.StatusServiceUnavailable},
+		ErrorBody: health.Report{},
+	},
+	{
+		ID: "GetStats", Method: http.MethodGet, Path: "/api/v1/stats", Alias: "/api/stats",
+		Tag:     "analytics",
+		Summary: "Total posts with counts by topic and sentiment",
+		Scope:   auth.ScopeReadAnalytics, RateClass: ratelimit.ClassDefault,
+		CacheTTL: 10 * time.Second,
+		Response: StatsResponse{},
+	},
+	{
+		ID: "GetTopics", Method: http.MethodGet, Path: "/api/v1/topics", Alias: "/api/topics",
+		Tag:     "analytics",
+		Summary: "Number of posts per top-level topic (a post counts once for each of its topics)",
+		Scope:   auth.ScopeReadAnalytics, RateClass: ratelimit.ClassDefault,
+		CacheTTL: 30 * time.Second,
+		Response: Counts{},
+	},
+	{
+		ID: "GetTaxonomy", Method: http.MethodGet, Path: "/api/v1/taxonomy", Alias: "/api/taxonomy",
+		Tag:     "analytics",
+		Summary: "Topic taxonomy (topics and their subtopics) with number of posts per topic",
+		Scope:   auth.ScopeReadAnalytics, RateClass: ratelimit.ClassDefault,
+		CacheTTL: 60 * time.Second,
+		Response: TaxonomyResponse{},
+	},
+	{
+		ID: "GetSentiment", Method: http.MethodGet, Path: "/api/v1/sentiment", Alias: "/api/sentiment",
+		Tag:     "analytics",
+		Summary: "Number of posts per sentiment",
+		Scope:   auth.ScopeReadAnalytics, RateClass: ratelimit.ClassDefault,
+		CacheTTL: 30 * time.Second,
+		Response: Counts{},
+	},
+	{
+		ID: "GetTopAuthors", Method: http.MethodGet, Path: "/api/v1/authors", Alias: "/api/authors",
+		Tag:     "analytics",
+		Summary: "Authors ranked by number of posts, engagement or engagement per post",
+		Query: []Param{
+			{Name: "sort", Type: "string", Description: "Ranking (engagement_rate = engagement per post)",
+				Enum: database.AuthorSorts, Default: "posts"},
+			{Name: "min_posts", Type: "integer", Description: "Skip authors with fewer posts", Default: "1"},
+		},
+		Scope: auth.ScopeReadAnalytics, RateClass: ratelimit.ClassDefault,
+		CacheTTL: 60 * time.Second,
+		List:     &TopAuthorsLimits,
+		Response: []models.AuthorStat{},
+	},
+	{
+		ID: "GetAuthor", Method: http.MethodGet, Path: "/api/v1/authors/{platform}/{handle}", Alias: "/api/authors/{platform}/{handle}",
+		Tag:     "analytics",
+		Summary: "Author profile: activity, engagement, topics and average sentiment",
+		Params: []Param{
+			{Name: "platform", Type: "string", Description: "Platform, e.g. hackernews"},
+			{Name: "handle", Type: "string", Description: "Username on the platform (case-insensitive)"},
+		},
+		Scope: auth.ScopeReadAnalytics, RateClass: ratelimit.ClassDefault,
+		CacheTTL: 60 * time.Second,
+		Response: models.AuthorProfile{},
+		Errors:   []int{http.StatusNotFound},
+	},
+	{
+		ID: "GetAuthorGraph", Method: http.MethodGet, Path: "/api/v1/graph/authors", Alias: "/api/graph/authors",
+		Tag:     "analytics",
+		Summary: "Most influential authors with their topics and keywords as a node/edge graph",
+		Query: []Param{
+			{Name: "topic", Type: "string", Description: "Only authors and posts of this topic"},
+			{Name: "platform", Type: "string", Description: "Only authors of this platform"},
+			{Name: "limit", Type: "integer", Description: "Author nodes, 1-" + strconv.Itoa(GraphMaxAuthors),
+				Default: strconv.Itoa(GraphDefaultAuthors)},
+			{Name: "keywords", Type: "integer", Description: "Keywords per author, 0-" + strconv.Itoa(GraphMaxKeywords),
+				Default: strconv.Itoa(GraphDefaultKeywords)},
+			{Name: "min_posts", Type: "integer", Description: "Skip authors with fewer posts (in the topic)",
+				Default: strconv.Itoa(GraphDefaultMinPosts)},
+		},
+		Scope: auth.ScopeReadAnalytics, RateClass: ratelimit.ClassHeavy,
+		CacheTTL: 60 * time.Second,
+		Response: AuthorGraphResponse{},
+	},
+	{
+		ID: "GetRecentPosts", Method: http.MethodGet, Path: "/api/v1/recent", Alias: "/api/recent",
+		Tag:     "posts",
+		Summary: "Latest posts (from Redis when unfiltered, otherwise PostgreSQL)",
+		Scope:   auth.ScopeReadPosts, RateClass: ratelimit.ClassDefault,
+		List:     &RecentPostsLimits,
+		Response: []models.Post{},
+	},
+	{
+		ID: "GetCrawlers", Method: http.MethodGet, Path: "/api/v1/crawlers", Alias: "/api/crawlers",
+		Tag:     "pipeline",
+		Summary: "Last crawl time per source",
+		Scope:   auth.ScopeReadAnalytics, RateClass: ratelimit.ClassDefault,
+		Response: CrawlerStatus{},
+	},
+	{
+		ID: "GetConsumers", Method: http.MethodGet, Path: "/api/v1/consumers", Alias: "/api/consumers",
+		Tag:     "pipeline",
+		Summary: "Consumer replicas and per-partition lag",
+		Scope:   auth.ScopeReadAnalytics, RateClass: ratelimit.ClassDefault,
+		Response: ConsumersResponse{},
+		Errors:   []int{http.StatusServiceUnavailable},
+	},
+	{
+		ID: "GetInsights", Method: http.MethodGet, Path: "/api/v1/insights", Alias: "/api/insights",
+		Tag:     "analytics",
+		Summary: "Insights detected in a time range (default last 24h)",
+		Scope:   auth.ScopeReadAnalytics, RateClass: ratelimit.ClassHeavy,
+		CacheTTL: 60 * time.Second,
+		List:     &InsightsLimits,
+		Response: InsightsResponse{},
+	},
+	{
+		ID: "GetCompare", Method: http.MethodGet, Path: "/api/v1/compare", Alias: "/api/compare",
+		Tag:     "analytics",
+		Summary: "Posts and engagement today vs yesterday",
+		Scope:   auth.ScopeReadAnalytics, RateClass: ratelimit.ClassHeavy,
+		CacheTTL: 30 * time.Second,
+		Response: CompareResponse{},
+	},
+	{
+		ID: "GetTrending", Method: http.MethodGet, Path: "/api/v1/trending", Alias: "/api/trending",
+		Tag:     "posts",
+		Summary: "Trending posts in a time range (default last 7 days)",
+		Scope:   auth.ScopeReadPosts, RateClass: ratelimit.ClassHeavy,
+		CacheTTL: 30 * time.Second,
+		List:     &TrendingLimits,
+		Response: TrendingResponse{},
+	},
+	{
+		ID: "GetCluster", Method: http.MethodGet, Path: "/api/v1/clusters/{id}", Alias: "/api/clusters/{id}",
+		Tag:     "posts",
+		Summary: "Cross-posted story cluster (near-duplicates) of a post",
+		Params:  []Param{{Name: "id", Type: "string", Description: "ID of the canonical post or any duplicate"}},
+		Scope:   auth.ScopeReadPosts, RateClass: ratelimit.ClassDefault,
+		CacheTTL: 60 * time.Second,
+		Response: ClusterResponse{},
+		Errors:   []int{http.StatusNotFound},
+	},
+	{
+		ID: "GetStory", Method: http.MethodGet, Path: "/api/v1/stories/{id}", Alias: "/api/stories/{id}",
+		Tag:     "posts",
+		Summary: "Link-based story: platforms and combined engagement",
+		Params:  []Param{{Name: "id", Type: "integer", Description: "Story ID"}},
+		Scope:   auth.ScopeReadPosts, RateClass: ratelimit.ClassDefault,
+		CacheTTL: 60 * time.Second,
+		Response: StoryResponse{},
+		Errors:   []int{http.StatusBadRequest, http.StatusNotFound},
+	},
+	{
+		ID: "ExportPosts", Method: http.MethodGet, Path: "/api/v1/export/posts", Alias: "/api/export/posts",
+		Tag:     "export",
+		Summary: "Stream posts matching the filters as CSV, NDJSON or Parquet",
+		Scope:   auth.ScopeReadPosts, RateClass: ratelimit.ClassExport,
+		Export:   true,
+		Response: export.PostRow{},
+	},
+	{
+		ID: "ExportAggregates", Method: http.MethodGet, Path: "/api/v1/export/aggregates", Alias: "/api/export/aggregates",
+		Tag:     "export",
+		Summary: "Stream post counts and engagement per time bucket, topic, platform and sentiment",
+		Query: []Param{{Name: "interval", Type: "string", Description: "Bucket width",
+			Enum: database.AggregateIntervals, Default: "day"}},
+		Scope: auth.ScopeReadAnalytics, RateClass: ratelimit.ClassExport,
+		Export:   true,
+		Response: export.AggregateRow{},
+	},
+}
+
+// CacheParams trả về các query param làm thay đổi response (tên → giá trị
+// mặc định), để cache key bỏ qua param lạ và giá trị mặc định
+func (op Operation) CacheParams() map[string]string {
+	params := make(map[string]string)
+	for _, p := range op.Query {
+		params[p.Name] = p.Default
+	}
+	if op.List != nil {
+		params["limit"] = strconv.Itoa(op.List.DefaultLimit)
+		params["offset"] = "0"
+		for _, name := range []string{"cursor", "from", "to", "topic", "platform"} {
+			params[name] = ""
+		}
+	}
+	return params
+}
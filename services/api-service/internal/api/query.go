@@ -0,0 +1,437 @@
+// =====================================================
+// LIST QUERY - Query params chung của endpoint danh sách
+// =====================================================
+// Mô tả: Mọi endpoint trả về danh sách nhận cùng bộ params:
+//   limit     số phần tử mỗi trang (1..MaxLimit)
+//   offset    bỏ qua n phần tử đầu (0..MaxOffset)
+//   cursor    trang tiếp theo, lấy từ header X-Next-Cursor
+//             (không dùng chung với offset)
+//   from, to  khoảng thời gian created_at [from, to), RFC3339 hoặc YYYY-MM-DD
+//   topic     lọc theo topic (ai, cloud, ...)
+//   platform  lọc theo nền tảng (hackernews, devto, medium, ...)
+// Giá trị sai → 400 invalid_parameter. Mặc định và giới hạn của
+// từng endpoint nằm trong ListLimits (khớp giá trị cứng trước đây)
+//
+// Cursor là chuỗi opaque: base64url của offset (danh sách xếp hạng)
+// hoặc (created_at, id) của phần tử cuối (posts, phân trang keyset).
+// Client gửi lại cursor cùng các filter như trang trước
+// =====================================================
+
+package api
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"social-insight/internal/cache"
+	"social-insight/internal/database"
+	"social-insight/internal/export"
+	"social-insight/internal/server"
+)
+
+// CodeInvalidParameter là mã lỗi khi query param không hợp lệ
+const CodeInvalidParameter = "invalid_parameter"
+
+// MaxOffset giới hạn offset (trang sâu hơn dùng cursor)
+const MaxOffset = 10000
+
+// HeaderNextCursor chứa cursor của trang tiếp theo (không có ở trang cuối)
+const HeaderNextCursor = "X-Next-Cursor"
+
+// labelPattern là dạng hợp lệ của topic/platform
+var labelPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,31}$`)
+
+// ListLimits là mặc định và giới hạn params của một endpoint
+type ListLimits struct {
+	DefaultLimit int
+	MaxLimit     int
+
+	// DefaultWindow: from mặc định = to - DefaultWindow (0 = không giới hạn)
+	DefaultWindow time.Duration
+
+	// MaxWindow giới hạn to - from (0 = không giới hạn); endpoint
+	// nạp cả khoảng thời gian vào memory phải đặt giới hạn này
+	MaxWindow time.Duration
+
+	// Keyset: cursor là (created_at, id) thay vì offset
+	Keyset bool
+}
+
+// Giới hạn của các endpoint danh sách
+var (
+	RecentPostsLimits = ListLimits{DefaultLimit: 20, MaxLimit: 100, Keyset: true}
+	TopAuthorsLimits  = ListLimits{DefaultLimit: 10, MaxLimit: 100}
+	TrendingLimits    = ListLimits{DefaultLimit: 10, MaxLimit: 50, DefaultWindow: 7 * 24 * time.Hour, MaxWindow: 30 * 24 * time.Hour}
+	InsightsLimits    = ListLimits{DefaultLimit: 20, MaxLimit: 100, DefaultWindow: 24 * time.Hour, MaxWindow: 30 * 24 * time.Hour}
+
+	// AggregatesLimits: aggregates của GraphQL (export không giới hạn)
+	AggregatesLimits = ListLimits{DefaultLimit: 100, MaxLimit: 1000, DefaultWindow: 7 * 24 * time.Hour, MaxWindow: 366 * 24 * time.Hour}
+)
+
+// ListQuery là params đã parse và kiểm tra
+type ListQuery struct {
+	Limit  int
+	Offset int
+
+	// After là phần tử cuối của trang trước (cursor keyset), nil nếu không có
+	After *database.PostKey
+
+	From     time.Time // Zero = không giới hạn
+	To       time.Time
+	Topic    string
+	Platform string
+
+	// filtered: client gửi from/to/topic/platform/offset/cursor
+	filtered bool
+}
+
+// Filtered báo client có gửi filter hoặc phân trang (không phải trang đầu mặc định)
+func (q ListQuery) Filtered() bool {
+	return q.filtered
+}
+
+// PostFilter trả về điều kiện lọc posts tương ứng
+func (q ListQuery) PostFilter() database.PostFilter {
+	return database.PostFilter{From: q.From, To: q.To, Topic: q.Topic, Platform: q.Platform}
+}
+
+// ParamError là lỗi của một query param
+type ParamError struct {
+	Param   string
+	Message string
+}
+
+func (e *ParamError) Error() string {
+	return "invalid " + e.Param + ": " + e.Message
+}
+
+// ParseListQuery đọc params của r theo limits; now là mốc của to mặc định
+func ParseListQuery(r *http.Request, limits ListLimits, now time.Time) (ListQuery, error) {
+	return ParseListValues(r.URL.Query(), limits, now)
+}
+
+// ParseListValues như ParseListQuery cho values có sẵn (arguments GraphQL)
+func ParseListValues(values url.Values, limits ListLimits, now time.Time) (ListQuery, error) {
+	q := ListQuery{Limit: limits.DefaultLimit, To: now}
+
+	var err error
+	if v := values.Get("limit"); v != "" {
+		if q.Limit, err = strconv.Atoi(v); err != nil || q.Limit < 1 || q.Limit > limits.MaxLimit {
+			return q, &ParamError{"limit", fmt.Sprintf("must be an integer between 1 and %d", limits.MaxLimit)}
+		}
+	}
+	if v := values.Get("offset"); v != "" {
+		if q.Offset, err = strconv.Atoi(v); err != nil || q.Offset < 0 || q.Offset > MaxOffset {
+			return q, &ParamError{"offset", fmt.Sprintf("must be an integer between 0 and %d (use cursor for deeper pages)", MaxOffset)}
+		}
+		q.filtered = true
+	}
+	if v := values.Get("cursor"); v != "" {
+		if values.Get("offset") != "" {
+			return q, &ParamError{"cursor", "cannot be combined with offset"}
+		}
+		c, err := decodeCursor(v)
+		if err != nil || (c.ID != "") != limits.Keyset {
+			return q, &ParamError{"cursor", "malformed or from another endpoint"}
+		}
+		if limits.Keyset {
+			q.After = &database.PostKey{CreatedAt: c.CreatedAt, ID: c.ID}
+		} else {
+			q.Offset = c.Offset
+		}
+		q.filtered = true
+	}
+
+	if err := parseFilters(values, limits, &q); err != nil {
+		return q, err
+	}
+	return q, nil
+}
+
+// parseFilters đọc from/to/topic/platform vào q (q.To đã là mốc mặc định)
+func parseFilters(values url.Values, limits ListLimits, q *ListQuery) error {
+	var err error
+	if v := values.Get("to"); v != "" {
+		if q.To, err = parseTime(v); err != nil {
+			return &ParamError{"to", "must be RFC3339 or YYYY-MM-DD"}
+		}
+		q.filtered = true
+	}
+	if v := values.Get("from"); v != "" {
+		if q.From, err = parseTime(v); err != nil {
+			return &ParamError{"from", "must be RFC3339 or YYYY-MM-DD"}
+		}
+		q.filtered = true
+	} else if limits.DefaultWindow > 0 {
+		q.From = q.To.Add(-limits.DefaultWindow)
+	}
+	if !q.From.IsZero() && !q.From.Before(q.To) {
+		return &ParamError{"from", "must be before to"}
+	}
+	if limits.MaxWindow > 0 {
+		if q.From.IsZero() {
+			q.From = q.To.Add(-limits.MaxWindow)
+		} else if q.To.Sub(q.From) > limits.MaxWindow {
+			return &ParamError{"from", fmt.Sprintf("time range is limited to %s", formatWindow(limits.MaxWindow))}
+		}
+	}
+
+	if q.Topic, err = parseTopic(values); err != nil {
+		return err
+	}
+	if q.Platform, err = parsePlatform(values); err != nil {
+		return err
+	}
+	q.filtered = q.filtered || q.Topic != "" || q.Platform != ""
+	return nil
+}
+
+// parseTopic đọc topic ("" nếu không có)
+func parseTopic(values url.Values) (string, error) {
+	v := values.Get("topic")
+	if v != "" && !labelPattern.MatchString(v) {
+		return "", &ParamError{"topic", "must be a lowercase name such as ai or devops"}
+	}
+	return v, nil
+}
+
+// parsePlatform đọc platform ("" nếu không có)
+func parsePlatform(values url.Values) (string, error) {
+	v := values.Get("platform")
+	if v != "" && !labelPattern.MatchString(v) {
+		return "", &ParamError{"platform", "must be a lowercase name such as hackernews or devto"}
+	}
+	return v, nil
+}
+
+// ExportQuery là params của GET /api/v1/export/*
+type ExportQuery struct {
+	Format export.Format
+	Filter database.PostFilter
+}
+
+// ParseExportQuery đọc format và from/to/topic/platform (không giới hạn
+// khoảng thời gian: export đọc bằng cursor nên không nạp hết vào memory)
+func ParseExportQuery(r *http.Request, now time.Time) (ExportQuery, error) {
+	return ParseExportValues(r.URL.Query(), now)
+}
+
+// ParseExportValues như ParseExportQuery cho values có sẵn (lệnh cmd/export)
+func ParseExportValues(values url.Values, now time.Time) (ExportQuery, error) {
+	format, err := export.ParseFormat(values.Get("format"))
+	if err != nil {
+		return ExportQuery{}, &ParamError{"format", "must be one of csv, ndjson, parquet"}
+	}
+	q := ListQuery{To: now}
+	if err := parseFilters(values, ListLimits{}, &q); err != nil {
+		return ExportQuery{}, err
+	}
+	return ExportQuery{Format: format, Filter: q.PostFilter()}, nil
+}
+
+// ParseInterval đọc interval của export aggregates (mặc định day)
+func ParseInterval(v string) (string, error) {
+	if v == "" {
+		return "day", nil
+	}
+	for _, i := range database.AggregateIntervals {
+		if i == v {
+			return v, nil
+		}
+	}
+	return "", &ParamError{"interval", "must be one of hour, day, week"}
+}
+
+// ParseAuthorRanking đọc sort (mặc định posts) và min_posts (mặc định 1)
+// của GET /api/v1/authors
+func ParseAuthorRanking(values url.Values) (database.AuthorRanking, error) {
+	r := database.AuthorRanking{Sort: "posts", MinPosts: 1}
+	if v := values.Get("sort"); v != "" {
+		valid := false
+		for _, s := range database.AuthorSorts {
+			valid = valid || s == v
+		}
+		if !valid {
+			return r, &ParamError{"sort", "must be one of " + strings.Join(database.AuthorSorts, ", ")}
+		}
+		r.Sort = v
+	}
+	if v := values.Get("min_posts"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			return r, &ParamError{"min_posts", "must be a positive integer"}
+		}
+		r.MinPosts = n
+	}
+	return r, nil
+}
+
+// Giới hạn params của GET /api/v1/graph/authors
+const (
+	GraphDefaultAuthors  = 25
+	GraphMaxAuthors      = 100
+	GraphDefaultKeywords = 5
+	GraphMaxKeywords     = 20
+	GraphDefaultMinPosts = 2
+)
+
+// GraphQuery là params đã parse của GET /api/v1/graph/authors
+type GraphQuery struct {
+	Filter   database.AuthorGraphFilter
+	Limit    int // Số tác giả
+	Keywords int // Số keywords tối đa mỗi tác giả (0 = không có đỉnh keyword)
+}
+
+// ParseGraphQuery đọc topic, platform, limit, keywords và min_posts
+func ParseGraphQuery(values url.Values) (GraphQuery, error) {
+	q := GraphQuery{
+		Filter:   database.AuthorGraphFilter{MinPosts: GraphDefaultMinPosts},
+		Limit:    GraphDefaultAuthors,
+		Keywords: GraphDefaultKeywords,
+	}
+	var err error
+	if q.Filter.Topic, err = parseTopic(values); err != nil {
+		return q, err
+	}
+	if q.Filter.Platform, err = parsePlatform(values); err != nil {
+		return q, err
+	}
+	if v := values.Get("limit"); v != "" {
+		if q.Limit, err = strconv.Atoi(v); err != nil || q.Limit < 1 || q.Limit > GraphMaxAuthors {
+			return q, &ParamError{"limit", fmt.Sprintf("must be an integer between 1 and %d", GraphMaxAuthors)}
+		}
+	}
+	if v := values.Get("keywords"); v != "" {
+		if q.Keywords, err = strconv.Atoi(v); err != nil || q.Keywords < 0 || q.Keywords > GraphMaxKeywords {
+			return q, &ParamError{"keywords", fmt.Sprintf("must be an integer between 0 and %d", GraphMaxKeywords)}
+		}
+	}
+	if v := values.Get("min_posts"); v != "" {
+		if q.Filter.MinPosts, err = strconv.Atoi(v); err != nil || q.Filter.MinPosts < 1 {
+			return q, &ParamError{"min_posts", "must be a positive integer"}
+		}
+	}
+	return q, nil
+}
+
+// WriteParamError trả 400 cho lỗi của ParseListQuery/ParseExportQuery
+func WriteParamError(w http.ResponseWriter, err error) {
+	server.WriteErrorCode(w, http.StatusBadRequest, CodeInvalidParameter, err.Error())
+}
+
+// parseTime nhận RFC3339 hoặc ngày YYYY-MM-DD (00:00 UTC)
+func parseTime(v string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", v)
+}
+
+// formatWindow in khoảng thời gian theo ngày nếu chẵn ngày (720h → 30 days)
+func formatWindow(d time.Duration) string {
+	if d%(24*time.Hour) == 0 {
+		return strconv.Itoa(int(d/(24*time.Hour))) + " days"
+	}
+	return d.String()
+}
+
+// =====================================================
+// CURSOR
+// =====================================================
+
+// cursor là nội dung của cursor opaque
+type cursor struct {
+	Offset    int       `json:"o,omitempty"`
+	CreatedAt time.Time `json:"t,omitempty"`
+	ID        string    `json:"id,omitempty"`
+}
+
+// decodeCursor giải mã cursor
+func decodeCursor(s string) (cursor, error) {
+	var c cursor
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, err
+	}
+	if err := json.Unmarshal(data, &c); err != nil {
+		return c, err
+	}
+	if c.Offset < 0 {
+		return c, fmt.Errorf("negative offset")
+	}
+	return c, nil
+}
+
+// encodeCursor mã hóa cursor
+func encodeCursor(c cursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// OffsetCursor là cursor của trang bắt đầu tại offset
+func OffsetCursor(offset int) string {
+	return encodeCursor(cursor{Offset: offset})
+}
+
+// KeysetCursor là cursor của trang sau post cuối cùng đã trả về
+func KeysetCursor(createdAt time.Time, id string) string {
+	return encodeCursor(cursor{CreatedAt: createdAt, ID: id})
+}
+
+// SetNextCursor gắn X-Next-Cursor và Link rel="next" (cùng filters, cursor mới)
+func SetNextCursor(w http.ResponseWriter, r *http.Request, next string) {
+	w.Header().Set(HeaderNextCursor, next)
+	w.Header().Add("Link", cache.NextLink(r, next))
+}
+
+// Page cắt items theo q.Offset/q.Limit (danh sách tính trong memory)
+// và trả về cursor trang sau ("" nếu hết)
+func Page[T any](items []T, q ListQuery) ([]T, string) {
+	if q.Offset >= len(items) {
+		return items[:0], ""
+	}
+	end := q.Offset + q.Limit
+	if end >= len(items) {
+		return items[q.Offset:], ""
+	}
+	return items[q.Offset:end], OffsetCursor(end)
+}
+
+// listParams là mô tả params danh sách cho spec
+func listParams(l ListLimits) []object {
+	window := "no lower bound by default"
+	if l.DefaultWindow > 0 {
+		window = "defaults to to - " + formatWindow(l.DefaultWindow)
+	}
+	if l.MaxWindow > 0 {
+		window += "; range limited to " + formatWindow(l.MaxWindow)
+	}
+	return append([]object{
+		{"name": "limit", "in": "query", "description": "Items per page",
+			"schema": object{"type": "integer", "minimum": 1, "maximum": l.MaxLimit, "default": l.DefaultLimit}},
+		{"name": "offset", "in": "query", "description": "Items to skip (not with cursor)",
+			"schema": object{"type": "integer", "minimum": 0, "maximum": MaxOffset, "default": 0}},
+		{"name": "cursor", "in": "query", "description": "Opaque cursor from the " + HeaderNextCursor + " header of the previous page",
+			"schema": object{"type": "string"}},
+	}, filterParams(window)...)
+}
+
+// filterParams là mô tả from/to/topic/platform cho spec
+func filterParams(window string) []object {
+	label := object{"type": "string", "pattern": labelPattern.String()}
+	return []object{
+		{"name": "from", "in": "query", "description": "Inclusive lower bound of created_at, RFC3339 or YYYY-MM-DD; " + window,
+			"schema": object{"type": "string"}},
+		{"name": "to", "in": "query", "description": "Exclusive upper bound of created_at, RFC3339 or YYYY-MM-DD; defaults to now",
+			"schema": object{"type": "string"}},
+		{"name": "topic", "in": "query", "description": "Only posts with this topic", "schema": label},
+		{"name": "platform", "in": "query", "description": "Only posts from this platform", "schema": label},
+	}
+}
@@ -0,0 +1,159 @@
+package api
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testNow = time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+
+func parse(t *testing.T, limits ListLimits, query string) (ListQuery, error) {
+	t.Helper()
+	r := httptest.NewRequest("GET", "/api/v1/things?"+query, nil)
+	return ParseListQuery(r, limits, testNow)
+}
+
+func TestParseListQueryDefaults(t *testing.T) {
+	q, err := parse(t, TrendingLimits, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q.Limit != 10 || q.Offset != 0 || q.Filtered() {
+		t.Errorf("got limit=%d offset=%d filtered=%v", q.Limit, q.Offset, q.Filtered())
+	}
+	if !q.To.Equal(testNow) || !q.From.Equal(testNow.Add(-7*24*time.Hour)) {
+		t.Errorf("got range %s..%s", q.From, q.To)
+	}
+
+	q, _ = parse(t, RecentPostsLimits, "")
+	if !q.From.IsZero() {
+		t.Errorf("recent posts should have no lower bound, got %s", q.From)
+	}
+}
+
+func TestParseListQueryFilters(t *testing.T) {
+	q, err := parse(t, TrendingLimits, "limit=5&offset=10&from=2024-03-01&to=2024-03-05T00:00:00Z&topic=ai&platform=devto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q.Limit != 5 || q.Offset != 10 || q.Topic != "ai" || q.Platform != "devto" || !q.Filtered() {
+		t.Errorf("unexpected query %+v", q)
+	}
+	if !q.From.Equal(time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)) || !q.To.Equal(time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("got range %s..%s", q.From, q.To)
+	}
+}
+
+func TestParseListQueryErrors(t *testing.T) {
+	tests := []struct {
+		limits ListLimits
+		query  string
+		param  string
+	}{
+		{TrendingLimits, "limit=0", "limit"},
+		{TrendingLimits, "limit=51", "limit"},
+		{TrendingLimits, "limit=abc", "limit"},
+		{TrendingLimits, "offset=-1", "offset"},
+		{TrendingLimits, "offset=10001", "offset"},
+		{TrendingLimits, "offset=1&cursor=" + OffsetCursor(5), "cursor"},
+		{TrendingLimits, "cursor=!!", "cursor"},
+		{TrendingLimits, "cursor=" + KeysetCursor(testNow, "abc"), "cursor"},
+		{RecentPostsLimits, "cursor=" + OffsetCursor(5), "cursor"},
+		{TrendingLimits, "from=yesterday", "from"},
+		{TrendingLimits, "from=2024-03-05&to=2024-03-01", "from"},
+		{TrendingLimits, "from=2023-01-01", "from"},
+		{TrendingLimits, "topic=AI", "topic"},
+		{TrendingLimits, "platform=" + strings.Repeat("x", 40), "platform"},
+	}
+	for _, tt := range tests {
+		_, err := parse(t, tt.limits, tt.query)
+		pe, ok := err.(*ParamError)
+		if !ok || pe.Param != tt.param {
+			t.Errorf("%s: got %v, want error on %s", tt.query, err, tt.param)
+		}
+	}
+}
+
+func TestCursors(t *testing.T) {
+	q, err := parse(t, TopAuthorsLimits, "cursor="+OffsetCursor(30))
+	if err != nil || q.Offset != 30 {
+		t.Errorf("offset cursor: got offset=%d err=%v", q.Offset, err)
+	}
+
+	created := time.Date(2024, 3, 9, 8, 0, 0, 0, time.UTC)
+	q, err = parse(t, RecentPostsLimits, "cursor="+KeysetCursor(created, "p1"))
+	if err != nil || q.After == nil || q.After.ID != "p1" || !q.After.CreatedAt.Equal(created) {
+		t.Errorf("keyset cursor: got %+v err=%v", q.After, err)
+	}
+}
+
+func TestPage(t *testing.T) {
+	items := []int{1, 2, 3, 4, 5}
+	page, next := Page(items, ListQuery{Limit: 2, Offset: 1})
+	if len(page) != 2 || page[0] != 2 || next != OffsetCursor(3) {
+		t.Errorf("got %v next=%q", page, next)
+	}
+	page, next = Page(items, ListQuery{Limit: 2, Offset: 4})
+	if len(page) != 1 || next != "" {
+		t.Errorf("last page: got %v next=%q", page, next)
+	}
+	page, next = Page(items, ListQuery{Limit: 2, Offset: 9})
+	if len(page) != 0 || next != "" {
+		t.Errorf("past the end: got %v next=%q", page, next)
+	}
+}
+
+func TestSetNextCursor(t *testing.T) {
+	r := httptest.NewRequest("GET", "/api/v1/trending?topic=ai&offset=10", nil)
+	w := httptest.NewRecorder()
+	SetNextCursor(w, r, "abc")
+	if got := w.Header().Get(HeaderNextCursor); got != "abc" {
+		t.Errorf("X-Next-Cursor = %q", got)
+	}
+	if got := w.Header().Get("Link"); got != `</api/v1/trending?cursor=abc&topic=ai>; rel="next"` {
+		t.Errorf("Link = %q", got)
+	}
+}
+
+func TestParseExportQuery(t *testing.T) {
+	r := httptest.NewRequest("GET", "/api/v1/export/posts?format=parquet&from=2020-01-01&topic=ai", nil)
+	q, err := ParseExportQuery(r, testNow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if q.Format != "parquet" || q.Filter.Topic != "ai" || !q.Filter.To.Equal(testNow) ||
+		!q.Filter.From.Equal(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("unexpected query %+v", q)
+	}
+
+	r = httptest.NewRequest("GET", "/api/v1/export/posts?format=xlsx", nil)
+	if _, err := ParseExportQuery(r, testNow); err == nil || err.(*ParamError).Param != "format" {
+		t.Errorf("format: got %v", err)
+	}
+
+	if i, err := ParseInterval(""); err != nil || i != "day" {
+		t.Errorf("default interval: got %q %v", i, err)
+	}
+	if _, err := ParseInterval("month"); err == nil {
+		t.Error("month should be rejected")
+	}
+}
+
+func TestParseAuthorRanking(t *testing.T) {
+	r, err := ParseAuthorRanking(url.Values{})
+	if err != nil || r.Sort != "posts" || r.MinPosts != 1 {
+		t.Errorf("defaults: got %+v %v", r, err)
+	}
+	r, err = ParseAuthorRanking(url.Values{"sort": {"engagement_rate"}, "min_posts": {"3"}})
+	if err != nil || r.Sort != "engagement_rate" || r.MinPosts != 3 {
+		t.Errorf("got %+v %v", r, err)
+	}
+	for _, query := range []url.Values{{"sort": {"likes"}}, {"min_posts": {"0"}}, {"min_posts": {"x"}}} {
+		if _, err := ParseAuthorRanking(query); err == nil {
+			t.Errorf("%v should be rejected", query)
+		}
+	}
+}
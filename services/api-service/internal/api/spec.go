@@ -0,0 +1,371 @@
+// =====================================================
+// OPENAPI SPEC - Sinh OpenAPI 3 từ Operations và kiểu response
+// =====================================================
+// Mô tả: Spec() dựng document OpenAPI 3.0 bằng reflection trên
+// kiểu response (json tags → properties, omitempty → không required).
+// Bản đã sinh được commit ở openapi.json và phục vụ tại
+// /api/openapi.json; contract test kiểm tra file khớp với Spec()
+//
+// Sinh lại spec và Go client sau khi sửa kiểu/endpoint:
+//   go generate ./internal/api
+// =====================================================
+
+//go:generate go run ../../cmd/apigen -spec openapi.json -client ../../client/client.gen.go
+
+package api
+
+import (
+	_ "embed"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"social-insight/internal/auth"
+	"social-insight/internal/cache"
+	"social-insight/internal/export"
+	"social-insight/internal/health"
+	"social-insight/internal/server"
+)
+
+// SpecVersion là version của API trong info.version
+const SpecVersion = "1.0.0"
+
+// specJSON là openapi.json đã sinh (go generate)
+//
+//go:embed openapi.json
+var specJSON []byte
+
+// SpecJSON trả về openapi.json đang phục vụ
+func SpecJSON() []byte {
+	return specJSON
+}
+
+// SpecHandler phục vụ openapi.json
+func SpecHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(specJSON)
+}
+
+// schemaNames đặt tên schema khác tên kiểu Go (tên quá chung)
+var schemaNames = map[reflect.Type]string{
+	reflect.TypeOf(health.Report{}):           "HealthReport",
+	reflect.TypeOf(health.DependencyStatus{}): "DependencyStatus",
+}
+
+// object là một node JSON của spec (map để json.Marshal sắp xếp key ổn định)
+type object = map[string]interface{}
+
+// specBuilder gom schemas trong components khi duyệt kiểu
+type specBuilder struct {
+	schemas object
+}
+
+// Spec dựng OpenAPI document từ Operations
+func Spec() ([]byte, error) {
+	b := &specBuilder{schemas: object{}}
+	errorRef := b.schema(reflect.TypeOf(server.ErrorResponse{}))
+
+	paths := object{}
+	for _, op := range Operations {
+		item, _ := paths[op.Path].(object)
+		if item == nil {
+			item = object{}
+			paths[op.Path] = item
+		}
+		item[strings.ToLower(op.Method)] = b.operation(op, errorRef)
+	}
+	paths["/api/openapi.json"] = object{
+		"get": object{
+			"operationId": "GetOpenAPI",
+			"summary":     "This OpenAPI document",
+			"tags":        []string{"meta"},
+			"responses": object{
+				"200": object{
+					"description": "OpenAPI 3 document",
+					"content":     object{"application/json": object{"schema": object{"type": "object"}}},
+				},
+			},
+		},
+	}
+
+	doc := object{
+		"openapi": "3.0.3",
+		"info": object{
+			"title":   "Social Insight API",
+			"version": SpecVersion,
+			"description": "REST API of the Social Insight dashboard. Errors always have the body " +
+				"`{\"error\": {\"code\": \"...\", \"message\": \"...\"}}`. " +
+				"Every /api/v1 route is also served without the version prefix (x-deprecated-alias); " +
+				"those aliases are deprecated and kept for the dashboard during migration.",
+		},
+		"servers": []object{{"url": "/"}},
+		"paths":   paths,
+		"components": object{
+			"schemas": b.schemas,
+			"securitySchemes": object{
+				"bearerAuth":   object{"type": "http", "scheme": "bearer"},
+				"apiKeyHeader": object{"type": "apiKey", "in": "header", "name": auth.HeaderAPIKey},
+			},
+		},
+	}
+
+	out, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(out, '\n'), nil
+}
+
+// operation dựng Operation Object
+func (b *specBuilder) operation(op Operation, errorRef object) object {
+	o := object{
+		"operationId": op.ID,
+		"summary":     op.Summary,
+		"tags":        []string{op.Tag},
+	}
+
+	if len(op.Params) > 0 || len(op.Query) > 0 || op.List != nil || op.Export || op.CacheTTL > 0 {
+		params := make([]object, 0, len(op.Params))
+		for _, p := range op.Params {
+			schema := object{"type": p.Type}
+			if p.Type == "integer" {
+				schema["format"] = "int64"
+			}
+			params = append(params, object{
+				"name":        p.Name,
+				"in":          "path",
+				"required":    true,
+				"description": p.Description,
+				"schema":      schema,
+			})
+		}
+		for _, p := range op.Query {
+			schema := object{"type": p.Type}
+			if len(p.Enum) > 0 {
+				schema["enum"] = p.Enum
+			}
+			if n, err := strconv.Atoi(p.Default); err == nil && p.Type == "integer" {
+				schema["default"] = n
+			} else if p.Default != "" {
+				schema["default"] = p.Default
+			}
+			params = append(params, object{
+				"name":        p.Name,
+				"in":          "query",
+				"description": p.Description,
+				"schema":      schema,
+			})
+		}
+		if op.List != nil {
+			params = append(params, listParams(*op.List)...)
+		}
+		if op.Export {
+			formats := make([]string, 0, len(export.Formats))
+			for _, f := range export.Formats {
+				formats = append(formats, string(f))
+			}
+			params = append(params, object{
+				"name":        "format",
+				"in":          "query",
+				"description": "File format",
+				"schema":      object{"type": "string", "enum": formats, "default": string(export.FormatCSV)},
+			})
+			params = append(params, filterParams("no lower bound by default")...)
+		}
+		if op.CacheTTL > 0 {
+			params = append(params, object{
+				"name":        "If-None-Match",
+				"in":          "header",
+				"description": "ETag of a previous response; 304 without body when unchanged",
+				"schema":      object{"type": "string"},
+			})
+		}
+		o["parameters"] = params
+	}
+	if op.Alias != "" {
+		o["x-deprecated-alias"] = op.Alias
+	}
+
+	ok := object{
+		"description": "OK",
+		"content":     object{"application/json": object{"schema": b.schema(reflect.TypeOf(op.Response))}},
+	}
+	responses := object{"200": ok}
+	addError := func(status int) {
+		responses[strconv.Itoa(status)] = object{
+			"description": http.StatusText(status),
+			"content":     object{"application/json": object{"schema": errorRef}},
+		}
+	}
+	if op.List != nil {
+		ok["headers"] = object{
+			HeaderNextCursor: object{
+				"description": "Cursor of the next page; absent on the last page",
+				"schema":      object{"type": "string"},
+			},
+			"Link": object{
+				"description": `Next page URL with rel="next"; absent on the last page`,
+				"schema":      object{"type": "string"},
+			},
+		}
+		addError(http.StatusBadRequest)
+	}
+	if op.Export {
+		// NDJSON: mỗi dòng một object theo schema của Response
+		ok["content"] = object{
+			export.FormatCSV.ContentType():     object{"schema": object{"type": "string"}},
+			export.FormatNDJSON.ContentType():  object{"schema": b.schema(reflect.TypeOf(op.Response))},
+			export.FormatParquet.ContentType(): object{"schema": object{"type": "string", "format": "binary"}},
+		}
+		ok["headers"] = object{
+			"Content-Disposition": object{
+				"description": "attachment; filename=<name>-<date>.<format>",
+				"schema":      object{"type": "string"},
+			},
+		}
+		addError(http.StatusBadRequest)
+	}
+	if op.CacheTTL > 0 {
+		// Headers của list (cursor) giữ nguyên, thêm ETag/X-Cache
+		headers, _ := ok["headers"].(object)
+		if headers == nil {
+			headers = object{}
+			ok["headers"] = headers
+		}
+		headers["ETag"] = object{
+			"description": "Weak validator of the body, send it back in If-None-Match",
+			"schema":      object{"type": "string"},
+		}
+		headers[cache.HeaderCache] = object{
+			"description": "HIT when served from the Redis response cache, MISS or BYPASS otherwise",
+			"schema":      object{"type": "string", "enum": []string{cache.ResultHit, cache.ResultMiss, cache.ResultBypass}},
+		}
+		responses[strconv.Itoa(http.StatusNotModified)] = object{
+			"description": "Not Modified: If-None-Match matches the current ETag",
+		}
+		o["x-cache-ttl-seconds"] = int(op.CacheTTL.Seconds())
+	}
+	for _, status := range op.Errors {
+		if op.ErrorBody == nil {
+			addError(status)
+			continue
+		}
+		responses[strconv.Itoa(status)] = object{
+			"description": http.StatusText(status),
+			"content":     object{"application/json": object{"schema": b.schema(reflect.TypeOf(op.ErrorBody))}},
+		}
+	}
+
+	if op.Scope != "" {
+		o["security"] = []object{{"bearerAuth": []string{}}, {"apiKeyHeader": []string{}}}
+		o["x-required-scope"] = op.Scope
+		o["description"] = "Requires an API key with scope `" + op.Scope + "` when AUTH_ENABLED=true."
+		addError(http.StatusUnauthorized)
+		addError(http.StatusForbidden)
+	}
+	if op.RateClass != "" {
+		o["x-rate-limit-class"] = op.RateClass
+		addError(http.StatusTooManyRequests)
+	}
+	addError(http.StatusInternalServerError)
+
+	o["responses"] = responses
+	return o
+}
+
+// timeType dùng để nhận ra time.Time
+var timeType = reflect.TypeOf(time.Time{})
+
+// schema trả về schema của t; struct có tên được đưa vào components
+// và trả về $ref
+func (b *specBuilder) schema(t reflect.Type) object {
+	switch {
+	case t == timeType:
+		return object{"type": "string", "format": "date-time"}
+	case t.Kind() == reflect.Ptr:
+		s := b.schema(t.Elem())
+		if ref, ok := s["$ref"]; ok {
+			return object{"allOf": []object{{"$ref": ref}}, "nullable": true}
+		}
+		s["nullable"] = true
+		return s
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return object{"type": "string"}
+	case reflect.Bool:
+		return object{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Uint8, reflect.Uint16:
+		return object{"type": "integer"}
+	case reflect.Int32:
+		return object{"type": "integer", "format": "int32"}
+	case reflect.Int64, reflect.Uint32, reflect.Uint64, reflect.Uint:
+		return object{"type": "integer", "format": "int64"}
+	case reflect.Float32, reflect.Float64:
+		return object{"type": "number", "format": "double"}
+	case reflect.Slice, reflect.Array:
+		return object{"type": "array", "items": b.schema(t.Elem())}
+	case reflect.Map:
+		return object{"type": "object", "additionalProperties": b.schema(t.Elem())}
+	case reflect.Struct:
+		return b.structRef(t)
+	}
+	return object{}
+}
+
+// structRef đưa struct vào components.schemas và trả về $ref
+func (b *specBuilder) structRef(t reflect.Type) object {
+	name := schemaNames[t]
+	if name == "" {
+		name = t.Name()
+	}
+	ref := object{"$ref": "#/components/schemas/" + name}
+	if _, ok := b.schemas[name]; ok {
+		return ref
+	}
+	// Đặt chỗ trước để kiểu đệ quy không lặp vô hạn
+	b.schemas[name] = object{}
+
+	props := object{}
+	var required []string
+	b.fields(t, props, &required)
+
+	s := object{"type": "object", "properties": props}
+	if len(required) > 0 {
+		sort.Strings(required)
+		s["required"] = required
+	}
+	b.schemas[name] = s
+	return ref
+}
+
+// fields thêm các field có json tag của t vào props (struct nhúng được trải phẳng)
+func (b *specBuilder) fields(t reflect.Type, props object, required *[]string) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+			b.fields(f.Type, props, required)
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		props[name] = b.schema(f.Type)
+		if !strings.Contains(opts, "omitempty") {
+			*required = append(*required, name)
+		}
+	}
+}
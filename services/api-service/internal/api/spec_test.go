@@ -0,0 +1,236 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"social-insight/internal/export"
+)
+
+// Contract test: openapi.json và client.gen.go đã commit phải khớp với
+// Operations/kiểu response hiện tại, và JSON thật của từng kiểu phải
+// đúng schema trong spec
+
+func TestSpecUpToDate(t *testing.T) {
+	spec, err := Spec()
+	if err != nil {
+		t.Fatalf("Spec: %v", err)
+	}
+	if !bytes.Equal(spec, SpecJSON()) {
+		t.Fatal("internal/api/openapi.json is out of date, run: go generate ./internal/api")
+	}
+}
+
+func TestClientUpToDate(t *testing.T) {
+	want, err := GenerateClient(SpecJSON())
+	if err != nil {
+		t.Fatalf("GenerateClient: %v", err)
+	}
+	got, err := os.ReadFile("../../client/client.gen.go")
+	if err != nil {
+		t.Fatalf("read client: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatal("client/client.gen.go is out of date, run: go generate ./internal/api")
+	}
+}
+
+func TestOperationsWellFormed(t *testing.T) {
+	ids := make(map[string]bool)
+	routes := make(map[string]bool)
+	for _, op := range Operations {
+		if ids[op.ID] {
+			t.Errorf("duplicate operation id %s", op.ID)
+		}
+		ids[op.ID] = true
+		if routes[op.Method+" "+op.Path] {
+			t.Errorf("duplicate route %s %s", op.Method, op.Path)
+		}
+		routes[op.Method+" "+op.Path] = true
+
+		var params []string
+		for _, seg := range strings.Split(op.Path, "/") {
+			if strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "}") {
+				params = append(params, seg[1:len(seg)-1])
+			}
+		}
+		if len(params) != len(op.Params) {
+			t.Errorf("%s: path has %d params, Params has %d", op.ID, len(params), len(op.Params))
+			continue
+		}
+		for i, p := range op.Params {
+			if p.Name != params[i] {
+				t.Errorf("%s: param %d is %q in path, %q in Params", op.ID, i, params[i], p.Name)
+			}
+		}
+	}
+}
+
+func TestResponsesMatchSchemas(t *testing.T) {
+	var doc map[string]interface{}
+	if err := json.Unmarshal(SpecJSON(), &doc); err != nil {
+		t.Fatalf("parse spec: %v", err)
+	}
+	schemas := doc["components"].(map[string]interface{})["schemas"].(map[string]interface{})
+	paths := doc["paths"].(map[string]interface{})
+
+	for _, op := range Operations {
+		operation := paths[op.Path].(map[string]interface{})[strings.ToLower(op.Method)].(map[string]interface{})
+		contentType := "application/json"
+		if op.Export {
+			// Mỗi dòng NDJSON là một Response
+			contentType = export.FormatNDJSON.ContentType()
+		}
+		schema := operation["responses"].(map[string]interface{})["200"].(map[string]interface{})["content"].(map[string]interface{})[contentType].(map[string]interface{})["schema"]
+
+		// Giá trị mẫu có mọi field khác zero để omitempty không che field thiếu
+		v := reflect.New(reflect.TypeOf(op.Response)).Elem()
+		fill(v, 0)
+		body, err := json.Marshal(v.Interface())
+		if err != nil {
+			t.Fatalf("%s: marshal: %v", op.ID, err)
+		}
+		var value interface{}
+		json.Unmarshal(body, &value)
+
+		for _, problem := range validate(value, schema.(map[string]interface{}), schemas, "$") {
+			t.Errorf("%s: %s", op.ID, problem)
+		}
+	}
+}
+
+// fill gán giá trị khác zero cho mọi field (slice/map có một phần tử)
+func fill(v reflect.Value, depth int) {
+	if depth > 6 {
+		return
+	}
+	if v.Type() == reflect.TypeOf(time.Time{}) {
+		v.Set(reflect.ValueOf(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)))
+		return
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString("x")
+	case reflect.Bool:
+		v.SetBool(true)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v.SetInt(1)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v.SetUint(1)
+	case reflect.Float32, reflect.Float64:
+		v.SetFloat(1.5)
+	case reflect.Ptr:
+		v.Set(reflect.New(v.Type().Elem()))
+		fill(v.Elem(), depth+1)
+	case reflect.Slice:
+		v.Set(reflect.MakeSlice(v.Type(), 1, 1))
+		fill(v.Index(0), depth+1)
+	case reflect.Map:
+		m := reflect.MakeMap(v.Type())
+		key := reflect.New(v.Type().Key()).Elem()
+		fill(key, depth+1)
+		elem := reflect.New(v.Type().Elem()).Elem()
+		fill(elem, depth+1)
+		m.SetMapIndex(key, elem)
+		v.Set(m)
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() {
+				fill(v.Field(i), depth+1)
+			}
+		}
+	}
+}
+
+// validate kiểm tra value (JSON đã decode) theo schema, trả về các chỗ sai
+func validate(value interface{}, schema, schemas map[string]interface{}, path string) []string {
+	if ref, ok := schema["$ref"].(string); ok {
+		name := ref[strings.LastIndex(ref, "/")+1:]
+		target, ok := schemas[name].(map[string]interface{})
+		if !ok {
+			return []string{path + ": unknown schema " + name}
+		}
+		return validate(value, target, schemas, path)
+	}
+	if allOf, ok := schema["allOf"].([]interface{}); ok {
+		if value == nil && schema["nullable"] == true {
+			return nil
+		}
+		return validate(value, allOf[0].(map[string]interface{}), schemas, path)
+	}
+	if value == nil {
+		if schema["nullable"] == true {
+			return nil
+		}
+		return []string{path + ": null but not nullable"}
+	}
+
+	var problems []string
+	switch schema["type"] {
+	case "string":
+		s, ok := value.(string)
+		if !ok {
+			return []string{path + ": want string"}
+		}
+		if schema["format"] == "date-time" {
+			if _, err := time.Parse(time.RFC3339Nano, s); err != nil {
+				problems = append(problems, path+": invalid date-time")
+			}
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			problems = append(problems, path+": want boolean")
+		}
+	case "integer":
+		n, ok := value.(float64)
+		if !ok || n != float64(int64(n)) {
+			problems = append(problems, path+": want integer")
+		}
+	case "number":
+		if _, ok := value.(float64); !ok {
+			problems = append(problems, path+": want number")
+		}
+	case "array":
+		items, ok := value.([]interface{})
+		if !ok {
+			return []string{path + ": want array"}
+		}
+		for _, item := range items {
+			problems = append(problems, validate(item, schema["items"].(map[string]interface{}), schemas, path+"[]")...)
+		}
+	case "object":
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			return []string{path + ": want object"}
+		}
+		if extra, ok := schema["additionalProperties"].(map[string]interface{}); ok {
+			for k, v := range obj {
+				problems = append(problems, validate(v, extra, schemas, path+"."+k)...)
+			}
+		}
+		props, _ := schema["properties"].(map[string]interface{})
+		if props == nil {
+			break
+		}
+		for k, v := range obj {
+			prop, ok := props[k].(map[string]interface{})
+			if !ok {
+				problems = append(problems, path+"."+k+": not in schema")
+				continue
+			}
+			problems = append(problems, validate(v, prop, schemas, path+"."+k)...)
+		}
+		required, _ := schema["required"].([]interface{})
+		for _, r := range required {
+			if _, ok := obj[r.(string)]; !ok {
+				problems = append(problems, path+"."+r.(string)+": required but missing")
+			}
+		}
+	}
+	return problems
+}
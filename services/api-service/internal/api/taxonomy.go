@@ -0,0 +1,33 @@
+// =====================================================
+// TAXONOMY - Cây topic cho GET /api/v1/taxonomy
+// =====================================================
+// Mô tả: Bảng topics lưu phẳng (consumer đồng bộ từ file taxonomy);
+// API trả về dạng cây topic cấp 1 → subtopics
+// =====================================================
+
+package api
+
+import "social-insight/internal/models"
+
+// BuildTaxonomy dựng cây từ danh sách phẳng theo thứ tự của topics;
+// subtopic có topic cha không còn dùng bị bỏ
+func BuildTaxonomy(version int, topics []models.Topic) TaxonomyResponse {
+	resp := TaxonomyResponse{Version: version, Topics: make([]TaxonomyTopic, 0)}
+	index := make(map[string]int)
+	for _, t := range topics {
+		if t.Parent == "" {
+			index[t.ID] = len(resp.Topics)
+			resp.Topics = append(resp.Topics, TaxonomyTopic{
+				ID: t.ID, Name: t.Name, Posts: t.Posts, Subtopics: make([]TaxonomySubtopic, 0),
+			})
+		}
+	}
+	for _, t := range topics {
+		if i, ok := index[t.Parent]; ok && t.Parent != "" {
+			resp.Topics[i].Subtopics = append(resp.Topics[i].Subtopics, TaxonomySubtopic{
+				ID: t.ID, Name: t.Name, Posts: t.Posts,
+			})
+		}
+	}
+	return resp
+}
@@ -0,0 +1,33 @@
+package api
+
+import (
+	"reflect"
+	"testing"
+
+	"social-insight/internal/models"
+)
+
+func TestBuildTaxonomy(t *testing.T) {
+	got := BuildTaxonomy(2, []models.Topic{
+		{ID: "ai", Name: "AI", Posts: 10},
+		{ID: "llm", Parent: "ai", Name: "LLMs", Posts: 4},
+		{ID: "other", Name: "Other"},
+		// Topic cha không còn dùng: bỏ subtopic
+		{ID: "aws", Parent: "cloud", Name: "AWS", Posts: 3},
+		{ID: "vision", Parent: "ai", Name: "Vision", Posts: 1},
+	})
+	want := TaxonomyResponse{Version: 2, Topics: []TaxonomyTopic{
+		{ID: "ai", Name: "AI", Posts: 10, Subtopics: []TaxonomySubtopic{
+			{ID: "llm", Name: "LLMs", Posts: 4},
+			{ID: "vision", Name: "Vision", Posts: 1},
+		}},
+		{ID: "other", Name: "Other", Subtopics: []TaxonomySubtopic{}},
+	}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v\nwant %+v", got, want)
+	}
+
+	if empty := BuildTaxonomy(0, nil); empty.Topics == nil {
+		t.Error("topics should be an empty list, not null")
+	}
+}
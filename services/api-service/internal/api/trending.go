@@ -0,0 +1,74 @@
+// =====================================================
+// TRENDING - Xếp hạng posts theo độ "hot"
+// =====================================================
+// Mô tả: Điểm = 40% độ mới (giảm dần theo ngày) + 60% engagement so
+// với trung bình của tập posts (tối đa 2 lần trung bình).
+// Dùng chung cho GET /api/v1/trending và field trending của GraphQL
+// =====================================================
+
+package api
+
+import (
+	"sort"
+	"time"
+
+	"social-insight/internal/models"
+)
+
+// RankTrending chấm điểm posts tại thời điểm now, điểm cao trước
+// (cùng điểm thì theo ID để phân trang ổn định)
+func RankTrending(posts []models.Post, now time.Time) []TrendingItem {
+	trending := make([]TrendingItem, 0, len(posts))
+	if len(posts) == 0 {
+		return trending
+	}
+
+	// Calculate average engagement
+	totalEngagement := 0
+	for _, p := range posts {
+		totalEngagement += p.Likes + p.Comments + p.Shares
+	}
+	avgEngagement := totalEngagement / len(posts)
+	if avgEngagement == 0 {
+		avgEngagement = 1
+	}
+
+	// Simple trending algorithm: recent + highly engaged
+	for _, post := range posts {
+		// Recency factor
+		hoursSince := now.Sub(post.CreatedAt).Hours()
+		recencyScore := 1.0 / (1.0 + hoursSince/24.0) // Decay over 24 hours
+
+		// Engagement factor
+		engagement := post.Likes + post.Comments*2 + post.Shares*3
+		engagementScore := float64(engagement) / float64(avgEngagement)
+		if engagementScore > 2 {
+			engagementScore = 2 // Cap
+		}
+
+		// Combined score
+		score := (recencyScore * 0.4) + (engagementScore * 0.6)
+
+		hotness := "🔥"
+		if score > 1.0 {
+			hotness = "🔥🔥"
+		}
+		if score > 1.5 {
+			hotness = "🔥🔥🔥"
+		}
+
+		trending = append(trending, TrendingItem{
+			Post:    post,
+			Score:   score,
+			Hotness: hotness,
+		})
+	}
+
+	sort.Slice(trending, func(i, j int) bool {
+		if trending[i].Score != trending[j].Score {
+			return trending[i].Score > trending[j].Score
+		}
+		return trending[i].Post.ID < trending[j].Post.ID
+	})
+	return trending
+}
@@ -0,0 +1,243 @@
+// =====================================================
+// API TYPES - Kiểu response của REST API
+// =====================================================
+// Mô tả: Mỗi endpoint trả về một kiểu cố định ở đây (hoặc trong
+// models/health), thay cho map[string]interface{}. OpenAPI spec
+// (/api/openapi.json) và Go client (package client) được sinh từ
+// các kiểu này: sửa field xong chạy `go generate ./internal/api`
+// =====================================================
+
+package api
+
+import (
+	"time"
+
+	"social-insight/internal/models"
+)
+
+// StatsResponse là body của GET /api/stats
+type StatsResponse struct {
+	TotalPosts int64 `json:"total_posts"`
+
+	// ByTopic là số posts mỗi topic cấp 1 của taxonomy; post nhiều topic
+	// được đếm ở mỗi topic nên tổng có thể lớn hơn TotalPosts
+	ByTopic     map[string]int64 `json:"by_topic"`
+	BySentiment map[string]int64 `json:"by_sentiment"`
+}
+
+// TaxonomyResponse là body của GET /api/taxonomy: cây topic đang dùng
+type TaxonomyResponse struct {
+	// Version là version của file taxonomy consumer đã đồng bộ
+	// (0 = chưa đồng bộ, danh sách là các topic cố định cũ)
+	Version int             `json:"version"`
+	Topics  []TaxonomyTopic `json:"topics"`
+}
+
+// TaxonomyTopic là một topic cấp 1 và các subtopic của nó
+type TaxonomyTopic struct {
+	ID        string             `json:"id"`
+	Name      string             `json:"name"`
+	Posts     int64              `json:"posts"` // Số posts có topic (chính hoặc phụ)
+	Subtopics []TaxonomySubtopic `json:"subtopics"`
+}
+
+// TaxonomySubtopic là một subtopic
+type TaxonomySubtopic struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Posts int64  `json:"posts"`
+}
+
+// Counts là số bài theo nhãn (topic → số bài, sentiment → số bài)
+type Counts map[string]int64
+
+// CrawlerStatus là lần crawl cuối của mỗi nguồn:
+// thời điểm RFC3339, "never" (chưa crawl) hoặc "unknown" (Redis down)
+type CrawlerStatus map[string]string
+
+// CrawlerSources là các nguồn crawl báo trạng thái (key trong Redis)
+var CrawlerSources = []string{"hn", "medium", "devto"}
+
+// ConsumersResponse là body của GET /api/consumers
+type ConsumersResponse struct {
+	Group            string            `json:"group"`
+	Status           string            `json:"status"` // ok | degraded | down
+	TotalLag         int64             `json:"total_lag"`
+	ThroughputPerSec float64           `json:"throughput_per_sec"`
+	Replicas         []ConsumerReplica `json:"replicas"`
+	Partitions       []PartitionLag    `json:"partitions"`
+}
+
+// ConsumerReplica là snapshot một replica consumer ghi vào Redis
+type ConsumerReplica struct {
+	Host             string         `json:"host"`
+	Group            string         `json:"group"`
+	Status           string         `json:"status"` // ok | lagging | stale
+	Stale            bool           `json:"stale"`
+	Processed        int64          `json:"processed"`
+	Batches          int64          `json:"batches"`
+	FailedFlushes    int64          `json:"failed_flushes"`
+	ThroughputPerSec float64        `json:"throughput_per_sec"`
+	BatchSize        MetricSummary  `json:"batch_size"`
+	FlushMs          MetricSummary  `json:"flush_ms"`
+	LatencyMs        MetricSummary  `json:"latency_ms"`
+	LastFlushAt      time.Time      `json:"last_flush_at"`
+	TotalLag         int64          `json:"total_lag"`
+	Partitions       []PartitionLag `json:"partitions"`
+	StartedAt        time.Time      `json:"started_at"`
+	UpdatedAt        time.Time      `json:"updated_at"`
+}
+
+// MetricSummary tóm tắt một chỉ số trong cửa sổ mẫu gần nhất
+type MetricSummary struct {
+	Count int64   `json:"count"`
+	Min   float64 `json:"min"`
+	Max   float64 `json:"max"`
+	Avg   float64 `json:"avg"`
+	P50   float64 `json:"p50"`
+	P95   float64 `json:"p95"`
+	P99   float64 `json:"p99"`
+}
+
+// PartitionLag là lag của một partition
+type PartitionLag struct {
+	Topic         string    `json:"topic"`
+	Partition     int32     `json:"partition"`
+	Offset        int64     `json:"offset"`
+	HighWaterMark int64     `json:"high_water_mark"`
+	Lag           int64     `json:"lag"`
+	Host          string    `json:"host,omitempty"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// InsightsResponse là body của GET /api/insights
+type InsightsResponse struct {
+	Insights []Insight `json:"insights"`
+	Total    int       `json:"total"`
+
+	// Message giải thích khi không có insight (không đủ dữ liệu)
+	Message string `json:"message,omitempty"`
+}
+
+// Insight là một phát hiện trong 24h gần nhất
+type Insight struct {
+	Type        string    `json:"type"` // trending
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	Confidence  float64   `json:"confidence"`
+	Timestamp   time.Time `json:"timestamp"`
+}
+
+// CompareResponse là body của GET /api/compare
+type CompareResponse struct {
+	Today      DayStats   `json:"today"`
+	Yesterday  DayStats   `json:"yesterday"`
+	Comparison Comparison `json:"comparison"`
+}
+
+// DayStats là số bài và engagement trong một ngày
+type DayStats struct {
+	Posts      int `json:"posts"`
+	Engagement int `json:"engagement"`
+}
+
+// Comparison là chênh lệch hôm nay so với hôm qua
+type Comparison struct {
+	PostsChange      int     `json:"posts_change"`
+	PostsPercent     float64 `json:"posts_percent"`
+	EngagementChange int     `json:"engagement_change"`
+}
+
+// ClusterResponse là body của GET /api/clusters/{id}
+type ClusterResponse struct {
+	CanonicalPostID string         `json:"canonical_post_id"`
+	Size            int            `json:"size"`
+	Platforms       map[string]int `json:"platforms"`
+	Engagement      int            `json:"engagement"`
+	Posts           []models.Post  `json:"posts"`
+}
+
+// StoryResponse là body của GET /api/stories/{id}
+type StoryResponse struct {
+	Story           models.Story           `json:"story"`
+	Platforms       []models.StoryPlatform `json:"platforms"`
+	TotalPosts      int                    `json:"total_posts"`
+	TotalEngagement int                    `json:"total_engagement"`
+	Posts           []models.Post          `json:"posts"`
+}
+
+// TrendingResponse là body của GET /api/trending
+type TrendingResponse struct {
+	Trending []TrendingItem `json:"trending"`
+	Total    int            `json:"total"`
+}
+
+// TrendingItem là một post kèm điểm trending
+type TrendingItem struct {
+	Post    models.Post `json:"post"`
+	Score   float64     `json:"score"`
+	Hotness string      `json:"hotness"` // 🔥 | 🔥🔥 | 🔥🔥🔥
+}
+
+// AuthorGraphResponse là body của GET /api/graph/authors: đồ thị hai phía,
+// tác giả một bên, topic và keyword bên kia
+type AuthorGraphResponse struct {
+	Topic string `json:"topic,omitempty"` // Topic đã lọc
+
+	// Baselines là engagement trung bình mỗi post của từng nền tảng
+	// (mốc của relative_engagement)
+	Baselines map[string]float64 `json:"baselines"`
+
+	Nodes []GraphNode `json:"nodes"`
+	Edges []GraphEdge `json:"edges"`
+}
+
+// Loại đỉnh của đồ thị tác giả
+const (
+	NodeAuthor  = "author"
+	NodeTopic   = "topic"
+	NodeKeyword = "keyword"
+)
+
+// GraphNode là một đỉnh của đồ thị
+type GraphNode struct {
+	ID    string `json:"id"`   // author:{platform}/{handle} | topic:{topic} | keyword:{keyword}
+	Type  string `json:"type"` // author | topic | keyword
+	Label string `json:"label"`
+
+	// Weight: điểm influence của tác giả; số posts của topic/keyword
+	// (chỉ đếm posts của các tác giả trong đồ thị)
+	Weight float64 `json:"weight"`
+
+	// Author là influence của tác giả (chỉ đỉnh author)
+	Author *AuthorInfluence `json:"author,omitempty"`
+}
+
+// GraphEdge nối một tác giả (Source) với một topic/keyword (Target)
+type GraphEdge struct {
+	Source     string `json:"source"`
+	Target     string `json:"target"`
+	Weight     int64  `json:"weight"`               // Số posts
+	Engagement int64  `json:"engagement,omitempty"` // Chỉ cạnh topic
+}
+
+// AuthorInfluence là điểm ảnh hưởng của một tác giả và các thành phần
+type AuthorInfluence struct {
+	Platform   string `json:"platform"`
+	Handle     string `json:"handle"`
+	PostCount  int64  `json:"post_count"`
+	Engagement int64  `json:"engagement"`
+
+	// Score 0-100: 60% relative_engagement, 25% consistency, 15% reach
+	Score float64 `json:"score"`
+
+	// RelativeEngagement là engagement mỗi post so với trung bình nền
+	// tảng (1 = bằng trung bình), kéo về 1 khi tác giả có ít posts
+	RelativeEngagement float64 `json:"relative_engagement"`
+
+	// Consistency là tỉ lệ tuần có post trong khoảng hoạt động (0-1)
+	Consistency float64 `json:"consistency"`
+
+	// Reach là số nền tảng có tác giả cùng handle
+	Reach int64 `json:"reach"`
+}
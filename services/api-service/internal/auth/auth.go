@@ -0,0 +1,240 @@
+// =====================================================
+// AUTHENTICATOR - Xác thực API key theo scope
+// =====================================================
+// Mô tả: Middleware kiểm tra API key cho từng route
+//   - Key gửi qua "Authorization: Bearer <key>" hoặc "X-API-Key"
+//   - Thiếu/sai/đã thu hồi → 401, thiếu scope → 403
+//   - Key tra cứu được cache cacheTTL để không query Postgres
+//     mỗi request (thu hồi có hiệu lực sau tối đa cacheTTL)
+//   - Usage counters cộng trong memory, Run() ghi xuống
+//     Postgres định kỳ (request_count, last_used_at)
+//
+// Dùng:
+//   a := auth.New(db, cfg.AuthEnabled)
+//   go a.Run(ctx, 30*time.Second)
+//   defer a.Flush(context.Background())
+//   http.HandleFunc("/api/x", a.Require(auth.ScopeReadPosts, handler))
+// =====================================================
+
+package auth
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"social-insight/internal/database"
+	"social-insight/internal/logger"
+	"social-insight/internal/metrics"
+	"social-insight/internal/models"
+	"social-insight/internal/server"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// HeaderAPIKey là header thay thế cho Authorization: Bearer
+const HeaderAPIKey = "X-API-Key"
+
+// Mã lỗi trong body {"error": {"code": ...}}
+const (
+	codeMissingKey   = "missing_api_key"
+	codeInvalidKey   = "invalid_api_key"
+	codeMissingScope = "missing_scope"
+)
+
+const (
+	// cacheTTL là thời gian giữ kết quả tra cứu key (kể cả key không tồn tại)
+	cacheTTL = 30 * time.Second
+
+	// maxCacheEntries giới hạn cache để key rác không làm phình memory
+	maxCacheEntries = 10000
+)
+
+// cachedKey là kết quả tra cứu một hash (key nil = không tồn tại)
+type cachedKey struct {
+	key     *models.APIKey
+	expires time.Time
+}
+
+// contextKey là kiểu khóa riêng cho context
+type contextKey struct{}
+
+// Authenticator kiểm tra API key và đếm usage
+type Authenticator struct {
+	db      *database.DB
+	enabled bool
+	now     func() time.Time
+
+	mu    sync.Mutex
+	cache map[string]cachedKey // key hash → key
+	usage map[int64]int64      // key ID → số request chưa ghi xuống DB
+}
+
+// New tạo Authenticator; enabled=false cho mọi request đi qua
+func New(db *database.DB, enabled bool) *Authenticator {
+	return &Authenticator{
+		db:      db,
+		enabled: enabled,
+		now:     time.Now,
+		cache:   make(map[string]cachedKey),
+		usage:   make(map[int64]int64),
+	}
+}
+
+// Enabled cho biết có bắt buộc API key không
+func (a *Authenticator) Enabled() bool {
+	return a.enabled
+}
+
+// FromContext trả về key đã xác thực của request (nil khi auth tắt)
+func FromContext(ctx context.Context) *models.APIKey {
+	key, _ := ctx.Value(contextKey{}).(*models.APIKey)
+	return key
+}
+
+// Require chỉ cho request có key hợp lệ mang scope đi qua
+func (a *Authenticator) Require(scope string, next http.HandlerFunc) http.HandlerFunc {
+	return a.check(scope, next)
+}
+
+// Authenticate cho mọi key hợp lệ đi qua, không cần scope cụ thể
+// (route tự kiểm tra scope theo phần dữ liệu được hỏi, ví dụ GraphQL);
+// key lấy bằng FromContext
+func (a *Authenticator) Authenticate(next http.HandlerFunc) http.HandlerFunc {
+	return a.check("", next)
+}
+
+// check xác thực key và kiểm tra scope ("" = không kiểm tra)
+func (a *Authenticator) check(scope string, next http.HandlerFunc) http.HandlerFunc {
+	if !a.enabled {
+		return next
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		raw := keyFromRequest(r)
+		if raw == "" {
+			unauthorized(w, codeMissingKey, "missing api key: send Authorization: Bearer <key> or "+HeaderAPIKey)
+			return
+		}
+		if PrefixOf(raw) == "" {
+			unauthorized(w, codeInvalidKey, "invalid or revoked api key")
+			return
+		}
+
+		key, err := a.lookup(r.Context(), HashKey(raw))
+		if err != nil {
+			slog.ErrorContext(r.Context(), "api key lookup error", logger.Err(err))
+			server.WriteError(w, http.StatusServiceUnavailable, "authentication unavailable")
+			return
+		}
+		if key == nil || key.Revoked() {
+			unauthorized(w, codeInvalidKey, "invalid or revoked api key")
+			return
+		}
+		if scope != "" && !key.HasScope(scope) {
+			server.WriteErrorCode(w, http.StatusForbidden, codeMissingScope, "api key lacks scope "+scope)
+			return
+		}
+
+		a.count(key)
+		label := scope
+		if label == "" {
+			label = "any"
+		}
+		metrics.APIKeyRequests.WithLabelValues(key.Prefix, label).Inc()
+		trace.SpanFromContext(r.Context()).SetAttributes(attribute.String("api_key.prefix", key.Prefix))
+
+		next(w, r.WithContext(context.WithValue(r.Context(), contextKey{}, key)))
+	}
+}
+
+// keyFromRequest lấy key từ Authorization: Bearer hoặc X-API-Key
+func keyFromRequest(r *http.Request) string {
+	if h := r.Header.Get("Authorization"); h != "" {
+		if scheme, token, ok := strings.Cut(h, " "); ok && strings.EqualFold(scheme, "Bearer") {
+			return strings.TrimSpace(token)
+		}
+	}
+	return strings.TrimSpace(r.Header.Get(HeaderAPIKey))
+}
+
+// unauthorized trả 401 kèm WWW-Authenticate
+func unauthorized(w http.ResponseWriter, code, msg string) {
+	w.Header().Set("WWW-Authenticate", `Bearer realm="social-insight"`)
+	server.WriteErrorCode(w, http.StatusUnauthorized, code, msg)
+}
+
+// lookup tra key theo hash, ưu tiên cache
+func (a *Authenticator) lookup(ctx context.Context, hash string) (*models.APIKey, error) {
+	now := a.now()
+
+	a.mu.Lock()
+	if c, ok := a.cache[hash]; ok && now.Before(c.expires) {
+		a.mu.Unlock()
+		return c.key, nil
+	}
+	a.mu.Unlock()
+
+	key, err := a.db.WithContext(ctx).GetAPIKeyByHash(hash)
+	if err != nil {
+		return nil, err
+	}
+
+	a.mu.Lock()
+	if len(a.cache) >= maxCacheEntries {
+		a.cache = make(map[string]cachedKey)
+	}
+	a.cache[hash] = cachedKey{key: key, expires: now.Add(cacheTTL)}
+	a.mu.Unlock()
+	return key, nil
+}
+
+// count cộng một request cho key
+func (a *Authenticator) count(key *models.APIKey) {
+	a.mu.Lock()
+	a.usage[key.ID]++
+	a.mu.Unlock()
+}
+
+// Run ghi usage counters xuống Postgres mỗi interval đến khi ctx bị hủy
+// Khi shutdown gọi Flush() trước khi đóng DB để không mất số đếm
+func (a *Authenticator) Run(ctx context.Context, interval time.Duration) {
+	if !a.enabled {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			a.Flush(ctx)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Flush ghi usage counters hiện có; lỗi thì cộng lại để lần sau ghi tiếp
+func (a *Authenticator) Flush(ctx context.Context) {
+	a.mu.Lock()
+	if len(a.usage) == 0 {
+		a.mu.Unlock()
+		return
+	}
+	pending := a.usage
+	a.usage = make(map[int64]int64)
+	a.mu.Unlock()
+
+	if err := a.db.WithContext(ctx).AddAPIKeyUsage(pending, a.now()); err != nil {
+		slog.Warn("api key usage flush error", "keys", len(pending), logger.Err(err))
+		a.mu.Lock()
+		for id, n := range pending {
+			a.usage[id] += n
+		}
+		a.mu.Unlock()
+	}
+}
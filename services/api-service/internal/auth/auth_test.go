@@ -0,0 +1,253 @@
+package auth
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"social-insight/internal/database"
+	"social-insight/internal/models"
+	"social-insight/internal/server"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+)
+
+const (
+	analyticsKey = "si_0a1b2c3d_00112233445566778899aabbccddeeff0011223344556677"
+	postsKey     = "si_deadbeef_ffeeddccbbaa99887766554433221100ffeeddccbbaa9988"
+)
+
+// testKeys là các dòng api_keys theo key gốc (key không có ở đây = không tồn tại)
+var testKeys = map[string]*models.APIKey{
+	analyticsKey: {ID: 1, Prefix: "0a1b2c3d", Scopes: []string{ScopeReadAnalytics}},
+	postsKey:     {ID: 2, Prefix: "deadbeef", Scopes: []string{ScopeReadPosts}},
+}
+
+// newTestAuthenticator trả về Authenticator bật auth trên sqlmock
+// và con trỏ tới đồng hồ của nó
+func newTestAuthenticator(t *testing.T) (*Authenticator, sqlmock.Sqlmock, *time.Time) {
+	t.Helper()
+	conn, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	now := time.Date(2026, 1, 31, 10, 0, 0, 0, time.UTC)
+	a := New(database.NewDBFromConn(conn), true)
+	a.now = func() time.Time { return now }
+	return a, mock, &now
+}
+
+// expectLookup khớp một lần tra api_keys theo hash của raw, trả về key
+// (nil = không có dòng nào)
+func expectLookup(t *testing.T, mock sqlmock.Sqlmock, raw string, key *models.APIKey) {
+	t.Helper()
+	rows := sqlmock.NewRows([]string{"id", "name", "prefix", "scopes", "request_count", "last_used_at", "created_at", "revoked_at"})
+	if key != nil {
+		scopes, err := pq.StringArray(key.Scopes).Value()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var revoked driver.Value
+		if key.RevokedAt != nil {
+			revoked = *key.RevokedAt
+		}
+		rows.AddRow(key.ID, key.Name, key.Prefix, scopes, key.RequestCount, nil, key.CreatedAt, revoked)
+	}
+	mock.ExpectQuery("FROM api_keys WHERE key_hash = \\$1").WithArgs(HashKey(raw)).WillReturnRows(rows)
+}
+
+func TestKeyFormat(t *testing.T) {
+	key, prefix, err := GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(key, "si_"+prefix+"_") || len(prefix) != 8 || PrefixOf(key) != prefix {
+		t.Errorf("GenerateKey() = %q, %q", key, prefix)
+	}
+
+	tests := map[string]string{
+		analyticsKey:            "0a1b2c3d",
+		"si_abc_secret":         "abc",
+		"":                      "",
+		"si_abc":                "",
+		"si_abc_secret_extra":   "",
+		"sk_0a1b2c3d_secret":    "",
+		"Bearer si_abc_secret ": "",
+	}
+	for key, want := range tests {
+		if got := PrefixOf(key); got != want {
+			t.Errorf("PrefixOf(%q) = %q, want %q", key, got, want)
+		}
+	}
+
+	h := HashKey(analyticsKey)
+	if len(h) != 64 || h != HashKey(analyticsKey) || h == HashKey(postsKey) {
+		t.Errorf("HashKey(%q) = %q", analyticsKey, h)
+	}
+}
+
+// call gửi request với header tới route cần scope, trả về status và mã lỗi
+func call(a *Authenticator, scope string, header, value string) (int, string) {
+	h := a.Require(scope, func(w http.ResponseWriter, r *http.Request) {
+		if FromContext(r.Context()) == nil {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	})
+	r := httptest.NewRequest("GET", "/api/v1/topics", nil)
+	if header != "" {
+		r.Header.Set(header, value)
+	}
+	w := httptest.NewRecorder()
+	h(w, r)
+
+	var body server.ErrorResponse
+	json.Unmarshal(w.Body.Bytes(), &body)
+	return w.Code, body.Error.Code
+}
+
+func TestRequire(t *testing.T) {
+	tests := []struct {
+		name          string
+		header, value string
+		lookup        string // Key được tra trong api_keys ("" = không query)
+		scope         string
+		status        int
+		code          string
+	}{
+		{"bearer", "Authorization", "Bearer " + analyticsKey, analyticsKey, ScopeReadAnalytics, 200, ""},
+		{"x-api-key", HeaderAPIKey, analyticsKey, analyticsKey, ScopeReadAnalytics, 200, ""},
+		{"lowercase bearer", "Authorization", "bearer " + analyticsKey, analyticsKey, ScopeReadAnalytics, 200, ""},
+		{"missing", "", "", "", ScopeReadAnalytics, 401, codeMissingKey},
+		{"basic auth", "Authorization", "Basic dXNlcjpwYXNz", "", ScopeReadAnalytics, 401, codeMissingKey},
+		{"malformed", HeaderAPIKey, "not-a-key", "", ScopeReadAnalytics, 401, codeInvalidKey},
+		{"unknown", HeaderAPIKey, "si_00000000_secret", "si_00000000_secret", ScopeReadAnalytics, 401, codeInvalidKey},
+		{"missing scope", HeaderAPIKey, postsKey, postsKey, ScopeReadAnalytics, 403, codeMissingScope},
+	}
+	for _, tt := range tests {
+		a, mock, _ := newTestAuthenticator(t)
+		if tt.lookup != "" {
+			expectLookup(t, mock, tt.lookup, testKeys[tt.lookup])
+		}
+		status, code := call(a, tt.scope, tt.header, tt.value)
+		if status != tt.status || code != tt.code {
+			t.Errorf("%s: status %d code %q, want %d %q", tt.name, status, code, tt.status, tt.code)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+		}
+	}
+}
+
+func TestLookupError(t *testing.T) {
+	a, mock, _ := newTestAuthenticator(t)
+	mock.ExpectQuery("FROM api_keys").WillReturnError(errors.New("connection refused"))
+
+	// Postgres lỗi: 503, không coi là key sai và không cache
+	if status, _ := call(a, ScopeReadAnalytics, HeaderAPIKey, analyticsKey); status != http.StatusServiceUnavailable {
+		t.Errorf("lookup error: status %d", status)
+	}
+	expectLookup(t, mock, analyticsKey, testKeys[analyticsKey])
+	if status, _ := call(a, ScopeReadAnalytics, HeaderAPIKey, analyticsKey); status != 200 {
+		t.Errorf("after recovery: status %d", status)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestAuthDisabled(t *testing.T) {
+	a := New(nil, false)
+	called := false
+	h := a.Require(ScopeReadPosts, func(w http.ResponseWriter, r *http.Request) { called = true })
+	h(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/v1/recent", nil))
+	if !called {
+		t.Error("auth disabled: handler not called")
+	}
+}
+
+func TestLookupCache(t *testing.T) {
+	a, mock, now := newTestAuthenticator(t)
+
+	// Lần đầu query Postgres, trong 30s dùng cache (cả key không tồn tại)
+	expectLookup(t, mock, analyticsKey, testKeys[analyticsKey])
+	expectLookup(t, mock, "si_00000000_secret", nil)
+	for i := 0; i < 3; i++ {
+		call(a, ScopeReadAnalytics, HeaderAPIKey, analyticsKey)
+		call(a, ScopeReadAnalytics, HeaderAPIKey, "si_00000000_secret")
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Thu hồi: request trong cache vẫn qua, hết cacheTTL thì bị từ chối
+	revokedAt := *now
+	revoked := *testKeys[analyticsKey]
+	revoked.RevokedAt = &revokedAt
+	expectLookup(t, mock, analyticsKey, &revoked)
+	*now = now.Add(cacheTTL - time.Second)
+	if status, _ := call(a, ScopeReadAnalytics, HeaderAPIKey, analyticsKey); status != 200 {
+		t.Errorf("before expiry: status %d", status)
+	}
+	*now = now.Add(time.Second)
+	if status, code := call(a, ScopeReadAnalytics, HeaderAPIKey, analyticsKey); status != 401 || code != codeInvalidKey {
+		t.Errorf("after expiry: status %d code %q", status, code)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+// usageArg lưu mảng ids/counts mà AddAPIKeyUsage gửi (thứ tự theo map)
+type usageArg struct{ into *pq.Int64Array }
+
+func (u usageArg) Match(v driver.Value) bool {
+	return u.into.Scan(v) == nil
+}
+
+func TestFlushUsage(t *testing.T) {
+	a, mock, now := newTestAuthenticator(t)
+	expectLookup(t, mock, analyticsKey, testKeys[analyticsKey])
+	expectLookup(t, mock, postsKey, testKeys[postsKey])
+	for i := 0; i < 3; i++ {
+		call(a, ScopeReadAnalytics, HeaderAPIKey, analyticsKey)
+	}
+	call(a, ScopeReadPosts, HeaderAPIKey, postsKey)
+	// Request bị từ chối không được đếm
+	call(a, ScopeReadAnalytics, HeaderAPIKey, postsKey)
+
+	// Lỗi DB: counters được giữ lại và cộng với request mới
+	mock.ExpectExec("UPDATE api_keys").WillReturnError(errors.New("db down"))
+	a.Flush(context.Background())
+	call(a, ScopeReadPosts, HeaderAPIKey, postsKey)
+
+	var ids, counts pq.Int64Array
+	mock.ExpectExec("UPDATE api_keys").WithArgs(usageArg{&ids}, usageArg{&counts}, *now).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	a.Flush(context.Background())
+	flushed := map[int64]int64{}
+	for i := range ids {
+		flushed[ids[i]] = counts[i]
+	}
+	if len(flushed) != 2 || flushed[1] != 3 || flushed[2] != 2 {
+		t.Fatalf("flushed = %v", flushed)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Không có request mới: không ghi (expectation phải còn nguyên)
+	mock.ExpectExec("UPDATE api_keys")
+	a.Flush(context.Background())
+	if err := mock.ExpectationsWereMet(); err == nil {
+		t.Error("empty flush wrote usage")
+	}
+}
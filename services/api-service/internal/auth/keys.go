@@ -0,0 +1,68 @@
+// =====================================================
+// API KEYS - Sinh key, hash và scopes
+// =====================================================
+// Mô tả: Key có dạng si_<prefix>_<secret>
+//   - prefix (8 hex) không bí mật: hiện trong `apikey list`,
+//     log và metrics để biết request của key nào
+//   - Chỉ SHA-256 của key đầy đủ được lưu trong Postgres,
+//     key gốc chỉ in ra một lần khi tạo
+// =====================================================
+
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// Scopes mà một key có thể được cấp
+const (
+	ScopeReadPosts       = "read:posts"       // Bài viết: recent, clusters, stories, trending
+	ScopeReadAnalytics   = "read:analytics"   // Thống kê: stats, topics, sentiment, insights, ...
+	ScopeAdminWatchlists = "admin:watchlists" // Quản lý watchlists
+)
+
+// Scopes là danh sách scope hợp lệ
+var Scopes = []string{ScopeReadPosts, ScopeReadAnalytics, ScopeAdminWatchlists}
+
+// keyPrefix đánh dấu key của hệ thống (dễ nhận ra khi lỡ commit lên git)
+const keyPrefix = "si_"
+
+// ValidScope kiểm tra scope có trong danh sách
+func ValidScope(scope string) bool {
+	for _, s := range Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// GenerateKey sinh key mới, trả về key đầy đủ và prefix của nó
+func GenerateKey() (key, prefix string, err error) {
+	buf := make([]byte, 4+24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", fmt.Errorf("generate api key error: %w", err)
+	}
+	prefix = hex.EncodeToString(buf[:4])
+	key = keyPrefix + prefix + "_" + hex.EncodeToString(buf[4:])
+	return key, prefix, nil
+}
+
+// HashKey trả về SHA-256 (hex) của key, giá trị được lưu trong api_keys.key_hash
+func HashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// PrefixOf lấy prefix từ key đầy đủ ("" nếu sai định dạng)
+func PrefixOf(key string) string {
+	parts := strings.Split(key, "_")
+	if len(parts) != 3 || parts[0]+"_" != keyPrefix {
+		return ""
+	}
+	return parts[1]
+}
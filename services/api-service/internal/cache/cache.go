@@ -0,0 +1,273 @@
+// =====================================================
+// RESPONSE CACHE - Cache response của endpoint nặng
+// =====================================================
+// Mô tả: Middleware read-through cho GET: response 200 của handler
+// được lưu trong Redis theo route + query params đã chuẩn hóa, với
+// TTL riêng từng endpoint (api.Operation.CacheTTL).
+// Mỗi entry ghi generation lúc đọc dữ liệu; consumer tăng generation
+// sau mỗi batch ghi Postgres, entry cũ hơn bị bỏ qua (invalidation
+// không cần xóa key, key cũ tự hết TTL).
+// Mọi response 200 có ETag (cả khi Redis down); If-None-Match khớp
+// → 304 không body. Link rel="next" không được cache mà dựng lại theo
+// path của từng request (alias /api/... và /api/v1/... dùng chung entry).
+// Consumer chỉ tăng generation tối đa một lần mỗi
+// API_CACHE_INVALIDATE_INTERVAL để entry sống được tới TTL.
+//
+// Dùng:
+//   c := cache.New(store)
+//   http.HandleFunc("/api/topics", c.Wrap("GetTopics", 30*time.Second, params, handler))
+// =====================================================
+
+package cache
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"social-insight/internal/logger"
+	"social-insight/internal/metrics"
+)
+
+// HeaderCache báo response lấy từ cache hay không
+const HeaderCache = "X-Cache"
+
+// Giá trị của HeaderCache (chữ thường là label result của metrics)
+const (
+	ResultHit    = "HIT"    // Lấy từ Redis
+	ResultMiss   = "MISS"   // Chạy handler, đã lưu vào Redis
+	ResultBypass = "BYPASS" // Chạy handler, không lưu (Redis down, response lỗi/degraded)
+)
+
+// keyPrefix là prefix của mọi key response cache
+const keyPrefix = "api:cache:"
+
+// headerDegraded: response thiếu dữ liệu Redis không được cache
+const headerDegraded = "X-Degraded"
+
+// headerNextCursor là cursor trang sau (api.HeaderNextCursor), dùng để
+// dựng lại Link rel="next" cho từng request
+const headerNextCursor = "X-Next-Cursor"
+
+// Store lưu entries (Redis client thỏa interface này)
+type Store interface {
+	// GetCached trả về generation hiện tại và entry của key (nil khi chưa có)
+	GetCached(key string) (int64, []byte, error)
+	SetCached(key string, entry []byte, ttl time.Duration) error
+}
+
+// Cache là middleware response cache
+type Cache struct {
+	// store trả về Store cho request, nil khi Redis down hoặc cache tắt
+	store func(r *http.Request) Store
+}
+
+// New tạo Cache; store nil cho mọi request = chỉ có ETag/304
+func New(store func(r *http.Request) Store) *Cache {
+	return &Cache{store: store}
+}
+
+// entry là một response đã cache
+type entry struct {
+	Generation int64       `json:"generation"`
+	Header     http.Header `json:"header"`
+	Body       []byte      `json:"body"`
+	ETag       string      `json:"etag"`
+}
+
+// Wrap cache response của next trong ttl; params là query params làm
+// thay đổi response (tên → giá trị mặc định, xem Key)
+func (c *Cache) Wrap(route string, ttl time.Duration, params map[string]string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			next(w, r)
+			return
+		}
+
+		key := Key(route, r, params)
+		store := c.store(r)
+		var generation int64
+		if store != nil {
+			gen, data, err := store.GetCached(key)
+			if err != nil {
+				// Lỗi Redis: chạy handler, không lưu (generation không rõ)
+				slog.WarnContext(r.Context(), "response cache read error", "route", route, logger.Err(err))
+				store = nil
+			} else {
+				generation = gen
+				var e entry
+				if data != nil && json.Unmarshal(data, &e) == nil && e.Generation == gen {
+					count(route, ResultHit)
+					e.serve(w, r, ResultHit)
+					return
+				}
+			}
+		}
+
+		rec := &recorder{header: make(http.Header)}
+		next(rec, r)
+		if rec.status != http.StatusOK {
+			count(route, ResultBypass)
+			rec.passThrough(w)
+			return
+		}
+
+		e := entry{
+			Generation: generation,
+			Header:     withoutNextLink(rec.header),
+			Body:       rec.body.Bytes(),
+			ETag:       etag(rec.body.Bytes()),
+		}
+		result := ResultBypass
+		if store != nil && rec.header.Get(headerDegraded) == "" {
+			data, err := json.Marshal(e)
+			if err == nil {
+				err = store.SetCached(key, data, ttl)
+			}
+			if err != nil {
+				slog.WarnContext(r.Context(), "response cache write error", "route", route, logger.Err(err))
+			} else {
+				result = ResultMiss
+			}
+		}
+		count(route, result)
+		e.serve(w, r, result)
+	}
+}
+
+// count đếm request theo kết quả (label hit | miss | bypass)
+func count(route, result string) {
+	metrics.CacheRequests.WithLabelValues(route, strings.ToLower(result)).Inc()
+}
+
+// Key dựng cache key từ route, path (bỏ /api/ và v1/ để alias dùng chung
+// entry với /api/v1) và query params đã chuẩn hóa: chỉ giữ params có
+// trong params, bỏ giá trị rỗng hoặc bằng mặc định, sắp xếp theo tên
+// Ví dụ /api/trending?limit=10&topic=ai&x=1 → api:cache:GetTrending:trending?topic=ai
+func Key(route string, r *http.Request, params map[string]string) string {
+	path := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, "/api/"), "v1/")
+
+	query := r.URL.Query()
+	values := url.Values{}
+	for name, def := range params {
+		if v := query.Get(name); v != "" && v != def {
+			values.Set(name, v)
+		}
+	}
+	return keyPrefix + route + ":" + path + "?" + values.Encode()
+}
+
+// NextLink là giá trị header Link rel="next" của r: cùng path và
+// filters, cursor mới (bỏ offset)
+func NextLink(r *http.Request, cursor string) string {
+	values := r.URL.Query()
+	values.Del("offset")
+	values.Set("cursor", cursor)
+	link := url.URL{Path: r.URL.Path, RawQuery: values.Encode()}
+	return "<" + link.String() + `>; rel="next"`
+}
+
+// withoutNextLink bỏ Link rel="next" khỏi h (path của request đã chạy
+// handler, không dùng lại cho request khác)
+func withoutNextLink(h http.Header) http.Header {
+	links := h.Values("Link")
+	h.Del("Link")
+	for _, link := range links {
+		if !strings.HasSuffix(link, `; rel="next"`) {
+			h.Add("Link", link)
+		}
+	}
+	return h
+}
+
+// etag là weak ETag của body (weak vì gzip middleware có thể nén body)
+func etag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `W/"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// serve ghi entry ra w (304 khi If-None-Match khớp ETag)
+func (e *entry) serve(w http.ResponseWriter, r *http.Request, result string) {
+	h := w.Header()
+	for k, values := range e.Header {
+		for _, v := range values {
+			h.Add(k, v)
+		}
+	}
+	if cursor := e.Header.Get(headerNextCursor); cursor != "" {
+		h.Add("Link", NextLink(r, cursor))
+	}
+	h.Set("ETag", e.ETag)
+	h.Set("Cache-Control", "private, no-cache")
+	h.Set(HeaderCache, result)
+
+	if matches(r.Header.Get("If-None-Match"), e.ETag) {
+		h.Del("Content-Type")
+		h.Del("Content-Length")
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(e.Body)
+}
+
+// matches kiểm tra If-None-Match (danh sách ETag hoặc *) với etag,
+// so sánh weak: bỏ qua prefix W/
+func matches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	want := strings.TrimPrefix(etag, "W/")
+	for _, tag := range strings.Split(ifNoneMatch, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == want {
+			return true
+		}
+	}
+	return false
+}
+
+// recorder giữ response của handler để cache trước khi ghi ra client
+type recorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (r *recorder) Header() http.Header {
+	return r.header
+}
+
+func (r *recorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+}
+
+func (r *recorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.body.Write(b)
+}
+
+// passThrough ghi nguyên response (lỗi, redirect, ...) ra w
+func (r *recorder) passThrough(w http.ResponseWriter) {
+	h := w.Header()
+	for k, values := range r.header {
+		for _, v := range values {
+			h.Add(k, v)
+		}
+	}
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	w.WriteHeader(r.status)
+	w.Write(r.body.Bytes())
+}
@@ -0,0 +1,168 @@
+package cache
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// fakeStore giữ entries trong map, generation đặt tay
+type fakeStore struct {
+	generation int64
+	entries    map[string][]byte
+}
+
+func (s *fakeStore) GetCached(key string) (int64, []byte, error) {
+	return s.generation, s.entries[key], nil
+}
+
+func (s *fakeStore) SetCached(key string, entry []byte, ttl time.Duration) error {
+	s.entries[key] = entry
+	return nil
+}
+
+var params = map[string]string{"limit": "10", "topic": ""}
+
+func TestKey(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"/api/v1/trending", "api:cache:GetTrending:trending?"},
+		// alias dùng chung entry với /api/v1
+		{"/api/trending", "api:cache:GetTrending:trending?"},
+		// bỏ param lạ, param rỗng và giá trị mặc định
+		{"/api/v1/trending?limit=10&topic=&x=1", "api:cache:GetTrending:trending?"},
+		// thứ tự query không đổi key
+		{"/api/v1/trending?topic=ai&limit=5", "api:cache:GetTrending:trending?limit=5&topic=ai"},
+		{"/api/v1/trending?limit=5&topic=ai", "api:cache:GetTrending:trending?limit=5&topic=ai"},
+	}
+	for _, tt := range tests {
+		if got := Key("GetTrending", httptest.NewRequest("GET", tt.url, nil), params); got != tt.want {
+			t.Errorf("Key(%s) = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+}
+
+// newTestCache trả về handler đã bọc cache và số lần handler thật chạy
+func newTestCache(store *fakeStore, handler http.HandlerFunc) (http.HandlerFunc, *int) {
+	calls := 0
+	var s Store
+	if store != nil {
+		s = store
+	}
+	c := New(func(*http.Request) Store { return s })
+	return c.Wrap("GetTopics", time.Minute, params, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		handler(w, r)
+	}), &calls
+}
+
+func get(h http.HandlerFunc, url, ifNoneMatch string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest("GET", url, nil)
+	if ifNoneMatch != "" {
+		r.Header.Set("If-None-Match", ifNoneMatch)
+	}
+	w := httptest.NewRecorder()
+	h(w, r)
+	return w
+}
+
+func ok(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte(`{"ai":2}`))
+}
+
+func TestHitAndInvalidation(t *testing.T) {
+	store := &fakeStore{entries: make(map[string][]byte)}
+	h, calls := newTestCache(store, ok)
+
+	if w := get(h, "/api/v1/topics", ""); w.Header().Get(HeaderCache) != ResultMiss {
+		t.Fatalf("first request: %s = %q", HeaderCache, w.Header().Get(HeaderCache))
+	}
+	w := get(h, "/api/topics?limit=10", "")
+	if w.Header().Get(HeaderCache) != ResultHit || w.Body.String() != `{"ai":2}` || w.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("second request: %s = %q, body %q", HeaderCache, w.Header().Get(HeaderCache), w.Body.String())
+	}
+	if *calls != 1 {
+		t.Errorf("handler called %d times, want 1", *calls)
+	}
+
+	// consumer ghi batch mới → entry cũ bị bỏ qua
+	store.generation++
+	if w := get(h, "/api/v1/topics", ""); w.Header().Get(HeaderCache) != ResultMiss || *calls != 2 {
+		t.Errorf("after invalidation: %s = %q, handler calls %d", HeaderCache, w.Header().Get(HeaderCache), *calls)
+	}
+}
+
+func TestNextLinkPerRequest(t *testing.T) {
+	store := &fakeStore{entries: make(map[string][]byte)}
+	h, calls := newTestCache(store, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(headerNextCursor, "abc")
+		w.Header().Add("Link", NextLink(r, "abc"))
+		ok(w, r)
+	})
+
+	tests := []struct {
+		url, want string
+	}{
+		{"/api/v1/topics?topic=ai", `</api/v1/topics?cursor=abc&topic=ai>; rel="next"`},
+		// Entry do /api/v1 tạo: alias nhận link theo path của chính nó
+		{"/api/topics?topic=ai&offset=20", `</api/topics?cursor=abc&topic=ai>; rel="next"`},
+	}
+	for _, tt := range tests {
+		if got := get(h, tt.url, "").Header().Values("Link"); len(got) != 1 || got[0] != tt.want {
+			t.Errorf("%s: Link = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+	if *calls != 1 {
+		t.Errorf("handler called %d times, want 1", *calls)
+	}
+}
+
+func TestNotModified(t *testing.T) {
+	// ETag có cả khi không có Redis
+	for _, store := range []*fakeStore{nil, {entries: make(map[string][]byte)}} {
+		h, _ := newTestCache(store, ok)
+		etag := get(h, "/api/v1/topics", "").Header().Get("ETag")
+		if etag == "" {
+			t.Fatal("missing ETag")
+		}
+		w := get(h, "/api/v1/topics", etag)
+		if w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+			t.Errorf("store %v: status %d, body %q", store != nil, w.Code, w.Body.String())
+		}
+		if w := get(h, "/api/v1/topics", `W/"other"`); w.Code != http.StatusOK {
+			t.Errorf("store %v: other ETag: status %d", store != nil, w.Code)
+		}
+	}
+}
+
+func TestBypass(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		status  int
+	}{
+		{"error", func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "boom", http.StatusInternalServerError)
+		}, http.StatusInternalServerError},
+		{"degraded", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Degraded", "redis")
+			ok(w, r)
+		}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		store := &fakeStore{entries: make(map[string][]byte)}
+		h, calls := newTestCache(store, tt.handler)
+		for i := 0; i < 2; i++ {
+			if w := get(h, "/api/v1/topics", ""); w.Code != tt.status {
+				t.Errorf("%s: status %d, want %d", tt.name, w.Code, tt.status)
+			}
+		}
+		if *calls != 2 || len(store.entries) != 0 {
+			t.Errorf("%s: handler calls %d, entries %d", tt.name, *calls, len(store.entries))
+		}
+	}
+}
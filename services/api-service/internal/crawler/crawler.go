@@ -8,8 +8,9 @@
 package crawler
 
 import (
-	"fmt"
+	"log/slog"
 	"social-insight/internal/kafka"
+	"social-insight/internal/logger"
 	"social-insight/internal/models"
 	"social-insight/internal/redis"
 	"time"
@@ -58,7 +59,7 @@ func (b *BaseCrawler) ProcessAndSend(posts []models.Post) (sent, skipped int, er
 		// Check dedup
 		seen, err := b.redis.CheckIfSeen(b.source, post.ID)
 		if err != nil {
-			fmt.Printf("❌ Redis check error for %s: %v\n", post.ID, err)
+			slog.Error("redis check error", "source", b.source, "post_id", post.ID, logger.Err(err))
 			skipped++
 			continue
 		}
@@ -71,20 +72,19 @@ func (b *BaseCrawler) ProcessAndSend(posts []models.Post) (sent, skipped int, er
 		// Send to Kafka
 		// Send post via producer
 		if err := b.producer.SendPost(post); err != nil {
-			fmt.Printf("❌ Kafka send error for %s: %v\n", post.ID, err)
+			slog.Error("kafka send error", "source", b.source, "post_id", post.ID, logger.Err(err))
 			skipped++
 			continue
 		}
 
 		// Mark as seen in Redis (TTL 7 days)
 		if err := b.redis.MarkAsSeen(b.source, post.ID, 7*24*time.Hour); err != nil {
-			fmt.Printf("⚠️  Redis mark error for %s: %v\n", post.ID, err)
+			slog.Warn("redis mark error", "source", b.source, "post_id", post.ID, logger.Err(err))
 			// Don't fail, just warn
 		}
 
 		sent++
-		fmt.Printf("✅ [%s] Sent post %s\n",
-			b.source, post.ID)
+		slog.Debug("post sent", "source", b.source, "post_id", post.ID)
 	}
 
 	return sent, skipped, nil
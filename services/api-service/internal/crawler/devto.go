@@ -12,7 +12,9 @@ package crawler
 import (
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	httpclient "social-insight/internal/http"
+	"social-insight/internal/logger"
 	"social-insight/internal/models"
 	"strings"
 	"time"
@@ -63,16 +65,16 @@ func (d *DevToCrawler) Name() string {
 
 // Fetch lấy posts từ Dev.to
 func (d *DevToCrawler) Fetch() ([]models.Post, error) {
-	fmt.Println("📡 Fetching Dev.to posts...")
+	slog.Info("fetching posts", "source", "devto", "tags", len(d.tags))
 
 	posts := make([]models.Post, 0)
 
 	for _, tag := range d.tags {
-		fmt.Printf("   📌 Crawling tag: %s\n", tag)
+		slog.Debug("crawling tag", "source", "devto", "tag", tag)
 
 		tagPosts, err := d.fetchFromTag(tag)
 		if err != nil {
-			fmt.Printf("   ⚠️  Tag error: %v\n", err)
+			slog.Warn("tag error", "source", "devto", "tag", tag, logger.Err(err))
 			continue
 		}
 
@@ -82,7 +84,7 @@ func (d *DevToCrawler) Fetch() ([]models.Post, error) {
 		time.Sleep(200 * time.Millisecond)
 	}
 
-	fmt.Printf("✅ Fetched %d posts from Dev.to\n", len(posts))
+	slog.Info("fetch complete", "source", "devto", "posts", len(posts))
 	return posts, nil
 }
 
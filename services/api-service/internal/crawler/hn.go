@@ -11,7 +11,9 @@ package crawler
 import (
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	httpclient "social-insight/internal/http"
+	"social-insight/internal/logger"
 	"social-insight/internal/models"
 	"strings"
 	"time"
@@ -54,7 +56,7 @@ func (h *HackerNewsCrawler) Name() string {
 
 // Fetch lấy top stories từ HN
 func (h *HackerNewsCrawler) Fetch() ([]models.Post, error) {
-	fmt.Println("📡 Fetching HackerNews top stories...")
+	slog.Info("fetching top stories", "source", "hn")
 
 	// Fetch list of top story IDs
 	url := "https://hacker-news.firebaseio.com/v0/topstories.json"
@@ -72,14 +74,14 @@ func (h *HackerNewsCrawler) Fetch() ([]models.Post, error) {
 		storyIDs = storyIDs[:h.storiesLimit]
 	}
 
-	fmt.Printf("✅ Got %d story IDs, fetching details...\n", len(storyIDs))
+	slog.Debug("fetching story details", "source", "hn", "stories", len(storyIDs))
 
 	// Fetch details cho từng story (parallel)
 	posts := make([]models.Post, 0, len(storyIDs))
 	for i, id := range storyIDs {
 		post, err := h.fetchStory(id)
 		if err != nil {
-			fmt.Printf("⚠️  Skip story %d: %v\n", id, err)
+			slog.Warn("skip story", "source", "hn", "story_id", id, logger.Err(err))
 			continue
 		}
 
@@ -93,7 +95,7 @@ func (h *HackerNewsCrawler) Fetch() ([]models.Post, error) {
 		}
 	}
 
-	fmt.Printf("✅ Fetched %d stories from HN\n", len(posts))
+	slog.Info("fetch complete", "source", "hn", "posts", len(posts))
 	return posts, nil
 }
 
@@ -12,7 +12,9 @@ package crawler
 import (
 	"encoding/xml"
 	"fmt"
+	"log/slog"
 	httpclient "social-insight/internal/http"
+	"social-insight/internal/logger"
 	"social-insight/internal/models"
 	"strings"
 	"time"
@@ -69,16 +71,16 @@ func (m *MediumCrawler) Name() string {
 
 // Fetch lấy posts từ Medium
 func (m *MediumCrawler) Fetch() ([]models.Post, error) {
-	fmt.Println("📡 Fetching Medium posts...")
+	slog.Info("fetching posts", "source", "medium", "topics", len(m.topics))
 
 	posts := make([]models.Post, 0)
 
 	for _, topic := range m.topics {
-		fmt.Printf("   📌 Crawling topic: %s\n", topic)
+		slog.Debug("crawling topic", "source", "medium", "topic", topic)
 
 		topicPosts, err := m.fetchFromTopic(topic)
 		if err != nil {
-			fmt.Printf("   ⚠️  Topic error: %v\n", err)
+			slog.Warn("topic error", "source", "medium", "topic", topic, logger.Err(err))
 			continue
 		}
 
@@ -88,7 +90,7 @@ func (m *MediumCrawler) Fetch() ([]models.Post, error) {
 		time.Sleep(200 * time.Millisecond)
 	}
 
-	fmt.Printf("✅ Fetched %d posts from Medium\n", len(posts))
+	slog.Info("fetch complete", "source", "medium", "posts", len(posts))
 	return posts, nil
 }
 
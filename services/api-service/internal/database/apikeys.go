@@ -0,0 +1,140 @@
+// =====================================================
+// API KEYS - Lưu trữ API keys
+// =====================================================
+// Mô tả: Tạo/tìm/thu hồi API key theo hash và cộng dồn
+// usage counters (bảng api_keys, migration 007)
+// =====================================================
+
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"social-insight/internal/models"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// apiKeyColumns là danh sách cột khi đọc api_keys (khớp với scanAPIKey)
+const apiKeyColumns = `id, name, prefix, scopes, request_count, last_used_at, created_at, revoked_at`
+
+// rowScanner là *sql.Row hoặc *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanAPIKey đọc một dòng (SELECT apiKeyColumns)
+func scanAPIKey(row rowScanner) (*models.APIKey, error) {
+	var key models.APIKey
+	var lastUsed, revoked sql.NullTime
+	if err := row.Scan(&key.ID, &key.Name, &key.Prefix, pq.Array(&key.Scopes),
+		&key.RequestCount, &lastUsed, &key.CreatedAt, &revoked); err != nil {
+		return nil, err
+	}
+	if lastUsed.Valid {
+		key.LastUsedAt = &lastUsed.Time
+	}
+	if revoked.Valid {
+		key.RevokedAt = &revoked.Time
+	}
+	return &key, nil
+}
+
+// CreateAPIKey lưu key mới (chỉ hash), trả về key đã tạo
+func (db *DB) CreateAPIKey(name, prefix, keyHash string, scopes []string) (_ *models.APIKey, err error) {
+	ctx, end := db.observe("create_api_key")
+	defer end(&err)
+
+	row := db.conn.QueryRowContext(ctx, `
+		INSERT INTO api_keys (name, prefix, key_hash, scopes)
+		VALUES ($1, $2, $3, $4)
+		RETURNING `+apiKeyColumns,
+		name, prefix, keyHash, pq.Array(scopes))
+	key, err := scanAPIKey(row)
+	if err != nil {
+		return nil, fmt.Errorf("create api key error: %w", err)
+	}
+	return key, nil
+}
+
+// GetAPIKeyByHash tìm key theo hash (kể cả key đã thu hồi), nil nếu không có
+func (db *DB) GetAPIKeyByHash(keyHash string) (_ *models.APIKey, err error) {
+	ctx, end := db.observe("get_api_key")
+	defer end(&err)
+
+	row := db.conn.QueryRowContext(ctx,
+		"SELECT "+apiKeyColumns+" FROM api_keys WHERE key_hash = $1", keyHash)
+	key, err := scanAPIKey(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return key, err
+}
+
+// ListAPIKeys trả về mọi key, mới nhất trước
+func (db *DB) ListAPIKeys() (_ []models.APIKey, err error) {
+	ctx, end := db.observe("list_api_keys")
+	defer end(&err)
+
+	rows, err := db.conn.QueryContext(ctx,
+		"SELECT "+apiKeyColumns+" FROM api_keys ORDER BY created_at DESC, id DESC")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := make([]models.APIKey, 0)
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, *key)
+	}
+	return keys, rows.Err()
+}
+
+// RevokeAPIKey thu hồi key theo ID hoặc prefix
+// Trả về false nếu không có key còn hiệu lực khớp
+func (db *DB) RevokeAPIKey(idOrPrefix string) (_ bool, err error) {
+	ctx, end := db.observe("revoke_api_key")
+	defer end(&err)
+
+	res, err := db.conn.ExecContext(ctx, `
+		UPDATE api_keys SET revoked_at = NOW()
+		WHERE (id::text = $1 OR prefix = $1) AND revoked_at IS NULL
+	`, idOrPrefix)
+	if err != nil {
+		return false, fmt.Errorf("revoke api key error: %w", err)
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// AddAPIKeyUsage cộng dồn số request của từng key (key ID → số request)
+func (db *DB) AddAPIKeyUsage(counts map[int64]int64, usedAt time.Time) (err error) {
+	if len(counts) == 0 {
+		return nil
+	}
+	ctx, end := db.observe("add_api_key_usage")
+	defer end(&err)
+
+	ids := make([]int64, 0, len(counts))
+	ns := make([]int64, 0, len(counts))
+	for id, n := range counts {
+		ids = append(ids, id)
+		ns = append(ns, n)
+	}
+
+	_, err = db.conn.ExecContext(ctx, `
+		UPDATE api_keys k
+		SET request_count = k.request_count + u.n, last_used_at = $3
+		FROM unnest($1::bigint[], $2::bigint[]) AS u(id, n)
+		WHERE k.id = u.id
+	`, pq.Array(ids), pq.Array(ns), usedAt)
+	if err != nil {
+		return fmt.Errorf("add api key usage error: %w", err)
+	}
+	return nil
+}
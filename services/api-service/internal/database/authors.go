@@ -0,0 +1,158 @@
+// =====================================================
+// AUTHOR QUERIES - Hồ sơ và xếp hạng tác giả
+// =====================================================
+// Mô tả: Tác giả là (platform, handle). Bảng authors do consumer
+// tính lại mỗi khi ghi posts (migration 008); xếp hạng không lọc
+// thời gian/topic đọc thẳng bảng này, có lọc thì gom từ posts
+// =====================================================
+
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/lib/pq"
+
+	"social-insight/internal/models"
+)
+
+// AuthorKey là khóa của một tác giả
+type AuthorKey struct {
+	Platform string
+	Handle   string
+}
+
+// AuthorSorts là các thứ tự xếp hạng hợp lệ của GetTopAuthors
+// (engagement_rate = engagement trung bình mỗi post)
+var AuthorSorts = []string{"posts", "engagement", "engagement_rate"}
+
+// authorOrders là ORDER BY của từng sort (platform, handle để thứ tự
+// ổn định khi phân trang bằng offset)
+var authorOrders = map[string]string{
+	"posts":           "post_count DESC, total_engagement DESC, platform, handle",
+	"engagement":      "total_engagement DESC, post_count DESC, platform, handle",
+	"engagement_rate": "engagement_rate DESC, post_count DESC, platform, handle",
+}
+
+// AuthorRanking là cách xếp hạng của GetTopAuthors
+type AuthorRanking struct {
+	Sort     string // Một trong AuthorSorts ("" = posts)
+	MinPosts int    // Bỏ tác giả có ít posts hơn (tránh một bài viral đứng đầu engagement_rate)
+}
+
+// authorStatColumns là các cột của bảng authors theo thứ tự scanAuthorStat
+const authorStatColumns = `platform, handle, display_name, post_count, total_likes,
+		total_likes + total_comments + total_shares AS total_engagement,
+		(total_likes + total_comments + total_shares)::float8 / GREATEST(post_count, 1) AS engagement_rate`
+
+// scanAuthorStat đọc một dòng (SELECT authorStatColumns hoặc tương đương)
+func scanAuthorStat(row interface{ Scan(...interface{}) error }, a *models.AuthorStat) error {
+	return row.Scan(&a.Platform, &a.Handle, &a.Author, &a.PostCount, &a.TotalLikes,
+		&a.TotalEngagement, &a.EngagementRate)
+}
+
+// GetTopAuthors trả về tác giả của các posts khớp f theo r
+// f chỉ lọc platform (hoặc không lọc) → đọc bảng authors, ngược lại gom từ posts
+func (db *DB) GetTopAuthors(f PostFilter, r AuthorRanking, limit, offset int) (_ []models.AuthorStat, err error) {
+	ctx, end := db.observe("get_top_authors")
+	defer end(&err)
+
+	if r.Sort == "" {
+		r.Sort = "posts"
+	}
+	order, ok := authorOrders[r.Sort]
+	if !ok {
+		return nil, fmt.Errorf("invalid author sort %q", r.Sort)
+	}
+	if r.MinPosts < 1 {
+		r.MinPosts = 1
+	}
+
+	var query string
+	var args []interface{}
+	if f.From.IsZero() && f.To.IsZero() && f.Topic == "" {
+		args = append(args, r.MinPosts)
+		where := "WHERE post_count >= $1"
+		if f.Platform != "" {
+			args = append(args, f.Platform)
+			where += " AND platform = $2"
+		}
+		query = `SELECT ` + authorStatColumns + ` FROM authors ` + where
+	} else {
+		var where string
+		where, args = f.where(nil)
+		args = append(args, r.MinPosts)
+		query = `
+			SELECT platform, author_handle AS handle,
+				(array_agg(author ORDER BY created_at DESC))[1],
+				COUNT(*) AS post_count, COALESCE(SUM(likes), 0),
+				COALESCE(SUM(likes + comments + shares), 0) AS total_engagement,
+				COALESCE(SUM(likes + comments + shares), 0)::float8 / COUNT(*) AS engagement_rate
+			FROM posts
+			` + where + `
+			GROUP BY platform, author_handle
+			HAVING COUNT(*) >= $` + strconv.Itoa(len(args))
+	}
+	args = append(args, limit, offset)
+	query += ` ORDER BY ` + order +
+		` LIMIT $` + strconv.Itoa(len(args)-1) + ` OFFSET $` + strconv.Itoa(len(args))
+
+	rows, err := db.conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := make([]models.AuthorStat, 0)
+	for rows.Next() {
+		var a models.AuthorStat
+		if err := scanAuthorStat(rows, &a); err != nil {
+			return nil, err
+		}
+		results = append(results, a)
+	}
+	return results, rows.Err()
+}
+
+// GetAuthor trả về hồ sơ của tác giả, nil nếu không tồn tại
+func (db *DB) GetAuthor(platform, handle string) (_ *models.AuthorProfile, err error) {
+	ctx, end := db.observe("get_author")
+	defer end(&err)
+
+	var a models.AuthorProfile
+	var topics []byte
+	err = db.conn.QueryRowContext(ctx, `
+		SELECT `+authorStatColumns+`, first_seen_at, last_seen_at,
+			total_comments, total_shares, topic_counts, avg_sentiment
+		FROM authors
+		WHERE platform = $1 AND handle = $2
+	`, platform, handle).Scan(
+		&a.Platform, &a.Handle, &a.Author, &a.PostCount, &a.TotalLikes,
+		&a.TotalEngagement, &a.EngagementRate, &a.FirstSeenAt, &a.LastSeenAt,
+		&a.TotalComments, &a.TotalShares, &topics, &a.AvgSentiment,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(topics, &a.Topics); err != nil {
+		return nil, fmt.Errorf("invalid topic_counts of %s/%s: %w", platform, handle, err)
+	}
+	return &a, nil
+}
+
+// authorKeyArrays tách keys thành hai mảng cho unnest($1::text[], $2::text[])
+func authorKeyArrays(keys []AuthorKey) (interface{}, interface{}) {
+	platforms := make([]string, len(keys))
+	handles := make([]string, len(keys))
+	for i, k := range keys {
+		platforms[i] = k.Platform
+		handles[i] = k.Handle
+	}
+	return pq.Array(platforms), pq.Array(handles)
+}
@@ -0,0 +1,112 @@
+package database
+
+import (
+	"database/sql/driver"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+
+	"social-insight/internal/models"
+)
+
+// authorStatRows là các cột của authorStatColumns
+var authorStatRows = []string{"platform", "handle", "display_name", "post_count", "total_likes", "total_engagement", "engagement_rate"}
+
+func TestGetTopAuthors(t *testing.T) {
+	from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		filter  PostFilter
+		ranking AuthorRanking
+		query   string // Regex của câu SQL
+		args    []driver.Value
+	}{
+		// Không lọc thời gian/topic: đọc bảng authors do consumer tính sẵn
+		{"table by posts", PostFilter{}, AuthorRanking{},
+			`FROM authors WHERE post_count >= \$1 ORDER BY post_count DESC, total_engagement DESC, platform, handle LIMIT \$2 OFFSET \$3`,
+			[]driver.Value{1, 10, 0}},
+		{"table by rate on platform", PostFilter{Platform: "devto"}, AuthorRanking{Sort: "engagement_rate", MinPosts: 3},
+			`FROM authors WHERE post_count >= \$1 AND platform = \$2 ORDER BY engagement_rate DESC, post_count DESC, platform, handle LIMIT \$3 OFFSET \$4`,
+			[]driver.Value{3, "devto", 10, 0}},
+		// Có lọc: gom từ posts theo (platform, author_handle)
+		{"posts by engagement", PostFilter{From: from, Topic: "ai"}, AuthorRanking{Sort: "engagement", MinPosts: 2},
+			`FROM posts\s+WHERE created_at >= \$1 AND EXISTS \(.*pt.topic = \$2\)\s+GROUP BY platform, author_handle\s+HAVING COUNT\(\*\) >= \$3 ORDER BY total_engagement DESC, post_count DESC, platform, handle LIMIT \$4 OFFSET \$5`,
+			[]driver.Value{from, "ai", 2, 10, 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer conn.Close()
+
+			mock.ExpectQuery(tt.query).WithArgs(tt.args...).WillReturnRows(sqlmock.NewRows(authorStatRows).
+				AddRow("devto", "ana", "Ana", 4, 90, 120, 30.0))
+
+			authors, err := NewDBFromConn(conn).GetTopAuthors(tt.filter, tt.ranking, 10, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := []models.AuthorStat{{Platform: "devto", Handle: "ana", Author: "Ana", PostCount: 4,
+				TotalLikes: 90, TotalEngagement: 120, EngagementRate: 30}}
+			if !reflect.DeepEqual(authors, want) {
+				t.Errorf("authors = %+v", authors)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+
+	// Sort lạ bị từ chối trước khi query
+	conn, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if _, err := NewDBFromConn(conn).GetTopAuthors(PostFilter{}, AuthorRanking{Sort: "likes"}, 10, 0); err == nil {
+		t.Error("invalid sort: expected error")
+	}
+}
+
+func TestGetAuthor(t *testing.T) {
+	conn, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	db := NewDBFromConn(conn)
+
+	first := time.Date(2025, 11, 2, 8, 0, 0, 0, time.UTC)
+	last := time.Date(2026, 1, 30, 21, 0, 0, 0, time.UTC)
+	columns := append(authorStatRows, "first_seen_at", "last_seen_at", "total_comments", "total_shares", "topic_counts", "avg_sentiment")
+	mock.ExpectQuery("FROM authors\\s+WHERE platform = \\$1 AND handle = \\$2").WithArgs("hackernews", "pg").
+		WillReturnRows(sqlmock.NewRows(columns).
+			AddRow("hackernews", "pg", "pg", 3, 300, 360, 120.0, first, last, 50, 10, []byte(`{"ai": 2, "startups": 1}`), 0.5))
+	mock.ExpectQuery("FROM authors").WithArgs("hackernews", "nobody").WillReturnRows(sqlmock.NewRows(columns))
+
+	author, err := db.GetAuthor("hackernews", "pg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &models.AuthorProfile{
+		AuthorStat: models.AuthorStat{Platform: "hackernews", Handle: "pg", Author: "pg", PostCount: 3,
+			TotalLikes: 300, TotalEngagement: 360, EngagementRate: 120},
+		FirstSeenAt: first, LastSeenAt: last, TotalComments: 50, TotalShares: 10,
+		Topics: map[string]int64{"ai": 2, "startups": 1}, AvgSentiment: 0.5,
+	}
+	if !reflect.DeepEqual(author, want) {
+		t.Errorf("author = %+v, want %+v", author, want)
+	}
+
+	// Tác giả chưa có trong bảng: nil, không lỗi (handler trả 404)
+	if author, err := db.GetAuthor("hackernews", "nobody"); author != nil || err != nil {
+		t.Errorf("missing author = %+v, %v", author, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,98 @@
+// =====================================================
+// BATCH QUERIES - Đọc theo lô nhiều khóa một lần
+// =====================================================
+// Mô tả: Query nhận một danh sách khóa (post IDs, tác giả) và
+// trả về map theo khóa, để dataloader của GraphQL gộp N lần đọc
+// của các field lồng nhau thành một query
+// =====================================================
+
+package database
+
+import (
+	"github.com/lib/pq"
+
+	"social-insight/internal/models"
+)
+
+// GetPostsByIDs trả về posts theo id (id không tồn tại không có trong map)
+func (db *DB) GetPostsByIDs(ids []string) (_ map[string]models.Post, err error) {
+	ctx, end := db.observe("get_posts_by_ids")
+	defer end(&err)
+
+	rows, err := db.conn.QueryContext(ctx,
+		`SELECT `+postColumns+` FROM posts WHERE id = ANY($1)`, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	posts, err := scanPosts(rows)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]models.Post, len(posts))
+	for _, p := range posts {
+		result[p.ID] = p
+	}
+	return result, nil
+}
+
+// GetAuthorStats trả về thống kê (bảng authors) của từng tác giả trong keys
+// (tác giả không tồn tại không có trong map)
+func (db *DB) GetAuthorStats(keys []AuthorKey) (_ map[AuthorKey]models.AuthorStat, err error) {
+	ctx, end := db.observe("get_author_stats")
+	defer end(&err)
+
+	platforms, handles := authorKeyArrays(keys)
+	rows, err := db.conn.QueryContext(ctx, `
+		SELECT `+authorStatColumns+`
+		FROM authors
+		WHERE (platform, handle) IN (SELECT * FROM unnest($1::text[], $2::text[]))`, platforms, handles)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make(map[AuthorKey]models.AuthorStat, len(keys))
+	for rows.Next() {
+		var a models.AuthorStat
+		if err := scanAuthorStat(rows, &a); err != nil {
+			return nil, err
+		}
+		result[AuthorKey{a.Platform, a.Handle}] = a
+	}
+	return result, rows.Err()
+}
+
+// GetPostsByAuthors trả về tối đa limit posts mới nhất của mỗi tác giả
+func (db *DB) GetPostsByAuthors(keys []AuthorKey, limit int) (_ map[AuthorKey][]models.Post, err error) {
+	ctx, end := db.observe("get_posts_by_authors")
+	defer end(&err)
+
+	// ROW_NUMBER theo từng tác giả: một query cho mọi tác giả thay vì N query LIMIT
+	platforms, handles := authorKeyArrays(keys)
+	rows, err := db.conn.QueryContext(ctx, `
+		SELECT `+postColumns+`
+		FROM (
+			SELECT *, ROW_NUMBER() OVER (PARTITION BY platform, author_handle ORDER BY created_at DESC, id DESC) AS rn
+			FROM posts
+			WHERE (platform, author_handle) IN (SELECT * FROM unnest($1::text[], $2::text[]))
+		) ranked
+		WHERE rn <= $3
+		ORDER BY platform, author_handle, created_at DESC, id DESC`, platforms, handles, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	posts, err := scanPosts(rows)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[AuthorKey][]models.Post, len(keys))
+	for _, p := range posts {
+		key := AuthorKey{p.Platform, p.AuthorHandle}
+		result[key] = append(result[key], p)
+	}
+	return result, nil
+}
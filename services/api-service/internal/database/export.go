@@ -0,0 +1,170 @@
+// =====================================================
+// EXPORT QUERIES - Đọc posts/aggregates bằng server-side cursor
+// =====================================================
+// Mô tả: Export có thể trả hàng triệu dòng. Query chạy trong một
+// transaction read-only với DECLARE CURSOR và FETCH từng lô, nên
+// client chỉ giữ một lô trong memory; fn được gọi cho từng dòng
+// (thường ghi thẳng ra HTTP response hoặc file)
+// =====================================================
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+
+	"social-insight/internal/models"
+)
+
+// exportFetchSize là số dòng mỗi lần FETCH
+const exportFetchSize = 1000
+
+// AggregateIntervals là các độ rộng bucket hợp lệ của ExportAggregates
+var AggregateIntervals = []string{"hour", "day", "week"}
+
+// ExportPosts gọi fn cho mọi post khớp f, theo thứ tự created_at, id
+func (db *DB) ExportPosts(f PostFilter, fn func(models.Post) error) (err error) {
+	ctx, end := db.observe("export_posts")
+	defer end(&err)
+
+	where, args := f.where(nil)
+	query := `SELECT ` + postColumns + ` FROM posts ` + where + ` ORDER BY created_at, id`
+
+	return db.withCursor(ctx, query, args, func(rows *sql.Rows) (int, error) {
+		posts, err := scanPosts(rows)
+		if err != nil {
+			return 0, err
+		}
+		for _, p := range posts {
+			if err := fn(p); err != nil {
+				return 0, err
+			}
+		}
+		return len(posts), nil
+	})
+}
+
+// ExportAggregates gọi fn cho từng (bucket, topic, platform, sentiment)
+// của các posts khớp f; interval là một trong AggregateIntervals
+func (db *DB) ExportAggregates(f PostFilter, interval string, fn func(models.PostAggregate) error) (err error) {
+	ctx, end := db.observe("export_aggregates")
+	defer end(&err)
+
+	if !validInterval(interval) {
+		return fmt.Errorf("invalid aggregate interval %q", interval)
+	}
+
+	query, args := aggregateQuery(f, interval)
+	return db.withCursor(ctx, query, args, func(rows *sql.Rows) (int, error) {
+		n := 0
+		for rows.Next() {
+			a, err := scanAggregate(rows)
+			if err != nil {
+				return n, err
+			}
+			if err := fn(a); err != nil {
+				return n, err
+			}
+			n++
+		}
+		return n, rows.Err()
+	})
+}
+
+// GetAggregates trả về tối đa limit aggregates (như ExportAggregates,
+// nhưng đọc một lần cho các truy vấn nhỏ như GraphQL)
+func (db *DB) GetAggregates(f PostFilter, interval string, limit int) (_ []models.PostAggregate, err error) {
+	ctx, end := db.observe("get_aggregates")
+	defer end(&err)
+
+	if !validInterval(interval) {
+		return nil, fmt.Errorf("invalid aggregate interval %q", interval)
+	}
+	query, args := aggregateQuery(f, interval)
+	args = append(args, limit)
+	query += ` LIMIT $` + strconv.Itoa(len(args))
+
+	rows, err := db.conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := make([]models.PostAggregate, 0)
+	for rows.Next() {
+		a, err := scanAggregate(rows)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, a)
+	}
+	return results, rows.Err()
+}
+
+// aggregateQuery dựng query gộp posts khớp f theo (bucket, topic, platform,
+// sentiment); interval phải hợp lệ (đã kiểm tra theo whitelist nên ghép
+// thẳng vào SQL)
+func aggregateQuery(f PostFilter, interval string) (string, []interface{}) {
+	where, args := f.where(nil)
+	query := `
+		SELECT date_trunc('` + interval + `', created_at AT TIME ZONE 'UTC') AS bucket,
+			topic, platform, sentiment, COUNT(*),
+			COALESCE(SUM(likes), 0), COALESCE(SUM(comments), 0), COALESCE(SUM(shares), 0)
+		FROM posts
+		` + where + `
+		GROUP BY 1, 2, 3, 4
+		ORDER BY 1, 2, 3, 4`
+	return query, args
+}
+
+// scanAggregate đọc một dòng của aggregateQuery
+func scanAggregate(rows *sql.Rows) (models.PostAggregate, error) {
+	var a models.PostAggregate
+	err := rows.Scan(&a.Bucket, &a.Topic, &a.Platform, &a.Sentiment,
+		&a.Posts, &a.Likes, &a.Comments, &a.Shares)
+	a.Bucket = a.Bucket.UTC()
+	return a, err
+}
+
+// withCursor mở cursor cho query rồi FETCH từng lô exportFetchSize dòng,
+// gọi batch cho mỗi lô (trả về số dòng đã đọc) đến khi hết dữ liệu
+func (db *DB) withCursor(ctx context.Context, query string, args []interface{}, batch func(*sql.Rows) (int, error)) error {
+	tx, err := db.conn.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return err
+	}
+	// Read-only: rollback cũng đóng cursor
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DECLARE export_cursor NO SCROLL CURSOR FOR "+query, args...); err != nil {
+		return fmt.Errorf("declare cursor: %w", err)
+	}
+
+	fetch := "FETCH FORWARD " + strconv.Itoa(exportFetchSize) + " FROM export_cursor"
+	for {
+		rows, err := tx.QueryContext(ctx, fetch)
+		if err != nil {
+			return fmt.Errorf("fetch: %w", err)
+		}
+		n, err := batch(rows)
+		rows.Close()
+		if err != nil {
+			return err
+		}
+		if n < exportFetchSize {
+			return nil
+		}
+	}
+}
+
+// validInterval kiểm tra interval thuộc AggregateIntervals
+func validInterval(interval string) bool {
+	for _, i := range AggregateIntervals {
+		if i == interval {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,57 @@
+// =====================================================
+// POST FILTER - Điều kiện lọc posts của API
+// =====================================================
+// Mô tả: PostFilter dựng mệnh đề WHERE (created_at, topic, platform)
+// dùng chung cho các query danh sách; trường rỗng = không lọc
+// =====================================================
+
+package database
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PostFilter lọc posts theo thời gian tạo [From, To), topic (topic cấp 1
+// hoặc subtopic bất kỳ của post) và platform
+type PostFilter struct {
+	From     time.Time
+	To       time.Time
+	Topic    string
+	Platform string
+}
+
+// PostKey là vị trí của một post theo thứ tự (created_at DESC, id DESC),
+// dùng cho phân trang keyset
+type PostKey struct {
+	CreatedAt time.Time
+	ID        string
+}
+
+// where trả về mệnh đề WHERE ("" nếu không lọc) và args cho query
+// FROM posts (không alias); placeholder đánh số tiếp sau các args đã có
+func (f PostFilter) where(args []interface{}) (string, []interface{}) {
+	var conds []string
+	add := func(cond string, v interface{}) {
+		args = append(args, v)
+		conds = append(conds, strings.ReplaceAll(cond, "?", "$"+strconv.Itoa(len(args))))
+	}
+	if !f.From.IsZero() {
+		add("created_at >= ?", f.From)
+	}
+	if !f.To.IsZero() {
+		add("created_at < ?", f.To)
+	}
+	if f.Topic != "" {
+		// Mọi topic/subtopic của post (post_topics), không chỉ topic chính
+		add("EXISTS (SELECT 1 FROM post_topics pt WHERE pt.post_id = posts.id AND pt.topic = ?)", f.Topic)
+	}
+	if f.Platform != "" {
+		add("platform = ?", f.Platform)
+	}
+	if len(conds) == 0 {
+		return "", args
+	}
+	return "WHERE " + strings.Join(conds, " AND "), args
+}
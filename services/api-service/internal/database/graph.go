@@ -0,0 +1,203 @@
+// =====================================================
+// AUTHOR GRAPH QUERIES - Influence và đồ thị tác giả – topic/keyword
+// =====================================================
+// Mô tả: Đọc số liệu để chấm influence (bảng authors, author_topics)
+// và cạnh của đồ thị (author_topics, author_keywords). Các bảng do
+// consumer tính lại mỗi khi ghi posts (migration 009)
+// =====================================================
+
+package database
+
+import (
+	"strconv"
+	"time"
+)
+
+// MaxGraphCandidates là số tác giả tối đa được chấm influence cho một đồ thị
+// (engagement mỗi post so với trung bình nền tảng cao nhất trước)
+const MaxGraphCandidates = 5000
+
+// AuthorGraphFilter chọn tác giả của đồ thị
+type AuthorGraphFilter struct {
+	Topic    string // "" = mọi topic
+	Platform string // "" = mọi nền tảng
+	MinPosts int    // Bỏ tác giả có ít posts hơn (trong Topic nếu có)
+}
+
+// AuthorActivity là số liệu của một tác giả để chấm influence
+// PostCount và Engagement chỉ tính posts trong topic khi lọc topic
+type AuthorActivity struct {
+	Platform    string
+	Handle      string
+	Name        string
+	PostCount   int64
+	Engagement  int64 // likes + comments + shares
+	FirstSeenAt time.Time
+	LastSeenAt  time.Time
+	ActiveWeeks int64 // Số tuần (lịch) có post
+	Platforms   int64 // Số nền tảng có tác giả cùng handle
+}
+
+// AuthorTopicEdge là cạnh tác giả → topic
+type AuthorTopicEdge struct {
+	Author     AuthorKey
+	Topic      string
+	PostCount  int64
+	Engagement int64
+}
+
+// AuthorKeywordEdge là cạnh tác giả → keyword (cộng qua các topic nếu không lọc)
+type AuthorKeywordEdge struct {
+	Author    AuthorKey
+	Keyword   string
+	PostCount int64
+}
+
+// platformBaselines là engagement trung bình mỗi post của từng nền tảng
+const platformBaselines = `
+	SELECT platform,
+		SUM(total_likes + total_comments + total_shares)::float8 / GREATEST(SUM(post_count), 1) AS baseline
+	FROM authors
+	GROUP BY platform`
+
+// GetPlatformBaselines trả về engagement trung bình mỗi post của từng nền tảng
+func (db *DB) GetPlatformBaselines() (_ map[string]float64, err error) {
+	ctx, end := db.observe("get_platform_baselines")
+	defer end(&err)
+
+	rows, err := db.conn.QueryContext(ctx, platformBaselines)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	baselines := make(map[string]float64)
+	for rows.Next() {
+		var platform string
+		var baseline float64
+		if err := rows.Scan(&platform, &baseline); err != nil {
+			return nil, err
+		}
+		baselines[platform] = baseline
+	}
+	return baselines, rows.Err()
+}
+
+// GetAuthorActivity trả về tối đa MaxGraphCandidates tác giả khớp f,
+// engagement mỗi post so với trung bình nền tảng cao nhất trước
+func (db *DB) GetAuthorActivity(f AuthorGraphFilter) (_ []AuthorActivity, err error) {
+	ctx, end := db.observe("get_author_activity")
+	defer end(&err)
+
+	if f.MinPosts < 1 {
+		f.MinPosts = 1
+	}
+
+	// Lọc topic: số posts/engagement lấy từ cạnh author_topics
+	from := `authors a`
+	posts, engagement := `a.post_count`, `a.total_likes + a.total_comments + a.total_shares`
+	args := []interface{}{f.MinPosts}
+	if f.Topic != "" {
+		args = append(args, f.Topic)
+		from = `authors a JOIN author_topics t ON t.platform = a.platform AND t.handle = a.handle AND t.topic = $2`
+		posts, engagement = `t.post_count`, `t.engagement`
+	}
+	where := `WHERE ` + posts + ` >= $1`
+	if f.Platform != "" {
+		args = append(args, f.Platform)
+		where += ` AND a.platform = $` + strconv.Itoa(len(args))
+	}
+	args = append(args, MaxGraphCandidates)
+
+	rows, err := db.conn.QueryContext(ctx, `
+		WITH baselines AS (`+platformBaselines+`)
+		SELECT a.platform, a.handle, a.display_name, `+posts+`, `+engagement+`,
+			a.first_seen_at, a.last_seen_at, a.active_weeks,
+			(SELECT COUNT(*) FROM authors o WHERE o.handle = a.handle)
+		FROM `+from+`
+		JOIN baselines b ON b.platform = a.platform
+		`+where+`
+		ORDER BY (`+engagement+`)::float8 / `+posts+` / GREATEST(b.baseline, 1) DESC, a.platform, a.handle
+		LIMIT $`+strconv.Itoa(len(args)), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	authors := make([]AuthorActivity, 0)
+	for rows.Next() {
+		var a AuthorActivity
+		if err := rows.Scan(&a.Platform, &a.Handle, &a.Name, &a.PostCount, &a.Engagement,
+			&a.FirstSeenAt, &a.LastSeenAt, &a.ActiveWeeks, &a.Platforms); err != nil {
+			return nil, err
+		}
+		authors = append(authors, a)
+	}
+	return authors, rows.Err()
+}
+
+// GetAuthorTopicEdges trả về cạnh topic của các tác giả trong keys
+// (chỉ cạnh tới topic nếu topic != ""), nhiều posts trước
+func (db *DB) GetAuthorTopicEdges(keys []AuthorKey, topic string) (_ []AuthorTopicEdge, err error) {
+	ctx, end := db.observe("get_author_topic_edges")
+	defer end(&err)
+
+	platforms, handles := authorKeyArrays(keys)
+	rows, err := db.conn.QueryContext(ctx, `
+		SELECT platform, handle, topic, post_count, engagement
+		FROM author_topics
+		WHERE (platform, handle) IN (SELECT * FROM unnest($1::text[], $2::text[]))
+			AND ($3 = '' OR topic = $3)
+		ORDER BY platform, handle, post_count DESC, topic
+	`, platforms, handles, topic)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	edges := make([]AuthorTopicEdge, 0)
+	for rows.Next() {
+		var e AuthorTopicEdge
+		if err := rows.Scan(&e.Author.Platform, &e.Author.Handle, &e.Topic, &e.PostCount, &e.Engagement); err != nil {
+			return nil, err
+		}
+		edges = append(edges, e)
+	}
+	return edges, rows.Err()
+}
+
+// GetAuthorKeywordEdges trả về tối đa perAuthor keywords nhiều posts nhất
+// của mỗi tác giả trong keys (chỉ posts trong topic nếu topic != "")
+func (db *DB) GetAuthorKeywordEdges(keys []AuthorKey, topic string, perAuthor int) (_ []AuthorKeywordEdge, err error) {
+	ctx, end := db.observe("get_author_keyword_edges")
+	defer end(&err)
+
+	platforms, handles := authorKeyArrays(keys)
+	rows, err := db.conn.QueryContext(ctx, `
+		SELECT platform, handle, keyword, n
+		FROM (
+			SELECT platform, handle, keyword, SUM(post_count) AS n,
+				ROW_NUMBER() OVER (PARTITION BY platform, handle ORDER BY SUM(post_count) DESC, keyword) AS rn
+			FROM author_keywords
+			WHERE (platform, handle) IN (SELECT * FROM unnest($1::text[], $2::text[]))
+				AND ($3 = '' OR topic = $3)
+			GROUP BY platform, handle, keyword
+		) ranked
+		WHERE rn <= $4
+		ORDER BY platform, handle, rn
+	`, platforms, handles, topic, perAuthor)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	edges := make([]AuthorKeywordEdge, 0)
+	for rows.Next() {
+		var e AuthorKeywordEdge
+		if err := rows.Scan(&e.Author.Platform, &e.Author.Handle, &e.Keyword, &e.PostCount); err != nil {
+			return nil, err
+		}
+		edges = append(edges, e)
+	}
+	return edges, rows.Err()
+}
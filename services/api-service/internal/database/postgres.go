@@ -8,19 +8,28 @@
 package database
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"social-insight/internal/models"
+	"social-insight/internal/tracing"
+	"strconv"
 	"strings"
 	"time"
 
 	_ "github.com/lib/pq" // PostgreSQL driver
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // DB là wrapper cho database connection
 type DB struct {
 	// conn là SQL connection
 	conn *sql.DB
+
+	// ctx là context của các query (span cha, xem WithContext)
+	ctx context.Context
 }
 
 // Config chứa cấu hình kết nối database
@@ -56,7 +65,38 @@ func NewDB(cfg Config) (*DB, error) {
 		return nil, fmt.Errorf("không thể kết nối database: %w", err)
 	}
 
-	return &DB{conn: conn}, nil
+	return NewDBFromConn(conn), nil
+}
+
+// NewDBFromConn bọc connection đã mở (pool do caller cấu hình)
+func NewDBFromConn(conn *sql.DB) *DB {
+	return &DB{conn: conn, ctx: context.Background()}
+}
+
+// WithContext trả về bản sao DB dùng ctx cho các query (dùng chung
+// connection pool); request bị hủy thì query cũng dừng
+func (db *DB) WithContext(ctx context.Context) *DB {
+	if db == nil {
+		return nil
+	}
+	clone := *db
+	clone.ctx = ctx
+	return &clone
+}
+
+// observe mở span "postgres <operation>" cho một thao tác
+// Dùng: ctx, end := db.observe("get_post_count"); defer end(&err)
+func (db *DB) observe(operation string) (context.Context, func(*error)) {
+	ctx, span := tracing.Start(db.ctx, "postgres "+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "postgresql"),
+			attribute.String("db.operation", operation),
+		),
+	)
+	return ctx, func(err *error) {
+		tracing.End(span, err)
+	}
 }
 
 // InsertPost chèn một post vào database
@@ -127,21 +167,28 @@ func (db *DB) InsertPosts(posts []models.Post) error {
 }
 
 // GetPostCount trả về tổng số posts trong database
-func (db *DB) GetPostCount() (int64, error) {
-	var count int64
-	err := db.conn.QueryRow("SELECT COUNT(*) FROM posts").Scan(&count)
+func (db *DB) GetPostCount() (count int64, err error) {
+	ctx, end := db.observe("get_post_count")
+	defer end(&err)
+
+	err = db.conn.QueryRowContext(ctx, "SELECT COUNT(*) FROM posts").Scan(&count)
 	return count, err
 }
 
-// GetStatsByTopic trả về thống kê theo topic
-func (db *DB) GetStatsByTopic() (map[string]int64, error) {
+// GetStatsByTopic trả về số posts của mỗi topic cấp 1
+// (post nhiều topic được đếm ở mỗi topic của nó)
+func (db *DB) GetStatsByTopic() (_ map[string]int64, err error) {
+	ctx, end := db.observe("get_stats_by_topic")
+	defer end(&err)
+
 	query := `
 		SELECT topic, COUNT(*) as count
-		FROM posts
+		FROM post_topics
+		WHERE parent IS NULL
 		GROUP BY topic
 	`
 
-	rows, err := db.conn.Query(query)
+	rows, err := db.conn.QueryContext(ctx, query)
 	if err != nil {
 		return nil, err
 	}
@@ -160,15 +207,51 @@ func (db *DB) GetStatsByTopic() (map[string]int64, error) {
 	return stats, nil
 }
 
+// GetTaxonomy trả về version taxonomy và các topic đang dùng (topic cấp
+// 1 trước subtopic của nó, theo thứ tự trong file taxonomy) kèm số posts
+func (db *DB) GetTaxonomy() (version int, _ []models.Topic, err error) {
+	ctx, end := db.observe("get_taxonomy")
+	defer end(&err)
+
+	rows, err := db.conn.QueryContext(ctx, `
+		SELECT t.id, COALESCE(t.parent, ''), t.name, t.taxonomy_version, COALESCE(c.posts, 0)
+		FROM topics t
+		LEFT JOIN (SELECT topic, COUNT(*) AS posts FROM post_topics GROUP BY topic) c ON c.topic = t.id
+		WHERE t.active
+		ORDER BY t.position, t.id
+	`)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer rows.Close()
+
+	topics := make([]models.Topic, 0)
+	for rows.Next() {
+		var t models.Topic
+		var v int
+		if err := rows.Scan(&t.ID, &t.Parent, &t.Name, &v, &t.Posts); err != nil {
+			return 0, nil, err
+		}
+		if v > version {
+			version = v
+		}
+		topics = append(topics, t)
+	}
+	return version, topics, rows.Err()
+}
+
 // GetStatsBySentiment trả về thống kê theo sentiment
-func (db *DB) GetStatsBySentiment() (map[string]int64, error) {
+func (db *DB) GetStatsBySentiment() (_ map[string]int64, err error) {
+	ctx, end := db.observe("get_stats_by_sentiment")
+	defer end(&err)
+
 	query := `
 		SELECT sentiment, COUNT(*) as count
 		FROM posts
 		GROUP BY sentiment
 	`
 
-	rows, err := db.conn.Query(query)
+	rows, err := db.conn.QueryContext(ctx, query)
 	if err != nil {
 		return nil, err
 	}
@@ -187,99 +270,240 @@ func (db *DB) GetStatsBySentiment() (map[string]int64, error) {
 	return stats, nil
 }
 
-// GetTopAuthors trả về top n tác giả có nhiều posts nhất
-func (db *DB) GetTopAuthors(limit int) ([]map[string]interface{}, error) {
-	query := `
-		SELECT author, COUNT(*) as post_count, SUM(likes) as total_likes
-		FROM posts
-		GROUP BY author
-		ORDER BY post_count DESC
-		LIMIT $1
-	`
+// CountDimensions là các cột CountPosts được nhóm theo
+var CountDimensions = []string{"topic", "sentiment", "platform"}
+
+// CountPosts đếm posts khớp f theo dimension (một trong CountDimensions)
+func (db *DB) CountPosts(f PostFilter, dimension string) (_ map[string]int64, err error) {
+	ctx, end := db.observe("count_posts")
+	defer end(&err)
+
+	valid := false
+	for _, d := range CountDimensions {
+		valid = valid || d == dimension
+	}
+	if !valid {
+		return nil, fmt.Errorf("invalid count dimension %q", dimension)
+	}
+
+	// dimension đã kiểm tra theo whitelist nên ghép thẳng vào SQL
+	where, args := f.where(nil)
+	query := `SELECT ` + dimension + `, COUNT(*) FROM posts ` + where + ` GROUP BY 1`
+	if dimension == "topic" {
+		// Theo topic cấp 1 của post_topics: post nhiều topic được đếm ở mỗi topic
+		query = `SELECT topic, COUNT(*) FROM post_topics
+			WHERE parent IS NULL AND post_id IN (SELECT id FROM posts ` + where + `) GROUP BY 1`
+	}
 
-	rows, err := db.conn.Query(query, limit)
+	rows, err := db.conn.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	results := make([]map[string]interface{}, 0)
+	counts := make(map[string]int64)
 	for rows.Next() {
-		var author string
-		var postCount, totalLikes int64
-		if err := rows.Scan(&author, &postCount, &totalLikes); err != nil {
+		var key string
+		var count int64
+		if err := rows.Scan(&key, &count); err != nil {
 			return nil, err
 		}
-		results = append(results, map[string]interface{}{
-			"author":      author,
-			"post_count":  postCount,
-			"total_likes": totalLikes,
-		})
+		counts[key] = count
 	}
-
-	return results, nil
+	return counts, rows.Err()
 }
 
-// GetPostsSince trả về posts từ một thời điểm nào đó
-func (db *DB) GetPostsSince(since time.Time) ([]models.Post, error) {
+// GetPosts trả về mọi posts khớp f, mới nhất trước
+// Caller phải giới hạn f.From/f.To (không có LIMIT)
+func (db *DB) GetPosts(f PostFilter) (_ []models.Post, err error) {
+	ctx, end := db.observe("get_posts")
+	defer end(&err)
+
+	where, args := f.where(nil)
 	query := `
-		SELECT id, author, content, topic, sentiment, likes, comments, shares, platform, created_at
+		SELECT ` + postColumns + `
 		FROM posts
-		WHERE created_at >= $1
+		` + where + `
 		ORDER BY created_at DESC
 	`
 
-	rows, err := db.conn.Query(query, since)
+	rows, err := db.conn.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	posts := make([]models.Post, 0)
-	for rows.Next() {
-		var post models.Post
-		if err := rows.Scan(
-			&post.ID, &post.Author, &post.Content, &post.Topic,
-			&post.Sentiment, &post.Likes, &post.Comments, &post.Shares,
-			&post.Platform, &post.CreatedAt,
-		); err != nil {
-			return nil, err
-		}
-		posts = append(posts, post)
-	}
-
-	return posts, nil
+	return scanPosts(rows)
 }
 
 // GetPostsInRange trả về posts trong một khoảng thời gian
-func (db *DB) GetPostsInRange(start, end time.Time) ([]models.Post, error) {
+func (db *DB) GetPostsInRange(start, until time.Time) (_ []models.Post, err error) {
+	ctx, end := db.observe("get_posts_in_range")
+	defer end(&err)
+
 	query := `
-		SELECT id, author, content, topic, sentiment, likes, comments, shares, platform, created_at
+		SELECT ` + postColumns + `
 		FROM posts
 		WHERE created_at >= $1 AND created_at < $2
 		ORDER BY created_at DESC
 	`
 
-	rows, err := db.conn.Query(query, start, end)
+	rows, err := db.conn.QueryContext(ctx, query, start, until)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
+	return scanPosts(rows)
+}
+
+// GetPostCluster trả về cluster chứa post: post gốc và tất cả near-duplicates
+// id có thể là post gốc hoặc một duplicate bất kỳ trong cluster
+// Trả về canonical ID và danh sách posts (post gốc đứng đầu), nil nếu không tồn tại
+func (db *DB) GetPostCluster(id string) (_ string, _ []models.Post, err error) {
+	ctx, end := db.observe("get_post_cluster")
+	defer end(&err)
+
+	var canonicalID string
+	err = db.conn.QueryRowContext(ctx,
+		"SELECT COALESCE(canonical_post_id, id) FROM posts WHERE id = $1", id,
+	).Scan(&canonicalID)
+	if err == sql.ErrNoRows {
+		return "", nil, nil
+	}
+	if err != nil {
+		return "", nil, err
+	}
+
+	query := `
+		SELECT ` + postColumns + `
+		FROM posts
+		WHERE id = $1 OR canonical_post_id = $1
+		ORDER BY (id = $1) DESC, created_at ASC
+	`
+
+	rows, err := db.conn.QueryContext(ctx, query, canonicalID)
+	if err != nil {
+		return "", nil, err
+	}
+	defer rows.Close()
+
+	posts, err := scanPosts(rows)
+	if err != nil {
+		return "", nil, err
+	}
+	return canonicalID, posts, nil
+}
+
+// GetStory trả về story theo ID cùng tất cả posts trỏ về link đó
+// Trả về nil nếu story không tồn tại
+func (db *DB) GetStory(id int64) (_ *models.Story, _ []models.Post, err error) {
+	ctx, end := db.observe("get_story")
+	defer end(&err)
+
+	var story models.Story
+	err = db.conn.QueryRowContext(ctx,
+		"SELECT id, canonical_url, first_seen_at, last_seen_at FROM stories WHERE id = $1", id,
+	).Scan(&story.ID, &story.CanonicalURL, &story.FirstSeenAt, &story.LastSeenAt)
+	if err == sql.ErrNoRows {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	query := `
+		SELECT ` + postColumns + `
+		FROM posts
+		WHERE story_id = $1
+		ORDER BY created_at ASC
+	`
+
+	rows, err := db.conn.QueryContext(ctx, query, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	posts, err := scanPosts(rows)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &story, posts, nil
+}
+
+// postColumns là danh sách cột chuẩn khi đọc posts (khớp thứ tự với scanPosts)
+// Topics là JSON từ post_topics: topic chính trước, rồi topic cấp 1 và
+// subtopic theo điểm giảm dần
+const postColumns = `id, author, COALESCE(title, ''), content, topic, sentiment,
+		likes, comments, shares, platform, created_at, COALESCE(canonical_post_id, ''),
+		COALESCE(url, ''), COALESCE(canonical_url, ''), COALESCE(author_handle, ''),
+		COALESCE((
+			SELECT json_agg(json_build_object('topic', pt.topic, 'parent', pt.parent, 'score', pt.score)
+				ORDER BY pt.is_primary DESC, (pt.parent IS NOT NULL), pt.score DESC, pt.topic)
+			FROM post_topics pt WHERE pt.post_id = id
+		), '[]')`
+
+// scanPosts đọc toàn bộ rows (SELECT postColumns) thành slice posts
+func scanPosts(rows *sql.Rows) ([]models.Post, error) {
 	posts := make([]models.Post, 0)
 	for rows.Next() {
 		var post models.Post
+		var topics []byte
 		if err := rows.Scan(
-			&post.ID, &post.Author, &post.Content, &post.Topic,
+			&post.ID, &post.Author, &post.Title, &post.Content, &post.Topic,
 			&post.Sentiment, &post.Likes, &post.Comments, &post.Shares,
-			&post.Platform, &post.CreatedAt,
+			&post.Platform, &post.CreatedAt, &post.CanonicalPostID,
+			&post.URL, &post.CanonicalURL, &post.AuthorHandle, &topics,
 		); err != nil {
 			return nil, err
 		}
+		if err := json.Unmarshal(topics, &post.Topics); err != nil {
+			return nil, fmt.Errorf("invalid topics of post %s: %w", post.ID, err)
+		}
 		posts = append(posts, post)
 	}
+	return posts, rows.Err()
+}
+
+// ListPosts trả về tối đa limit posts khớp f, mới nhất trước (thay Redis
+// recent_posts khi có filter hoặc Redis không dùng được)
+// after != nil: chỉ lấy posts đứng sau after (phân trang keyset), bỏ qua offset
+func (db *DB) ListPosts(f PostFilter, after *PostKey, limit, offset int) (_ []models.Post, err error) {
+	ctx, end := db.observe("list_posts")
+	defer end(&err)
+
+	where, args := f.where(nil)
+	if after != nil {
+		args = append(args, after.CreatedAt, after.ID)
+		cond := "(created_at, id) < ($" + strconv.Itoa(len(args)-1) + ", $" + strconv.Itoa(len(args)) + ")"
+		if where == "" {
+			where = "WHERE " + cond
+		} else {
+			where += " AND " + cond
+		}
+		offset = 0
+	}
+	args = append(args, limit, offset)
+	query := `
+		SELECT ` + postColumns + `
+		FROM posts
+		` + where + `
+		ORDER BY created_at DESC, id DESC
+		LIMIT $` + strconv.Itoa(len(args)-1) + ` OFFSET $` + strconv.Itoa(len(args))
+
+	rows, err := db.conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanPosts(rows)
+}
 
-	return posts, nil
+// Ping kiểm tra kết nối PostgreSQL (health check)
+func (db *DB) Ping(ctx context.Context) error {
+	return db.conn.PingContext(ctx)
 }
 
 // Close đóng database connection
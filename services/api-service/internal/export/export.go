@@ -0,0 +1,273 @@
+// =====================================================
+// EXPORT - Ghi posts/aggregates ra CSV, NDJSON hoặc Parquet
+// =====================================================
+// Mô tả: Writer ghi từng dòng ra io.Writer (HTTP response, file)
+// mà không giữ cả export trong memory:
+//   - csv: header ở dòng đầu, thời gian RFC3339; ô bắt đầu bằng
+//     = + - @ tab hoặc CR được thêm ' (chống CSV formula injection
+//     khi mở bằng Excel/Sheets; text crawl được do người ngoài viết)
+//   - ndjson: mỗi dòng một JSON object
+//   - parquet: row group mỗi RowGroupSize dòng, nén snappy
+// Dùng chung cho GET /api/v1/export/* và lệnh cmd/export
+//
+// Dùng:
+//   w := export.NewWriter[export.PostRow](out, export.FormatCSV)
+//   w.Write(export.NewPostRow(post))
+//   w.Close()
+// =====================================================
+
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/parquet-go/parquet-go/compress/snappy"
+
+	"social-insight/internal/models"
+)
+
+// Format là định dạng file export
+type Format string
+
+// Các định dạng hỗ trợ
+const (
+	FormatCSV     Format = "csv"
+	FormatNDJSON  Format = "ndjson"
+	FormatParquet Format = "parquet"
+)
+
+// Formats là các định dạng hợp lệ (giá trị của ?format=)
+var Formats = []Format{FormatCSV, FormatNDJSON, FormatParquet}
+
+// RowGroupSize là số dòng mỗi row group Parquet (giới hạn memory khi ghi)
+const RowGroupSize = 10000
+
+// ParseFormat đọc tên định dạng ("" = csv)
+func ParseFormat(s string) (Format, error) {
+	if s == "" {
+		return FormatCSV, nil
+	}
+	for _, f := range Formats {
+		if string(f) == s {
+			return f, nil
+		}
+	}
+	return "", fmt.Errorf("unknown export format %q (csv, ndjson, parquet)", s)
+}
+
+// ContentType là Content-Type của định dạng
+func (f Format) ContentType() string {
+	switch f {
+	case FormatNDJSON:
+		return "application/x-ndjson"
+	case FormatParquet:
+		return "application/vnd.apache.parquet"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// Row là một dòng export; CSV dùng Header/Record, NDJSON và Parquet
+// dùng json/parquet tags của struct
+type Row interface {
+	Header() []string
+	Record() []string
+}
+
+// Writer ghi các dòng kiểu T theo một định dạng
+type Writer[T Row] struct {
+	format Format
+	rows   int64
+
+	csv       *csv.Writer
+	csvHeader bool
+	json      *json.Encoder
+	parquet   *parquet.GenericWriter[T]
+}
+
+// NewWriter tạo Writer ghi ra w
+func NewWriter[T Row](w io.Writer, format Format) *Writer[T] {
+	ew := &Writer[T]{format: format}
+	switch format {
+	case FormatNDJSON:
+		ew.json = json.NewEncoder(w)
+	case FormatParquet:
+		ew.parquet = parquet.NewGenericWriter[T](w,
+			parquet.Compression(&snappy.Codec{}),
+			parquet.MaxRowsPerRowGroup(RowGroupSize),
+		)
+	default:
+		ew.csv = csv.NewWriter(w)
+	}
+	return ew
+}
+
+// Write ghi một dòng
+func (w *Writer[T]) Write(row T) error {
+	w.rows++
+	switch w.format {
+	case FormatNDJSON:
+		return w.json.Encode(row)
+	case FormatParquet:
+		_, err := w.parquet.Write([]T{row})
+		return err
+	}
+	if err := w.writeCSVHeader(row); err != nil {
+		return err
+	}
+	record := row.Record()
+	for i, cell := range record {
+		record[i] = escapeFormula(cell)
+	}
+	return w.csv.Write(record)
+}
+
+// escapeFormula thêm ' trước ô mà bảng tính sẽ hiểu là công thức
+func escapeFormula(cell string) string {
+	if cell != "" && strings.ContainsRune("=+-@\t\r", rune(cell[0])) {
+		return "'" + cell
+	}
+	return cell
+}
+
+// Flush đẩy dữ liệu đã đệm của CSV ra writer (Parquet chỉ ghi theo row group)
+func (w *Writer[T]) Flush() error {
+	if w.csv != nil {
+		w.csv.Flush()
+		return w.csv.Error()
+	}
+	return nil
+}
+
+// Close ghi phần còn lại (CSV rỗng vẫn có header, Parquet ghi footer)
+// Không đóng io.Writer bên dưới
+func (w *Writer[T]) Close() error {
+	switch w.format {
+	case FormatNDJSON:
+		return nil
+	case FormatParquet:
+		return w.parquet.Close()
+	}
+	var zero T
+	if err := w.writeCSVHeader(zero); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+// Rows là số dòng đã ghi
+func (w *Writer[T]) Rows() int64 {
+	return w.rows
+}
+
+// writeCSVHeader ghi header một lần trước dòng đầu tiên
+func (w *Writer[T]) writeCSVHeader(row T) error {
+	if w.csvHeader {
+		return nil
+	}
+	w.csvHeader = true
+	return w.csv.Write(row.Header())
+}
+
+// =====================================================
+// ROWS
+// =====================================================
+
+// PostRow là một post trong file export
+type PostRow struct {
+	ID              string    `json:"id" parquet:"id"`
+	Platform        string    `json:"platform" parquet:"platform,dict"`
+	Topic           string    `json:"topic" parquet:"topic,dict"`
+	Sentiment       string    `json:"sentiment" parquet:"sentiment,dict"`
+	Author          string    `json:"author" parquet:"author"`
+	Title           string    `json:"title" parquet:"title"`
+	Content         string    `json:"content" parquet:"content"`
+	URL             string    `json:"url" parquet:"url"`
+	CanonicalURL    string    `json:"canonical_url" parquet:"canonical_url"`
+	CanonicalPostID string    `json:"canonical_post_id" parquet:"canonical_post_id"`
+	Likes           int64     `json:"likes" parquet:"likes"`
+	Comments        int64     `json:"comments" parquet:"comments"`
+	Shares          int64     `json:"shares" parquet:"shares"`
+	CreatedAt       time.Time `json:"created_at" parquet:"created_at,timestamp(millisecond)"`
+}
+
+// NewPostRow chuyển post thành dòng export
+func NewPostRow(p models.Post) PostRow {
+	return PostRow{
+		ID:              p.ID,
+		Platform:        p.Platform,
+		Topic:           p.Topic,
+		Sentiment:       p.Sentiment,
+		Author:          p.Author,
+		Title:           p.Title,
+		Content:         p.Content,
+		URL:             p.URL,
+		CanonicalURL:    p.CanonicalURL,
+		CanonicalPostID: p.CanonicalPostID,
+		Likes:           int64(p.Likes),
+		Comments:        int64(p.Comments),
+		Shares:          int64(p.Shares),
+		CreatedAt:       p.CreatedAt.UTC(),
+	}
+}
+
+// Header implement Row
+func (PostRow) Header() []string {
+	return []string{"id", "platform", "topic", "sentiment", "author", "title", "content",
+		"url", "canonical_url", "canonical_post_id", "likes", "comments", "shares", "created_at"}
+}
+
+// Record implement Row
+func (r PostRow) Record() []string {
+	return []string{r.ID, r.Platform, r.Topic, r.Sentiment, r.Author, r.Title, r.Content,
+		r.URL, r.CanonicalURL, r.CanonicalPostID, itoa(r.Likes), itoa(r.Comments), itoa(r.Shares),
+		r.CreatedAt.Format(time.RFC3339)}
+}
+
+// AggregateRow là số liệu gộp của một bucket trong file export
+type AggregateRow struct {
+	Bucket    time.Time `json:"bucket" parquet:"bucket,timestamp(millisecond)"`
+	Topic     string    `json:"topic" parquet:"topic,dict"`
+	Platform  string    `json:"platform" parquet:"platform,dict"`
+	Sentiment string    `json:"sentiment" parquet:"sentiment,dict"`
+	Posts     int64     `json:"posts" parquet:"posts"`
+	Likes     int64     `json:"likes" parquet:"likes"`
+	Comments  int64     `json:"comments" parquet:"comments"`
+	Shares    int64     `json:"shares" parquet:"shares"`
+}
+
+// NewAggregateRow chuyển aggregate thành dòng export
+func NewAggregateRow(a models.PostAggregate) AggregateRow {
+	return AggregateRow{
+		Bucket:    a.Bucket.UTC(),
+		Topic:     a.Topic,
+		Platform:  a.Platform,
+		Sentiment: a.Sentiment,
+		Posts:     a.Posts,
+		Likes:     a.Likes,
+		Comments:  a.Comments,
+		Shares:    a.Shares,
+	}
+}
+
+// Header implement Row
+func (AggregateRow) Header() []string {
+	return []string{"bucket", "topic", "platform", "sentiment", "posts", "likes", "comments", "shares"}
+}
+
+// Record implement Row
+func (r AggregateRow) Record() []string {
+	return []string{r.Bucket.Format(time.RFC3339), r.Topic, r.Platform, r.Sentiment,
+		itoa(r.Posts), itoa(r.Likes), itoa(r.Comments), itoa(r.Shares)}
+}
+
+// itoa định dạng số nguyên cho CSV
+func itoa(n int64) string {
+	return strconv.FormatInt(n, 10)
+}
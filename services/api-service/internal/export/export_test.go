@@ -0,0 +1,152 @@
+package export
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+
+	"social-insight/internal/models"
+)
+
+var testPosts = []models.Post{
+	{ID: "a1", Platform: "devto", Topic: "ai", Sentiment: "positive", Author: "ann",
+		Title: "LLMs, again", Content: "line one\nline \"two\"", Likes: 10, Comments: 2, Shares: 1,
+		CreatedAt: time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)},
+	{ID: "b2", Platform: "hackernews", Topic: "cloud", Sentiment: "neutral", Author: "bob",
+		URL: "https://example.com/x", CanonicalURL: "example.com/x", CanonicalPostID: "a1",
+		CreatedAt: time.Date(2024, 3, 2, 11, 30, 0, 0, time.UTC)},
+}
+
+func write(t *testing.T, format Format) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := NewWriter[PostRow](&buf, format)
+	for _, p := range testPosts {
+		if err := w.Write(NewPostRow(p)); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	if w.Rows() != int64(len(testPosts)) {
+		t.Errorf("Rows() = %d", w.Rows())
+	}
+	return buf.Bytes()
+}
+
+func TestParseFormat(t *testing.T) {
+	if f, err := ParseFormat(""); err != nil || f != FormatCSV {
+		t.Errorf("default: got %q %v", f, err)
+	}
+	if f, err := ParseFormat("parquet"); err != nil || f != FormatParquet {
+		t.Errorf("parquet: got %q %v", f, err)
+	}
+	if _, err := ParseFormat("xlsx"); err == nil {
+		t.Error("xlsx should be rejected")
+	}
+}
+
+func TestCSV(t *testing.T) {
+	records, err := csv.NewReader(bytes.NewReader(write(t, FormatCSV))).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 3 || records[0][0] != "id" || records[0][len(records[0])-1] != "created_at" {
+		t.Fatalf("unexpected records %q", records)
+	}
+	if records[1][6] != "line one\nline \"two\"" || records[1][13] != "2024-03-01T10:00:00Z" {
+		t.Errorf("row 1 = %q", records[1])
+	}
+}
+
+func TestCSVFormulaInjection(t *testing.T) {
+	post := models.Post{ID: "c3", Platform: "hackernews", Topic: "ai", Sentiment: "neutral",
+		Author: "@mallory", Title: "=HYPERLINK(\"http://evil.example\")", Content: "+1 for this",
+		URL: "-2+3", CanonicalURL: "\tcmd", CanonicalPostID: "\rx", Likes: 5,
+		CreatedAt: time.Date(2024, 3, 3, 0, 0, 0, 0, time.UTC)}
+
+	for _, format := range []Format{FormatCSV, FormatNDJSON} {
+		var buf bytes.Buffer
+		w := NewWriter[PostRow](&buf, format)
+		if err := w.Write(NewPostRow(post)); err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		var got []string
+		if format == FormatCSV {
+			records, err := csv.NewReader(&buf).ReadAll()
+			if err != nil {
+				t.Fatal(err)
+			}
+			got = records[1]
+		} else {
+			var row PostRow
+			if err := json.Unmarshal(buf.Bytes(), &row); err != nil {
+				t.Fatal(err)
+			}
+			got = row.Record()
+		}
+
+		want := NewPostRow(post).Record()
+		if format == FormatCSV {
+			// Chỉ CSV được escape; ô số và ô thường giữ nguyên
+			for i := 4; i <= 9; i++ {
+				want[i] = "'" + want[i]
+			}
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("%s: column %d = %q, want %q", format, i, got[i], want[i])
+			}
+		}
+	}
+}
+
+func TestCSVEmptyHasHeader(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewWriter[AggregateRow](&buf, FormatCSV)
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); got != "bucket,topic,platform,sentiment,posts,likes,comments,shares\n" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestNDJSON(t *testing.T) {
+	scanner := bufio.NewScanner(bytes.NewReader(write(t, FormatNDJSON)))
+	var rows []PostRow
+	for scanner.Scan() {
+		var r PostRow
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			t.Fatalf("line %q: %v", scanner.Text(), err)
+		}
+		rows = append(rows, r)
+	}
+	if len(rows) != 2 || rows[1].CanonicalPostID != "a1" || !rows[0].CreatedAt.Equal(testPosts[0].CreatedAt) {
+		t.Errorf("unexpected rows %+v", rows)
+	}
+}
+
+func TestParquet(t *testing.T) {
+	data := write(t, FormatParquet)
+	rows, err := parquet.Read[PostRow](bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 || rows[0].ID != "a1" || rows[1].Platform != "hackernews" || rows[0].Likes != 10 {
+		t.Fatalf("unexpected rows %+v", rows)
+	}
+	if !rows[1].CreatedAt.Equal(testPosts[1].CreatedAt) {
+		t.Errorf("created_at = %s", rows[1].CreatedAt)
+	}
+}
@@ -0,0 +1,196 @@
+// =====================================================
+// QUERY COMPLEXITY - Chặn query quá nặng trước khi chạy
+// =====================================================
+// Mô tả: Duyệt AST của operation sẽ chạy (sau validate) và tính:
+//   - depth: số tầng field lồng nhau sâu nhất
+//   - cost: mỗi field 1 điểm (+ fieldCosts cho field đọc nhiều dữ liệu);
+//     field có argument limit nhân cost của các field con với limit
+//     (giá trị gửi lên, biến, hoặc mặc định của argument)
+// Ví dụ authors(limit: 100) { items { posts(limit: 20) { title } } }
+// tốn khoảng 1 + 100 × (1 + 1 + 20 × 1) = 2201 điểm.
+// Field introspection (__schema, __type, __typename) không tính
+// =====================================================
+
+package gql
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// fieldCosts là cost riêng của field (Type.field) ngoài 1 điểm mặc định:
+// field nạp cả khoảng thời gian vào memory hoặc quét nhiều dòng
+var fieldCosts = map[string]int{
+	"Query.stats":      10,
+	"Query.topics":     10,
+	"Query.taxonomy":   10,
+	"Query.sentiment":  10,
+	"Query.platforms":  10,
+	"Query.aggregates": 20,
+	"Query.trending":   50,
+}
+
+// Complexity là kết quả phân tích một operation
+type Complexity struct {
+	Cost  int
+	Depth int
+}
+
+// analyzer giữ trạng thái khi duyệt AST
+type analyzer struct {
+	fragments map[string]*ast.FragmentDefinition
+	variables map[string]interface{}
+	defaults  map[string]ast.Value // giá trị mặc định của biến trong operation
+}
+
+// Analyze tính complexity của operation operationName ("" = operation duy
+// nhất) trong doc đã validate
+func Analyze(schema graphql.Schema, doc *ast.Document, operationName string, variables map[string]interface{}) (Complexity, error) {
+	a := &analyzer{
+		fragments: make(map[string]*ast.FragmentDefinition),
+		variables: variables,
+		defaults:  make(map[string]ast.Value),
+	}
+	var op *ast.OperationDefinition
+	ops := 0
+	for _, def := range doc.Definitions {
+		switch def := def.(type) {
+		case *ast.FragmentDefinition:
+			a.fragments[def.Name.Value] = def
+		case *ast.OperationDefinition:
+			ops++
+			if operationName == "" || (def.Name != nil && def.Name.Value == operationName) {
+				op = def
+			}
+		}
+	}
+	if op == nil || (operationName == "" && ops > 1) {
+		return Complexity{}, fmt.Errorf("unknown operation %q", operationName)
+	}
+	for _, v := range op.VariableDefinitions {
+		if v.DefaultValue != nil {
+			a.defaults[v.Variable.Name.Value] = v.DefaultValue
+		}
+	}
+
+	root := schema.QueryType()
+	if op.Operation == ast.OperationTypeMutation {
+		root = schema.MutationType()
+	}
+	if root == nil {
+		return Complexity{}, fmt.Errorf("schema has no %s type", op.Operation)
+	}
+	cost, depth := a.selectionSet(root, op.SelectionSet, 0)
+	return Complexity{Cost: cost, Depth: depth}, nil
+}
+
+// selectionSet trả về cost và depth của các field trong set (parent là kiểu chứa)
+func (a *analyzer) selectionSet(parent *graphql.Object, set *ast.SelectionSet, visited int) (int, int) {
+	if set == nil || visited > 100 {
+		return 0, 0
+	}
+	cost, depth := 0, 0
+	add := func(c, d int) {
+		cost += c
+		if d > depth {
+			depth = d
+		}
+	}
+	for _, sel := range set.Selections {
+		switch sel := sel.(type) {
+		case *ast.Field:
+			add(a.field(parent, sel, visited))
+		case *ast.InlineFragment:
+			add(a.selectionSet(parent, sel.SelectionSet, visited+1))
+		case *ast.FragmentSpread:
+			if frag, ok := a.fragments[sel.Name.Value]; ok {
+				add(a.selectionSet(parent, frag.SelectionSet, visited+1))
+			}
+		}
+	}
+	return cost, depth
+}
+
+// field trả về cost và depth của một field (tính cả field con)
+func (a *analyzer) field(parent *graphql.Object, f *ast.Field, visited int) (int, int) {
+	name := f.Name.Value
+	if strings.HasPrefix(name, "__") {
+		return 0, 0
+	}
+	def, ok := parent.Fields()[name]
+	if !ok {
+		return 1, 1
+	}
+
+	cost := 1 + fieldCosts[parent.Name()+"."+name]
+	child, ok := unwrapObject(def.Type)
+	if !ok {
+		return cost, 1
+	}
+	childCost, childDepth := a.selectionSet(child, f.SelectionSet, visited)
+	return cost + a.limit(def, f)*childCost, 1 + childDepth
+}
+
+// limit là giá trị argument limit của field (1 nếu field không phân trang)
+func (a *analyzer) limit(def *graphql.FieldDefinition, f *ast.Field) int {
+	var fallback int
+	hasLimit := false
+	for _, arg := range def.Args {
+		if arg.Name() == "limit" {
+			hasLimit = true
+			fallback, _ = arg.DefaultValue.(int)
+		}
+	}
+	if !hasLimit {
+		return 1
+	}
+	for _, arg := range f.Arguments {
+		if arg.Name.Value != "limit" {
+			continue
+		}
+		if n, ok := a.intValue(arg.Value); ok {
+			return max(n, 1)
+		}
+	}
+	return max(fallback, 1)
+}
+
+// intValue đọc số nguyên từ literal hoặc biến (giá trị gửi lên, rồi mặc định)
+func (a *analyzer) intValue(v ast.Value) (int, bool) {
+	switch v := v.(type) {
+	case *ast.IntValue:
+		n, err := strconv.Atoi(v.Value)
+		return n, err == nil
+	case *ast.Variable:
+		switch n := a.variables[v.Name.Value].(type) {
+		case float64:
+			return int(n), true
+		case int:
+			return n, true
+		}
+		if d, ok := a.defaults[v.Name.Value]; ok {
+			return a.intValue(d)
+		}
+	}
+	return 0, false
+}
+
+// unwrapObject bỏ NonNull/List để lấy kiểu object của field
+func unwrapObject(t graphql.Type) (*graphql.Object, bool) {
+	for {
+		switch tt := t.(type) {
+		case *graphql.NonNull:
+			t = tt.OfType
+		case *graphql.List:
+			t = tt.OfType
+		case *graphql.Object:
+			return tt, true
+		default:
+			return nil, false
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package gql
+
+import (
+	"testing"
+
+	"github.com/graphql-go/graphql/language/parser"
+)
+
+func TestAnalyze(t *testing.T) {
+	h := newTestHandler(t, newFakeStore())
+	tests := []struct {
+		query     string
+		variables map[string]interface{}
+		want      Complexity
+	}{
+		// stats (1 + 10) + totalPosts
+		{`{ stats { totalPosts } }`, nil, Complexity{Cost: 12, Depth: 2}},
+		// posts mặc định limit 20: 1 + 20 × (items 1 + id 1)
+		{`{ posts { items { id } } }`, nil, Complexity{Cost: 41, Depth: 3}},
+		// authors(limit: 100) { items { posts(limit: 20) { id } } }
+		{`{ authors(limit: 100) { items { posts(limit: 20) { id } } } }`, nil, Complexity{Cost: 2201, Depth: 4}},
+		// limit từ biến, field trong fragment
+		{`query($n: Int = 3) { posts(limit: $n) { ...page } } fragment page on PostPage { items { id } nextCursor }`,
+			nil, Complexity{Cost: 10, Depth: 3}},
+		{`query($n: Int = 3) { posts(limit: $n) { items { id } } }`,
+			map[string]interface{}{"n": float64(5)}, Complexity{Cost: 11, Depth: 3}},
+		// introspection không tính
+		{`{ __typename crawlers { source } }`, nil, Complexity{Cost: 2, Depth: 2}},
+	}
+	for _, tt := range tests {
+		doc, err := parser.Parse(parser.ParseParams{Source: tt.query})
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := Analyze(h.Schema(), doc, "", tt.variables)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.query, err)
+		}
+		if got != tt.want {
+			t.Errorf("%s: got %+v, want %+v", tt.query, got, tt.want)
+		}
+	}
+}
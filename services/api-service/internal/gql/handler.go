@@ -0,0 +1,193 @@
+// =====================================================
+// GRAPHQL HANDLER - GET/POST /api/v1/graphql
+// =====================================================
+// Mô tả: Nhận query theo GraphQL over HTTP:
+//   - POST body JSON {"query", "variables", "operationName"}
+//   - GET ?query=...&variables=<json>&operationName=...
+// Thứ tự: parse → validate → complexity (chặn trước khi chạm DB) →
+// execute với loaders mới của request.
+// Lỗi parse/validate/complexity trả 400, lỗi của field khi chạy trả
+// 200 với data từng phần và errors[].extensions.code
+// =====================================================
+
+package gql
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+
+	"social-insight/internal/api"
+	"social-insight/internal/server"
+)
+
+// maxBodyBytes giới hạn body của POST
+const maxBodyBytes = 1 << 20
+
+// Config chứa dependencies và giới hạn của endpoint
+type Config struct {
+	// Store trả về DB gắn context của request
+	Store func(ctx context.Context) Store
+
+	// Stats và Crawlers dùng chung cache/Redis với GET /stats, /crawlers
+	Stats    func(ctx context.Context) (api.StatsResponse, error)
+	Crawlers func(ctx context.Context) api.CrawlerStatus
+
+	// MaxComplexity và MaxDepth chặn query quá nặng (xem Analyze)
+	MaxComplexity int
+	MaxDepth      int
+}
+
+// Handler phục vụ GraphQL
+type Handler struct {
+	cfg    Config
+	schema graphql.Schema
+}
+
+// request là body của POST (GET đọc cùng các trường từ query string)
+type request struct {
+	Query         string                 `json:"query"`
+	Variables     map[string]interface{} `json:"variables"`
+	OperationName string                 `json:"operationName"`
+}
+
+// NewHandler dựng schema và trả về Handler
+func NewHandler(cfg Config) (*Handler, error) {
+	schema, err := newSchema(&resolver{cfg: cfg})
+	if err != nil {
+		return nil, fmt.Errorf("graphql schema: %w", err)
+	}
+	return &Handler{cfg: cfg, schema: schema}, nil
+}
+
+// Schema trả về schema (introspection, tests)
+func (h *Handler) Schema() graphql.Schema {
+	return h.schema
+}
+
+// ServeHTTP chạy một GraphQL request
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	req, err := readRequest(w, r)
+	if err != nil {
+		writeErrors(w, http.StatusBadRequest, newError(server.CodeBadRequest, err.Error()))
+		return
+	}
+	if req.Query == "" {
+		writeErrors(w, http.StatusBadRequest, newError(server.CodeBadRequest, "missing query"))
+		return
+	}
+
+	doc, err := parser.Parse(parser.ParseParams{Source: source.NewSource(&source.Source{
+		Body: []byte(req.Query),
+		Name: "GraphQL request",
+	})})
+	if err != nil {
+		fe := gqlerrors.FormatError(err)
+		fe.Extensions = map[string]interface{}{"code": CodeParseFailed}
+		writeErrors(w, http.StatusBadRequest, fe)
+		return
+	}
+
+	if result := graphql.ValidateDocument(&h.schema, doc, nil); !result.IsValid {
+		for i := range result.Errors {
+			result.Errors[i].Extensions = map[string]interface{}{"code": CodeInvalidQuery}
+		}
+		writeErrors(w, http.StatusBadRequest, result.Errors...)
+		return
+	}
+	if isMutation(doc, req.OperationName) {
+		writeErrors(w, http.StatusBadRequest, newError(CodeInvalidQuery, "only queries are supported"))
+		return
+	}
+
+	c, err := Analyze(h.schema, doc, req.OperationName, req.Variables)
+	if err != nil {
+		writeErrors(w, http.StatusBadRequest, newError(CodeInvalidQuery, err.Error()))
+		return
+	}
+	if c.Depth > h.cfg.MaxDepth {
+		writeErrors(w, http.StatusBadRequest, newError(CodeTooDeep,
+			fmt.Sprintf("query depth %d exceeds limit %d", c.Depth, h.cfg.MaxDepth)))
+		return
+	}
+	if c.Cost > h.cfg.MaxComplexity {
+		writeErrors(w, http.StatusBadRequest, newError(CodeTooComplex,
+			fmt.Sprintf("query cost %d exceeds limit %d, lower the limit arguments or split the query", c.Cost, h.cfg.MaxComplexity)))
+		return
+	}
+
+	ctx := withState(r.Context(), newState(h.cfg.Store(r.Context())))
+	result := graphql.Execute(graphql.ExecuteParams{
+		Schema:        h.schema,
+		AST:           doc,
+		OperationName: req.OperationName,
+		Args:          req.Variables,
+		Context:       ctx,
+	})
+	result.Extensions = map[string]interface{}{
+		"complexity": map[string]int{"cost": c.Cost, "depth": c.Depth, "limit": h.cfg.MaxComplexity},
+	}
+	slog.DebugContext(ctx, "graphql query",
+		slog.String("operation", req.OperationName),
+		slog.Int("cost", c.Cost),
+		slog.Int("depth", c.Depth),
+		slog.Int("errors", len(result.Errors)),
+	)
+	server.WriteJSON(w, http.StatusOK, result)
+}
+
+// readRequest đọc query, variables, operationName theo method
+func readRequest(w http.ResponseWriter, r *http.Request) (request, error) {
+	var req request
+	if r.Method == http.MethodGet {
+		q := r.URL.Query()
+		req.Query = q.Get("query")
+		req.OperationName = q.Get("operationName")
+		if v := q.Get("variables"); v != "" {
+			if err := json.Unmarshal([]byte(v), &req.Variables); err != nil {
+				return req, fmt.Errorf("invalid variables: %v", err)
+			}
+		}
+		return req, nil
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxBodyBytes)
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return req, fmt.Errorf("invalid JSON body: %v", err)
+	}
+	return req, nil
+}
+
+// isMutation báo operation sẽ chạy không phải query (schema chỉ có Query,
+// nhưng chặn rõ ràng để GET không bao giờ ghi)
+func isMutation(doc *ast.Document, operationName string) bool {
+	for _, def := range doc.Definitions {
+		op, ok := def.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+		if operationName == "" || (op.Name != nil && op.Name.Value == operationName) {
+			return op.Operation != ast.OperationTypeQuery
+		}
+	}
+	return false
+}
+
+// newError tạo lỗi GraphQL không gắn vị trí trong query
+func newError(code, message string) gqlerrors.FormattedError {
+	e := &Error{Code: code, Message: message}
+	return gqlerrors.FormattedError{Message: e.Message, Extensions: e.Extensions()}
+}
+
+// writeErrors ghi response chỉ có errors (request không chạy được)
+func writeErrors(w http.ResponseWriter, status int, errs ...gqlerrors.FormattedError) {
+	server.WriteJSON(w, status, &graphql.Result{Errors: errs})
+}
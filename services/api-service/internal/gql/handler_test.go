@@ -0,0 +1,288 @@
+package gql
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"social-insight/internal/api"
+	"social-insight/internal/database"
+	"social-insight/internal/models"
+)
+
+// fakeStore trả dữ liệu cố định và đếm số lần gọi mỗi query
+type fakeStore struct {
+	posts []models.Post
+	calls map[string]int
+}
+
+func newFakeStore() *fakeStore {
+	now := time.Now().UTC()
+	return &fakeStore{
+		calls: make(map[string]int),
+		posts: []models.Post{
+			{ID: "p1", Author: "Alice", AuthorHandle: "alice", Topic: "ai", Sentiment: "positive", Platform: "hn", Likes: 10, CreatedAt: now.Add(-time.Hour),
+				Topics: []models.TopicScore{{Topic: "ai", Score: 8}, {Topic: "llm", Parent: "ai", Score: 3}}},
+			{ID: "p2", Author: "bob", AuthorHandle: "bob", Topic: "cloud", Sentiment: "neutral", Platform: "hn", Likes: 3, CreatedAt: now.Add(-2 * time.Hour)},
+			{ID: "p3", Author: "Alice", AuthorHandle: "alice", Topic: "ai", Sentiment: "negative", Platform: "hn", Likes: 1, CreatedAt: now.Add(-3 * time.Hour), CanonicalPostID: "p1"},
+			// Cùng tên trên nền tảng khác là tác giả khác
+			{ID: "p4", Author: "Alice", AuthorHandle: "alice_s", Topic: "cloud", Sentiment: "neutral", Platform: "devto", Likes: 50, CreatedAt: now.Add(-4 * time.Hour)},
+		},
+	}
+}
+
+func (s *fakeStore) GetPostCount() (int64, error) {
+	return int64(len(s.posts)), nil
+}
+
+func (s *fakeStore) CountPosts(f database.PostFilter, dimension string) (map[string]int64, error) {
+	s.calls["CountPosts"]++
+	counts := make(map[string]int64)
+	for _, p := range s.posts {
+		switch dimension {
+		case "topic":
+			counts[p.Topic]++
+		case "sentiment":
+			counts[p.Sentiment]++
+		case "platform":
+			counts[p.Platform]++
+		}
+	}
+	return counts, nil
+}
+
+func (s *fakeStore) ListPosts(f database.PostFilter, after *database.PostKey, limit, offset int) ([]models.Post, error) {
+	s.calls["ListPosts"]++
+	posts := s.posts
+	if len(posts) > limit {
+		posts = posts[:limit]
+	}
+	return posts, nil
+}
+
+func (s *fakeStore) GetPosts(f database.PostFilter) ([]models.Post, error) {
+	s.calls["GetPosts"]++
+	return s.posts, nil
+}
+
+func (s *fakeStore) GetTopAuthors(f database.PostFilter, r database.AuthorRanking, limit, offset int) ([]models.AuthorStat, error) {
+	s.calls["GetTopAuthors"]++
+	return []models.AuthorStat{{Platform: "hn", Handle: "alice", Author: "Alice", PostCount: 2, TotalLikes: 11}}, nil
+}
+
+func (s *fakeStore) GetAggregates(f database.PostFilter, interval string, limit int) ([]models.PostAggregate, error) {
+	s.calls["GetAggregates"]++
+	return nil, nil
+}
+
+func (s *fakeStore) GetPostsByIDs(ids []string) (map[string]models.Post, error) {
+	s.calls["GetPostsByIDs"]++
+	result := make(map[string]models.Post)
+	for _, p := range s.posts {
+		for _, id := range ids {
+			if p.ID == id {
+				result[id] = p
+			}
+		}
+	}
+	return result, nil
+}
+
+func (s *fakeStore) GetAuthorStats(keys []database.AuthorKey) (map[database.AuthorKey]models.AuthorStat, error) {
+	s.calls["GetAuthorStats"]++
+	result := make(map[database.AuthorKey]models.AuthorStat)
+	for _, p := range s.posts {
+		for _, k := range keys {
+			if p.Platform == k.Platform && p.AuthorHandle == k.Handle {
+				st := result[k]
+				st.Platform, st.Handle, st.Author = k.Platform, k.Handle, p.Author
+				st.PostCount++
+				st.TotalLikes += int64(p.Likes)
+				result[k] = st
+			}
+		}
+	}
+	return result, nil
+}
+
+func (s *fakeStore) GetPostsByAuthors(keys []database.AuthorKey, limit int) (map[database.AuthorKey][]models.Post, error) {
+	s.calls["GetPostsByAuthors"]++
+	result := make(map[database.AuthorKey][]models.Post)
+	for _, p := range s.posts {
+		for _, k := range keys {
+			if p.Platform == k.Platform && p.AuthorHandle == k.Handle && len(result[k]) < limit {
+				result[k] = append(result[k], p)
+			}
+		}
+	}
+	return result, nil
+}
+
+func (s *fakeStore) GetTaxonomy() (int, []models.Topic, error) {
+	s.calls["GetTaxonomy"]++
+	return 1, []models.Topic{
+		{ID: "ai", Name: "AI", Posts: 2},
+		{ID: "llm", Parent: "ai", Name: "LLM", Posts: 1},
+		{ID: "cloud", Name: "Cloud", Posts: 2},
+	}, nil
+}
+
+func newTestHandler(t *testing.T, store *fakeStore) *Handler {
+	t.Helper()
+	h, err := NewHandler(Config{
+		Store: func(context.Context) Store { return store },
+		Stats: func(context.Context) (api.StatsResponse, error) {
+			return api.StatsResponse{TotalPosts: 3, ByTopic: map[string]int64{"ai": 2, "cloud": 1}}, nil
+		},
+		Crawlers: func(context.Context) api.CrawlerStatus {
+			return api.CrawlerStatus{"hn": "2024-03-10T12:00:00Z", "medium": "never"}
+		},
+		MaxComplexity: 1000,
+		MaxDepth:      6,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return h
+}
+
+// response là body đã decode
+type response struct {
+	Data   map[string]json.RawMessage `json:"data"`
+	Errors []struct {
+		Message    string                 `json:"message"`
+		Extensions map[string]interface{} `json:"extensions"`
+	} `json:"errors"`
+}
+
+func post(t *testing.T, h *Handler, query string, variables map[string]interface{}) (int, response) {
+	t.Helper()
+	body, _ := json.Marshal(map[string]interface{}{"query": query, "variables": variables})
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("POST", "/api/v1/graphql", strings.NewReader(string(body))))
+	var resp response
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode %q: %v", w.Body.String(), err)
+	}
+	return w.Code, resp
+}
+
+func TestDashboardQuery(t *testing.T) {
+	store := newFakeStore()
+	h := newTestHandler(t, store)
+	code, resp := post(t, h, `{
+		stats { totalPosts byTopic { key count } }
+		topics { key count }
+		taxonomy { version topics { id posts subtopics { id } } }
+		posts(limit: 2) { items { id topics { topic parent score } author { name postCount } } nextCursor }
+		crawlers { source status lastCrawl }
+	}`, nil)
+	if code != 200 || len(resp.Errors) > 0 {
+		t.Fatalf("status %d, errors %+v", code, resp.Errors)
+	}
+
+	var topics []count
+	json.Unmarshal(resp.Data["topics"], &topics)
+	if len(topics) != 2 || topics[0] != (count{"ai", 2}) {
+		t.Errorf("topics = %+v", topics)
+	}
+
+	var taxonomy api.TaxonomyResponse
+	json.Unmarshal(resp.Data["taxonomy"], &taxonomy)
+	if taxonomy.Version != 1 || len(taxonomy.Topics) != 2 || len(taxonomy.Topics[0].Subtopics) != 1 {
+		t.Errorf("taxonomy = %s", resp.Data["taxonomy"])
+	}
+
+	var posts struct {
+		Items []struct {
+			ID     string
+			Topics []struct {
+				Topic  string
+				Parent *string
+				Score  float64
+			}
+			Author struct {
+				Name      string
+				PostCount int
+			}
+		}
+		NextCursor *string
+	}
+	json.Unmarshal(resp.Data["posts"], &posts)
+	if len(posts.Items) != 2 || posts.NextCursor == nil {
+		t.Fatalf("posts = %s", resp.Data["posts"])
+	}
+	if tp := posts.Items[0].Topics; len(tp) != 2 || tp[0].Parent != nil || *tp[1].Parent != "ai" || tp[1].Score != 3 {
+		t.Errorf("topics = %s", resp.Data["posts"])
+	}
+	if tp := posts.Items[1].Topics; tp == nil || len(tp) != 0 {
+		t.Errorf("topics of post without labels = %+v", tp)
+	}
+	// Alice trên devto là tác giả khác, không cộng vào
+	if a := posts.Items[0].Author; a.Name != "Alice" || a.PostCount != 2 {
+		t.Errorf("author = %+v", a)
+	}
+
+	var crawlers []struct{ Source, Status string }
+	json.Unmarshal(resp.Data["crawlers"], &crawlers)
+	if len(crawlers) != 3 || crawlers[0].Status != "ok" || crawlers[1].Status != "never" || crawlers[2].Status != "unknown" {
+		t.Errorf("crawlers = %+v", crawlers)
+	}
+}
+
+func TestNestedFieldsAreBatched(t *testing.T) {
+	store := newFakeStore()
+	h := newTestHandler(t, store)
+	code, resp := post(t, h, `{
+		trending(limit: 4) { items { post { author { name posts(limit: 2) { id } } canonicalPost { id } } } }
+	}`, nil)
+	if code != 200 || len(resp.Errors) > 0 {
+		t.Fatalf("status %d, errors %+v", code, resp.Errors)
+	}
+	// 4 posts, 3 tác giả: mỗi loại field lồng nhau chỉ một query
+	for _, name := range []string{"GetAuthorStats", "GetPostsByAuthors", "GetPostsByIDs"} {
+		if store.calls[name] != 1 {
+			t.Errorf("%s called %d times, want 1", name, store.calls[name])
+		}
+	}
+}
+
+func TestQueryLimits(t *testing.T) {
+	h := newTestHandler(t, newFakeStore())
+	tests := []struct {
+		name      string
+		query     string
+		variables map[string]interface{}
+		code      string
+	}{
+		{"too complex", `{ authors(limit: 100) { items { posts(limit: 20) { id } } } }`, nil, CodeTooComplex},
+		{"too complex via variable", `query($n: Int) { authors(limit: $n) { items { posts(limit: 20) { id } } } }`,
+			map[string]interface{}{"n": 100}, CodeTooComplex},
+		{"too deep", `{ posts { items { canonicalPost { canonicalPost { canonicalPost { canonicalPost { canonicalPost { id } } } } } } } }`, nil, CodeTooDeep},
+		{"parse error", `{ posts {`, nil, CodeParseFailed},
+		{"unknown field", `{ nope }`, nil, CodeInvalidQuery},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, resp := post(t, h, tt.query, tt.variables)
+			if code != 400 || len(resp.Errors) == 0 || resp.Errors[0].Extensions["code"] != tt.code {
+				t.Errorf("status %d, errors %+v, want code %s", code, resp.Errors, tt.code)
+			}
+		})
+	}
+}
+
+func TestInvalidArgument(t *testing.T) {
+	h := newTestHandler(t, newFakeStore())
+	code, resp := post(t, h, `{ posts(filter: {from: "yesterday"}) { items { id } } }`, nil)
+	if code != 200 || len(resp.Errors) != 1 {
+		t.Fatalf("status %d, errors %+v", code, resp.Errors)
+	}
+	ext := resp.Errors[0].Extensions
+	if ext["code"] != api.CodeInvalidParameter || ext["param"] != "from" {
+		t.Errorf("extensions = %v", ext)
+	}
+}
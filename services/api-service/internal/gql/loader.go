@@ -0,0 +1,78 @@
+// =====================================================
+// DATALOADER - Gộp các lần đọc theo khóa của một request
+// =====================================================
+// Mô tả: Field lồng nhau (post.author, author.posts, ...) gọi Load
+// cho từng phần tử và trả về thunk; executor gọi các thunk sau khi
+// đã resolve cả tầng, nên thunk đầu tiên đọc một lần mọi khóa đang
+// chờ. Kết quả được giữ trong Loader suốt request (mỗi request một
+// bộ loaders, không dùng chung giữa các request)
+// =====================================================
+
+package gql
+
+import (
+	"context"
+	"sync"
+)
+
+// Loader đọc giá trị V theo khóa K, theo lô
+type Loader[K comparable, V any] struct {
+	fetch func(ctx context.Context, keys []K) (map[K]V, error)
+
+	mu      sync.Mutex
+	pending []K
+	results map[K]loaded[V]
+}
+
+// loaded là kết quả của một khóa (found = false khi không có trong map)
+type loaded[V any] struct {
+	value V
+	found bool
+	err   error
+}
+
+// NewLoader tạo Loader; fetch nhận các khóa khác nhau, khóa thiếu trong
+// map kết quả coi như không tồn tại
+func NewLoader[K comparable, V any](fetch func(ctx context.Context, keys []K) (map[K]V, error)) *Loader[K, V] {
+	return &Loader[K, V]{fetch: fetch, results: make(map[K]loaded[V])}
+}
+
+// Load xếp key vào lô đang chờ và trả về thunk đọc kết quả
+func (l *Loader[K, V]) Load(ctx context.Context, key K) func() (V, bool, error) {
+	l.mu.Lock()
+	if _, ok := l.results[key]; !ok && !l.isPending(key) {
+		l.pending = append(l.pending, key)
+	}
+	l.mu.Unlock()
+
+	return func() (V, bool, error) {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if _, ok := l.results[key]; !ok {
+			l.dispatch(ctx)
+		}
+		r := l.results[key]
+		return r.value, r.found, r.err
+	}
+}
+
+// dispatch đọc mọi khóa đang chờ trong một lần fetch (giữ l.mu)
+func (l *Loader[K, V]) dispatch(ctx context.Context) {
+	keys := l.pending
+	l.pending = nil
+	values, err := l.fetch(ctx, keys)
+	for _, k := range keys {
+		v, ok := values[k]
+		l.results[k] = loaded[V]{value: v, found: ok, err: err}
+	}
+}
+
+// isPending kiểm tra key đã nằm trong lô chờ chưa (giữ l.mu)
+func (l *Loader[K, V]) isPending(key K) bool {
+	for _, k := range l.pending {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,766 @@
+// =====================================================
+// GRAPHQL SCHEMA - Kiểu và resolver của /api/v1/graphql
+// =====================================================
+// Mô tả: Một query lấy được mọi thứ dashboard cần (stats, topics,
+// sentiment, posts, authors, aggregates, trending, crawlers) với đúng
+// các field client chọn. Field danh sách nhận cùng arguments với query
+// params REST (filter, limit, offset, cursor) và dùng chung
+// api.ListLimits/ParseListValues nên mặc định, giới hạn và lỗi giống
+// REST. Field lồng nhau (post.author, author.posts, post.canonicalPost)
+// đọc qua dataloader của request.
+//
+// Scope: field gốc kiểm tra scope của API key giống endpoint REST
+// tương ứng (read:posts cho posts, analytics cho thống kê)
+// =====================================================
+
+package gql
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/graphql-go/graphql"
+
+	"social-insight/internal/api"
+	"social-insight/internal/auth"
+	"social-insight/internal/database"
+	"social-insight/internal/logger"
+	"social-insight/internal/models"
+	"social-insight/internal/server"
+)
+
+// Store là phần của database.DB mà resolver dùng
+// (*database.DB đã gắn context của request thỏa interface này)
+type Store interface {
+	GetPostCount() (int64, error)
+	CountPosts(f database.PostFilter, dimension string) (map[string]int64, error)
+	ListPosts(f database.PostFilter, after *database.PostKey, limit, offset int) ([]models.Post, error)
+	GetPosts(f database.PostFilter) ([]models.Post, error)
+	GetTopAuthors(f database.PostFilter, r database.AuthorRanking, limit, offset int) ([]models.AuthorStat, error)
+	GetAggregates(f database.PostFilter, interval string, limit int) ([]models.PostAggregate, error)
+	GetPostsByIDs(ids []string) (map[string]models.Post, error)
+	GetAuthorStats(keys []database.AuthorKey) (map[database.AuthorKey]models.AuthorStat, error)
+	GetPostsByAuthors(keys []database.AuthorKey, limit int) (map[database.AuthorKey][]models.Post, error)
+	GetTaxonomy() (int, []models.Topic, error)
+}
+
+// Các mã lỗi trong extensions.code
+const (
+	CodeParseFailed     = "graphql_parse_failed"
+	CodeInvalidQuery    = "graphql_validation_failed"
+	CodeTooComplex      = "query_too_complex"
+	CodeTooDeep         = "query_too_deep"
+	CodeMissingScope    = "missing_scope"
+	maxAuthorPostsLimit = 20
+)
+
+// Error là lỗi GraphQL kèm extensions (code, param)
+type Error struct {
+	Code    string
+	Message string
+	Param   string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Extensions implement gqlerrors.ExtendedError
+func (e *Error) Extensions() map[string]interface{} {
+	ext := map[string]interface{}{"code": e.Code}
+	if e.Param != "" {
+		ext["param"] = e.Param
+	}
+	return ext
+}
+
+// =====================================================
+// REQUEST STATE
+// =====================================================
+
+// authorPostsKey là khóa của loader author.posts (limit khác nhau = lô khác nhau)
+type authorPostsKey struct {
+	author database.AuthorKey
+	limit  int
+}
+
+// state là dữ liệu của một request: store gắn context và các loader
+type state struct {
+	store       Store
+	posts       *Loader[string, models.Post]
+	authors     *Loader[database.AuthorKey, models.AuthorStat]
+	authorPosts *Loader[authorPostsKey, []models.Post]
+}
+
+type stateKey struct{}
+
+// newState tạo loaders cho một request
+func newState(store Store) *state {
+	return &state{
+		store: store,
+		posts: NewLoader(func(_ context.Context, ids []string) (map[string]models.Post, error) {
+			return store.GetPostsByIDs(ids)
+		}),
+		authors: NewLoader(func(_ context.Context, keys []database.AuthorKey) (map[database.AuthorKey]models.AuthorStat, error) {
+			return store.GetAuthorStats(keys)
+		}),
+		authorPosts: NewLoader(func(_ context.Context, keys []authorPostsKey) (map[authorPostsKey][]models.Post, error) {
+			// Mỗi limit khác nhau một query (thường chỉ có một)
+			byLimit := make(map[int][]database.AuthorKey)
+			for _, k := range keys {
+				byLimit[k.limit] = append(byLimit[k.limit], k.author)
+			}
+			result := make(map[authorPostsKey][]models.Post, len(keys))
+			for limit, authors := range byLimit {
+				posts, err := store.GetPostsByAuthors(authors, limit)
+				if err != nil {
+					return nil, err
+				}
+				for _, a := range authors {
+					result[authorPostsKey{a, limit}] = posts[a]
+				}
+			}
+			return result, nil
+		}),
+	}
+}
+
+// withState gắn state vào context của request
+func withState(ctx context.Context, s *state) context.Context {
+	return context.WithValue(ctx, stateKey{}, s)
+}
+
+// stateOf lấy state của request
+func stateOf(ctx context.Context) *state {
+	return ctx.Value(stateKey{}).(*state)
+}
+
+// =====================================================
+// HELPERS
+// =====================================================
+
+// requireScope chặn field khi API key không có scope
+// (không có key = auth tắt, route đã qua Authenticator.Authenticate)
+func requireScope(scope string, resolve graphql.FieldResolveFn) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		if key := auth.FromContext(p.Context); key != nil && !key.HasScope(scope) {
+			return nil, &Error{Code: CodeMissingScope, Message: "api key lacks scope " + scope}
+		}
+		return resolve(p)
+	}
+}
+
+// internalError log lỗi và trả lỗi chung (không lộ lỗi DB cho client)
+func internalError(ctx context.Context, err error) error {
+	slog.ErrorContext(ctx, "graphql resolver failed", logger.Err(err))
+	return &Error{Code: server.CodeInternal, Message: "internal server error"}
+}
+
+// listQuery đọc filter/limit/offset/cursor của field theo limits
+func listQuery(p graphql.ResolveParams, limits api.ListLimits) (api.ListQuery, error) {
+	values := url.Values{}
+	for _, name := range []string{"limit", "offset"} {
+		if n, ok := p.Args[name].(int); ok {
+			values.Set(name, strconv.Itoa(n))
+		}
+	}
+	if c, ok := p.Args["cursor"].(string); ok && c != "" {
+		values.Set("cursor", c)
+	}
+	if f, ok := p.Args["filter"].(map[string]interface{}); ok {
+		for _, name := range []string{"from", "to", "topic", "platform"} {
+			if v, ok := f[name].(string); ok && v != "" {
+				values.Set(name, v)
+			}
+		}
+	}
+	q, err := api.ParseListValues(values, limits, time.Now())
+	var pe *api.ParamError
+	if errors.As(err, &pe) {
+		return q, &Error{Code: api.CodeInvalidParameter, Message: pe.Error(), Param: pe.Param}
+	}
+	return q, err
+}
+
+// page là kết quả của field phân trang
+func page(items interface{}, next string) map[string]interface{} {
+	result := map[string]interface{}{"items": items, "nextCursor": nil}
+	if next != "" {
+		result["nextCursor"] = next
+	}
+	return result
+}
+
+// count là một cặp (nhãn, số bài)
+type count struct {
+	Key   string `json:"key"`
+	Count int64  `json:"count"`
+}
+
+// sortedCounts đổi map thành danh sách, nhiều bài trước
+func sortedCounts(m map[string]int64) []count {
+	counts := make([]count, 0, len(m))
+	for k, n := range m {
+		counts = append(counts, count{k, n})
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Count != counts[j].Count {
+			return counts[i].Count > counts[j].Count
+		}
+		return counts[i].Key < counts[j].Key
+	})
+	return counts
+}
+
+// crawler là trạng thái crawl của một nguồn
+type crawler struct {
+	Source    string
+	Status    string // ok | never | unknown
+	LastCrawl *time.Time
+}
+
+// =====================================================
+// SCHEMA
+// =====================================================
+
+// resolver chứa dependencies ngoài DB (Redis qua các hàm của Config)
+type resolver struct {
+	cfg Config
+}
+
+// newSchema dựng schema GraphQL
+func newSchema(r *resolver) (graphql.Schema, error) {
+	filterInput := graphql.NewInputObject(graphql.InputObjectConfig{
+		Name:        "PostFilter",
+		Description: "Same values as the REST query params: from/to are RFC3339 or YYYY-MM-DD, [from, to)",
+		Fields: graphql.InputObjectConfigFieldMap{
+			"from":     &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"to":       &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"topic":    &graphql.InputObjectFieldConfig{Type: graphql.String},
+			"platform": &graphql.InputObjectFieldConfig{Type: graphql.String},
+		},
+	})
+	listArgs := func(limits api.ListLimits, cursor bool) graphql.FieldConfigArgument {
+		args := graphql.FieldConfigArgument{
+			"filter": &graphql.ArgumentConfig{Type: filterInput},
+			"limit": &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: limits.DefaultLimit,
+				Description: "1.." + strconv.Itoa(limits.MaxLimit)},
+		}
+		if cursor {
+			args["offset"] = &graphql.ArgumentConfig{Type: graphql.Int}
+			args["cursor"] = &graphql.ArgumentConfig{Type: graphql.String,
+				Description: "nextCursor of the previous page, with the same filter"}
+		}
+		return args
+	}
+
+	countType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Count",
+		Fields: graphql.Fields{
+			"key":   &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"count": &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+		},
+	})
+	// Field gốc nullable: lỗi một field chỉ làm field đó null, không mất cả data
+	countList := graphql.NewList(graphql.NewNonNull(countType))
+
+	topicScoreType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "TopicScore",
+		Fields: graphql.Fields{
+			"topic": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+			"parent": &graphql.Field{Type: graphql.String, Description: "Top-level topic of a subtopic, null for top-level topics",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					if parent := p.Source.(models.TopicScore).Parent; parent != "" {
+						return parent, nil
+					}
+					return nil, nil
+				}},
+			"score": &graphql.Field{Type: graphql.NewNonNull(graphql.Float)},
+		},
+	})
+
+	var postType, authorType *graphql.Object
+	postField := func(t graphql.Output, get func(models.Post) interface{}) *graphql.Field {
+		return &graphql.Field{Type: t, Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return get(p.Source.(models.Post)), nil
+		}}
+	}
+	authorField := func(t graphql.Output, get func(models.AuthorStat) interface{}) *graphql.Field {
+		return &graphql.Field{Type: t, Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return get(p.Source.(models.AuthorStat)), nil
+		}}
+	}
+	nonNullString := graphql.NewNonNull(graphql.String)
+	nonNullInt := graphql.NewNonNull(graphql.Int)
+
+	postType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Post",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"id":       postField(graphql.NewNonNull(graphql.ID), func(p models.Post) interface{} { return p.ID }),
+				"platform": postField(nonNullString, func(p models.Post) interface{} { return p.Platform }),
+				"topic":    postField(nonNullString, func(p models.Post) interface{} { return p.Topic }),
+				"topics": postField(graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(topicScoreType))), func(p models.Post) interface{} {
+					if p.Topics == nil {
+						return []models.TopicScore{}
+					}
+					return p.Topics
+				}),
+				"sentiment":  postField(nonNullString, func(p models.Post) interface{} { return p.Sentiment }),
+				"authorName": postField(nonNullString, func(p models.Post) interface{} { return p.Author }),
+				"authorHandle": postField(nonNullString, func(p models.Post) interface{} {
+					return p.AuthorHandle
+				}),
+				"title":   postField(nonNullString, func(p models.Post) interface{} { return p.Title }),
+				"content": postField(nonNullString, func(p models.Post) interface{} { return p.Content }),
+				"url":     postField(nonNullString, func(p models.Post) interface{} { return p.URL }),
+				"canonicalUrl": postField(nonNullString, func(p models.Post) interface{} {
+					return p.CanonicalURL
+				}),
+				"canonicalPostId": postField(graphql.ID, func(p models.Post) interface{} {
+					if p.CanonicalPostID == "" {
+						return nil
+					}
+					return p.CanonicalPostID
+				}),
+				"likes":    postField(nonNullInt, func(p models.Post) interface{} { return p.Likes }),
+				"comments": postField(nonNullInt, func(p models.Post) interface{} { return p.Comments }),
+				"shares":   postField(nonNullInt, func(p models.Post) interface{} { return p.Shares }),
+				"engagement": postField(nonNullInt, func(p models.Post) interface{} {
+					return p.Likes + p.Comments + p.Shares
+				}),
+				"createdAt": postField(graphql.NewNonNull(graphql.DateTime), func(p models.Post) interface{} {
+					return p.CreatedAt
+				}),
+				"author": {
+					Type:        authorType,
+					Description: "Requires read:analytics",
+					Resolve: requireScope(auth.ScopeReadAnalytics, func(p graphql.ResolveParams) (interface{}, error) {
+						post := p.Source.(models.Post)
+						return r.loadAuthor(p, database.AuthorKey{Platform: post.Platform, Handle: post.AuthorHandle}), nil
+					}),
+				},
+				"canonicalPost": {
+					Type:        postType,
+					Description: "Original post of a cross-post cluster, null for originals",
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						id := p.Source.(models.Post).CanonicalPostID
+						if id == "" {
+							return nil, nil
+						}
+						return r.loadPost(p, id), nil
+					},
+				},
+			}
+		}),
+	})
+
+	authorType = graphql.NewObject(graphql.ObjectConfig{
+		Name: "Author",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"platform":        authorField(nonNullString, func(a models.AuthorStat) interface{} { return a.Platform }),
+				"handle":          authorField(nonNullString, func(a models.AuthorStat) interface{} { return a.Handle }),
+				"name":            authorField(nonNullString, func(a models.AuthorStat) interface{} { return a.Author }),
+				"postCount":       authorField(nonNullInt, func(a models.AuthorStat) interface{} { return a.PostCount }),
+				"totalLikes":      authorField(nonNullInt, func(a models.AuthorStat) interface{} { return a.TotalLikes }),
+				"totalEngagement": authorField(nonNullInt, func(a models.AuthorStat) interface{} { return a.TotalEngagement }),
+				"engagementRate": authorField(graphql.NewNonNull(graphql.Float), func(a models.AuthorStat) interface{} {
+					return a.EngagementRate
+				}),
+				"posts": &graphql.Field{
+					Type:        graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(postType))),
+					Description: "Latest posts of the author. Requires read:posts",
+					Args: graphql.FieldConfigArgument{
+						"limit": &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 5,
+							Description: "1.." + strconv.Itoa(maxAuthorPostsLimit)},
+					},
+					Resolve: requireScope(auth.ScopeReadPosts, func(p graphql.ResolveParams) (interface{}, error) {
+						limit, _ := p.Args["limit"].(int)
+						if limit < 1 || limit > maxAuthorPostsLimit {
+							return nil, &Error{Code: api.CodeInvalidParameter, Param: "limit",
+								Message: "invalid limit: must be an integer between 1 and " + strconv.Itoa(maxAuthorPostsLimit)}
+						}
+						a := p.Source.(models.AuthorStat)
+						key := authorPostsKey{database.AuthorKey{Platform: a.Platform, Handle: a.Handle}, limit}
+						thunk := stateOf(p.Context).authorPosts.Load(p.Context, key)
+						return func() (interface{}, error) {
+							posts, _, err := thunk()
+							if err != nil {
+								return nil, internalError(p.Context, err)
+							}
+							if posts == nil {
+								posts = []models.Post{}
+							}
+							return posts, nil
+						}, nil
+					}),
+				},
+			}
+		}),
+	})
+
+	pageType := func(name string, item graphql.Type) *graphql.Object {
+		return graphql.NewObject(graphql.ObjectConfig{
+			Name: name,
+			Fields: graphql.Fields{
+				"items":      &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(item)))},
+				"nextCursor": &graphql.Field{Type: graphql.String, Description: "null on the last page"},
+			},
+		})
+	}
+
+	trendingItemType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "TrendingItem",
+		Fields: graphql.Fields{
+			"post": &graphql.Field{Type: graphql.NewNonNull(postType), Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(api.TrendingItem).Post, nil
+			}},
+			"score": &graphql.Field{Type: graphql.NewNonNull(graphql.Float), Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(api.TrendingItem).Score, nil
+			}},
+			"hotness": &graphql.Field{Type: nonNullString, Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(api.TrendingItem).Hotness, nil
+			}},
+		},
+	})
+
+	aggregateField := func(t graphql.Output, get func(models.PostAggregate) interface{}) *graphql.Field {
+		return &graphql.Field{Type: t, Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+			return get(p.Source.(models.PostAggregate)), nil
+		}}
+	}
+	aggregateType := graphql.NewObject(graphql.ObjectConfig{
+		Name:        "Aggregate",
+		Description: "Posts and engagement of one (bucket, topic, platform, sentiment)",
+		Fields: graphql.Fields{
+			"bucket": aggregateField(graphql.NewNonNull(graphql.DateTime), func(a models.PostAggregate) interface{} {
+				return a.Bucket
+			}),
+			"topic":     aggregateField(nonNullString, func(a models.PostAggregate) interface{} { return a.Topic }),
+			"platform":  aggregateField(nonNullString, func(a models.PostAggregate) interface{} { return a.Platform }),
+			"sentiment": aggregateField(nonNullString, func(a models.PostAggregate) interface{} { return a.Sentiment }),
+			"posts":     aggregateField(nonNullInt, func(a models.PostAggregate) interface{} { return a.Posts }),
+			"likes":     aggregateField(nonNullInt, func(a models.PostAggregate) interface{} { return a.Likes }),
+			"comments":  aggregateField(nonNullInt, func(a models.PostAggregate) interface{} { return a.Comments }),
+			"shares":    aggregateField(nonNullInt, func(a models.PostAggregate) interface{} { return a.Shares }),
+		},
+	})
+
+	statsType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Stats",
+		Fields: graphql.Fields{
+			"totalPosts":  &graphql.Field{Type: nonNullInt},
+			"byTopic":     &graphql.Field{Type: graphql.NewNonNull(countList)},
+			"bySentiment": &graphql.Field{Type: graphql.NewNonNull(countList)},
+		},
+	})
+
+	subtopicType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "TaxonomySubtopic",
+		Fields: graphql.Fields{
+			"id":    &graphql.Field{Type: nonNullString},
+			"name":  &graphql.Field{Type: nonNullString},
+			"posts": &graphql.Field{Type: nonNullInt},
+		},
+	})
+	taxonomyTopicType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "TaxonomyTopic",
+		Fields: graphql.Fields{
+			"id":        &graphql.Field{Type: nonNullString},
+			"name":      &graphql.Field{Type: nonNullString},
+			"posts":     &graphql.Field{Type: nonNullInt, Description: "Posts labelled with the topic (primary or not)"},
+			"subtopics": &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(subtopicType)))},
+		},
+	})
+	taxonomyType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Taxonomy",
+		Fields: graphql.Fields{
+			"version": &graphql.Field{Type: nonNullInt, Description: "0 until the consumer has synced a taxonomy file"},
+			"topics":  &graphql.Field{Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(taxonomyTopicType)))},
+		},
+	})
+
+	crawlerType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Crawler",
+		Fields: graphql.Fields{
+			"source": &graphql.Field{Type: nonNullString, Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(crawler).Source, nil
+			}},
+			"status": &graphql.Field{Type: nonNullString, Description: "ok, never (not crawled yet) or unknown (Redis down)",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return p.Source.(crawler).Status, nil
+				}},
+			"lastCrawl": &graphql.Field{Type: graphql.DateTime, Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				if t := p.Source.(crawler).LastCrawl; t != nil {
+					return *t, nil
+				}
+				return nil, nil
+			}},
+		},
+	})
+
+	intervals := graphql.EnumValueConfigMap{}
+	for _, i := range database.AggregateIntervals {
+		intervals[i] = &graphql.EnumValueConfig{Value: i}
+	}
+	intervalEnum := graphql.NewEnum(graphql.EnumConfig{Name: "Interval", Values: intervals})
+
+	authorSorts := graphql.EnumValueConfigMap{}
+	for _, s := range database.AuthorSorts {
+		authorSorts[s] = &graphql.EnumValueConfig{Value: s}
+	}
+	authorSortEnum := graphql.NewEnum(graphql.EnumConfig{Name: "AuthorSort", Values: authorSorts})
+
+	countField := func(dimension, description string) *graphql.Field {
+		return &graphql.Field{
+			Type:        countList,
+			Description: description + ". Requires read:analytics",
+			Args:        graphql.FieldConfigArgument{"filter": &graphql.ArgumentConfig{Type: filterInput}},
+			Resolve: requireScope(auth.ScopeReadAnalytics, func(p graphql.ResolveParams) (interface{}, error) {
+				q, err := listQuery(p, api.ListLimits{})
+				if err != nil {
+					return nil, err
+				}
+				counts, err := stateOf(p.Context).store.CountPosts(q.PostFilter(), dimension)
+				if err != nil {
+					return nil, internalError(p.Context, err)
+				}
+				return sortedCounts(counts), nil
+			}),
+		}
+	}
+
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"stats": &graphql.Field{
+				Type:        statsType,
+				Description: "Overall counters, same as GET /api/v1/stats. Requires read:analytics",
+				Resolve:     requireScope(auth.ScopeReadAnalytics, r.stats),
+			},
+			"topics":    countField("topic", "Posts per top-level topic (a post can count for several)"),
+			"sentiment": countField("sentiment", "Posts per sentiment"),
+			"platforms": countField("platform", "Posts per platform"),
+			"taxonomy": &graphql.Field{
+				Type:        taxonomyType,
+				Description: "Topic tree in use, same as GET /api/v1/taxonomy. Requires read:analytics",
+				Resolve:     requireScope(auth.ScopeReadAnalytics, r.taxonomy),
+			},
+			"posts": &graphql.Field{
+				Type:        pageType("PostPage", postType),
+				Description: "Posts, newest first (keyset cursor). Requires read:posts",
+				Args:        listArgs(api.RecentPostsLimits, true),
+				Resolve:     requireScope(auth.ScopeReadPosts, r.posts),
+			},
+			"post": &graphql.Field{
+				Type:        postType,
+				Description: "One post by id. Requires read:posts",
+				Args:        graphql.FieldConfigArgument{"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)}},
+				Resolve: requireScope(auth.ScopeReadPosts, func(p graphql.ResolveParams) (interface{}, error) {
+					return r.loadPost(p, p.Args["id"].(string)), nil
+				}),
+			},
+			"authors": &graphql.Field{
+				Type:        pageType("AuthorPage", authorType),
+				Description: "Authors ranked like GET /api/v1/authors. Requires read:analytics",
+				Args: func() graphql.FieldConfigArgument {
+					args := listArgs(api.TopAuthorsLimits, true)
+					args["sort"] = &graphql.ArgumentConfig{Type: authorSortEnum, DefaultValue: "posts"}
+					args["minPosts"] = &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 1}
+					return args
+				}(),
+				Resolve: requireScope(auth.ScopeReadAnalytics, r.authors),
+			},
+			"author": &graphql.Field{
+				Type:        authorType,
+				Description: "One author by platform and handle (case-insensitive). Requires read:analytics",
+				Args: graphql.FieldConfigArgument{
+					"platform": &graphql.ArgumentConfig{Type: nonNullString},
+					"handle":   &graphql.ArgumentConfig{Type: nonNullString},
+				},
+				Resolve: requireScope(auth.ScopeReadAnalytics, func(p graphql.ResolveParams) (interface{}, error) {
+					key := database.AuthorKey{
+						Platform: p.Args["platform"].(string),
+						Handle:   strings.ToLower(p.Args["handle"].(string)),
+					}
+					return r.loadAuthor(p, key), nil
+				}),
+			},
+			"aggregates": &graphql.Field{
+				Type:        graphql.NewList(graphql.NewNonNull(aggregateType)),
+				Description: "Posts and engagement per time bucket (UTC), topic, platform and sentiment. Requires read:analytics",
+				Args: func() graphql.FieldConfigArgument {
+					args := listArgs(api.AggregatesLimits, false)
+					args["interval"] = &graphql.ArgumentConfig{Type: intervalEnum, DefaultValue: "day"}
+					return args
+				}(),
+				Resolve: requireScope(auth.ScopeReadAnalytics, r.aggregates),
+			},
+			"trending": &graphql.Field{
+				Type:        pageType("TrendingPage", trendingItemType),
+				Description: "Trending posts, same ranking as GET /api/v1/trending. Requires read:posts",
+				Args:        listArgs(api.TrendingLimits, true),
+				Resolve:     requireScope(auth.ScopeReadPosts, r.trending),
+			},
+			"crawlers": &graphql.Field{
+				Type:        graphql.NewList(graphql.NewNonNull(crawlerType)),
+				Description: "Last crawl per source. Requires read:analytics",
+				Resolve:     requireScope(auth.ScopeReadAnalytics, r.crawlers),
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{Query: query})
+}
+
+// =====================================================
+// RESOLVERS
+// =====================================================
+
+// loadAuthor trả về thunk đọc tác giả qua loader (null khi không tồn tại)
+func (r *resolver) loadAuthor(p graphql.ResolveParams, key database.AuthorKey) func() (interface{}, error) {
+	thunk := stateOf(p.Context).authors.Load(p.Context, key)
+	return func() (interface{}, error) {
+		a, ok, err := thunk()
+		if err != nil {
+			return nil, internalError(p.Context, err)
+		}
+		if !ok {
+			return nil, nil
+		}
+		return a, nil
+	}
+}
+
+// loadPost trả về thunk đọc post qua loader (null khi không tồn tại)
+func (r *resolver) loadPost(p graphql.ResolveParams, id string) func() (interface{}, error) {
+	thunk := stateOf(p.Context).posts.Load(p.Context, id)
+	return func() (interface{}, error) {
+		post, ok, err := thunk()
+		if err != nil {
+			return nil, internalError(p.Context, err)
+		}
+		if !ok {
+			return nil, nil
+		}
+		return post, nil
+	}
+}
+
+func (r *resolver) stats(p graphql.ResolveParams) (interface{}, error) {
+	stats, err := r.cfg.Stats(p.Context)
+	if err != nil {
+		return nil, internalError(p.Context, err)
+	}
+	return map[string]interface{}{
+		"totalPosts":  stats.TotalPosts,
+		"byTopic":     sortedCounts(stats.ByTopic),
+		"bySentiment": sortedCounts(stats.BySentiment),
+	}, nil
+}
+
+func (r *resolver) taxonomy(p graphql.ResolveParams) (interface{}, error) {
+	version, topics, err := stateOf(p.Context).store.GetTaxonomy()
+	if err != nil {
+		return nil, internalError(p.Context, err)
+	}
+	return api.BuildTaxonomy(version, topics), nil
+}
+
+func (r *resolver) posts(p graphql.ResolveParams) (interface{}, error) {
+	q, err := listQuery(p, api.RecentPostsLimits)
+	if err != nil {
+		return nil, err
+	}
+	// Lấy thêm 1 post để biết còn trang sau
+	posts, err := stateOf(p.Context).store.ListPosts(q.PostFilter(), q.After, q.Limit+1, q.Offset)
+	if err != nil {
+		return nil, internalError(p.Context, err)
+	}
+	next := ""
+	if len(posts) > q.Limit {
+		posts = posts[:q.Limit]
+		last := posts[len(posts)-1]
+		next = api.KeysetCursor(last.CreatedAt, last.ID)
+	}
+	return page(posts, next), nil
+}
+
+func (r *resolver) authors(p graphql.ResolveParams) (interface{}, error) {
+	q, err := listQuery(p, api.TopAuthorsLimits)
+	if err != nil {
+		return nil, err
+	}
+	values := url.Values{}
+	if s, ok := p.Args["sort"].(string); ok {
+		values.Set("sort", s)
+	}
+	if n, ok := p.Args["minPosts"].(int); ok {
+		values.Set("min_posts", strconv.Itoa(n))
+	}
+	// sort đã được enum kiểm tra, lỗi chỉ có thể là min_posts
+	ranking, err := api.ParseAuthorRanking(values)
+	var pe *api.ParamError
+	if errors.As(err, &pe) {
+		return nil, &Error{Code: api.CodeInvalidParameter, Message: pe.Error(), Param: "minPosts"}
+	}
+	authors, err := stateOf(p.Context).store.GetTopAuthors(q.PostFilter(), ranking, q.Limit+1, q.Offset)
+	if err != nil {
+		return nil, internalError(p.Context, err)
+	}
+	next := ""
+	if len(authors) > q.Limit {
+		authors = authors[:q.Limit]
+		next = api.OffsetCursor(q.Offset + q.Limit)
+	}
+	return page(authors, next), nil
+}
+
+func (r *resolver) aggregates(p graphql.ResolveParams) (interface{}, error) {
+	q, err := listQuery(p, api.AggregatesLimits)
+	if err != nil {
+		return nil, err
+	}
+	interval, _ := p.Args["interval"].(string)
+	aggregates, err := stateOf(p.Context).store.GetAggregates(q.PostFilter(), interval, q.Limit)
+	if err != nil {
+		return nil, internalError(p.Context, err)
+	}
+	return aggregates, nil
+}
+
+func (r *resolver) trending(p graphql.ResolveParams) (interface{}, error) {
+	q, err := listQuery(p, api.TrendingLimits)
+	if err != nil {
+		return nil, err
+	}
+	posts, err := stateOf(p.Context).store.GetPosts(q.PostFilter())
+	if err != nil {
+		return nil, internalError(p.Context, err)
+	}
+	items, next := api.Page(api.RankTrending(posts, time.Now()), q)
+	return page(items, next), nil
+}
+
+func (r *resolver) crawlers(p graphql.ResolveParams) (interface{}, error) {
+	status := r.cfg.Crawlers(p.Context)
+	result := make([]crawler, 0, len(api.CrawlerSources))
+	for _, src := range api.CrawlerSources {
+		c := crawler{Source: src, Status: status[src]}
+		if t, err := time.Parse(time.RFC3339, c.Status); err == nil {
+			c.Status = "ok"
+			c.LastCrawl = &t
+		} else if c.Status == "" {
+			c.Status = "unknown"
+		}
+		result = append(result, c)
+	}
+	return result, nil
+}
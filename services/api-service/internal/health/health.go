@@ -0,0 +1,192 @@
+// =====================================================
+// HEALTH - Kiểm tra dependencies (PostgreSQL, Redis, Kafka)
+// =====================================================
+// Mô tả: Chạy song song các check có timeout, trả về trạng thái
+// và latency của từng dependency
+//   - /healthz: process còn sống (luôn 200), kèm trạng thái dependencies
+//   - /readyz:  503 khi một dependency bắt buộc bị down
+// Dependency không bắt buộc bị down → status "degraded" nhưng vẫn ready
+//
+// Dùng:
+//   checker := health.NewChecker(2 * time.Second)
+//   checker.Add("postgres", true, db.Ping)
+//   report := checker.Run(ctx)
+// =====================================================
+
+package health
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"social-insight/internal/logger"
+)
+
+// Trạng thái của một dependency hoặc của cả service
+const (
+	StatusOK       = "ok"
+	StatusDegraded = "degraded"
+	StatusDown     = "down"
+)
+
+// CheckFunc ping một dependency, trả về lỗi nếu không dùng được
+type CheckFunc func(ctx context.Context) error
+
+// check là một dependency đã đăng ký
+type check struct {
+	name     string
+	required bool
+	fn       CheckFunc
+}
+
+// DependencyStatus là kết quả check của một dependency
+type DependencyStatus struct {
+	Status    string  `json:"status"`
+	Required  bool    `json:"required"`
+	LatencyMs float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// Report là body của /healthz và /readyz
+type Report struct {
+	Status       string                      `json:"status"`
+	Dependencies map[string]DependencyStatus `json:"dependencies"`
+	Time         time.Time                   `json:"time"`
+}
+
+// Ready là false khi có dependency bắt buộc bị down
+func (r Report) Ready() bool {
+	return r.Status != StatusDown
+}
+
+// HTTPStatus là status code của /readyz
+func (r Report) HTTPStatus() int {
+	if r.Ready() {
+		return http.StatusOK
+	}
+	return http.StatusServiceUnavailable
+}
+
+// Checker chạy các check và nhớ kết quả lần gần nhất
+type Checker struct {
+	timeout time.Duration
+	checks  []check
+
+	mu sync.RWMutex
+	up map[string]bool
+}
+
+// NewChecker tạo Checker, mỗi check bị hủy sau timeout
+func NewChecker(timeout time.Duration) *Checker {
+	return &Checker{timeout: timeout, up: make(map[string]bool)}
+}
+
+// Add đăng ký dependency; required = true thì down làm service not ready
+// Gọi trước Run/Watch
+func (c *Checker) Add(name string, required bool, fn CheckFunc) {
+	c.checks = append(c.checks, check{name: name, required: required, fn: fn})
+}
+
+// Run chạy mọi check song song và cập nhật trạng thái đã nhớ
+func (c *Checker) Run(ctx context.Context) Report {
+	report := Report{
+		Status:       StatusOK,
+		Dependencies: make(map[string]DependencyStatus, len(c.checks)),
+		Time:         time.Now(),
+	}
+
+	results := make([]DependencyStatus, len(c.checks))
+	var wg sync.WaitGroup
+	for i, chk := range c.checks {
+		wg.Add(1)
+		go func(i int, chk check) {
+			defer wg.Done()
+			results[i] = c.runOne(ctx, chk)
+		}(i, chk)
+	}
+	wg.Wait()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, chk := range c.checks {
+		res := results[i]
+		report.Dependencies[chk.name] = res
+
+		ok := res.Status == StatusOK
+		if prev, seen := c.up[chk.name]; seen && prev != ok {
+			if ok {
+				slog.Info("dependency recovered", "dependency", chk.name)
+			} else {
+				slog.Warn("dependency down", "dependency", chk.name, logger.KeyError, res.Error)
+			}
+		}
+		c.up[chk.name] = ok
+
+		if ok {
+			continue
+		}
+		if chk.required {
+			report.Status = StatusDown
+		} else if report.Status == StatusOK {
+			report.Status = StatusDegraded
+		}
+	}
+	return report
+}
+
+// runOne chạy một check với timeout, đo latency
+func (c *Checker) runOne(ctx context.Context, chk check) DependencyStatus {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	start := time.Now()
+	err := chk.fn(ctx)
+	res := DependencyStatus{
+		Status:    StatusOK,
+		Required:  chk.required,
+		LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		res.Status = StatusDown
+		res.Error = err.Error()
+	}
+	return res
+}
+
+// Up trả về kết quả lần check gần nhất của dependency
+// (true nếu chưa check lần nào)
+func (c *Checker) Up(name string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	ok, seen := c.up[name]
+	return !seen || ok
+}
+
+// Watch chạy Run mỗi interval cho tới khi ctx bị hủy,
+// để Up phản ánh trạng thái hiện tại mà request không phải chờ ping
+func (c *Checker) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.Run(ctx)
+		}
+	}
+}
+
+// LogReport ghi trạng thái từng dependency (dùng lúc khởi động)
+func LogReport(report Report) {
+	for name, dep := range report.Dependencies {
+		if dep.Status == StatusOK {
+			slog.Info("dependency ok", "dependency", name, "latency_ms", dep.LatencyMs)
+			continue
+		}
+		slog.Warn("dependency down", "dependency", name, "required", dep.Required, logger.KeyError, dep.Error)
+	}
+}
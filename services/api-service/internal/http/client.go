@@ -10,7 +10,9 @@ package http
 import (
 	"fmt"
 	"io"
+	"log/slog"
 	nethttp "net/http"
+	"social-insight/internal/logger"
 	"time"
 )
 
@@ -44,7 +46,7 @@ func (c *Client) Get(url string) ([]byte, error) {
 		resp, err := c.doRequest("GET", url, nil)
 		if err != nil {
 			lastErr = err
-			fmt.Printf("⚠️  Attempt %d failed: %v, retrying...\n", attempt+1, err)
+			slog.Warn("http request failed, retrying", "url", url, "attempt", attempt+1, logger.Err(err))
 			time.Sleep(c.retryDelay)
 			continue
 		}
@@ -53,7 +55,7 @@ func (c *Client) Get(url string) ([]byte, error) {
 		// Check status code
 		if resp.StatusCode != nethttp.StatusOK {
 			lastErr = fmt.Errorf("HTTP %d: %s", resp.StatusCode, resp.Status)
-			fmt.Printf("⚠️  HTTP %d, retrying...\n", resp.StatusCode)
+			slog.Warn("http error status, retrying", "url", url, "status", resp.StatusCode, "attempt", attempt+1)
 			time.Sleep(c.retryDelay)
 			continue
 		}
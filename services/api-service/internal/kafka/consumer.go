@@ -11,6 +11,8 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"log/slog"
+	"social-insight/internal/logger"
 	"social-insight/internal/models"
 
 	"github.com/IBM/sarama"
@@ -82,7 +84,7 @@ func (c *Consumer) Start(ctx context.Context) error {
 		// Consume messages
 		err := c.consumerGroup.Consume(ctx, []string{c.topic}, handler)
 		if err != nil {
-			fmt.Printf("❌ Consumer error: %v\n", err)
+			slog.ErrorContext(ctx, "consumer error", "topic", c.topic, logger.Err(err))
 		}
 	}
 }
@@ -103,13 +105,13 @@ func (c *Consumer) Close() error {
 
 // Setup được gọi khi consumer group session bắt đầu
 func (h *consumerGroupHandler) Setup(sarama.ConsumerGroupSession) error {
-	fmt.Println("✅ Consumer group session started")
+	slog.Info("consumer group session started")
 	return nil
 }
 
 // Cleanup được gọi khi consumer group session kết thúc
 func (h *consumerGroupHandler) Cleanup(sarama.ConsumerGroupSession) error {
-	fmt.Println("🔄 Consumer group session ended")
+	slog.Info("consumer group session ended")
 	return nil
 }
 
@@ -119,13 +121,13 @@ func (h *consumerGroupHandler) ConsumeClaim(session sarama.ConsumerGroupSession,
 		// Parse JSON thành Post
 		var post models.Post
 		if err := json.Unmarshal(message.Value, &post); err != nil {
-			fmt.Printf("❌ Cannot unmarshal message: %v\n", err)
+			slog.Error("cannot unmarshal message", "partition", message.Partition, "offset", message.Offset, logger.Err(err))
 			continue
 		}
 
 		// Xử lý post (lưu vào DB, cache, etc.)
 		if err := h.consumer.handler.HandlePost(post); err != nil {
-			fmt.Printf("❌ Cannot handle post %s: %v\n", post.ID, err)
+			slog.Error("cannot handle post", "post_id", post.ID, logger.Err(err))
 			continue
 		}
 
@@ -135,7 +137,7 @@ func (h *consumerGroupHandler) ConsumeClaim(session sarama.ConsumerGroupSession,
 
 		// Log progress mỗi 10000 messages
 		if h.consumer.messageCount%10000 == 0 {
-			fmt.Printf("📊 Processed %d messages\n", h.consumer.messageCount)
+			slog.Info("consumer progress", "processed", h.consumer.messageCount)
 		}
 	}
 
@@ -0,0 +1,82 @@
+// =====================================================
+// KAFKA PINGER - Kiểm tra kết nối Kafka cho health check
+// =====================================================
+// Mô tả: Gửi metadata request cho topic qua một client riêng
+// (không dùng client của consumer group để không ảnh hưởng rebalance)
+// Client được tạo lần đầu Ping nên Kafka down lúc khởi động không lỗi
+// =====================================================
+
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+// Pinger kiểm tra broker có trả lời metadata của topic không
+type Pinger struct {
+	brokers []string
+	topic   string
+
+	mu     sync.Mutex
+	client sarama.Client
+}
+
+// NewPinger tạo Pinger cho topic
+func NewPinger(brokers []string, topic string) *Pinger {
+	return &Pinger{brokers: brokers, topic: topic}
+}
+
+// Ping implement health.CheckFunc
+func (p *Pinger) Ping(ctx context.Context) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- p.refresh()
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// refresh tạo client nếu chưa có rồi lấy metadata của topic
+func (p *Pinger) refresh() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.client == nil {
+		config := sarama.NewConfig()
+		config.Net.DialTimeout = 2 * time.Second
+		config.Net.ReadTimeout = 2 * time.Second
+		config.Metadata.Retry.Max = 0
+		config.Metadata.Full = false
+
+		client, err := sarama.NewClient(p.brokers, config)
+		if err != nil {
+			return fmt.Errorf("kafka connect error: %w", err)
+		}
+		p.client = client
+	}
+
+	if err := p.client.RefreshMetadata(p.topic); err != nil {
+		return fmt.Errorf("kafka metadata error: %w", err)
+	}
+	return nil
+}
+
+// Close đóng client (nếu đã tạo)
+func (p *Pinger) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.client == nil {
+		return nil
+	}
+	return p.client.Close()
+}
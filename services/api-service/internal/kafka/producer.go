@@ -10,6 +10,8 @@ package kafka
 import (
 	"encoding/json"
 	"fmt"
+	"log/slog"
+	"social-insight/internal/logger"
 	"social-insight/internal/models"
 	"time"
 
@@ -72,7 +74,7 @@ func NewProducer(brokers []string, topic string) (*Producer, error) {
 	go func() {
 		for err := range producer.Errors() {
 			p.errorCount++
-			fmt.Printf("❌ Kafka error: %v\n", err)
+			slog.Error("kafka produce error", "topic", err.Msg.Topic, logger.Err(err.Err))
 		}
 	}()
 
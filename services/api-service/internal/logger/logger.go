@@ -0,0 +1,214 @@
+// =====================================================
+// LOGGER - Structured logging trên log/slog
+// =====================================================
+// Mô tả: Cấu hình slog mặc định cho API server, cùng format
+// với processing-service để gom log chung một chỗ:
+//   - LOG_FORMAT: text (dễ đọc khi dev) | json (máy parse được)
+//   - LOG_LEVEL: debug | info | warn | error
+//   - Tự gắn service, request_id, trace_id từ context vào mỗi dòng log
+//     (trace_id lấy từ span OpenTelemetry nếu context không gắn sẵn)
+//
+// Dùng:
+//   logger.Setup("api", cfg.LogLevel, cfg.LogFormat)
+//   h := logger.RequestIDMiddleware(logger.Middleware("/api/x", handler))
+//   slog.InfoContext(r.Context(), "cache miss", "key", key)
+// =====================================================
+
+package logger
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Tên các attribute dùng chung, giữ thống nhất với processing-service
+const (
+	KeyService   = "service"
+	KeyRequestID = "request_id"
+	KeyTraceID   = "trace_id"
+	KeyError     = "error"
+)
+
+// HeaderRequestID là header nhận/trả request id
+// Client (hoặc reverse proxy) gửi sẵn thì dùng lại, không thì tự sinh
+const HeaderRequestID = "X-Request-ID"
+
+// contextKey là kiểu khóa riêng để không trùng với package khác
+type contextKey int
+
+const (
+	requestIDKey contextKey = iota
+	traceIDKey
+)
+
+// Setup tạo logger theo level/format và đặt làm slog default
+// Log của package "log" chuẩn cũng đi qua handler này
+func Setup(service, level, format string) *slog.Logger {
+	return SetupWriter(os.Stdout, service, level, format)
+}
+
+// SetupWriter giống Setup nhưng ghi ra w thay vì stdout
+func SetupWriter(w io.Writer, service, level, format string) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: ParseLevel(level)}
+
+	var handler slog.Handler
+	if strings.EqualFold(format, "json") {
+		handler = slog.NewJSONHandler(w, opts)
+	} else {
+		handler = slog.NewTextHandler(w, opts)
+	}
+
+	l := slog.New(&contextHandler{Handler: handler}).With(KeyService, service)
+	slog.SetDefault(l)
+	return l
+}
+
+// ParseLevel chuyển chuỗi level thành slog.Level (mặc định info)
+func ParseLevel(level string) slog.Level {
+	switch strings.ToLower(strings.TrimSpace(level)) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn", "warning":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// Err là attribute chuẩn cho lỗi
+func Err(err error) slog.Attr {
+	if err == nil {
+		return slog.String(KeyError, "")
+	}
+	return slog.String(KeyError, err.Error())
+}
+
+// WithRequestID gắn request id vào context
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey, requestID)
+}
+
+// RequestID lấy request id từ context ("" nếu không có)
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
+
+// WithTraceID gắn trace id của một post/event vào context
+func WithTraceID(ctx context.Context, traceID string) context.Context {
+	return context.WithValue(ctx, traceIDKey, traceID)
+}
+
+// TraceID lấy trace id từ context ("" nếu không có)
+func TraceID(ctx context.Context) string {
+	id, _ := ctx.Value(traceIDKey).(string)
+	return id
+}
+
+// NewRequestID sinh id ngẫu nhiên 16 bytes dạng hex
+func NewRequestID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b[:])
+}
+
+// =====================================================
+// HTTP MIDDLEWARE
+// =====================================================
+
+// statusRecorder ghi lại status code handler trả về
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+// WriteHeader lưu status code rồi chuyển tiếp
+func (r *statusRecorder) WriteHeader(code int) {
+	r.status = code
+	r.ResponseWriter.WriteHeader(code)
+}
+
+// Unwrap cho http.ResponseController tới writer gốc (Flush, SetWriteDeadline)
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// RequestIDMiddleware gắn request id vào context và response header
+// Đặt ngoài cùng chain để mọi log (kể cả panic) đều có request_id
+func RequestIDMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(HeaderRequestID)
+		if id == "" || len(id) > 128 {
+			id = NewRequestID()
+		}
+		w.Header().Set(HeaderRequestID, id)
+		next(w, r.WithContext(WithRequestID(r.Context(), id)))
+	}
+}
+
+// Middleware ghi một dòng access log khi request kết thúc
+// (request id lấy từ context, do RequestIDMiddleware gắn)
+// route là pattern đã đăng ký, giống label route của metrics
+func Middleware(route string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next(rec, r)
+
+		level := slog.LevelInfo
+		if rec.status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		slog.Log(ctx, level, "http request",
+			"route", route,
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", rec.status,
+			"duration_ms", float64(time.Since(start).Microseconds())/1000,
+			"remote_addr", r.RemoteAddr,
+		)
+	}
+}
+
+// contextHandler thêm request_id/trace_id từ context vào record
+type contextHandler struct {
+	slog.Handler
+}
+
+// Handle implement slog.Handler
+func (h *contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		r.AddAttrs(slog.String(KeyRequestID, id))
+	}
+	if id := TraceID(ctx); id != "" {
+		r.AddAttrs(slog.String(KeyTraceID, id))
+	} else if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		r.AddAttrs(slog.String(KeyTraceID, sc.TraceID().String()))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+// WithAttrs implement slog.Handler (giữ wrapper)
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+// WithGroup implement slog.Handler (giữ wrapper)
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,110 @@
+// =====================================================
+// PROMETHEUS METRICS - Metrics của API server
+// =====================================================
+// Mô tả: Tên và labels thống nhất với processing-service
+// (namespace social_insight, labels route/method/status/operation)
+// Expose qua Handler() tại /metrics
+// =====================================================
+
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "social_insight"
+
+var (
+	// APIRequests đếm requests theo route, method và status code
+	APIRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "api_requests_total",
+		Help:      "API requests, by route, method and response status.",
+	}, []string{"route", "method", "status"})
+
+	// APIRequestDuration đo thời gian xử lý request theo route
+	APIRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "api_request_duration_seconds",
+		Help:      "API request latency, by route and method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method"})
+
+	// RedisErrors đếm lỗi Redis theo command (không tính key không tồn tại)
+	RedisErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "redis_errors_total",
+		Help:      "Redis command errors, by operation.",
+	}, []string{"operation"})
+
+	// APIKeyRequests đếm requests đã xác thực theo prefix của API key và scope
+	APIKeyRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "api_key_requests_total",
+		Help:      "Authenticated API requests, by API key prefix and scope.",
+	}, []string{"key", "scope"})
+
+	// RateLimited đếm requests bị từ chối 429 theo nhóm route
+	RateLimited = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "api_rate_limited_total",
+		Help:      "API requests rejected by the rate limiter, by route class.",
+	}, []string{"class"})
+
+	// ExportRows đếm số dòng đã export theo loại (posts, aggregates) và định dạng
+	ExportRows = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "api_export_rows_total",
+		Help:      "Rows streamed by export endpoints, by kind and format.",
+	}, []string{"kind", "format"})
+
+	// CacheRequests đếm request qua response cache theo route và kết quả
+	// (hit: từ Redis, miss: đã lưu, bypass: không lưu được)
+	CacheRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "api_cache_requests_total",
+		Help:      "Requests to cached API endpoints, by route and result (hit, miss, bypass).",
+	}, []string{"route", "result"})
+)
+
+// Handler trả về HTTP handler cho /metrics
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// statusRecorder ghi lại status code handler trả về
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+// WriteHeader lưu status code rồi chuyển tiếp
+func (r *statusRecorder) WriteHeader(code int) {
+	r.status = code
+	r.ResponseWriter.WriteHeader(code)
+}
+
+// Unwrap cho http.ResponseController tới writer gốc (Flush, SetWriteDeadline)
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// Instrument bọc handler để đo latency và đếm requests
+// route là pattern đã đăng ký (ví dụ /api/stories/) chứ không phải URL thực
+// để số lượng label không tăng theo ID
+func Instrument(route string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next(rec, r)
+
+		APIRequestDuration.WithLabelValues(route, r.Method).Observe(time.Since(start).Seconds())
+		APIRequests.WithLabelValues(route, r.Method, strconv.Itoa(rec.status)).Inc()
+	}
+}
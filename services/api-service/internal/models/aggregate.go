@@ -0,0 +1,25 @@
+// =====================================================
+// AGGREGATE MODEL - Số liệu gộp theo khoảng thời gian
+// =====================================================
+// Mô tả: Số bài và engagement theo (bucket, topic, platform,
+// sentiment), dùng cho export aggregates
+// =====================================================
+
+package models
+
+import "time"
+
+// PostAggregate là số liệu gộp của các posts trong một bucket thời gian
+type PostAggregate struct {
+	// Bucket là đầu khoảng thời gian (date_trunc theo hour/day/week, UTC)
+	Bucket time.Time
+
+	Topic     string
+	Platform  string
+	Sentiment string
+
+	Posts    int64
+	Likes    int64
+	Comments int64
+	Shares   int64
+}
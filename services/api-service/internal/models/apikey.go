@@ -0,0 +1,44 @@
+// =====================================================
+// API KEY MODEL - Khóa truy cập API
+// =====================================================
+// Mô tả: Metadata của một API key; key gốc không bao giờ
+// được lưu, chỉ có hash (xem internal/auth)
+// =====================================================
+
+package models
+
+import (
+	"time"
+)
+
+// APIKey là một API key đã tạo
+type APIKey struct {
+	ID     int64  `json:"id"`
+	Name   string `json:"name"`
+	Prefix string `json:"prefix"`
+
+	// Scopes là các quyền của key (read:posts, read:analytics, ...)
+	Scopes []string `json:"scopes"`
+
+	// RequestCount/LastUsedAt là usage counters (cập nhật định kỳ)
+	RequestCount int64      `json:"request_count"`
+	LastUsedAt   *time.Time `json:"last_used_at,omitempty"`
+
+	CreatedAt time.Time  `json:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+// HasScope kiểm tra key có scope không
+func (k *APIKey) HasScope(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// Revoked là true khi key đã bị thu hồi
+func (k *APIKey) Revoked() bool {
+	return k.RevokedAt != nil
+}
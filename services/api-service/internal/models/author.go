@@ -0,0 +1,50 @@
+// =====================================================
+// AUTHOR MODEL - Thống kê theo tác giả
+// =====================================================
+// Mô tả: Tác giả được nhận diện theo (platform, handle). AuthorStat
+// là một dòng xếp hạng (/api/authors), AuthorProfile là thống kê đầy
+// đủ của bảng authors (/api/authors/{platform}/{handle}) do consumer
+// tính lại mỗi khi ghi posts
+// =====================================================
+
+package models
+
+import (
+	"time"
+)
+
+// AuthorStat là thống kê bài viết của một tác giả
+type AuthorStat struct {
+	Platform string `json:"platform"`
+	Handle   string `json:"handle"`
+
+	// Author là tên hiển thị (của post mới nhất)
+	Author string `json:"author"`
+
+	PostCount  int64 `json:"post_count"`
+	TotalLikes int64 `json:"total_likes"`
+
+	// TotalEngagement = likes + comments + shares
+	TotalEngagement int64 `json:"total_engagement"`
+
+	// EngagementRate là engagement trung bình mỗi post
+	EngagementRate float64 `json:"engagement_rate"`
+}
+
+// AuthorProfile là hồ sơ của một tác giả trong bảng authors
+type AuthorProfile struct {
+	AuthorStat
+
+	// FirstSeenAt/LastSeenAt là thời điểm post cũ nhất/mới nhất
+	FirstSeenAt time.Time `json:"first_seen_at"`
+	LastSeenAt  time.Time `json:"last_seen_at"`
+
+	TotalComments int64 `json:"total_comments"`
+	TotalShares   int64 `json:"total_shares"`
+
+	// Topics là số posts theo topic
+	Topics map[string]int64 `json:"topics"`
+
+	// AvgSentiment là trung bình sentiment: positive = 1, neutral = 0, negative = -1
+	AvgSentiment float64 `json:"avg_sentiment"`
+}
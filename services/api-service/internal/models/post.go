@@ -21,14 +21,24 @@ type Post struct {
 	// Author là tên người đăng bài
 	Author string `json:"author"`
 
+	// AuthorHandle là username của tác giả trên nền tảng
+	// (cùng Platform là khóa của /api/authors/{platform}/{handle})
+	AuthorHandle string `json:"author_handle,omitempty"`
+
+	// Title là tiêu đề gốc của bài viết (dùng cho near-duplicate detection)
+	Title string `json:"title,omitempty"`
+
 	// Content là nội dung bài viết
 	// Chủ đề xoay quanh công nghệ và AI
 	Content string `json:"content"`
 
-	// Topic là chủ đề của bài viết
-	// Giá trị: "ai", "cloud", "devops", "programming", "startup"
+	// Topic là chủ đề chính của bài viết (topic cấp 1 điểm cao nhất);
+	// các giá trị có thể có: GET /api/v1/taxonomy
 	Topic string `json:"topic"`
 
+	// Topics là mọi topic/subtopic của bài viết kèm điểm, topic chính trước
+	Topics []TopicScore `json:"topics,omitempty"`
+
 	// Sentiment là cảm xúc của bài viết
 	// Giá trị: "positive", "negative", "neutral"
 	Sentiment string `json:"sentiment"`
@@ -46,17 +56,30 @@ type Post struct {
 	// Giá trị: "twitter", "linkedin", "reddit", "hackernews"
 	Platform string `json:"platform"`
 
+	// URL là link bài viết (HN: link bài được share, Dev.to/Medium: link bài gốc)
+	URL string `json:"url,omitempty"`
+
+	// CanonicalURL là URL đã chuẩn hóa, dùng để gom các bài cùng link thành story
+	// Được consumer tính khi lưu, rỗng nếu post không có URL hợp lệ
+	CanonicalURL string `json:"canonical_url,omitempty"`
+
 	// CreatedAt là thời điểm tạo bài viết
 	CreatedAt time.Time `json:"created_at"`
+
+	// CanonicalPostID trỏ tới post gốc nếu bài này là near-duplicate
+	// (cùng story được cross-post sang nền tảng khác). Rỗng nếu là bài gốc.
+	CanonicalPostID string `json:"canonical_post_id,omitempty"`
 }
 
-// Topics là danh sách các chủ đề công nghệ
-var Topics = []string{
-	"ai",          // Trí tuệ nhân tạo, Machine Learning
-	"cloud",       // Cloud Computing (AWS, GCP, Azure)
-	"devops",      // DevOps, Kubernetes, Docker
-	"programming", // Ngôn ngữ lập trình, frameworks
-	"startup",     // Startup, tech news, funding
+// TopicScore là một topic (hoặc subtopic) được gán cho post
+type TopicScore struct {
+	Topic string `json:"topic"`
+
+	// Parent là topic cấp 1 chứa subtopic (rỗng nếu Topic là topic cấp 1)
+	Parent string `json:"parent,omitempty"`
+
+	// Score là tổng trọng số các luật/tag khớp (0 = topic fallback)
+	Score float64 `json:"score"`
 }
 
 // Platforms là danh sách các nền tảng mạng xã hội
@@ -0,0 +1,35 @@
+// =====================================================
+// STORY MODEL - Nhóm posts cùng trỏ về một link
+// =====================================================
+// Mô tả: Một story là một canonical URL được thảo luận
+// trên một hoặc nhiều nền tảng (HN, Dev.to, Medium)
+// =====================================================
+
+package models
+
+import (
+	"time"
+)
+
+// Story là một link đã chuẩn hóa cùng các posts thảo luận về nó
+type Story struct {
+	// ID là mã định danh của story
+	ID int64 `json:"id"`
+
+	// CanonicalURL là URL đã chuẩn hóa (bỏ tracking params, AMP...)
+	CanonicalURL string `json:"canonical_url"`
+
+	// FirstSeenAt/LastSeenAt là lần đầu/cuối link xuất hiện trên bất kỳ nền tảng nào
+	FirstSeenAt time.Time `json:"first_seen_at"`
+	LastSeenAt  time.Time `json:"last_seen_at"`
+}
+
+// StoryPlatform tổng hợp engagement của story trên một nền tảng
+type StoryPlatform struct {
+	Platform   string `json:"platform"`
+	Posts      int    `json:"posts"`
+	Likes      int    `json:"likes"`
+	Comments   int    `json:"comments"`
+	Shares     int    `json:"shares"`
+	Engagement int    `json:"engagement"`
+}
@@ -0,0 +1,21 @@
+// =====================================================
+// TOPIC MODEL - Topic trong taxonomy
+// =====================================================
+// Mô tả: Cây topic do consumer đồng bộ từ file taxonomy vào
+// bảng topics (xem 010_create_post_topics.sql)
+// =====================================================
+
+package models
+
+// Topic là một topic cấp 1 hoặc subtopic của taxonomy
+type Topic struct {
+	ID string `json:"id"`
+
+	// Parent là topic cấp 1 chứa subtopic (rỗng nếu là topic cấp 1)
+	Parent string `json:"parent,omitempty"`
+
+	Name string `json:"name"`
+
+	// Posts là số posts được gán topic (chính hoặc phụ)
+	Posts int64 `json:"posts"`
+}
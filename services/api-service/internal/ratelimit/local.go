@@ -0,0 +1,84 @@
+// =====================================================
+// LOCAL BUCKETS - Token bucket trong process khi Redis down
+// =====================================================
+// Mô tả: Cùng thuật toán với script Lua trong internal/redis, nhưng
+// bucket nằm trong memory của từng replica: khi Redis down mỗi replica
+// giới hạn riêng (tổng giới hạn = số replica × rule) thay vì cho qua
+// hết, đúng lúc handlers degraded dồn mọi request xuống PostgreSQL
+// =====================================================
+
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	redisclient "social-insight/internal/redis"
+)
+
+// maxLocalBuckets: vượt số bucket này thì bỏ các bucket đã đầy lại
+// (client không gửi request trong cả Period)
+const maxLocalBuckets = 10000
+
+// localBucket là trạng thái một bucket
+type localBucket struct {
+	rule    Rule
+	tokens  float64
+	updated time.Time
+}
+
+// refill trả về số token của b tại now (nạp lại từ lần cập nhật trước)
+func (b *localBucket) refill(now time.Time) float64 {
+	return math.Min(float64(b.rule.Limit), b.tokens+math.Max(0, now.Sub(b.updated).Seconds())*b.rule.rate())
+}
+
+// localBuckets là các bucket trong memory, an toàn khi dùng đồng thời
+type localBuckets struct {
+	mu      sync.Mutex
+	buckets map[string]*localBucket
+	now     func() time.Time
+}
+
+func newLocalBuckets() *localBuckets {
+	return &localBuckets{buckets: make(map[string]*localBucket), now: time.Now}
+}
+
+// take lấy 1 token từ bucket key theo rule
+func (l *localBuckets) take(key string, rule Rule) redisclient.TokenBucketResult {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	capacity, rate := float64(rule.Limit), rule.rate()
+
+	b, ok := l.buckets[key]
+	if !ok || b.rule != rule {
+		if len(l.buckets) >= maxLocalBuckets {
+			for k, old := range l.buckets {
+				if old.refill(now) >= float64(old.rule.Limit) {
+					delete(l.buckets, k)
+				}
+			}
+		}
+		b = &localBucket{rule: rule, tokens: capacity, updated: now}
+		l.buckets[key] = b
+	}
+	b.tokens, b.updated = b.refill(now), now
+
+	res := redisclient.TokenBucketResult{}
+	if b.tokens >= 1 {
+		b.tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = seconds((1 - b.tokens) / rate)
+	}
+	res.Remaining = int(b.tokens)
+	res.Reset = seconds((capacity - b.tokens) / rate)
+	return res
+}
+
+// seconds đổi số giây (float) thành Duration
+func seconds(f float64) time.Duration {
+	return time.Duration(f * float64(time.Second))
+}
@@ -0,0 +1,253 @@
+// =====================================================
+// RATE LIMIT - Giới hạn request theo client
+// =====================================================
+// Mô tả: Middleware token bucket cho từng client (API key,
+// hoặc IP khi request không có key) và từng nhóm route:
+//   - default: endpoint đọc cache/aggregate rẻ
+//   - heavy: endpoint nạp nhiều posts vào memory (trending, insights, compare)
+//   - export: stream file lớn từ Postgres (/api/v1/export/*)
+//   - auth: mọi route cần API key, bucket theo IP và đặt trước bước
+//     tra key (LimitIP), để key thiếu/sai cũng bị giới hạn
+// Bucket nằm trong Redis nên giới hạn dùng chung giữa các API replica.
+// Vượt giới hạn → 429 + Retry-After; mọi response có X-RateLimit-*.
+// Redis down → bucket trong memory của từng replica (xem local.go)
+//
+// Dùng:
+//   l := ratelimit.New(cfg.RateLimit(), server.cache)
+//   http.HandleFunc("/api/trending", l.Limit(ratelimit.ClassHeavy, handler))
+//   http.HandleFunc("/api/x", l.LimitIP(ratelimit.ClassAuth, a.Require(scope, handler)))
+// =====================================================
+
+package ratelimit
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"social-insight/internal/auth"
+	"social-insight/internal/logger"
+	"social-insight/internal/metrics"
+	redisclient "social-insight/internal/redis"
+	"social-insight/internal/server"
+)
+
+// Các nhóm route
+const (
+	ClassDefault = "default"
+	ClassHeavy   = "heavy"
+	ClassExport  = "export"
+	ClassAuth    = "auth"
+)
+
+// Headers trả về cho client
+const (
+	HeaderLimit     = "X-RateLimit-Limit"
+	HeaderRemaining = "X-RateLimit-Remaining"
+	HeaderReset     = "X-RateLimit-Reset"
+)
+
+// Rule là giới hạn Limit requests mỗi Period (Limit = 0: không giới hạn)
+// Bucket chứa tối đa Limit token, nạp lại đều trong Period
+type Rule struct {
+	Limit  int
+	Period time.Duration
+}
+
+// String trả về dạng "60/1m0s"
+func (r Rule) String() string {
+	if r.Limit == 0 {
+		return "off"
+	}
+	return fmt.Sprintf("%d/%s", r.Limit, r.Period)
+}
+
+// rate là số token nạp lại mỗi giây
+func (r Rule) rate() float64 {
+	return float64(r.Limit) / r.Period.Seconds()
+}
+
+// ParseRule parse "60/1m" (60 requests mỗi phút); "" hoặc "off" = không giới hạn
+func ParseRule(s string) (Rule, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || s == "off" || s == "0" {
+		return Rule{}, nil
+	}
+	n, period, ok := strings.Cut(s, "/")
+	if !ok {
+		return Rule{}, fmt.Errorf("rate limit %q must look like 60/1m", s)
+	}
+	limit, err := strconv.Atoi(strings.TrimSpace(n))
+	if err != nil || limit < 0 {
+		return Rule{}, fmt.Errorf("rate limit %q: invalid request count", s)
+	}
+	d, err := time.ParseDuration(strings.TrimSpace(period))
+	if err != nil || d <= 0 {
+		return Rule{}, fmt.Errorf("rate limit %q: invalid period", s)
+	}
+	return Rule{Limit: limit, Period: d}, nil
+}
+
+// Config cấu hình Limiter
+type Config struct {
+	Rules map[string]Rule // Giới hạn theo nhóm route
+
+	// TrustedProxies là các reverse proxy (CIDR) được tin X-Forwarded-For:
+	// IP client là hop ngoài cùng bên phải không thuộc danh sách này
+	TrustedProxies []*net.IPNet
+}
+
+// ParseTrustedProxies parse danh sách CIDR hoặc IP cách nhau bởi dấu phẩy
+// ("10.0.0.0/8,192.168.1.10"); "" = không tin proxy nào
+func ParseTrustedProxies(s string) ([]*net.IPNet, error) {
+	var nets []*net.IPNet
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		if !strings.Contains(part, "/") {
+			ip := net.ParseIP(part)
+			if ip == nil {
+				return nil, fmt.Errorf("trusted proxy %q is not an IP or CIDR", part)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, ipNet, err := net.ParseCIDR(part)
+		if err != nil {
+			return nil, fmt.Errorf("trusted proxy %q is not an IP or CIDR", part)
+		}
+		nets = append(nets, ipNet)
+	}
+	return nets, nil
+}
+
+// Limiter áp dụng token bucket cho các route
+type Limiter struct {
+	cfg Config
+
+	// redis trả về Redis client cho request, nil khi Redis down
+	redis func(r *http.Request) *redisclient.Client
+
+	// local thay Redis khi Redis down hoặc lỗi
+	local *localBuckets
+}
+
+// New tạo Limiter; redis thường là Server.cache (nil khi degraded)
+func New(cfg Config, redis func(r *http.Request) *redisclient.Client) *Limiter {
+	return &Limiter{cfg: cfg, redis: redis, local: newLocalBuckets()}
+}
+
+// Limit giới hạn next theo rule của class, bucket theo client (xem clientID)
+func (l *Limiter) Limit(class string, next http.HandlerFunc) http.HandlerFunc {
+	return l.limit(class, l.clientID, next)
+}
+
+// LimitIP giới hạn next theo rule của class, bucket luôn theo IP
+// Đặt trước auth: request chưa xác thực chưa có key trong context, và
+// key sai không được query Postgres không giới hạn
+func (l *Limiter) LimitIP(class string, next http.HandlerFunc) http.HandlerFunc {
+	return l.limit(class, func(r *http.Request) string { return "ip:" + l.clientIP(r) }, next)
+}
+
+// limit áp dụng rule của class với bucket theo clientID(r)
+func (l *Limiter) limit(class string, clientID func(r *http.Request) string, next http.HandlerFunc) http.HandlerFunc {
+	rule := l.cfg.Rules[class]
+	if rule.Limit == 0 {
+		return next
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := "ratelimit:" + class + ":" + clientID(r)
+		var res redisclient.TokenBucketResult
+		rdb := l.redis(r)
+		if rdb == nil {
+			res = l.local.take(key, rule)
+		} else {
+			var err error
+			if res, err = rdb.TakeToken(key, rule.Limit, rule.rate()); err != nil {
+				slog.WarnContext(r.Context(), "rate limit check error, using local bucket", "class", class, logger.Err(err))
+				res = l.local.take(key, rule)
+			}
+		}
+
+		w.Header().Set(HeaderLimit, strconv.Itoa(rule.Limit))
+		w.Header().Set(HeaderRemaining, strconv.Itoa(res.Remaining))
+		w.Header().Set(HeaderReset, ceilSeconds(res.Reset))
+
+		if !res.Allowed {
+			metrics.RateLimited.WithLabelValues(class).Inc()
+			w.Header().Set("Retry-After", ceilSeconds(res.RetryAfter))
+			server.WriteError(w, http.StatusTooManyRequests,
+				"rate limit exceeded ("+rule.String()+" for "+class+" endpoints), retry in "+ceilSeconds(res.RetryAfter)+"s")
+			return
+		}
+		next(w, r)
+	}
+}
+
+// clientID định danh client: ID API key nếu có (prefix không unique),
+// không thì IP
+func (l *Limiter) clientID(r *http.Request) string {
+	if key := auth.FromContext(r.Context()); key != nil {
+		return "key:" + strconv.FormatInt(key.ID, 10)
+	}
+	return "ip:" + l.clientIP(r)
+}
+
+// clientIP lấy IP client: RemoteAddr, hoặc khi RemoteAddr là trusted
+// proxy thì hop ngoài cùng bên phải của X-Forwarded-For không phải trusted
+// proxy. Các hop bên trái do client tự gửi nên không được dùng (client
+// đổi IP giả mỗi request sẽ không bao giờ hết token)
+func (l *Limiter) clientIP(r *http.Request) string {
+	remote, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		remote = r.RemoteAddr
+	}
+	if !l.trusted(net.ParseIP(remote)) {
+		return remote
+	}
+
+	var hops []string
+	for _, xff := range r.Header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(xff, ",")...)
+	}
+	for i := len(hops) - 1; i >= 0; i-- {
+		ip := net.ParseIP(strings.TrimSpace(hops[i]))
+		if ip == nil {
+			// Hop không hợp lệ: không tin các hop bên trái nó
+			break
+		}
+		if !l.trusted(ip) {
+			return ip.String()
+		}
+	}
+	return remote
+}
+
+// trusted kiểm tra ip thuộc TrustedProxies
+func (l *Limiter) trusted(ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+	for _, n := range l.cfg.TrustedProxies {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// ceilSeconds làm tròn lên số giây (header Retry-After/X-RateLimit-Reset)
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
@@ -0,0 +1,134 @@
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	redisclient "social-insight/internal/redis"
+)
+
+func TestParseRule(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Rule
+		wantErr bool
+	}{
+		{"60/1m", Rule{Limit: 60, Period: time.Minute}, false},
+		{" 30 / 10s ", Rule{Limit: 30, Period: 10 * time.Second}, false},
+		{"", Rule{}, false},
+		{"off", Rule{}, false},
+		{"0", Rule{}, false},
+		{"60", Rule{}, true},
+		{"x/1m", Rule{}, true},
+		{"-1/1m", Rule{}, true},
+		{"60/soon", Rule{}, true},
+		{"60/0s", Rule{}, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseRule(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseRule(%q) = %v, %v; want %v, error %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestParseTrustedProxies(t *testing.T) {
+	nets, err := ParseTrustedProxies("10.0.0.0/8, 192.168.1.10,,::1")
+	if err != nil || len(nets) != 3 {
+		t.Fatalf("nets = %v, err = %v", nets, err)
+	}
+	if nets[1].String() != "192.168.1.10/32" || nets[2].String() != "::1/128" {
+		t.Errorf("single IPs = %s, %s", nets[1], nets[2])
+	}
+	for _, bad := range []string{"proxy", "10.0.0.0/40"} {
+		if _, err := ParseTrustedProxies(bad); err == nil {
+			t.Errorf("ParseTrustedProxies(%q): expected error", bad)
+		}
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	proxies, _ := ParseTrustedProxies("10.0.0.0/8")
+	tests := []struct {
+		name    string
+		trusted bool
+		remote  string
+		xff     []string
+		want    string
+	}{
+		{"no proxy", false, "203.0.113.7:5123", nil, "203.0.113.7"},
+		// Không tin proxy nào: X-Forwarded-For bị bỏ qua
+		{"xff without trusted proxies", false, "203.0.113.7:5123", []string{"1.2.3.4"}, "203.0.113.7"},
+		// Client không qua proxy tự gửi header
+		{"xff from untrusted peer", true, "203.0.113.7:5123", []string{"1.2.3.4"}, "203.0.113.7"},
+		{"behind proxy", true, "10.0.0.2:80", []string{"203.0.113.7"}, "203.0.113.7"},
+		// Client gửi IP giả ở bên trái, proxy nối IP thật vào bên phải
+		{"spoofed leftmost", true, "10.0.0.2:80", []string{"1.2.3.4, 203.0.113.7"}, "203.0.113.7"},
+		{"multiple proxies", true, "10.0.0.2:80", []string{"1.2.3.4, 203.0.113.7, 10.1.1.1"}, "203.0.113.7"},
+		{"multiple headers", true, "10.0.0.2:80", []string{"1.2.3.4", "203.0.113.7, 10.1.1.1"}, "203.0.113.7"},
+		{"invalid hop", true, "10.0.0.2:80", []string{"1.2.3.4, garbage, 10.1.1.1"}, "10.0.0.2"},
+		{"only proxies", true, "10.0.0.2:80", []string{"10.1.1.1"}, "10.0.0.2"},
+		{"no xff", true, "10.0.0.2:80", nil, "10.0.0.2"},
+	}
+	for _, tt := range tests {
+		cfg := Config{}
+		if tt.trusted {
+			cfg.TrustedProxies = proxies
+		}
+		l := New(cfg, nil)
+		r := httptest.NewRequest("GET", "/api/v1/topics", nil)
+		r.RemoteAddr = tt.remote
+		for _, v := range tt.xff {
+			r.Header.Add("X-Forwarded-For", v)
+		}
+		if got := l.clientIP(r); got != tt.want {
+			t.Errorf("%s: clientIP = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestLimitWithoutRedis(t *testing.T) {
+	l := New(Config{Rules: map[string]Rule{ClassHeavy: {Limit: 2, Period: time.Minute}}},
+		func(*http.Request) *redisclient.Client { return nil })
+	h := l.Limit(ClassHeavy, func(w http.ResponseWriter, r *http.Request) {})
+
+	do := func(remote string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("GET", "/api/v1/trending", nil)
+		r.RemoteAddr = remote
+		w := httptest.NewRecorder()
+		h(w, r)
+		return w
+	}
+	for i := 0; i < 2; i++ {
+		if w := do("203.0.113.7:1"); w.Code != http.StatusOK {
+			t.Fatalf("request %d: status %d", i+1, w.Code)
+		}
+	}
+	w := do("203.0.113.7:1")
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") != "30" {
+		t.Errorf("over limit: status %d, Retry-After %q", w.Code, w.Header().Get("Retry-After"))
+	}
+	// Bucket riêng cho mỗi client
+	if w := do("198.51.100.1:1"); w.Code != http.StatusOK {
+		t.Errorf("other client: status %d", w.Code)
+	}
+}
+
+func TestLocalBucketRefill(t *testing.T) {
+	now := time.Date(2026, 1, 31, 10, 0, 0, 0, time.UTC)
+	b := newLocalBuckets()
+	b.now = func() time.Time { return now }
+	rule := Rule{Limit: 2, Period: 10 * time.Second}
+
+	b.take("k", rule)
+	b.take("k", rule)
+	if res := b.take("k", rule); res.Allowed || res.RetryAfter != 5*time.Second {
+		t.Fatalf("empty bucket: %+v", res)
+	}
+	now = now.Add(5 * time.Second)
+	if res := b.take("k", rule); !res.Allowed || res.Remaining != 0 || res.Reset != 10*time.Second {
+		t.Errorf("after refill: %+v", res)
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"social-insight/internal/models"
+	"strconv"
 	"time"
 
 	"github.com/go-redis/redis/v8"
@@ -26,27 +27,53 @@ type Client struct {
 	ctx context.Context
 }
 
-// NewClient tạo Redis client mới
+// NewClient tạo Redis client mới và kiểm tra kết nối
 // addr: địa chỉ Redis (ví dụ: "localhost:6379")
 func NewClient(addr string) (*Client, error) {
-	ctx := context.Background()
-
-	rdb := redis.NewClient(&redis.Options{
-		Addr:     addr,
-		Password: "",  // Không có password cho local dev
-		DB:       0,   // Database mặc định
-		PoolSize: 100, // Connection pool size
-	})
+	c := Connect(addr)
 
 	// Test connection
-	if err := rdb.Ping(ctx).Err(); err != nil {
+	if err := c.Ping(c.ctx); err != nil {
+		c.Close()
 		return nil, fmt.Errorf("không thể kết nối Redis: %w", err)
 	}
+	return c, nil
+}
+
+// Connect tạo Redis client không kiểm tra kết nối
+// go-redis tự kết nối lại, nên Redis down lúc khởi động vẫn dùng được
+// khi Redis lên lại (API chạy degraded mode trong lúc chờ)
+func Connect(addr string) *Client {
+	rdb := redis.NewClient(&redis.Options{
+		Addr:        addr,
+		Password:    "",  // Không có password cho local dev
+		DB:          0,   // Database mặc định
+		PoolSize:    100, // Connection pool size
+		DialTimeout: 2 * time.Second,
+	})
+	rdb.AddHook(metricsHook{})
+	rdb.AddHook(tracingHook{})
 
 	return &Client{
 		rdb: rdb,
-		ctx: ctx,
-	}, nil
+		ctx: context.Background(),
+	}
+}
+
+// Ping kiểm tra kết nối Redis (health check)
+func (c *Client) Ping(ctx context.Context) error {
+	return c.rdb.Ping(ctx).Err()
+}
+
+// WithContext trả về bản sao Client dùng ctx cho mọi command
+// (dùng chung connection pool); command có span nếu ctx mang span
+func (c *Client) WithContext(ctx context.Context) *Client {
+	if c == nil {
+		return nil
+	}
+	clone := *c
+	clone.ctx = ctx
+	return &clone
 }
 
 // CachePost lưu post vào cache với TTL
@@ -134,14 +161,18 @@ func (c *Client) GetRecentPosts(count int64) ([]models.Post, error) {
 	return posts, nil
 }
 
+// TopicCountersKey là hash số posts theo topic cấp 1 do consumer tăng
+// (cùng tên với processing-service/internal/redis)
+const TopicCountersKey = "stats:topics"
+
 // GetStats lấy thống kê từ cache
+// Số posts theo topic (hash TopicCountersKey) trả về dạng posts:{topic}
 func (c *Client) GetStats() (map[string]int64, error) {
 	stats := make(map[string]int64)
 
 	// Danh sách các keys cần lấy
 	keys := []string{
 		"posts:total",
-		"posts:ai", "posts:cloud", "posts:devops", "posts:programming", "posts:startup",
 		"sentiment:positive", "sentiment:negative", "sentiment:neutral",
 	}
 
@@ -153,6 +184,16 @@ func (c *Client) GetStats() (map[string]int64, error) {
 		stats[key] = val
 	}
 
+	topics, err := c.rdb.HGetAll(c.ctx, TopicCountersKey).Result()
+	if err != nil {
+		return stats, err
+	}
+	for topic, val := range topics {
+		if n, err := strconv.ParseInt(val, 10, 64); err == nil {
+			stats["posts:"+topic] = n
+		}
+	}
+
 	return stats, nil
 }
 
@@ -203,6 +244,17 @@ func (c *Client) MarkAsSeen(source, postID string, ttl time.Duration) error {
 	return c.rdb.SetEX(c.ctx, key, "1", ttl).Err()
 }
 
+// GetConsumerMetrics đọc metrics snapshot của các consumer replicas (hostname → JSON)
+// Consumer ghi hash consumer:metrics:{group} mỗi 5 giây
+func (c *Client) GetConsumerMetrics(group string) (map[string]string, error) {
+	return c.rdb.HGetAll(c.ctx, fmt.Sprintf("consumer:metrics:%s", group)).Result()
+}
+
+// GetConsumerLag đọc lag các partitions của group (partition → JSON)
+func (c *Client) GetConsumerLag(group string) (map[string]string, error) {
+	return c.rdb.HGetAll(c.ctx, fmt.Sprintf("consumer:lag:%s", group)).Result()
+}
+
 // Close đóng Redis connection
 func (c *Client) Close() error {
 	return c.rdb.Close()
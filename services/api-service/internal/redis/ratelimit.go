@@ -0,0 +1,110 @@
+// =====================================================
+// RATE LIMIT - Token bucket trên Redis
+// =====================================================
+// Mô tả: Mỗi bucket là một hash {tokens, ts} cập nhật nguyên tử
+// bằng Lua script, nên nhiều API replica dùng chung một giới hạn.
+// Thời gian lấy từ Redis TIME để không phụ thuộc đồng hồ từng replica
+// =====================================================
+
+package redis
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// tokenBucketScript lấy 1 token từ bucket KEYS[1]
+// ARGV: capacity, rate (tokens/giây)
+// Trả về: {allowed, tokens còn lại, giây đến khi có 1 token, giây đến khi đầy}
+var tokenBucketScript = redis.NewScript(`
+local capacity = tonumber(ARGV[1])
+local rate = tonumber(ARGV[2])
+
+local t = redis.call('TIME')
+local now = tonumber(t[1]) + tonumber(t[2]) / 1000000
+
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(state[1])
+local ts = tonumber(state[2])
+if tokens == nil or ts == nil then
+	tokens = capacity
+	ts = now
+end
+
+tokens = math.min(capacity, tokens + math.max(0, now - ts) * rate)
+
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', tostring(now))
+redis.call('EXPIRE', KEYS[1], math.ceil(capacity / rate) + 1)
+
+local retry = 0
+if allowed == 0 then
+	retry = (1 - tokens) / rate
+end
+local reset = (capacity - tokens) / rate
+
+return {allowed, tostring(tokens), tostring(retry), tostring(reset)}
+`)
+
+// TokenBucketResult là kết quả một lần lấy token
+type TokenBucketResult struct {
+	Allowed    bool
+	Remaining  int           // Số token nguyên còn lại
+	RetryAfter time.Duration // Thời gian đến khi có token (0 nếu Allowed)
+	Reset      time.Duration // Thời gian đến khi bucket đầy lại
+}
+
+// TakeToken lấy 1 token từ bucket key
+// capacity: số request tối đa liên tiếp, rate: tokens nạp lại mỗi giây
+func (c *Client) TakeToken(key string, capacity int, rate float64) (TokenBucketResult, error) {
+	res, err := tokenBucketScript.Run(c.ctx, c.rdb, []string{key}, capacity, rate).Slice()
+	if err != nil {
+		return TokenBucketResult{}, err
+	}
+	if len(res) != 4 {
+		return TokenBucketResult{}, fmt.Errorf("unexpected token bucket reply: %v", res)
+	}
+
+	allowed, _ := res[0].(int64)
+	tokens, err := parseReplyFloat(res[1])
+	if err != nil {
+		return TokenBucketResult{}, err
+	}
+	retry, err := parseReplyFloat(res[2])
+	if err != nil {
+		return TokenBucketResult{}, err
+	}
+	reset, err := parseReplyFloat(res[3])
+	if err != nil {
+		return TokenBucketResult{}, err
+	}
+
+	return TokenBucketResult{
+		Allowed:    allowed == 1,
+		Remaining:  int(tokens),
+		RetryAfter: seconds(retry),
+		Reset:      seconds(reset),
+	}, nil
+}
+
+// parseReplyFloat đọc số thực Lua trả về dạng string
+func parseReplyFloat(v interface{}) (float64, error) {
+	s, ok := v.(string)
+	if !ok {
+		return 0, fmt.Errorf("unexpected token bucket value: %v", v)
+	}
+	return strconv.ParseFloat(s, 64)
+}
+
+// seconds đổi số giây (float) thành Duration
+func seconds(f float64) time.Duration {
+	return time.Duration(f * float64(time.Second))
+}
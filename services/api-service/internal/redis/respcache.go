@@ -0,0 +1,45 @@
+// =====================================================
+// RESPONSE CACHE - Lưu response của API trong Redis
+// =====================================================
+// Mô tả: Mỗi response cache là một key api:cache:{route}:{...} kèm
+// generation lúc đọc dữ liệu. Consumer tăng CacheGenerationKey sau
+// mỗi batch ghi Postgres (processing-service), nên entry của
+// generation cũ coi như hết hạn ngay mà không cần xóa key
+// =====================================================
+
+package redis
+
+import (
+	"strconv"
+	"time"
+)
+
+// CacheGenerationKey là counter tăng mỗi lần dữ liệu posts thay đổi
+// (cùng tên với processing-service/internal/redis)
+const CacheGenerationKey = "api:cache:generation"
+
+// GetCached đọc generation hiện tại và entry của key trong một round trip
+// (entry nil khi chưa có; generation 0 khi consumer chưa ghi batch nào)
+func (c *Client) GetCached(key string) (int64, []byte, error) {
+	values, err := c.rdb.MGet(c.ctx, CacheGenerationKey, key).Result()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	var generation int64
+	if s, ok := values[0].(string); ok {
+		if generation, err = strconv.ParseInt(s, 10, 64); err != nil {
+			return 0, nil, err
+		}
+	}
+	var entry []byte
+	if s, ok := values[1].(string); ok {
+		entry = []byte(s)
+	}
+	return generation, entry, nil
+}
+
+// SetCached lưu entry của key với TTL
+func (c *Client) SetCached(key string, entry []byte, ttl time.Duration) error {
+	return c.rdb.Set(c.ctx, key, entry, ttl).Err()
+}
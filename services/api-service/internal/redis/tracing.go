@@ -0,0 +1,79 @@
+// =====================================================
+// REDIS TRACING HOOK - Span cho command Redis
+// =====================================================
+// Mô tả: go-redis hook mở span cho mỗi command/pipeline khi ctx
+// đã có span cha (Client.WithContext), để không sinh trace gốc
+// cho các lệnh nền ngoài request
+// =====================================================
+
+package redis
+
+import (
+	"context"
+	"errors"
+	"social-insight/internal/tracing"
+
+	"github.com/go-redis/redis/v8"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracingHook implement redis.Hook
+type tracingHook struct{}
+
+// spanKey giữ span do hook mở, để AfterProcess không kết thúc nhầm span cha
+type spanKey struct{}
+
+// BeforeProcess mở span "redis <command>"
+func (tracingHook) BeforeProcess(ctx context.Context, cmd redis.Cmder) (context.Context, error) {
+	return startSpan(ctx, cmd.Name(), 1), nil
+}
+
+// AfterProcess kết thúc span của command
+func (tracingHook) AfterProcess(ctx context.Context, cmd redis.Cmder) error {
+	endSpan(ctx, []redis.Cmder{cmd})
+	return nil
+}
+
+// BeforeProcessPipeline mở một span cho cả pipeline
+func (tracingHook) BeforeProcessPipeline(ctx context.Context, cmds []redis.Cmder) (context.Context, error) {
+	return startSpan(ctx, "pipeline", len(cmds)), nil
+}
+
+// AfterProcessPipeline kết thúc span của pipeline
+func (tracingHook) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder) error {
+	endSpan(ctx, cmds)
+	return nil
+}
+
+// startSpan chỉ mở span khi ctx đã có span cha hợp lệ
+func startSpan(ctx context.Context, operation string, commands int) context.Context {
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		return ctx
+	}
+	ctx, span := tracing.Start(ctx, "redis "+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "redis"),
+			attribute.String("db.operation", operation),
+			attribute.Int("db.redis.commands", commands),
+		),
+	)
+	return context.WithValue(ctx, spanKey{}, span)
+}
+
+// endSpan kết thúc span do startSpan mở, ghi lỗi đầu tiên (redis.Nil không tính)
+func endSpan(ctx context.Context, cmds []redis.Cmder) {
+	span, ok := ctx.Value(spanKey{}).(trace.Span)
+	if !ok {
+		return
+	}
+	var err error
+	for _, cmd := range cmds {
+		if e := cmd.Err(); e != nil && !errors.Is(e, redis.Nil) {
+			err = e
+			break
+		}
+	}
+	tracing.End(span, &err)
+}
@@ -0,0 +1,93 @@
+// =====================================================
+// JSON RESPONSES - Body JSON và lỗi thống nhất
+// =====================================================
+// Mô tả: Mọi lỗi của API có cùng dạng
+//   {"error": {"code": "not_found", "message": "story not found"}}
+// để client xử lý theo code thay vì đọc chuỗi text.
+// Handler, middleware (auth, rate limit, recover, timeout)
+// và router (404/405) đều dùng WriteError
+// =====================================================
+
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// Mã lỗi chuẩn theo status code (WriteError dùng khi không chỉ định code)
+const (
+	CodeBadRequest       = "bad_request"
+	CodeUnauthorized     = "unauthorized"
+	CodeForbidden        = "forbidden"
+	CodeNotFound         = "not_found"
+	CodeMethodNotAllowed = "method_not_allowed"
+	CodeRateLimited      = "rate_limited"
+	CodeInternal         = "internal_error"
+	CodeUnavailable      = "unavailable"
+	CodeTimeout          = "timeout"
+)
+
+// ErrorResponse là body của mọi response lỗi
+type ErrorResponse struct {
+	Error ErrorDetail `json:"error"`
+}
+
+// ErrorDetail mô tả lỗi
+type ErrorDetail struct {
+	// Code là mã lỗi ổn định cho máy đọc (not_found, rate_limited, ...)
+	Code string `json:"code"`
+
+	// Message là mô tả cho người đọc, có thể thay đổi
+	Message string `json:"message"`
+}
+
+// WriteJSON ghi data dạng JSON với status code
+func WriteJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
+
+// WriteError ghi lỗi JSON với code mặc định của status
+func WriteError(w http.ResponseWriter, status int, message string) {
+	WriteErrorCode(w, status, codeFor(status), message)
+}
+
+// WriteErrorCode ghi lỗi JSON với code cụ thể (ví dụ invalid_api_key)
+func WriteErrorCode(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Del("Content-Length")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	WriteJSON(w, status, ErrorResponse{Error: ErrorDetail{Code: code, Message: message}})
+}
+
+// NotFound trả 404 JSON (mount cho /api/ để route lạ không rơi vào static files)
+func NotFound(w http.ResponseWriter, r *http.Request) {
+	WriteError(w, http.StatusNotFound, "no route for "+r.Method+" "+r.URL.Path)
+}
+
+// codeFor trả về mã lỗi mặc định của status code
+func codeFor(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return CodeBadRequest
+	case http.StatusUnauthorized:
+		return CodeUnauthorized
+	case http.StatusForbidden:
+		return CodeForbidden
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusMethodNotAllowed:
+		return CodeMethodNotAllowed
+	case http.StatusTooManyRequests:
+		return CodeRateLimited
+	case http.StatusServiceUnavailable:
+		return CodeUnavailable
+	case http.StatusGatewayTimeout:
+		return CodeTimeout
+	}
+	if status >= http.StatusInternalServerError {
+		return CodeInternal
+	}
+	return CodeBadRequest
+}
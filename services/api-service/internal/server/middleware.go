@@ -0,0 +1,208 @@
+// =====================================================
+// MIDDLEWARE - Recovery, CORS, nén gzip, timeout
+// =====================================================
+// Mô tả: Middleware dùng chung cho các route /api/*
+// (request id, access log, metrics, tracing nằm ở package
+// logger/metrics/tracing, xem chain trong cmd/api)
+// =====================================================
+
+package server
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Recover bắt panic của handler: log kèm stack và trả 500
+// thay vì để net/http đóng connection
+func Recover(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			rec := recover()
+			if rec == nil {
+				return
+			}
+			if rec == http.ErrAbortHandler {
+				panic(rec)
+			}
+			slog.ErrorContext(r.Context(), "handler panic",
+				"path", r.URL.Path,
+				"panic", fmt.Sprint(rec),
+				"stack", string(debug.Stack()))
+			WriteError(w, http.StatusInternalServerError, "internal server error")
+		}()
+		next(w, r)
+	}
+}
+
+// CORSConfig cấu hình CORS
+type CORSConfig struct {
+	// AllowedOrigins: rỗng = chỉ same-origin, "*" = mọi origin
+	AllowedOrigins []string
+	AllowedHeaders []string
+	ExposedHeaders []string
+}
+
+// CORS thêm CORS headers cho origins được phép
+// Preflight (OPTIONS) trả 204 ngay, không vào handler (không cần API key)
+func CORS(cfg CORSConfig) func(http.HandlerFunc) http.HandlerFunc {
+	origins := make(map[string]bool, len(cfg.AllowedOrigins))
+	for _, o := range cfg.AllowedOrigins {
+		origins[strings.TrimRight(o, "/")] = true
+	}
+	allowHeaders := strings.Join(cfg.AllowedHeaders, ", ")
+	exposeHeaders := strings.Join(cfg.ExposedHeaders, ", ")
+
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if origin := r.Header.Get("Origin"); origin != "" {
+				w.Header().Add("Vary", "Origin")
+				switch {
+				case origins["*"]:
+					w.Header().Set("Access-Control-Allow-Origin", "*")
+				case origins[origin]:
+					w.Header().Set("Access-Control-Allow-Origin", origin)
+				}
+				w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
+				w.Header().Set("Access-Control-Allow-Headers", allowHeaders)
+				w.Header().Set("Access-Control-Expose-Headers", exposeHeaders)
+			}
+
+			if r.Method == http.MethodOptions {
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+
+			next(w, r)
+		}
+	}
+}
+
+// Timeout hủy context của request sau d và trả 503 (code timeout) nếu
+// handler chưa xong (query PostgreSQL/Redis dùng r.Context() nên dừng theo)
+func Timeout(d time.Duration) func(http.HandlerFunc) http.HandlerFunc {
+	body, _ := json.Marshal(ErrorResponse{Error: ErrorDetail{
+		Code:    CodeTimeout,
+		Message: "request timed out after " + d.String(),
+	}})
+
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		if d <= 0 {
+			return next
+		}
+		h := http.TimeoutHandler(next, d, string(body))
+		return func(w http.ResponseWriter, r *http.Request) {
+			// TimeoutHandler không đặt Content-Type cho body lỗi; khi handler
+			// xong kịp thì header của handler ghi đè giá trị này
+			w.Header().Set("Content-Type", "application/json")
+			h.ServeHTTP(w, r)
+		}
+	}
+}
+
+// gzipPool tái sử dụng gzip.Writer giữa các request
+var gzipPool = sync.Pool{
+	New: func() interface{} {
+		return gzip.NewWriter(io.Discard)
+	},
+}
+
+// gzipResponseWriter nén body khi handler ghi
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	gz          *gzip.Writer
+	wroteHeader bool
+	compress    bool
+}
+
+// WriteHeader quyết định có nén không (bỏ qua nếu handler đã tự nén
+// hoặc response không có body)
+func (w *gzipResponseWriter) WriteHeader(code int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+
+	h := w.Header()
+	w.compress = h.Get("Content-Encoding") == "" &&
+		code != http.StatusNoContent && code != http.StatusNotModified && code >= http.StatusOK
+	if w.compress {
+		h.Set("Content-Encoding", "gzip")
+		h.Del("Content-Length")
+		w.gz = gzipPool.Get().(*gzip.Writer)
+		w.gz.Reset(w.ResponseWriter)
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Write ghi body (nén nếu đã chọn nén)
+func (w *gzipResponseWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		if w.Header().Get("Content-Type") == "" {
+			w.Header().Set("Content-Type", http.DetectContentType(b))
+		}
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.compress {
+		return w.gz.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush đẩy dữ liệu đã nén ra client (response streaming)
+func (w *gzipResponseWriter) Flush() {
+	if w.gz != nil {
+		w.gz.Flush()
+	}
+	http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Unwrap cho http.ResponseController tới writer gốc
+func (w *gzipResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// close flush gzip và trả writer về pool
+func (w *gzipResponseWriter) close() {
+	if w.gz == nil {
+		return
+	}
+	w.gz.Close()
+	gzipPool.Put(w.gz)
+	w.gz = nil
+}
+
+// Compress nén response bằng gzip khi client gửi Accept-Encoding: gzip
+func Compress(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+		if r.Method == http.MethodHead || !acceptsGzip(r) {
+			next(w, r)
+			return
+		}
+
+		gw := &gzipResponseWriter{ResponseWriter: w}
+		defer gw.close()
+		next(gw, r)
+	}
+}
+
+// acceptsGzip kiểm tra Accept-Encoding có gzip (bỏ qua q=0)
+func acceptsGzip(r *http.Request) bool {
+	for _, part := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		enc, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if strings.TrimSpace(enc) != "gzip" {
+			continue
+		}
+		return strings.ReplaceAll(strings.TrimSpace(params), " ", "") != "q=0"
+	}
+	return false
+}
@@ -0,0 +1,120 @@
+package server
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// errorCode đọc mã lỗi trong body {"error": {"code": ...}}
+func errorCode(t *testing.T, w *httptest.ResponseRecorder) string {
+	t.Helper()
+	var body ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("body %q: %v", w.Body.String(), err)
+	}
+	return body.Error.Code
+}
+
+func TestRecover(t *testing.T) {
+	h := Recover(func(w http.ResponseWriter, r *http.Request) { panic("nil map") })
+	w := httptest.NewRecorder()
+	h(w, httptest.NewRequest(http.MethodGet, "/api/stats", nil))
+	if w.Code != http.StatusInternalServerError || errorCode(t, w) != CodeInternal {
+		t.Errorf("code = %d, body %s", w.Code, w.Body)
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	slow := Timeout(20 * time.Millisecond)(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	})
+	w := httptest.NewRecorder()
+	slow(w, httptest.NewRequest(http.MethodGet, "/api/trending", nil))
+	if w.Code != http.StatusServiceUnavailable || errorCode(t, w) != CodeTimeout ||
+		w.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("slow handler: code %d, type %q, body %s", w.Code, w.Header().Get("Content-Type"), w.Body)
+	}
+
+	fast := Timeout(time.Second)(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/csv")
+		io.WriteString(w, "a,b\n")
+	})
+	w = httptest.NewRecorder()
+	fast(w, httptest.NewRequest(http.MethodGet, "/api/stats", nil))
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "text/csv" || w.Body.String() != "a,b\n" {
+		t.Errorf("fast handler: code %d, type %q, body %q", w.Code, w.Header().Get("Content-Type"), w.Body)
+	}
+}
+
+func TestCORS(t *testing.T) {
+	called := false
+	h := CORS(CORSConfig{AllowedOrigins: []string{"https://dash.example/"}})(
+		func(w http.ResponseWriter, r *http.Request) { called = true })
+
+	tests := []struct {
+		name       string
+		method     string
+		origin     string
+		wantCode   int
+		wantOrigin string
+		wantCalled bool
+	}{
+		{"allowed origin", http.MethodGet, "https://dash.example", 200, "https://dash.example", true},
+		{"other origin", http.MethodGet, "https://evil.example", 200, "", true},
+		// Preflight trả lời ngay, không vào handler (không cần API key)
+		{"preflight", http.MethodOptions, "https://dash.example", 204, "https://dash.example", false},
+	}
+	for _, tt := range tests {
+		called = false
+		r := httptest.NewRequest(tt.method, "/api/stats", nil)
+		r.Header.Set("Origin", tt.origin)
+		w := httptest.NewRecorder()
+		h(w, r)
+		if w.Code != tt.wantCode || w.Header().Get("Access-Control-Allow-Origin") != tt.wantOrigin || called != tt.wantCalled {
+			t.Errorf("%s: code %d, origin %q, handler called %v", tt.name, w.Code,
+				w.Header().Get("Access-Control-Allow-Origin"), called)
+		}
+	}
+}
+
+func TestCompress(t *testing.T) {
+	body := strings.Repeat(`{"topic":"ai"}`, 100)
+	h := Compress(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, body)
+	})
+
+	for _, tt := range []struct {
+		accept string
+		gzip   bool
+	}{
+		{"gzip, deflate", true},
+		{"br;q=1.0, gzip;q=0.5", true},
+		{"gzip;q=0", false},
+		{"", false},
+	} {
+		r := httptest.NewRequest(http.MethodGet, "/api/topics", nil)
+		r.Header.Set("Accept-Encoding", tt.accept)
+		w := httptest.NewRecorder()
+		h(w, r)
+
+		got := w.Body.String()
+		if tt.gzip {
+			zr, err := gzip.NewReader(w.Body)
+			if err != nil {
+				t.Fatalf("%q: %v", tt.accept, err)
+			}
+			data, _ := io.ReadAll(zr)
+			got = string(data)
+		}
+		if (w.Header().Get("Content-Encoding") == "gzip") != tt.gzip || got != body {
+			t.Errorf("%q: Content-Encoding %q, body %d bytes", tt.accept, w.Header().Get("Content-Encoding"), len(got))
+		}
+	}
+}
@@ -0,0 +1,199 @@
+// =====================================================
+// ROUTER - Định tuyến theo method và path params
+// =====================================================
+// Mô tả: Router nhỏ thay cho http.DefaultServeMux:
+//   - Route = method + pattern, segment {name} là path param
+//     (GET /api/clusters/{id} → server.Param(r, "id"))
+//   - Path khớp nhưng sai method → 405 kèm header Allow
+//   - HEAD dùng route GET; OPTIONS đi vào chain của route cùng path
+//     (CORS middleware trả lời preflight)
+//   - Mount(prefix, handler) cho handler có sẵn (/metrics, static files),
+//     khớp prefix dài nhất khi không có route nào khớp
+//
+// Dùng:
+//   r := server.NewRouter()
+//   api := r.Group(metrics.Instrument, logger.Middleware)
+//   api.Handle(http.MethodGet, "/api/stories/{id}", handler)
+//   r.Mount("/", http.FileServer(http.Dir("web")))
+// =====================================================
+
+package server
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// Middleware bọc handler của một route
+// route là pattern đã đăng ký (label metrics, tên span, access log)
+type Middleware func(route string, next http.HandlerFunc) http.HandlerFunc
+
+// Plain dùng middleware không cần biết route như một Middleware
+func Plain(mw func(http.HandlerFunc) http.HandlerFunc) Middleware {
+	return func(_ string, next http.HandlerFunc) http.HandlerFunc {
+		return mw(next)
+	}
+}
+
+// route là một route đã đăng ký
+type route struct {
+	method   string
+	pattern  string
+	segments []string
+	handler  http.HandlerFunc
+}
+
+// mount là handler gắn theo prefix
+type mount struct {
+	prefix  string
+	handler http.Handler
+}
+
+// Router định tuyến request tới route/mount
+type Router struct {
+	routes []route
+	mounts []mount
+}
+
+// Group đăng ký routes với cùng middleware chain
+type Group struct {
+	router      *Router
+	middlewares []Middleware
+}
+
+// paramsKey là khóa context chứa path params
+type paramsKey struct{}
+
+// NewRouter tạo Router rỗng
+func NewRouter() *Router {
+	return &Router{}
+}
+
+// Handle đăng ký route không qua middleware
+func (rt *Router) Handle(method, pattern string, h http.HandlerFunc) {
+	rt.routes = append(rt.routes, route{
+		method:   method,
+		pattern:  pattern,
+		segments: splitPath(pattern),
+		handler:  h,
+	})
+}
+
+// Mount gắn handler cho mọi request có path bắt đầu bằng prefix
+func (rt *Router) Mount(prefix string, h http.Handler) {
+	rt.mounts = append(rt.mounts, mount{prefix: prefix, handler: h})
+	sort.SliceStable(rt.mounts, func(i, j int) bool {
+		return len(rt.mounts[i].prefix) > len(rt.mounts[j].prefix)
+	})
+}
+
+// Group tạo nhóm route dùng middlewares (phần tử đầu bọc ngoài cùng)
+func (rt *Router) Group(middlewares ...Middleware) *Group {
+	return &Group{router: rt, middlewares: middlewares}
+}
+
+// Handle đăng ký route, bọc handler bằng middleware chain của nhóm
+func (g *Group) Handle(method, pattern string, h http.HandlerFunc) {
+	g.router.Handle(method, pattern, g.Wrap(pattern, h))
+}
+
+// Wrap bọc h bằng middleware chain của nhóm với nhãn route
+// (dùng cho handler Mount, ví dụ 404 của /api/)
+func (g *Group) Wrap(route string, h http.HandlerFunc) http.HandlerFunc {
+	for i := len(g.middlewares) - 1; i >= 0; i-- {
+		h = g.middlewares[i](route, h)
+	}
+	return h
+}
+
+// ServeHTTP implement http.Handler
+func (rt *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	segments := splitPath(r.URL.Path)
+
+	var allowed []string
+	var fallback *route
+	var fallbackParams map[string]string
+	for i := range rt.routes {
+		rte := &rt.routes[i]
+		params, ok := match(rte.segments, segments)
+		if !ok {
+			continue
+		}
+		if rte.method == r.Method || (r.Method == http.MethodHead && rte.method == http.MethodGet) {
+			rt.serve(w, r, rte, params)
+			return
+		}
+		if fallback == nil {
+			fallback, fallbackParams = rte, params
+		}
+		allowed = append(allowed, rte.method)
+	}
+
+	if fallback != nil {
+		if r.Method == http.MethodOptions {
+			rt.serve(w, r, fallback, fallbackParams)
+			return
+		}
+		w.Header().Set("Allow", strings.Join(append(allowed, http.MethodOptions), ", "))
+		WriteError(w, http.StatusMethodNotAllowed, r.Method+" not allowed on "+r.URL.Path)
+		return
+	}
+
+	for _, m := range rt.mounts {
+		if strings.HasPrefix(r.URL.Path, m.prefix) {
+			m.handler.ServeHTTP(w, r)
+			return
+		}
+	}
+	NotFound(w, r)
+}
+
+// serve gọi handler của route, gắn path params vào context
+func (rt *Router) serve(w http.ResponseWriter, r *http.Request, rte *route, params map[string]string) {
+	if len(params) > 0 {
+		r = r.WithContext(context.WithValue(r.Context(), paramsKey{}, params))
+	}
+	rte.handler(w, r)
+}
+
+// Param lấy path param theo tên ("" nếu không có)
+func Param(r *http.Request, name string) string {
+	params, _ := r.Context().Value(paramsKey{}).(map[string]string)
+	return params[name]
+}
+
+// match so khớp segments của pattern với path
+func match(pattern, path []string) (map[string]string, bool) {
+	if len(pattern) != len(path) {
+		return nil, false
+	}
+	var params map[string]string
+	for i, seg := range pattern {
+		if strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "}") {
+			if path[i] == "" {
+				return nil, false
+			}
+			if params == nil {
+				params = make(map[string]string)
+			}
+			params[seg[1:len(seg)-1]] = path[i]
+			continue
+		}
+		if seg != path[i] {
+			return nil, false
+		}
+	}
+	return params, true
+}
+
+// splitPath tách "/api/stories/42" thành ["api", "stories", "42"]
+// (bỏ "/" cuối để /api/stats/ khớp /api/stats)
+func splitPath(p string) []string {
+	p = strings.Trim(p, "/")
+	if p == "" {
+		return nil
+	}
+	return strings.Split(p, "/")
+}
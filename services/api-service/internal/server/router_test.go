@@ -0,0 +1,97 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// newTestRouter đăng ký vài route ghi lại route/param đã khớp vào body
+func newTestRouter() *Router {
+	rt := NewRouter()
+	handle := func(name string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, "%s %s id=%s", r.Method, name, Param(r, "id"))
+		}
+	}
+	rt.Handle(http.MethodGet, "/api/stats", handle("stats"))
+	rt.Handle(http.MethodGet, "/api/stories/{id}", handle("story"))
+	rt.Handle(http.MethodGet, "/api/keys/{id}", handle("get key"))
+	rt.Handle(http.MethodDelete, "/api/keys/{id}", handle("delete key"))
+	rt.Mount("/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { fmt.Fprint(w, "static") }))
+	rt.Mount("/metrics", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { fmt.Fprint(w, "metrics") }))
+	return rt
+}
+
+func TestRouter(t *testing.T) {
+	tests := []struct {
+		name      string
+		method    string
+		path      string
+		wantCode  int
+		wantBody  string
+		wantAllow string
+	}{
+		{"static route", http.MethodGet, "/api/stats", 200, "GET stats id=", ""},
+		{"trailing slash", http.MethodGet, "/api/stats/", 200, "GET stats id=", ""},
+		{"path param", http.MethodGet, "/api/stories/42", 200, "GET story id=42", ""},
+		{"head uses get", http.MethodHead, "/api/stories/42", 200, "", ""},
+		{"second method", http.MethodDelete, "/api/keys/si_ab", 200, "DELETE delete key id=si_ab", ""},
+		{"wrong method", http.MethodPost, "/api/stats", 405, "", "GET, OPTIONS"},
+		{"wrong method, two routes", http.MethodPut, "/api/keys/7", 405, "", "GET, DELETE, OPTIONS"},
+		// Preflight đi vào chain của route đầu tiên cùng path (CORS trả lời)
+		{"options", http.MethodOptions, "/api/keys/7", 200, "OPTIONS get key id=7", ""},
+		{"missing param", http.MethodGet, "/api/stories/", 200, "static", ""},
+		{"longest mount", http.MethodGet, "/metrics", 200, "metrics", ""},
+		{"fallback mount", http.MethodGet, "/index.html", 200, "static", ""},
+	}
+	rt := newTestRouter()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			rt.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+			if w.Code != tt.wantCode {
+				t.Fatalf("code = %d, want %d", w.Code, tt.wantCode)
+			}
+			if tt.wantCode == http.StatusOK && tt.method != http.MethodHead && w.Body.String() != tt.wantBody {
+				t.Errorf("body = %q, want %q", w.Body.String(), tt.wantBody)
+			}
+			if got := w.Header().Get("Allow"); got != tt.wantAllow {
+				t.Errorf("Allow = %q, want %q", got, tt.wantAllow)
+			}
+		})
+	}
+}
+
+func TestRouterNotFound(t *testing.T) {
+	rt := NewRouter()
+	rt.Handle(http.MethodGet, "/api/stories/{id}", func(w http.ResponseWriter, r *http.Request) {})
+
+	w := httptest.NewRecorder()
+	rt.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/stories/42/comments", nil))
+	if w.Code != http.StatusNotFound || w.Header().Get("Allow") != "" {
+		t.Errorf("code = %d, Allow = %q", w.Code, w.Header().Get("Allow"))
+	}
+}
+
+func TestGroupMiddlewareOrder(t *testing.T) {
+	var calls []string
+	mw := func(name string) Middleware {
+		return func(route string, next http.HandlerFunc) http.HandlerFunc {
+			return func(w http.ResponseWriter, r *http.Request) {
+				calls = append(calls, name+" "+route)
+				next(w, r)
+			}
+		}
+	}
+	rt := NewRouter()
+	rt.Group(mw("outer"), mw("inner")).Handle(http.MethodGet, "/api/stories/{id}",
+		func(w http.ResponseWriter, r *http.Request) { calls = append(calls, "handler "+Param(r, "id")) })
+
+	rt.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/stories/9", nil))
+	want := []string{"outer /api/stories/{id}", "inner /api/stories/{id}", "handler 9"}
+	if fmt.Sprint(calls) != fmt.Sprint(want) {
+		t.Errorf("calls = %v, want %v", calls, want)
+	}
+}
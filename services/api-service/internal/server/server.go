@@ -0,0 +1,83 @@
+// =====================================================
+// HTTP SERVER - http.Server có timeout và graceful shutdown
+// =====================================================
+// Mô tả: Bọc http.Server với timeouts đọc/ghi/idle để client chậm
+// không giữ connection mãi, và Shutdown(ctx) chờ request đang chạy
+// xong trước khi main đóng Redis/PostgreSQL
+//
+// Dùng:
+//   srv := server.New(cfg.Server(), router)
+//   go srv.ListenAndServe()
+//   <-sigChan
+//   srv.Shutdown(ctx)   // rồi mới đóng DB
+// =====================================================
+
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Config cấu hình HTTP server
+type Config struct {
+	Addr string
+
+	ReadTimeout    time.Duration // Đọc toàn bộ request (header + body)
+	WriteTimeout   time.Duration // Từ lúc đọc xong header đến khi ghi xong response
+	IdleTimeout    time.Duration // Keep-alive giữa hai request
+	HandlerTimeout time.Duration // Thời gian tối đa của handler (middleware Timeout)
+}
+
+// Validate kiểm tra timeouts
+func (c Config) Validate() error {
+	if c.ReadTimeout <= 0 || c.WriteTimeout <= 0 || c.IdleTimeout <= 0 || c.HandlerTimeout <= 0 {
+		return fmt.Errorf("http server timeouts must be positive")
+	}
+	if c.HandlerTimeout >= c.WriteTimeout {
+		return fmt.Errorf("handler timeout (%s) must be shorter than write timeout (%s)",
+			c.HandlerTimeout, c.WriteTimeout)
+	}
+	return nil
+}
+
+// Server là HTTP server của API
+type Server struct {
+	srv *http.Server
+}
+
+// New tạo Server phục vụ handler
+func New(cfg Config, handler http.Handler) *Server {
+	return &Server{
+		srv: &http.Server{
+			Addr:              cfg.Addr,
+			Handler:           handler,
+			ReadHeaderTimeout: cfg.ReadTimeout,
+			ReadTimeout:       cfg.ReadTimeout,
+			WriteTimeout:      cfg.WriteTimeout,
+			IdleTimeout:       cfg.IdleTimeout,
+		},
+	}
+}
+
+// ListenAndServe chạy server đến khi Shutdown
+// Trả về nil khi dừng do Shutdown
+func (s *Server) ListenAndServe() error {
+	if err := s.srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// Shutdown ngừng nhận request mới và chờ request đang chạy xong
+// (hoặc ctx hết hạn, khi đó đóng hẳn các connection còn lại)
+func (s *Server) Shutdown(ctx context.Context) error {
+	err := s.srv.Shutdown(ctx)
+	if errors.Is(err, context.DeadlineExceeded) {
+		s.srv.Close()
+	}
+	return err
+}
@@ -0,0 +1,128 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// startStreamServer chạy Server với một route stream: ghi dòng đầu, báo
+// started rồi chờ release mới ghi dòng cuối (như export đang chạy)
+func startStreamServer(t *testing.T) (srv *Server, url string, started, release chan struct{}) {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("cannot listen: %v", err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+
+	started, release = make(chan struct{}), make(chan struct{})
+	rt := NewRouter()
+	rt.Handle(http.MethodGet, "/api/v1/export/posts", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "row 1")
+		w.(http.Flusher).Flush()
+		close(started)
+		<-release
+		fmt.Fprintln(w, "row 2")
+	})
+	srv = New(Config{Addr: addr, ReadTimeout: 5 * time.Second, WriteTimeout: 10 * time.Second,
+		IdleTimeout: time.Minute, HandlerTimeout: 5 * time.Second}, rt)
+
+	served := make(chan error, 1)
+	go func() { served <- srv.ListenAndServe() }()
+	t.Cleanup(func() {
+		srv.srv.Close()
+		if err := <-served; err != nil {
+			t.Errorf("ListenAndServe = %v", err)
+		}
+	})
+
+	// Chờ server nhận connection
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		conn, err := net.Dial("tcp", addr)
+		if err == nil {
+			conn.Close()
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("server not listening: %v", err)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	return srv, "http://" + addr + "/api/v1/export/posts", started, release
+}
+
+// fetch đọc toàn bộ body của url
+func fetch(url string, result chan<- string) {
+	resp, err := http.Get(url)
+	if err != nil {
+		result <- "error: " + err.Error()
+		return
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		result <- string(body) + "error: " + err.Error()
+		return
+	}
+	result <- string(body)
+}
+
+func TestShutdownDrainsStream(t *testing.T) {
+	srv, url, started, release := startStreamServer(t)
+	result := make(chan string, 1)
+	go fetch(url, result)
+	<-started
+
+	shutdown := make(chan error, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		shutdown <- srv.Shutdown(ctx)
+	}()
+
+	// Stream đang chạy: Shutdown chờ, không cắt response
+	select {
+	case err := <-shutdown:
+		t.Fatalf("Shutdown returned before stream finished: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	if err := <-shutdown; err != nil {
+		t.Fatalf("Shutdown = %v", err)
+	}
+	if body := <-result; body != "row 1\nrow 2\n" {
+		t.Errorf("body = %q", body)
+	}
+}
+
+func TestShutdownDeadlineClosesStream(t *testing.T) {
+	srv, url, started, release := startStreamServer(t)
+	defer close(release)
+	result := make(chan string, 1)
+	go fetch(url, result)
+	<-started
+
+	// Hết hạn: Shutdown đóng hẳn connection, client nhận response cụt
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := srv.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Shutdown = %v, want deadline exceeded", err)
+	}
+	select {
+	case body := <-result:
+		if body == "row 1\nrow 2\n" {
+			t.Errorf("stream completed after forced shutdown")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("connection not closed after shutdown deadline")
+	}
+}
@@ -0,0 +1,60 @@
+// =====================================================
+// HTTP TRACING MIDDLEWARE - Span server cho mỗi request
+// =====================================================
+// Mô tả: Đọc traceparent từ request (dashboard/proxy gửi kèm thì span
+// nối vào trace của client), mở span "<METHOD> <route>" và ghi status
+// =====================================================
+
+package tracing
+
+import (
+	"net/http"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// statusRecorder ghi lại status code handler trả về
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+// WriteHeader lưu status code rồi chuyển tiếp
+func (r *statusRecorder) WriteHeader(code int) {
+	r.status = code
+	r.ResponseWriter.WriteHeader(code)
+}
+
+// Unwrap cho http.ResponseController tới writer gốc (Flush, SetWriteDeadline)
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// Middleware mở span server cho request; span nằm trong r.Context()
+// nên query PostgreSQL/Redis của handler là span con
+// route là pattern đã đăng ký, giống label route của metrics
+func Middleware(route string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := Start(ctx, r.Method+" "+route,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				attribute.String("http.request.method", r.Method),
+				attribute.String("http.route", route),
+				attribute.String("url.path", r.URL.Path),
+			),
+		)
+		defer span.End()
+
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next(rec, r.WithContext(ctx))
+
+		span.SetAttributes(attribute.Int("http.response.status_code", rec.status))
+		if rec.status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(rec.status))
+		}
+	}
+}
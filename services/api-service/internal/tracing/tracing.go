@@ -0,0 +1,170 @@
+// =====================================================
+// TRACING - OpenTelemetry distributed tracing
+// =====================================================
+// Mô tả: Cấu hình TracerProvider dùng chung cho mọi binary
+//   - TRACING_EXPORTER: none (mặc định) | stdout | otlp
+//   - TRACING_ENDPOINT: OTLP/HTTP collector (host:4318 hoặc URL)
+//   - TRACING_SAMPLE_RATIO: tỉ lệ trace gốc được lấy mẫu (0..1)
+//
+// Span API nối với processing-service qua cùng collector; request
+// có header traceparent thì span handler là con của trace đó
+//
+// Dùng:
+//   shutdown, err := tracing.Setup(ctx, "api", cfg.Tracing())
+//   defer tracing.Close(shutdown)
+//   http.HandleFunc("/api/x", tracing.Middleware("/api/x", handler))
+// =====================================================
+
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"social-insight/internal/logger"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Các exporter hỗ trợ (TRACING_EXPORTER), giống processing-service
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterOTLP   = "otlp"
+)
+
+// tracerName là instrumentation scope của mọi span trong project
+const tracerName = "social-insight"
+
+// shutdownTimeout là thời gian tối đa chờ flush span khi thoát
+const shutdownTimeout = 5 * time.Second
+
+// Config cấu hình exporter và sampling
+type Config struct {
+	Exporter    string  // none | stdout | otlp
+	Endpoint    string  // OTLP/HTTP endpoint, ví dụ otel-collector:4318
+	SampleRatio float64 // 0..1, áp dụng cho trace gốc (span con theo parent)
+}
+
+// Validate kiểm tra giá trị config
+func (c Config) Validate() error {
+	switch c.Exporter {
+	case ExporterNone, ExporterStdout, ExporterOTLP:
+	default:
+		return fmt.Errorf("tracing exporter must be none, stdout or otlp")
+	}
+	if c.SampleRatio < 0 || c.SampleRatio > 1 {
+		return fmt.Errorf("tracing sample ratio must be between 0 and 1")
+	}
+	return nil
+}
+
+// Setup đặt TracerProvider và propagator toàn cục
+// Propagator luôn được đặt để traceparent vẫn được chuyển tiếp
+// qua Kafka/HTTP kể cả khi binary này không export span
+// Trả về hàm shutdown flush các span còn trong buffer
+func Setup(ctx context.Context, service string, cfg Config) (func(context.Context) error, error) {
+	setPropagator()
+
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch cfg.Exporter {
+	case ExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	case ExporterOTLP:
+		exporter, err = otlptracehttp.New(ctx, otlpOptions(cfg.Endpoint)...)
+	default:
+		return func(context.Context) error { return nil }, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("create %s exporter error: %w", cfg.Exporter, err)
+	}
+
+	return SetupWithExporter(service, exporter, cfg.SampleRatio), nil
+}
+
+// SetupWithExporter đặt TracerProvider toàn cục ghi span ra exporter
+func SetupWithExporter(service string, exporter sdktrace.SpanExporter, sampleRatio float64) func(context.Context) error {
+	setPropagator()
+
+	res, _ := resource.Merge(resource.Default(), resource.NewSchemaless(
+		attribute.String("service.name", service),
+	))
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(sampleRatio))),
+	)
+	otel.SetTracerProvider(tp)
+	return tp.Shutdown
+}
+
+// setPropagator đặt propagator W3C trace context + baggage
+func setPropagator() {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+}
+
+// Close gọi shutdown với timeout để collector chết không giữ process lại
+func Close(shutdown func(context.Context) error) {
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := shutdown(ctx); err != nil {
+		slog.Warn("tracing shutdown error", logger.Err(err))
+	}
+}
+
+// otlpOptions nhận endpoint dạng host:port (http) hoặc URL đầy đủ
+func otlpOptions(endpoint string) []otlptracehttp.Option {
+	if endpoint == "" {
+		return nil // Dùng OTEL_EXPORTER_OTLP_* hoặc mặc định localhost:4318
+	}
+	if strings.Contains(endpoint, "://") {
+		return []otlptracehttp.Option{otlptracehttp.WithEndpointURL(endpoint)}
+	}
+	return []otlptracehttp.Option{otlptracehttp.WithEndpoint(endpoint), otlptracehttp.WithInsecure()}
+}
+
+// Tracer trả về tracer dùng chung
+func Tracer() trace.Tracer {
+	return otel.Tracer(tracerName)
+}
+
+// Start mở span con của span trong ctx (hoặc span gốc nếu chưa có)
+func Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, name, opts...)
+}
+
+// End kết thúc span; nếu *err != nil thì ghi lỗi và đặt status Error
+// Nhận con trỏ để dùng với named return: defer tracing.End(span, &err)
+func End(span trace.Span, err *error) {
+	if err != nil && *err != nil {
+		span.RecordError(*err)
+		span.SetStatus(codes.Error, (*err).Error())
+	}
+	span.End()
+}
+
+// Inject ghi context của span hiện tại vào carrier (HTTP headers)
+func Inject(ctx context.Context, carrier propagation.TextMapCarrier) {
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+}
+
+// Extract đọc context từ carrier, trả về ctx con của ctx đã cho
+func Extract(ctx context.Context, carrier propagation.TextMapCarrier) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, carrier)
+}
@@ -0,0 +1,22 @@
+-- =====================================================
+-- MIGRATION: Near-duplicate clustering
+-- =====================================================
+-- Mô tả: Lưu title gốc và link canonical_post_id cho các bài
+-- cross-post giữa nhiều nguồn (phát hiện bằng SimHash ở crawler)
+-- =====================================================
+
+-- Title gốc của bài viết (content có thể là description)
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS title TEXT;
+
+-- ID của post gốc nếu bài này là near-duplicate, NULL nếu là bài gốc
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS canonical_post_id VARCHAR(50);
+
+-- Index để gom cluster theo post gốc
+CREATE INDEX IF NOT EXISTS idx_posts_canonical_post_id
+    ON posts(canonical_post_id)
+    WHERE canonical_post_id IS NOT NULL;
+
+DO $$
+BEGIN
+    RAISE NOTICE '✅ Migration completed: posts.title and posts.canonical_post_id added!';
+END $$;
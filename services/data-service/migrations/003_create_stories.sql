@@ -0,0 +1,35 @@
+-- =====================================================
+-- MIGRATION: Story clustering theo canonical URL
+-- =====================================================
+-- Mô tả: Gom các bài trên nhiều nền tảng cùng trỏ về một link
+-- (sau khi chuẩn hóa URL) thành một story
+-- =====================================================
+
+-- =====================================================
+-- BẢNG STORIES - Mỗi canonical URL là một story
+-- =====================================================
+CREATE TABLE IF NOT EXISTS stories (
+    id BIGSERIAL PRIMARY KEY,
+
+    -- URL đã chuẩn hóa (bỏ tracking params, AMP, mobile host...)
+    canonical_url TEXT NOT NULL UNIQUE,
+
+    -- Lần đầu/cuối thấy link này trên bất kỳ nền tảng nào
+    first_seen_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    last_seen_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- =====================================================
+-- POSTS - Thêm URL và liên kết tới story
+-- =====================================================
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS url TEXT;
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS canonical_url TEXT;
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS story_id BIGINT REFERENCES stories(id);
+
+-- Index để lấy tất cả posts của một story
+CREATE INDEX IF NOT EXISTS idx_posts_story_id ON posts(story_id) WHERE story_id IS NOT NULL;
+
+DO $$
+BEGIN
+    RAISE NOTICE '✅ Migration completed: Table stories created, posts linked by canonical URL!';
+END $$;
@@ -0,0 +1,47 @@
+-- =====================================================
+-- MIGRATION: Tạo bảng dead_letters
+-- =====================================================
+-- Mô tả: Lưu các message không xử lý được (poison message,
+-- lỗi validation, lỗi lưu DB) để điều tra và replay sau khi sửa
+-- =====================================================
+
+CREATE TABLE IF NOT EXISTS dead_letters (
+    id BIGSERIAL PRIMARY KEY,
+
+    -- Message gốc (bytes nguyên vẹn để replay)
+    payload BYTEA NOT NULL,
+    message_key TEXT,
+
+    -- Vị trí gốc trên Kafka (-1 nếu lỗi trước khi lên Kafka)
+    source_topic VARCHAR(255) NOT NULL,
+    source_partition INTEGER NOT NULL DEFAULT -1,
+    source_offset BIGINT NOT NULL DEFAULT -1,
+
+    -- Stage lỗi: validation, unmarshal, handle
+    stage VARCHAR(50) NOT NULL,
+    error TEXT NOT NULL,
+
+    -- Thành phần sinh lỗi: consumer, crawler:hn, ...
+    producer VARCHAR(100),
+
+    failed_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+
+    -- Replay
+    replayed_at TIMESTAMP WITH TIME ZONE,
+    replay_count INTEGER NOT NULL DEFAULT 0
+);
+
+-- Mỗi vị trí Kafka chỉ lưu một lần (archiver có thể đọc lại message)
+CREATE UNIQUE INDEX IF NOT EXISTS idx_dead_letters_source_position
+    ON dead_letters(source_topic, source_partition, source_offset)
+    WHERE source_offset >= 0;
+
+-- Index để chọn dead letters chưa replay theo stage
+CREATE INDEX IF NOT EXISTS idx_dead_letters_pending
+    ON dead_letters(stage, failed_at)
+    WHERE replayed_at IS NULL;
+
+DO $$
+BEGIN
+    RAISE NOTICE '✅ Migration completed: Table dead_letters created!';
+END $$;
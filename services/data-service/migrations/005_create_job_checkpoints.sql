@@ -0,0 +1,32 @@
+-- =====================================================
+-- MIGRATION: Checkpoint cho các job xử lý lại dữ liệu
+-- =====================================================
+-- Mô tả: Lệnh replay (chế độ postgres) lưu vị trí đã xử lý
+-- sau mỗi batch để chạy tiếp sau khi bị dừng
+-- =====================================================
+
+CREATE TABLE IF NOT EXISTS job_checkpoints (
+    -- Tên job (ví dụ: enrich-2024-06)
+    job_name TEXT PRIMARY KEY,
+
+    -- Post ID cuối cùng đã xử lý (keyset pagination theo posts.id)
+    cursor TEXT NOT NULL DEFAULT '',
+
+    -- Số posts đã đọc / đã cập nhật
+    processed BIGINT NOT NULL DEFAULT 0,
+    updated BIGINT NOT NULL DEFAULT 0,
+
+    -- Version luật enrichment khi chạy job
+    enrichment_version INTEGER NOT NULL DEFAULT 0,
+
+    started_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+
+    -- NULL khi job chưa chạy xong
+    completed_at TIMESTAMP WITH TIME ZONE
+);
+
+DO $$
+BEGIN
+    RAISE NOTICE '✅ Migration completed: Table job_checkpoints created!';
+END $$;
@@ -0,0 +1,15 @@
+-- =====================================================
+-- MIGRATION: Trace id cho posts
+-- =====================================================
+-- Mô tả: Lưu trace_id của event post.created để nối một post
+-- trong PostgreSQL với log của crawler và consumer
+-- =====================================================
+
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS trace_id TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_posts_trace_id ON posts(trace_id) WHERE trace_id IS NOT NULL;
+
+DO $$
+BEGIN
+    RAISE NOTICE '✅ Migration completed: posts.trace_id added!';
+END $$;
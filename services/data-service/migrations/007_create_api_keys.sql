@@ -0,0 +1,37 @@
+-- =====================================================
+-- MIGRATION: API keys
+-- =====================================================
+-- Mô tả: Khóa truy cập API. Chỉ lưu SHA-256 của key, key gốc
+-- chỉ hiện một lần khi tạo (cmd/apikey). Scopes giới hạn
+-- nhóm endpoint key được gọi; request_count đếm số lần dùng
+-- =====================================================
+
+CREATE TABLE IF NOT EXISTS api_keys (
+    id BIGSERIAL PRIMARY KEY,
+
+    -- Tên gợi nhớ (ví dụ: grafana, partner-acme)
+    name TEXT NOT NULL,
+
+    -- Phần đầu của key (không bí mật), dùng để nhận diện trong log/list
+    prefix TEXT NOT NULL UNIQUE,
+
+    -- SHA-256 (hex) của toàn bộ key
+    key_hash TEXT NOT NULL UNIQUE,
+
+    -- Ví dụ: {read:posts,read:analytics}
+    scopes TEXT[] NOT NULL DEFAULT '{}',
+
+    -- Usage counters, API cộng dồn định kỳ
+    request_count BIGINT NOT NULL DEFAULT 0,
+    last_used_at TIMESTAMP WITH TIME ZONE,
+
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+
+    -- NULL khi key còn hiệu lực
+    revoked_at TIMESTAMP WITH TIME ZONE
+);
+
+DO $$
+BEGIN
+    RAISE NOTICE '✅ Migration completed: Table api_keys created!';
+END $$;
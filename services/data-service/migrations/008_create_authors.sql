@@ -0,0 +1,75 @@
+-- =====================================================
+-- MIGRATION: Author profiles
+-- =====================================================
+-- Mô tả: Tác giả được nhận diện theo (platform, handle) thay vì
+-- chuỗi author thô ("john" trên HN khác "John Smith" trên Dev.to).
+-- Bảng authors là thống kê của từng tác giả, consumer tính lại từ
+-- posts trong cùng transaction mỗi khi ghi/cập nhật posts của họ
+-- =====================================================
+
+-- =====================================================
+-- POSTS - Handle của tác giả
+-- =====================================================
+-- Crawler gửi username ổn định (HN: by, Dev.to: username, Medium:
+-- @username); posts cũ dùng tên tác giả chữ thường. Posts Dev.to cũ
+-- vì vậy có handle là tên hiển thị, khác handle của posts mới
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS author_handle TEXT;
+UPDATE posts SET author_handle = lower(ltrim(btrim(author), '@')) WHERE author_handle IS NULL;
+
+-- Index để tính lại thống kê và lấy posts của một tác giả
+CREATE INDEX IF NOT EXISTS idx_posts_author ON posts(platform, author_handle, created_at DESC);
+
+-- =====================================================
+-- BẢNG AUTHORS - Mỗi (platform, handle) là một tác giả
+-- =====================================================
+CREATE TABLE IF NOT EXISTS authors (
+    platform VARCHAR(50) NOT NULL,
+    handle TEXT NOT NULL,
+
+    -- Tên hiển thị của post mới nhất
+    display_name TEXT NOT NULL,
+
+    -- created_at của post cũ nhất/mới nhất
+    first_seen_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    last_seen_at TIMESTAMP WITH TIME ZONE NOT NULL,
+
+    post_count BIGINT NOT NULL DEFAULT 0,
+    total_likes BIGINT NOT NULL DEFAULT 0,
+    total_comments BIGINT NOT NULL DEFAULT 0,
+    total_shares BIGINT NOT NULL DEFAULT 0,
+
+    -- Số posts theo topic, ví dụ {"ai": 3, "cloud": 1}
+    topic_counts JSONB NOT NULL DEFAULT '{}',
+
+    -- Trung bình sentiment: positive = 1, neutral = 0, negative = -1
+    avg_sentiment DOUBLE PRECISION NOT NULL DEFAULT 0,
+
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+
+    PRIMARY KEY (platform, handle)
+);
+
+-- Xếp hạng theo số posts / engagement
+CREATE INDEX IF NOT EXISTS idx_authors_post_count ON authors(post_count DESC);
+CREATE INDEX IF NOT EXISTS idx_authors_engagement ON authors((total_likes + total_comments + total_shares) DESC);
+
+-- Thống kê của posts đã có
+INSERT INTO authors (platform, handle, display_name, first_seen_at, last_seen_at,
+    post_count, total_likes, total_comments, total_shares, topic_counts, avg_sentiment)
+SELECT p.platform, p.author_handle,
+    (array_agg(p.author ORDER BY p.created_at DESC))[1],
+    MIN(p.created_at), MAX(p.created_at), COUNT(*),
+    COALESCE(SUM(p.likes), 0), COALESCE(SUM(p.comments), 0), COALESCE(SUM(p.shares), 0),
+    (SELECT jsonb_object_agg(t.topic, t.n) FROM (
+        SELECT topic, COUNT(*) AS n FROM posts
+        WHERE platform = p.platform AND author_handle = p.author_handle
+        GROUP BY topic) t),
+    AVG(CASE p.sentiment WHEN 'positive' THEN 1 WHEN 'negative' THEN -1 ELSE 0 END)
+FROM posts p
+GROUP BY p.platform, p.author_handle
+ON CONFLICT (platform, handle) DO NOTHING;
+
+DO $$
+BEGIN
+    RAISE NOTICE '✅ Migration completed: Table authors created, posts.author_handle backfilled!';
+END $$;
@@ -0,0 +1,81 @@
+-- =====================================================
+-- MIGRATION: Author influence và đồ thị tác giả – topic/keyword
+-- =====================================================
+-- Mô tả: Cạnh tác giả → topic và tác giả → keyword cho
+-- GET /api/v1/graph/authors, cùng số tuần hoạt động để tính độ
+-- đều đặn. Consumer tính lại các bảng này trong cùng transaction
+-- với bảng authors (xem 008_create_authors.sql)
+-- =====================================================
+
+-- =====================================================
+-- POSTS - Keywords của tiêu đề
+-- =====================================================
+-- Consumer điền khi enrich (enrichment v2); posts cũ là NULL cho
+-- tới khi chạy lệnh replay -source postgres với job mới
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS keywords TEXT[];
+
+-- =====================================================
+-- AUTHORS - Số tuần có post (độ đều đặn)
+-- =====================================================
+ALTER TABLE authors ADD COLUMN IF NOT EXISTS active_weeks INT NOT NULL DEFAULT 0;
+
+UPDATE authors a SET active_weeks = w.weeks
+FROM (
+    SELECT platform, author_handle, COUNT(DISTINCT date_trunc('week', created_at)) AS weeks
+    FROM posts
+    GROUP BY platform, author_handle
+) w
+WHERE a.platform = w.platform AND a.handle = w.author_handle;
+
+-- Cùng handle trên các nền tảng khác (độ phủ của tác giả)
+CREATE INDEX IF NOT EXISTS idx_authors_handle ON authors(handle);
+
+-- =====================================================
+-- BẢNG AUTHOR_TOPICS - Cạnh tác giả → topic
+-- =====================================================
+CREATE TABLE IF NOT EXISTS author_topics (
+    platform VARCHAR(50) NOT NULL,
+    handle TEXT NOT NULL,
+    topic VARCHAR(50) NOT NULL,
+
+    post_count BIGINT NOT NULL DEFAULT 0,
+
+    -- likes + comments + shares của các posts trong topic
+    engagement BIGINT NOT NULL DEFAULT 0,
+
+    last_post_at TIMESTAMP WITH TIME ZONE NOT NULL,
+
+    PRIMARY KEY (platform, handle, topic),
+    FOREIGN KEY (platform, handle) REFERENCES authors(platform, handle) ON DELETE CASCADE
+);
+
+-- Tác giả của một topic
+CREATE INDEX IF NOT EXISTS idx_author_topics_topic ON author_topics(topic);
+
+-- =====================================================
+-- BẢNG AUTHOR_KEYWORDS - Cạnh tác giả → keyword (theo topic)
+-- =====================================================
+CREATE TABLE IF NOT EXISTS author_keywords (
+    platform VARCHAR(50) NOT NULL,
+    handle TEXT NOT NULL,
+    topic VARCHAR(50) NOT NULL,
+    keyword TEXT NOT NULL,
+
+    -- Số posts trong topic có keyword trong tiêu đề
+    post_count BIGINT NOT NULL DEFAULT 0,
+
+    PRIMARY KEY (platform, handle, topic, keyword),
+    FOREIGN KEY (platform, handle) REFERENCES authors(platform, handle) ON DELETE CASCADE
+);
+
+-- Cạnh của các posts đã có (keywords có sau khi replay)
+INSERT INTO author_topics (platform, handle, topic, post_count, engagement, last_post_at)
+SELECT platform, author_handle, topic, COUNT(*), COALESCE(SUM(likes + comments + shares), 0), MAX(created_at)
+FROM posts
+GROUP BY platform, author_handle, topic
+ON CONFLICT (platform, handle, topic) DO NOTHING;
+
+DO $$
+BEGIN
+    RAISE NOTICE '✅ Migration completed: Tables author_topics, author_keywords created!';
+END $$;
@@ -0,0 +1,77 @@
+-- =====================================================
+-- MIGRATION: Topic taxonomy và phân loại nhiều topic
+-- =====================================================
+-- Mô tả: Mỗi post có thể thuộc nhiều topic/subtopic kèm điểm
+-- (bảng post_topics); posts.topic giữ topic chính. Danh sách topic
+-- do consumer đồng bộ từ file taxonomy khi khởi động (bảng topics)
+-- =====================================================
+
+-- =====================================================
+-- POSTS - Tag của bài trên nền tảng
+-- =====================================================
+-- Dev.to tag_list, tag/categories của feed Medium (tín hiệu phân loại)
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS tags TEXT[];
+
+-- =====================================================
+-- BẢNG TOPICS - Cây topic của taxonomy đang dùng
+-- =====================================================
+CREATE TABLE IF NOT EXISTS topics (
+    id VARCHAR(50) PRIMARY KEY,
+
+    -- Topic cấp 1 chứa subtopic (NULL nếu là topic cấp 1)
+    parent VARCHAR(50),
+
+    name TEXT NOT NULL,
+
+    -- Thứ tự trong file taxonomy
+    position INT NOT NULL DEFAULT 0,
+
+    -- Version của file taxonomy lần đồng bộ gần nhất
+    taxonomy_version INT NOT NULL DEFAULT 0,
+
+    -- FALSE: topic đã bị bỏ khỏi taxonomy (posts cũ vẫn giữ nhãn)
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Topics cố định trước khi có taxonomy (consumer cập nhật tên/thứ tự)
+INSERT INTO topics (id, name, position) VALUES
+    ('ai', 'AI & Machine Learning', 0),
+    ('cloud', 'Cloud Computing', 1),
+    ('devops', 'DevOps', 2),
+    ('programming', 'Programming', 3),
+    ('startup', 'Startups & Business', 4)
+ON CONFLICT (id) DO NOTHING;
+
+-- =====================================================
+-- BẢNG POST_TOPICS - Topics của từng post
+-- =====================================================
+CREATE TABLE IF NOT EXISTS post_topics (
+    post_id VARCHAR(50) NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+    topic VARCHAR(50) NOT NULL,
+
+    -- Topic cấp 1 của subtopic (NULL nếu topic là topic cấp 1)
+    parent VARCHAR(50),
+
+    -- Tổng trọng số luật/tag khớp (0 = fallback của taxonomy)
+    score REAL NOT NULL DEFAULT 0,
+
+    -- TRUE cho đúng một dòng mỗi post: topic chính (= posts.topic)
+    is_primary BOOLEAN NOT NULL DEFAULT FALSE,
+
+    PRIMARY KEY (post_id, topic)
+);
+
+-- Lọc posts theo topic và đếm posts mỗi topic
+CREATE INDEX IF NOT EXISTS idx_post_topics_topic ON post_topics(topic, post_id);
+
+-- Posts đã có: topic cũ thành topic chính (điểm có sau khi replay)
+INSERT INTO post_topics (post_id, topic, is_primary)
+SELECT id, topic, TRUE FROM posts
+ON CONFLICT (post_id, topic) DO NOTHING;
+
+DO $$
+BEGIN
+    RAISE NOTICE '✅ Migration completed: Tables topics, post_topics created!';
+END $$;
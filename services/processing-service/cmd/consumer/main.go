@@ -11,7 +11,10 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"log/slog"
+	"net/http"
 	"os"
 	"os/signal"
 	"sync/atomic"
@@ -20,87 +23,202 @@ import (
 
 	"social-insight/config"
 	"social-insight/internal/database"
+	"social-insight/internal/deadletter"
+	"social-insight/internal/enrichment"
+	"social-insight/internal/events"
+	"social-insight/internal/health"
 	"social-insight/internal/kafka"
+	"social-insight/internal/logger"
+	"social-insight/internal/metrics"
 	"social-insight/internal/models"
+	"social-insight/internal/monitor"
 	redisclient "social-insight/internal/redis"
+	"social-insight/internal/taxonomy"
+	"social-insight/internal/tracing"
+	"social-insight/internal/urlnorm"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
-// PostHandler xử lý posts nhận từ Kafka
-type PostHandler struct {
-	redis     *redisclient.Client
-	db        *database.DB
-	batchSize int
+// sharedDeps là các kết nối và worker pool dùng chung giữa các partitions
+type sharedDeps struct {
+	redis *redisclient.Client
+	db    *database.DB
+	pool  *enrichment.Pool
 
-	// Buffer để batch insert
-	buffer []models.Post
+	// invalidator gộp các lần làm response cache của API hết hiệu lực
+	invalidator *redisclient.Invalidator
 
-	// Counter
+	// processedCount đếm posts đã lưu của cả process (atomic)
 	processedCount int64
 }
 
-// HandlePost xử lý một post
-func (h *PostHandler) HandlePost(post models.Post) error {
-	// 1. Cache vào Redis (TTL 1 giờ)
-	if err := h.redis.CachePost(post, time.Hour); err != nil {
-		fmt.Printf("⚠️  Redis cache error: %v\n", err)
+// PostHandler xử lý batch posts của MỘT partition
+// Mỗi partition được assign có instance riêng (tạo bởi newHandlerFactory) nên
+// không có state chia sẻ ngoài các kết nối thread-safe trong sharedDeps.
+// Offset chỉ commit sau khi HandleBatch trả về nil
+type PostHandler struct {
+	*sharedDeps
+	partition int32
+}
+
+// newHandlerFactory trả về kafka.HandlerFactory tạo PostHandler cho từng partition
+func newHandlerFactory(deps *sharedDeps) kafka.HandlerFactory {
+	return func(topic string, partition int32) kafka.BatchHandler {
+		slog.Info("partition assigned", "topic", topic, "partition", partition)
+		return &PostHandler{sharedDeps: deps, partition: partition}
 	}
+}
 
-	// 2. Cập nhật counters trong Redis
-	h.redis.IncrementCounter("posts:total")
-	h.redis.IncrementCounter(fmt.Sprintf("posts:%s", post.Topic))
-	h.redis.IncrementCounter(fmt.Sprintf("sentiment:%s", post.Sentiment))
+// HandleBatch lưu batch vào PostgreSQL rồi cập nhật Redis
+// Redis chỉ cập nhật sau khi ghi DB thành công để retry không đếm trùng
+// ctx mang span consumer.flush, các span enrich/postgres/redis là con của nó
+func (h *PostHandler) HandleBatch(ctx context.Context, posts []models.Post) error {
+	db := h.db.WithContext(ctx)
+	rdb := h.redis.WithContext(ctx)
+
+	// 0. Điền topics, sentiment, keywords còn trống song song trên worker pool
+	// (giữ topic/keywords crawler đã gán; chỉ lệnh replay mới tính lại tất cả)
+	_, span := tracing.Start(ctx, "consumer.enrich", trace.WithAttributes(attribute.Int("posts", len(posts))))
+	h.pool.FillAll(posts)
+	span.End()
+
+	// Chuẩn hóa handle tác giả (bảng authors) và URL để gom story theo link
+	for i := range posts {
+		posts[i].AuthorHandle = posts[i].Handle()
+		if posts[i].URL == "" || posts[i].CanonicalURL != "" {
+			continue
+		}
+		canonical, err := urlnorm.Canonicalize(posts[i].URL)
+		if err != nil {
+			slog.Warn("cannot canonicalize url", "post_id", posts[i].ID, logger.KeyTraceID, posts[i].TraceID, logger.Err(err))
+		}
+		posts[i].CanonicalURL = canonical
+	}
 
-	// 3. Thêm vào recent posts
-	h.redis.AddToRecentPosts(post)
+	// 1. Batch insert vào PostgreSQL (cùng transaction: stories, authors)
+	ids, err := db.InsertPosts(posts)
+	if err != nil {
+		return fmt.Errorf("batch insert error: %w", err)
+	}
+	slog.Info("batch saved", "partition", h.partition, "posts", len(posts), "inserted", len(ids))
 
-	// 4. Thêm vào buffer để batch insert
-	h.buffer = append(h.buffer, post)
+	// Chỉ posts thực sự được thêm mới cập nhật Redis: batch bị redeliver
+	// (posts đã có, ON CONFLICT bỏ qua) không đếm lại counters
+	inserted := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		inserted[id] = true
+	}
+	counters := map[string]int64{"posts:total": int64(len(ids))}
+	topics := make(map[string]int64)
+	for _, post := range posts {
+		if !inserted[post.ID] {
+			continue
+		}
+		// 2. Cache vào Redis (TTL 1 giờ)
+		if err := rdb.CachePost(post, time.Hour); err != nil {
+			slog.Warn("redis cache error", "post_id", post.ID, logger.KeyTraceID, post.TraceID, logger.Err(err))
+		}
+		slog.Debug("post saved", "post_id", post.ID, logger.KeyTraceID, post.TraceID, "partition", h.partition)
 
-	// 5. Flush nếu đủ batch size
-	if len(h.buffer) >= h.batchSize {
-		if err := h.flush(); err != nil {
-			return err
+		// Gom counters của cả batch, cập nhật một lần ở bước 3
+		for _, t := range post.Topics {
+			if t.Parent == "" {
+				topics[t.Topic]++
+			}
 		}
+		counters[fmt.Sprintf("sentiment:%s", post.Sentiment)]++
+
+		// 4. Thêm vào recent posts
+		rdb.AddToRecentPosts(post)
+	}
+
+	// 3. Cập nhật counters trong Redis (một pipeline cho cả batch)
+	if err := rdb.IncrementCounters(counters); err != nil {
+		slog.Warn("redis counters error", logger.Err(err))
+	}
+	if err := rdb.IncrementTopicCounters(topics); err != nil {
+		slog.Warn("redis topic counters error", logger.Err(err))
 	}
 
-	atomic.AddInt64(&h.processedCount, 1)
+	// 5. Response cache của API hết hiệu lực (tối đa một lần mỗi interval)
+	if _, err := h.invalidator.Invalidate(ctx); err != nil {
+		slog.Warn("api cache invalidation error", logger.Err(err))
+	}
+
+	atomic.AddInt64(&h.processedCount, int64(len(posts)))
 	return nil
 }
 
-// flush lưu buffer vào PostgreSQL
-func (h *PostHandler) flush() error {
-	if len(h.buffer) == 0 {
-		return nil
-	}
+// redisLagReporter ghi lag từng partition vào Redis để API/monitor đọc
+type redisLagReporter struct {
+	redis *redisclient.Client
+	group string
+	host  string
+}
 
-	if err := h.db.InsertPosts(h.buffer); err != nil {
-		return fmt.Errorf("batch insert error: %w", err)
+// ReportLag implement kafka.LagReporter
+func (r *redisLagReporter) ReportLag(lag kafka.PartitionLag) {
+	data, err := json.Marshal(struct {
+		kafka.PartitionLag
+		Host string `json:"host"`
+	}{lag, r.host})
+	if err != nil {
+		return
+	}
+	if err := r.redis.SetConsumerLag(r.group, lag.Partition, data, 2*time.Minute); err != nil {
+		slog.Warn("redis lag report error", "partition", lag.Partition, logger.Err(err))
 	}
+}
 
-	fmt.Printf("💾 Saved %d posts to PostgreSQL\n", len(h.buffer))
-	h.buffer = h.buffer[:0] // Clear buffer
-	return nil
+// consumerHealth là body của GET /health và snapshot ghi vào Redis cho API
+type consumerHealth struct {
+	Status string `json:"status"` // ok | lagging
+	Group  string `json:"group"`
+	Host   string `json:"host"`
+	kafka.MetricsSnapshot
 }
 
-func main() {
-	fmt.Println("╔════════════════════════════════════════════════════════════╗")
-	fmt.Println("║     SOCIAL INSIGHT - DATA CONSUMER                         ║")
-	fmt.Println("║     Kafka → Redis + PostgreSQL                             ║")
-	fmt.Println("╚════════════════════════════════════════════════════════════╝")
-	fmt.Println()
+// healthOf tính trạng thái consumer từ metrics
+func healthOf(consumer *kafka.Consumer, group, host string, maxLag int64) consumerHealth {
+	h := consumerHealth{
+		Status:          "ok",
+		Group:           group,
+		Host:            host,
+		MetricsSnapshot: consumer.Metrics(),
+	}
+	if maxLag > 0 && h.TotalLag > maxLag {
+		h.Status = "lagging"
+	}
+	return h
+}
 
+func main() {
 	// Load config
 	if err := config.LoadEnvFile(".env"); err != nil {
-		fmt.Printf("⚠️  Warning: %v\n", err)
+		slog.Warn("cannot load .env", logger.Err(err))
 	}
 	cfg, err := config.Load()
 	if err != nil {
-		fmt.Printf("❌ Config error: %v\n", err)
+		slog.Error("config error", logger.Err(err))
+		os.Exit(1)
+	}
+	logger.Setup("consumer", cfg.LogLevel, cfg.LogFormat)
+	if err := cfg.Validate(); err != nil {
+		slog.Error("invalid config", logger.Err(err))
 		os.Exit(1)
 	}
-	cfg.Validate()
+	slog.Info("starting consumer", "pipeline", "kafka → redis + postgres")
 	cfg.LogConfig()
 
+	shutdownTracing, err := tracing.Setup(context.Background(), "consumer", cfg.Tracing())
+	if err != nil {
+		slog.Error("tracing setup error", logger.Err(err))
+		os.Exit(1)
+	}
+	defer tracing.Close(shutdownTracing)
+
 	// Context để graceful shutdown
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -110,17 +228,15 @@ func main() {
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 
 	// ====== BƯỚC 1: Kết nối Redis ======
-	fmt.Println("📡 Đang kết nối Redis...")
 	redisClient, err := redisclient.NewClient(cfg.RedisAddr)
 	if err != nil {
-		fmt.Printf("❌ Lỗi Redis: %v\n", err)
+		slog.Error("redis connect error", "addr", cfg.RedisAddr, logger.Err(err))
 		os.Exit(1)
 	}
 	defer redisClient.Close()
-	fmt.Println("✅ Đã kết nối Redis")
+	slog.Info("connected to redis", "addr", cfg.RedisAddr)
 
 	// ====== BƯỚC 2: Kết nối PostgreSQL ======
-	fmt.Println("📡 Đang kết nối PostgreSQL...")
 	db, err := database.NewDB(database.Config{
 		Host:     cfg.PGHost,
 		Port:     cfg.PGPort,
@@ -129,56 +245,125 @@ func main() {
 		DBName:   cfg.PGDBName,
 	})
 	if err != nil {
-		fmt.Printf("❌ Lỗi PostgreSQL: %v\n", err)
+		slog.Error("postgres connect error", "host", cfg.PGHost, logger.Err(err))
 		os.Exit(1)
 	}
 	defer db.Close()
-	fmt.Println("✅ Đã kết nối PostgreSQL")
+	slog.Info("connected to postgres", "host", cfg.PGHost, "db", cfg.PGDBName)
 
 	// ====== BƯỚC 3: Tạo Handler ======
-	handler := &PostHandler{
-		redis:     redisClient,
-		db:        db,
-		batchSize: cfg.ConsumerBatchSize,
-		buffer:    make([]models.Post, 0, cfg.ConsumerBatchSize),
+	// Taxonomy topic: đồng bộ cây topic vào PostgreSQL cho API
+	tax, err := taxonomy.Load(cfg.TaxonomyFile)
+	if err != nil {
+		slog.Error("taxonomy error", logger.Err(err))
+		os.Exit(1)
+	}
+	if err := db.SyncTopics(tax); err != nil {
+		slog.Error("sync topics error", logger.Err(err))
+		os.Exit(1)
+	}
+	slog.Info("taxonomy loaded", "version", tax.Version, "topics", len(tax.Nodes()), "fallback", tax.Fallback)
+
+	// Worker pool enrich dùng chung cho tất cả partitions của process này
+	pool := enrichment.NewPool(enrichment.New(tax), cfg.ConsumerWorkers)
+	defer pool.Close()
+	deps := &sharedDeps{
+		redis: redisClient,
+		db:    db,
+		pool:  pool,
+		invalidator: redisclient.NewInvalidator(func(ctx context.Context) error {
+			return redisClient.WithContext(ctx).InvalidateAPICache()
+		}, cfg.CacheInvalidateInterval),
 	}
 
 	// ====== BƯỚC 4: Tạo Kafka Consumer ======
-	fmt.Println("📡 Đang kết nối Kafka...")
-	consumer, err := kafka.NewConsumer(
+	dlqProducer, err := kafka.NewProducer(cfg.KafkaBrokers, cfg.DLQTopic)
+	if err != nil {
+		slog.Error("kafka producer error", "topic", cfg.DLQTopic, logger.Err(err))
+		os.Exit(1)
+	}
+	defer dlqProducer.Close()
+
+	consumer, err := kafka.NewBatchConsumer(
 		cfg.KafkaBrokers,
 		cfg.ConsumerGroup,
 		cfg.KafkaTopic,
-		handler,
+		newHandlerFactory(deps),
+		deadletter.NewPublisher(dlqProducer, cfg.DLQTopic, db),
+		kafka.BatchConfig{
+			Size:     cfg.ConsumerBatchSize,
+			Interval: cfg.ConsumerFlushInterval,
+			Retries:  3,
+		},
 	)
 	if err != nil {
-		fmt.Printf("❌ Lỗi Kafka: %v\n", err)
+		slog.Error("kafka consumer error", "topic", cfg.KafkaTopic, logger.Err(err))
 		os.Exit(1)
 	}
 	defer consumer.Close()
-	fmt.Printf("✅ Đã subscribe topic: %s\n\n", cfg.KafkaTopic)
 
-	// ====== BƯỚC 5: Bắt đầu consume ======
-	fmt.Println("👂 Đang lắng nghe messages từ Kafka...")
-	fmt.Println("   Nhấn Ctrl+C để dừng")
-	fmt.Println()
+	// Decode envelope JSON/Avro theo schemas trong registry
+	codec, err := events.Open(cfg.EventEncoding, cfg.SchemaRegistryDir)
+	if err != nil {
+		slog.Error("event codec error", logger.Err(err))
+		os.Exit(1)
+	}
+	consumer.SetEventCodec(codec)
 
-	// Goroutine để flush định kỳ
-	go func() {
-		ticker := time.NewTicker(cfg.ConsumerFlushInterval)
-		defer ticker.Stop()
+	// Mỗi replica tự báo lag các partitions của nó
+	hostname, _ := os.Hostname()
+	if hostname == "" {
+		hostname = "consumer"
+	}
+	consumer.SetLagReporter(&redisLagReporter{redis: redisClient, group: cfg.ConsumerGroup, host: hostname})
 
-		for {
-			select {
-			case <-ctx.Done():
-				return
-			case <-ticker.C:
-				handler.flush()
-			}
-		}
-	}()
+	slog.Info("subscribed", "topic", cfg.KafkaTopic, "group", cfg.ConsumerGroup, "host", hostname)
+
+	// Archiver lưu dead letters (từ consumer và crawlers) vào PostgreSQL
+	dlqArchiver, err := kafka.NewMessageConsumer(
+		cfg.KafkaBrokers,
+		cfg.ConsumerGroup+"_dlq_archiver",
+		cfg.DLQTopic,
+		deadletter.NewArchiver(db),
+	)
+	if err != nil {
+		slog.Error("kafka consumer error", "topic", cfg.DLQTopic, logger.Err(err))
+		os.Exit(1)
+	}
+	defer dlqArchiver.Close()
+	slog.Info("subscribed", "topic", cfg.DLQTopic, "group", cfg.ConsumerGroup+"_dlq_archiver")
 
-	// Goroutine để in stats định kỳ
+	// ====== BƯỚC 5: Bắt đầu consume ======
+
+	// Dependency checks: consumer cần cả PostgreSQL, Redis và Kafka
+	kafkaPinger := kafka.NewPinger(cfg.KafkaBrokers, cfg.KafkaTopic)
+	defer kafkaPinger.Close()
+	checker := health.NewChecker(cfg.HealthCheckTimeout)
+	checker.Add("postgres", true, db.Ping)
+	checker.Add("redis", true, redisClient.Ping)
+	checker.Add("kafka", true, kafkaPinger.Ping)
+
+	// Health endpoint: GET /health trả về lag, throughput, latency, batch size
+	// GET /healthz, /readyz kiểm tra dependencies; GET /metrics cho Prometheus
+	var monitorServer *monitor.Server
+	if cfg.ConsumerHealthAddr != "" {
+		monitorServer = monitor.NewServer(cfg.ConsumerHealthAddr)
+		monitorServer.HandleJSON("/health", func() (interface{}, int) {
+			return healthOf(consumer, cfg.ConsumerGroup, hostname, cfg.ConsumerMaxLag), http.StatusOK
+		})
+		monitorServer.HandleJSON("/healthz", func() (interface{}, int) {
+			return checker.Run(context.Background()), http.StatusOK
+		})
+		monitorServer.HandleJSON("/readyz", func() (interface{}, int) {
+			report := checker.Run(context.Background())
+			return report, report.HTTPStatus()
+		})
+		monitorServer.Handle("/metrics", metrics.Handler())
+		monitorServer.Start()
+		slog.Info("monitor server started", "addr", cfg.ConsumerHealthAddr, "paths", "/health,/healthz,/readyz,/metrics")
+	}
+
+	// Goroutine để in stats định kỳ và ghi snapshot vào Redis cho API
 	go func() {
 		ticker := time.NewTicker(5 * time.Second)
 		defer ticker.Stop()
@@ -188,38 +373,60 @@ func main() {
 			case <-ctx.Done():
 				return
 			case <-ticker.C:
-				count := atomic.LoadInt64(&handler.processedCount)
-				fmt.Printf("📊 Total processed: %d posts\n", count)
+				health := healthOf(consumer, cfg.ConsumerGroup, hostname, cfg.ConsumerMaxLag)
+				slog.Info("consumer stats",
+					"processed", atomic.LoadInt64(&deps.processedCount),
+					"lag", health.TotalLag,
+					"partitions", len(health.Partitions),
+					"throughput_per_sec", health.Throughput,
+					"latency_p95_ms", health.LatencyMs.P95)
+
+				if data, err := json.Marshal(health); err == nil {
+					if err := redisClient.SetConsumerMetrics(cfg.ConsumerGroup, hostname, data, time.Minute); err != nil {
+						slog.Warn("redis metrics error", logger.Err(err))
+					}
+				}
 			}
 		}
 	}()
 
 	// Goroutine để consume
+	consumerDone := make(chan struct{})
 	go func() {
+		defer close(consumerDone)
 		if err := consumer.Start(ctx); err != nil {
-			fmt.Printf("❌ Consumer error: %v\n", err)
+			slog.Error("consumer stopped", logger.Err(err))
+		}
+	}()
+
+	// Goroutine để archive dead letters
+	go func() {
+		if err := dlqArchiver.Start(ctx); err != nil {
+			slog.Error("dlq archiver stopped", logger.Err(err))
 		}
 	}()
 
 	// Đợi signal
 	<-sigChan
-	fmt.Println("\n⚠️  Nhận tín hiệu dừng, đang shutdown...")
+	slog.Info("shutdown signal received")
 
-	// Cancel context
+	// Cancel context: mỗi partition flush batch còn lại và commit offset
 	cancel()
+	<-consumerDone
 
-	// Flush remaining buffer
-	handler.flush()
+	// Chờ dead letters đang gửi nhận ack
+	flushCtx, flushCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	if monitorServer != nil {
+		monitorServer.Shutdown(flushCtx)
+	}
+	if err := dlqProducer.Flush(flushCtx); err != nil {
+		slog.Warn("dlq flush error", logger.Err(err))
+	}
+	flushCancel()
 
 	// In kết quả cuối
-	finalCount := atomic.LoadInt64(&handler.processedCount)
+	finalCount := atomic.LoadInt64(&deps.processedCount)
 	dbCount, _ := db.GetPostCount()
 
-	fmt.Println()
-	fmt.Println("╔════════════════════════════════════════════════════════════╗")
-	fmt.Println("║                     KẾT QUẢ                                ║")
-	fmt.Println("╠════════════════════════════════════════════════════════════╣")
-	fmt.Printf("║  📝 Tổng posts đã xử lý: %d\n", finalCount)
-	fmt.Printf("║  💾 Posts trong PostgreSQL: %d\n", dbCount)
-	fmt.Println("╚════════════════════════════════════════════════════════════╝")
+	slog.Info("consumer stopped", "processed", finalCount, "posts_in_db", dbCount)
 }
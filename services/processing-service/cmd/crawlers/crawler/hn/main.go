@@ -9,7 +9,8 @@
 package main
 
 import (
-	"fmt"
+	"context"
+	"log/slog"
 	"os"
 	"os/signal"
 	"sync/atomic"
@@ -18,57 +19,71 @@ import (
 
 	"social-insight/config"
 	"social-insight/internal/crawler"
+	"social-insight/internal/deadletter"
+	"social-insight/internal/dedup"
+	"social-insight/internal/events"
 	"social-insight/internal/kafka"
+	"social-insight/internal/logger"
+	"social-insight/internal/metrics"
+	"social-insight/internal/monitor"
 	"social-insight/internal/redis"
+	"social-insight/internal/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 func main() {
-	fmt.Println("╔════════════════════════════════════════════════════════════╗")
-	fmt.Println("║     HACKERNEWS CRAWLER                                     ║")
-	fmt.Println("║     Crawl top stories mỗi 5 phút                           ║")
-	fmt.Println("╚════════════════════════════════════════════════════════════╝")
-	fmt.Println()
-
-	// Load configuration
+	// Load config
 	if err := config.LoadEnvFile(".env"); err != nil {
-		fmt.Printf("⚠️  Warning: %v\n", err)
+		slog.Warn("cannot load .env", logger.Err(err))
 	}
 	cfg, err := config.Load()
 	if err != nil {
-		fmt.Printf("❌ Config error: %v\n", err)
+		slog.Error("config error", logger.Err(err))
 		os.Exit(1)
 	}
 	if err := cfg.Validate(); err != nil {
-		fmt.Printf("❌ Validation error: %v\n", err)
+		slog.Error("config validation error", logger.Err(err))
 		os.Exit(1)
 	}
+	logger.Setup("crawler:hn", cfg.LogLevel, cfg.LogFormat)
 	cfg.LogConfig()
 
+	shutdownTracing, err := tracing.Setup(context.Background(), "crawler:hn", cfg.Tracing())
+	if err != nil {
+		slog.Error("tracing setup error", logger.Err(err))
+		os.Exit(1)
+	}
+	defer tracing.Close(shutdownTracing)
+
 	// Bắt signal để graceful shutdown
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 
 	// ====== BƯỚC 1: Tạo Kafka Producer ======
-	fmt.Println("📡 Đang kết nối Kafka...")
 	producer, err := kafka.NewProducer(cfg.KafkaBrokers, cfg.KafkaTopic)
 	if err != nil {
-		fmt.Printf("❌ Kafka error: %v\n", err)
-		fmt.Println("   Hãy chắc chắn Kafka đang chạy: docker-compose up -d")
+		slog.Error("kafka connect error", "brokers", cfg.KafkaBrokers, logger.Err(err))
 		os.Exit(1)
 	}
 	defer producer.Close()
-	fmt.Printf("✅ Kafka ready\n\n")
+	codec, err := events.Open(cfg.EventEncoding, cfg.SchemaRegistryDir)
+	if err != nil {
+		slog.Error("event codec error", logger.Err(err))
+		os.Exit(1)
+	}
+	producer.SetEventCodec(codec, "crawler:hn")
+	slog.Info("kafka ready", "topic", cfg.KafkaTopic, "encoding", codec.Encoding())
 
 	// ====== BƯỚC 2: Tạo Redis Client ======
-	fmt.Println("🔴 Đang kết nối Redis...")
 	redisClient, err := redis.NewClient(cfg.RedisAddr)
 	if err != nil {
-		fmt.Printf("❌ Redis error: %v\n", err)
-		fmt.Println("   Hãy chắc chắn Redis đang chạy: docker-compose up -d")
+		slog.Error("redis connect error", "addr", cfg.RedisAddr, logger.Err(err))
 		os.Exit(1)
 	}
 	defer redisClient.Close()
-	fmt.Printf("✅ Redis ready\n\n")
+	slog.Info("redis ready", "addr", cfg.RedisAddr)
 
 	// ====== BƯỚC 3: Tạo BaseCrawler ======
 	baseCrawler := crawler.NewBaseCrawler(
@@ -77,16 +92,32 @@ func main() {
 		"hn",
 		cfg.KafkaTopic,
 	)
+	contentHashes, err := dedup.NewHashSet(cfg.DedupHashMode, redisClient,
+		int64(cfg.DedupBloomCapacity), cfg.DedupBloomFPRate)
+	if err != nil {
+		slog.Error("dedup error", logger.Err(err))
+		os.Exit(1)
+	}
+	baseCrawler.SetContentHashSet(contentHashes)
+	baseCrawler.SetDeadLetterSink(deadletter.NewPublisher(producer, cfg.DLQTopic, nil))
+	if cfg.NearDupEnabled {
+		baseCrawler.SetNearDuplicateDetector(dedup.NewDetector(redisClient, cfg.NearDupThreshold, cfg.NearDupTTL))
+	}
 
 	// ====== BƯỚC 4: Tạo HN Crawler ======
 	hnCrawler := crawler.NewHackerNewsCrawler(baseCrawler, cfg.HNStoriesLimit)
 
+	// Prometheus metrics: GET /metrics
+	if cfg.MetricsAddr != "" {
+		metricsServer := monitor.NewServer(cfg.MetricsAddr)
+		metricsServer.Handle("/metrics", metrics.Handler())
+		metricsServer.Start()
+		defer metricsServer.Shutdown(context.Background())
+		slog.Info("metrics server listening", "addr", cfg.MetricsAddr)
+	}
+
 	// ====== BƯỚC 5: Chạy crawl loop ======
-	fmt.Println("🚀 Bắt đầu crawling...")
-	fmt.Printf("   Interval: %v, Limit: %d stories\n", cfg.HNCrawlInterval, cfg.HNStoriesLimit)
-	fmt.Println("   Nhấn Ctrl+C để dừng")
-	fmt.Println()
-	fmt.Println("════════════════════════════════════════════════════════════")
+	slog.Info("crawler started", "interval", cfg.HNCrawlInterval, "stories_limit", cfg.HNStoriesLimit)
 
 	// Counters
 	var totalSent int64
@@ -96,7 +127,7 @@ func main() {
 	// Channel để dừng goroutines
 	stopChan := make(chan struct{})
 
-	// Goroutine in stats mỗi 10 giây
+	// Goroutine log stats mỗi 10 giây
 	go func() {
 		ticker := time.NewTicker(10 * time.Second)
 		defer ticker.Stop()
@@ -109,11 +140,20 @@ func main() {
 				sent := atomic.LoadInt64(&totalSent)
 				skipped := atomic.LoadInt64(&totalSkipped)
 				elapsed := time.Since(startTime).Seconds()
-				rate := float64(sent) / elapsed
-
-				fmt.Printf("📊 [%s] Sent: %d | Skipped: %d | Rate: %.1f/s\n",
-					time.Now().Format("15:04:05"),
-					sent, skipped, rate)
+				dd := baseCrawler.DedupStats()
+
+				slog.Info("crawler stats",
+					"sent", sent,
+					"skipped", skipped,
+					"rate_per_sec", float64(sent)/elapsed,
+					slog.Group("dedup",
+						"content_hash", dd.ContentHash,
+						"source_id", dd.SourceID,
+						"near_dup", dd.NearDup,
+						"validation", dd.Validation,
+						"errors", dd.Errors,
+					),
+				)
 			}
 		}
 	}()
@@ -125,27 +165,40 @@ func main() {
 		defer ticker.Stop()
 
 		for running {
-			// Crawl
-			fmt.Printf("\n🔍 [%s] Starting crawl...\n", time.Now().Format("15:04:05"))
-
-			posts, err := hnCrawler.Fetch()
+			// Mỗi lần crawl có run_id riêng, gắn vào mọi log của lần đó
+			// và là một trace: crawler.run → fetch → process_and_send → Kafka
+			runID := events.NewTraceID()
+			ctx := logger.WithRunID(context.Background(), runID)
+			ctx, span := tracing.Start(ctx, "crawler.run", trace.WithAttributes(
+				attribute.String("source", hnCrawler.Name()),
+				attribute.String(logger.KeyRunID, runID),
+			))
+			slog.InfoContext(ctx, "crawl started")
+
+			posts, err := crawler.FetchTraced(ctx, hnCrawler)
 			if err != nil {
-				fmt.Printf("❌ Fetch error: %v\n", err)
+				metrics.CrawlRuns.WithLabelValues(hnCrawler.Name(), "error").Inc()
+				slog.ErrorContext(ctx, "fetch error", logger.Err(err))
+				tracing.End(span, &err)
 				<-ticker.C
 				continue
 			}
 
 			// Process & send
-			sent, skipped, err := baseCrawler.ProcessAndSend(posts)
+			sent, skipped, err := baseCrawler.ProcessAndSend(ctx, posts)
 			if err != nil {
-				fmt.Printf("❌ Process error: %v\n", err)
+				metrics.CrawlRuns.WithLabelValues(hnCrawler.Name(), "error").Inc()
+				slog.ErrorContext(ctx, "process error", logger.Err(err))
+			} else {
+				metrics.CrawlRuns.WithLabelValues(hnCrawler.Name(), "ok").Inc()
 			}
 
+			tracing.End(span, &err)
+
 			atomic.AddInt64(&totalSent, int64(sent))
 			atomic.AddInt64(&totalSkipped, int64(skipped))
 
-			fmt.Printf("✅ Crawl complete: %d sent, %d skipped (duplicates)\n",
-				sent, skipped)
+			slog.InfoContext(ctx, "crawl complete", "fetched", len(posts), "sent", sent, "skipped", skipped)
 
 			// Wait for next interval
 			<-ticker.C
@@ -157,20 +210,27 @@ func main() {
 	running = false
 	close(stopChan)
 
+	// Chờ các messages đang gửi nhận ack trước khi thoát
+	flushCtx, flushCancel := context.WithTimeout(context.Background(), 10*time.Second)
+	if err := producer.Flush(flushCtx); err != nil {
+		slog.Warn("producer flush incomplete", logger.Err(err))
+	}
+	flushCancel()
 
 	// ====== KẾT THÚC ======
-	fmt.Println("\n")
-	fmt.Println("════════════════════════════════════════════════════════════")
 	elapsed := time.Since(startTime)
 	sent := atomic.LoadInt64(&totalSent)
-	skipped := atomic.LoadInt64(&totalSkipped)
-
-	fmt.Println("╔════════════════════════════════════════════════════════════╗")
-	fmt.Println("║              HACKERNEWS CRAWLER KẾT QUẢ                    ║")
-	fmt.Println("╠════════════════════════════════════════════════════════════╣")
-	fmt.Printf("║  ✅ Đã gửi: %d posts\n", sent)
-	fmt.Printf("║  ⏭️  Đã bỏ qua: %d (duplicates)\n", skipped)
-	fmt.Printf("║  ⏱️  Thời gian chạy: %s\n", elapsed.Round(time.Second))
-	fmt.Printf("║  🚀 Tốc độ: %.1f posts/phút\n", float64(sent)*60/elapsed.Seconds())
-	fmt.Println("╚════════════════════════════════════════════════════════════╝")
+	dd := baseCrawler.DedupStats()
+	slog.Info("crawler stopped",
+		"sent", sent,
+		"skipped", atomic.LoadInt64(&totalSkipped),
+		"uptime", elapsed.Round(time.Second).String(),
+		"posts_per_min", float64(sent)*60/elapsed.Seconds(),
+		slog.Group("dedup",
+			"mode", contentHashes.Mode(),
+			"content_hash", dd.ContentHash,
+			"source_id", dd.SourceID,
+			"near_dup", dd.NearDup,
+		),
+	)
 }
@@ -0,0 +1,161 @@
+// =====================================================
+// REPLAY DLQ - Gửi lại dead letters vào raw_posts
+// =====================================================
+// Mô tả: Chọn dead letters trong PostgreSQL theo ID/stage/thời gian
+// và publish lại payload gốc vào topic raw_posts sau khi đã sửa lỗi
+//
+// Cách chạy:
+//   go run ./cmd/replay-dlq -stage handle -since 24h -dry-run
+//   go run ./cmd/replay-dlq -ids 12,13,20
+// =====================================================
+
+package main
+
+import (
+	"context"
+	"flag"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"social-insight/config"
+	"social-insight/internal/database"
+	"social-insight/internal/kafka"
+	"social-insight/internal/logger"
+	"social-insight/internal/tracing"
+)
+
+func main() {
+	ids := flag.String("ids", "", "Danh sách dead letter ID, phân cách bằng dấu phẩy")
+	stage := flag.String("stage", "", "Chỉ replay stage này (validation, unmarshal, handle)")
+	since := flag.String("since", "", "Chỉ replay lỗi trong khoảng này (ví dụ 24h) hoặc từ thời điểm RFC3339")
+	limit := flag.Int("limit", 100, "Số dead letters tối đa")
+	includeReplayed := flag.Bool("include-replayed", false, "Replay cả dead letters đã replay trước đó")
+	topic := flag.String("topic", "", "Topic đích (mặc định KAFKA_TOPIC)")
+	dryRun := flag.Bool("dry-run", false, "Chỉ in danh sách, không gửi")
+	flag.Parse()
+
+	// Load config
+	if err := config.LoadEnvFile(".env"); err != nil {
+		slog.Warn("cannot load .env", logger.Err(err))
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		slog.Error("config error", logger.Err(err))
+		os.Exit(1)
+	}
+	if err := cfg.Validate(); err != nil {
+		slog.Error("config validation error", logger.Err(err))
+		os.Exit(1)
+	}
+	logger.Setup("replay-dlq", cfg.LogLevel, cfg.LogFormat)
+
+	shutdownTracing, err := tracing.Setup(context.Background(), "replay-dlq", cfg.Tracing())
+	if err != nil {
+		slog.Error("tracing setup error", logger.Err(err))
+		os.Exit(1)
+	}
+	defer tracing.Close(shutdownTracing)
+
+	filter := database.DeadLetterFilter{
+		Stage:           *stage,
+		IncludeReplayed: *includeReplayed,
+		Limit:           *limit,
+	}
+	if filter.IDs, err = parseIDs(*ids); err != nil {
+		slog.Error("invalid -ids", logger.Err(err))
+		os.Exit(1)
+	}
+	if filter.Since, err = parseSince(*since); err != nil {
+		slog.Error("invalid -since", logger.Err(err))
+		os.Exit(1)
+	}
+	target := *topic
+	if target == "" {
+		target = cfg.KafkaTopic
+	}
+
+	// ====== Kết nối PostgreSQL ======
+	db, err := database.NewDB(database.Config{
+		Host:     cfg.PGHost,
+		Port:     cfg.PGPort,
+		User:     cfg.PGUser,
+		Password: cfg.PGPassword,
+		DBName:   cfg.PGDBName,
+	})
+	if err != nil {
+		slog.Error("postgres connect error", logger.Err(err))
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	letters, err := db.GetDeadLetters(filter)
+	if err != nil {
+		slog.Error("query dead letters error", logger.Err(err))
+		os.Exit(1)
+	}
+	slog.Info("dead letters found", "count", len(letters), "dry_run", *dryRun)
+	for _, dl := range letters {
+		slog.Info("dead letter", "id", dl.ID, "stage", dl.Stage, "topic", dl.SourceTopic,
+			"partition", dl.Partition, "offset", dl.Offset, "key", dl.Key, "error", dl.Error)
+	}
+	if *dryRun || len(letters) == 0 {
+		return
+	}
+
+	// ====== Kết nối Kafka ======
+	producer, err := kafka.NewProducer(cfg.KafkaBrokers, target)
+	if err != nil {
+		slog.Error("kafka connect error", logger.Err(err))
+		os.Exit(1)
+	}
+	defer producer.Close()
+
+	replayed := 0
+	for _, dl := range letters {
+		if err := producer.SendRaw(target, dl.Key, dl.Payload); err != nil {
+			slog.Error("replay dead letter error", "id", dl.ID, logger.Err(err))
+			continue
+		}
+		if err := db.MarkDeadLetterReplayed(dl.ID); err != nil {
+			slog.Warn("mark replayed error", "id", dl.ID, logger.Err(err))
+		}
+		replayed++
+	}
+
+	slog.Info("dead letters replayed", "replayed", replayed, "total", len(letters), "topic", target)
+}
+
+// parseIDs parse "1,2,3" thành []int64
+func parseIDs(s string) ([]int64, error) {
+	if s == "" {
+		return nil, nil
+	}
+	parts := strings.Split(s, ",")
+	ids := make([]int64, 0, len(parts))
+	for _, p := range parts {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		id, err := strconv.ParseInt(p, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// parseSince nhận duration (24h) hoặc thời điểm RFC3339
+func parseSince(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
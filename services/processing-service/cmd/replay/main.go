@@ -0,0 +1,197 @@
+// =====================================================
+// REPLAY - Xử lý lại lịch sử sau khi đổi enrichment
+// =====================================================
+// Mô tả: Hai chế độ
+//   kafka:    đọc lại raw_posts theo khoảng thời gian/offset bằng
+//             consumer group riêng, enrich lại và ghi PostgreSQL
+//   postgres: chạy lại enrichment trên bảng posts theo batch,
+//             checkpoint trong job_checkpoints để chạy tiếp khi bị dừng
+//
+// Cách chạy:
+//   go run ./cmd/replay -source kafka -from 72h
+//   go run ./cmd/replay -source kafka -from-offset 1000 -to-offset 5000 -partitions 0
+//   go run ./cmd/replay -source postgres -job enrich-v3-taxonomy-v1
+// =====================================================
+
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"social-insight/config"
+	"social-insight/internal/database"
+	"social-insight/internal/enrichment"
+	"social-insight/internal/events"
+	"social-insight/internal/logger"
+	"social-insight/internal/models"
+	"social-insight/internal/replay"
+	"social-insight/internal/taxonomy"
+	"social-insight/internal/tracing"
+	"social-insight/internal/urlnorm"
+)
+
+// replayHandler ghi posts đọc lại từ Kafka vào PostgreSQL
+// Post chưa có thì insert, post đã có thì cập nhật topics/sentiment/keywords
+type replayHandler struct {
+	db       *database.DB
+	enricher *enrichment.Enricher
+}
+
+// HandleBatch enrich rồi insert/update batch
+func (h *replayHandler) HandleBatch(ctx context.Context, posts []models.Post) error {
+	db := h.db.WithContext(ctx)
+	for i := range posts {
+		h.enricher.Enrich(&posts[i])
+		if posts[i].URL != "" && posts[i].CanonicalURL == "" {
+			posts[i].CanonicalURL, _ = urlnorm.Canonicalize(posts[i].URL)
+		}
+	}
+	if _, err := db.InsertPosts(posts); err != nil {
+		return fmt.Errorf("batch insert error: %w", err)
+	}
+	return db.UpdateEnrichment(posts)
+}
+
+func main() {
+	source := flag.String("source", "", "Nguồn replay: kafka hoặc postgres")
+	batch := flag.Int("batch", 500, "Số posts mỗi batch")
+
+	// Kafka
+	from := flag.String("from", "", "kafka: bắt đầu từ thời điểm RFC3339 hoặc khoảng lùi (ví dụ 72h)")
+	to := flag.String("to", "", "kafka: dừng tại thời điểm RFC3339 hoặc khoảng lùi (mặc định: offset mới nhất lúc bắt đầu)")
+	fromOffset := flag.Int64("from-offset", -1, "kafka: offset bắt đầu (ưu tiên hơn -from)")
+	toOffset := flag.Int64("to-offset", -1, "kafka: offset dừng, không bao gồm (ưu tiên hơn -to)")
+	partitions := flag.String("partitions", "", "kafka: danh sách partition, phân cách bằng dấu phẩy (mặc định: tất cả)")
+	group := flag.String("group", "", "kafka: consumer group lưu tiến độ (mặc định CONSUMER_GROUP_replay)")
+	resume := flag.Bool("resume", false, "kafka: tiếp tục từ offset đã commit của group")
+
+	// Postgres
+	job := flag.String("job", "", "postgres: tên job (khóa checkpoint, mặc định enrich-v{enrichment}-taxonomy-v{taxonomy})")
+	restart := flag.Bool("restart", false, "postgres: bỏ checkpoint cũ, chạy lại từ đầu")
+	dryRun := flag.Bool("dry-run", false, "postgres: chỉ đếm rows sẽ thay đổi")
+	flag.Parse()
+
+	// Load config
+	if err := config.LoadEnvFile(".env"); err != nil {
+		slog.Warn("cannot load .env", logger.Err(err))
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		slog.Error("config error", logger.Err(err))
+		os.Exit(1)
+	}
+	if err := cfg.Validate(); err != nil {
+		slog.Error("config validation error", logger.Err(err))
+		os.Exit(1)
+	}
+	logger.Setup("replay", cfg.LogLevel, cfg.LogFormat)
+
+	shutdownTracing, err := tracing.Setup(context.Background(), "replay", cfg.Tracing())
+	if err != nil {
+		slog.Error("tracing setup error", logger.Err(err))
+		os.Exit(1)
+	}
+	defer tracing.Close(shutdownTracing)
+
+	// Ctrl+C dừng sau batch hiện tại, tiến độ đã lưu
+	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+
+	// ====== Kết nối PostgreSQL ======
+	db, err := database.NewDB(database.Config{
+		Host:     cfg.PGHost,
+		Port:     cfg.PGPort,
+		User:     cfg.PGUser,
+		Password: cfg.PGPassword,
+		DBName:   cfg.PGDBName,
+	})
+	if err != nil {
+		slog.Error("postgres connect error", logger.Err(err))
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	// Taxonomy cùng file với consumer; bảng topics khớp nhãn sẽ ghi
+	tax, err := taxonomy.Load(cfg.TaxonomyFile)
+	if err != nil {
+		slog.Error("taxonomy error", logger.Err(err))
+		os.Exit(1)
+	}
+	if err := db.SyncTopics(tax); err != nil {
+		slog.Error("sync topics error", logger.Err(err))
+		os.Exit(1)
+	}
+	if *job == "" {
+		*job = fmt.Sprintf("enrich-v%d-taxonomy-v%d", enrichment.Version, tax.Version)
+	}
+
+	enricher := enrichment.New(tax)
+	start := time.Now()
+
+	switch *source {
+	case "kafka":
+		rng := replay.KafkaRange{FromOffset: *fromOffset, ToOffset: *toOffset}
+		if rng.From, err = replay.ParseTime(*from, start); err != nil {
+			slog.Error("invalid -from", logger.Err(err))
+			os.Exit(1)
+		}
+		if rng.To, err = replay.ParseTime(*to, start); err != nil {
+			slog.Error("invalid -to", logger.Err(err))
+			os.Exit(1)
+		}
+		if rng.Partitions, err = replay.ParsePartitions(*partitions); err != nil {
+			slog.Error("invalid -partitions", logger.Err(err))
+			os.Exit(1)
+		}
+		if *group == "" {
+			*group = cfg.ConsumerGroup + "_replay"
+		}
+
+		codec, err := events.Open(cfg.EventEncoding, cfg.SchemaRegistryDir)
+		if err != nil {
+			slog.Error("event codec error", logger.Err(err))
+			os.Exit(1)
+		}
+		replayer, err := replay.NewKafkaReplayer(cfg.KafkaBrokers, cfg.KafkaTopic, *group, codec,
+			&replayHandler{db: db, enricher: enricher}, *batch)
+		if err != nil {
+			slog.Error("kafka connect error", logger.Err(err))
+			os.Exit(1)
+		}
+		defer replayer.Close()
+
+		slog.Info("replay started", "source", "kafka", "topic", cfg.KafkaTopic, "group", *group)
+		n, err := replayer.Run(ctx, rng, *resume)
+		if err != nil {
+			slog.Warn("replay stopped, rerun with -resume", "messages", n, logger.Err(err))
+			return
+		}
+		slog.Info("replay complete", "messages", n, "duration", time.Since(start).Round(time.Second).String())
+
+	case "postgres":
+		reprocessor := replay.NewReprocessor(db, enricher, *job, *batch)
+		reprocessor.SetDryRun(*dryRun)
+
+		slog.Info("reprocess started", "source", "postgres", "job", *job,
+			"enrichment_version", enrichment.Version, "taxonomy_version", tax.Version, "dry_run", *dryRun)
+		cp, err := reprocessor.Run(ctx, *restart)
+		if err != nil {
+			slog.Warn("reprocess stopped", logger.Err(err))
+			return
+		}
+		slog.Info("reprocess complete", "job", cp.JobName, "processed", cp.Processed,
+			"updated", cp.Updated, "duration", time.Since(start).Round(time.Second).String())
+
+	default:
+		slog.Error("-source must be kafka or postgres", "source", *source)
+		flag.Usage()
+		os.Exit(1)
+	}
+}
@@ -9,10 +9,14 @@ package config
 
 import (
 	"fmt"
+	"log/slog"
 	"os"
+	"runtime"
 	"strconv"
 	"strings"
 	"time"
+
+	"social-insight/internal/tracing"
 )
 
 // Config chứa tất cả cấu hình ứng dụng
@@ -20,6 +24,7 @@ type Config struct {
 	// Kafka
 	KafkaBrokers  []string
 	KafkaTopic    string
+	DLQTopic      string
 	ConsumerGroup string
 
 	// Redis
@@ -49,9 +54,48 @@ type Config struct {
 	MediumPostsPerTopic int
 	MediumTopics        []string
 
+	// Dedup content hash: keys | bloom | redisbloom
+	DedupHashMode      string
+	DedupBloomCapacity int
+	DedupBloomFPRate   float64
+
+	// Near-duplicate detection (SimHash)
+	NearDupEnabled   bool
+	NearDupThreshold int
+	NearDupTTL       time.Duration
+
+	// Event envelope: json | avro
+	EventEncoding     string
+	SchemaRegistryDir string // Rỗng = chỉ dùng schemas embedded
+
+	// File taxonomy topic (rỗng = taxonomy mặc định được embed)
+	TaxonomyFile string
+
 	// Consumer
 	ConsumerBatchSize     int
 	ConsumerFlushInterval time.Duration
+	ConsumerWorkers       int    // Số workers enrich dùng chung cho mọi partitions
+	ConsumerHealthAddr    string // Địa chỉ HTTP /health của consumer (rỗng = tắt)
+	ConsumerMaxLag        int64  // Tổng lag vượt ngưỡng này thì /health báo lagging
+
+	// Khoảng tối thiểu giữa hai lần làm response cache của API hết hiệu lực
+	// (0 = sau mỗi batch)
+	CacheInvalidateInterval time.Duration
+
+	// Timeout mỗi dependency check của /healthz, /readyz
+	HealthCheckTimeout time.Duration
+
+	// Prometheus /metrics của crawlers (rỗng = tắt); consumer dùng ConsumerHealthAddr
+	MetricsAddr string
+
+	// Logging
+	LogLevel  string // debug | info | warn | error
+	LogFormat string // text | json
+
+	// Tracing (OpenTelemetry)
+	TracingExporter    string  // none | stdout | otlp
+	TracingEndpoint    string  // OTLP/HTTP endpoint (host:4318 hoặc URL)
+	TracingSampleRatio float64 // Tỉ lệ lấy mẫu trace gốc (0..1)
 
 	// HTTP Client
 	HTTPClientTimeout time.Duration
@@ -72,29 +116,50 @@ func Load() (*Config, error) {
 
 	cfg := &Config{
 		// Default values (Local development)
-		KafkaBrokers:          kafkaBrokers,
-		KafkaTopic:            getEnv("KAFKA_TOPIC", "raw_posts"),
-		ConsumerGroup:         getEnv("CONSUMER_GROUP", "social_insight_consumer"),
-		RedisAddr:             getEnv("REDIS_ADDR", "localhost:6379"),
-		PGHost:                getEnv("PG_HOST", "localhost"),
-		PGPort:                getEnvInt("PG_PORT", 5432),
-		PGUser:                getEnv("PG_USER", "postgres"),
-		PGPassword:            getEnv("PG_PASSWORD", "postgres123"),
-		PGDBName:              getEnv("PG_DBNAME", "social_insight"),
-		APIPort:               getEnv("API_PORT", ":8888"),
-		HNCrawlInterval:       parseDuration(getEnv("HN_CRAWL_INTERVAL", "5m")),
-		HNStoriesLimit:        getEnvInt("HN_STORIES_LIMIT", 30),
-		DevtoCrawlInterval:    parseDuration(getEnv("DEVTO_CRAWL_INTERVAL", "10m")),
-		DevtoPostsPerTag:      getEnvInt("DEVTO_POSTS_PER_TAG", 6),
-		DevtoTags:             parseStringSlice(getEnv("DEVTO_TAGS", "ai,machine-learning,cloud,devops,startups"), ""),
-		MediumCrawlInterval:   parseDuration(getEnv("MEDIUM_CRAWL_INTERVAL", "10m")),
-		MediumPostsPerTopic:   getEnvInt("MEDIUM_POSTS_PER_TOPIC", 10),
-		MediumTopics:          parseStringSlice(getEnv("MEDIUM_TOPICS", "machine-learning,artificial-intelligence,cloud-computing,devops,startups"), ""),
-		ConsumerBatchSize:     getEnvInt("CONSUMER_BATCH_SIZE", 500),
-		ConsumerFlushInterval: parseDuration(getEnv("CONSUMER_FLUSH_INTERVAL", "2s")),
-		HTTPClientTimeout:     parseDuration(getEnv("HTTP_CLIENT_TIMEOUT", "10s")),
-		HTTPMaxRetries:        getEnvInt("HTTP_MAX_RETRIES", 3),
-		HTTPRetryDelay:        parseDuration(getEnv("HTTP_RETRY_DELAY", "1s")),
+		KafkaBrokers:             kafkaBrokers,
+		KafkaTopic:               getEnv("KAFKA_TOPIC", "raw_posts"),
+		DLQTopic:                 getEnv("DLQ_TOPIC", "raw_posts_dlq"),
+		ConsumerGroup:            getEnv("CONSUMER_GROUP", "social_insight_consumer"),
+		RedisAddr:                getEnv("REDIS_ADDR", "localhost:6379"),
+		PGHost:                   getEnv("PG_HOST", "localhost"),
+		PGPort:                   getEnvInt("PG_PORT", 5432),
+		PGUser:                   getEnv("PG_USER", "postgres"),
+		PGPassword:               getEnv("PG_PASSWORD", "postgres123"),
+		PGDBName:                 getEnv("PG_DBNAME", "social_insight"),
+		APIPort:                  getEnv("API_PORT", ":8888"),
+		HNCrawlInterval:          parseDuration(getEnv("HN_CRAWL_INTERVAL", "5m")),
+		HNStoriesLimit:           getEnvInt("HN_STORIES_LIMIT", 30),
+		DevtoCrawlInterval:       parseDuration(getEnv("DEVTO_CRAWL_INTERVAL", "10m")),
+		DevtoPostsPerTag:         getEnvInt("DEVTO_POSTS_PER_TAG", 6),
+		DevtoTags:                parseStringSlice(getEnv("DEVTO_TAGS", "ai,machine-learning,cloud,devops,startups"), ""),
+		MediumCrawlInterval:      parseDuration(getEnv("MEDIUM_CRAWL_INTERVAL", "10m")),
+		MediumPostsPerTopic:      getEnvInt("MEDIUM_POSTS_PER_TOPIC", 10),
+		MediumTopics:             parseStringSlice(getEnv("MEDIUM_TOPICS", "machine-learning,artificial-intelligence,cloud-computing,devops,startups"), ""),
+		DedupHashMode:            getEnv("DEDUP_HASH_MODE", "keys"),
+		DedupBloomCapacity:       getEnvInt("DEDUP_BLOOM_CAPACITY", 1000000),
+		DedupBloomFPRate:         getEnvFloat("DEDUP_BLOOM_FP_RATE", 0.001),
+		NearDupEnabled:           getEnvBool("NEAR_DUP_ENABLED", true),
+		NearDupThreshold:         getEnvInt("NEAR_DUP_THRESHOLD", 3),
+		NearDupTTL:               parseDuration(getEnv("NEAR_DUP_TTL", "720h")),
+		EventEncoding:            getEnv("EVENT_ENCODING", "json"),
+		SchemaRegistryDir:        getEnv("SCHEMA_REGISTRY_DIR", ""),
+		TaxonomyFile:             getEnv("TAXONOMY_FILE", ""),
+		ConsumerBatchSize:        getEnvInt("CONSUMER_BATCH_SIZE", 500),
+		ConsumerFlushInterval:    parseDuration(getEnv("CONSUMER_FLUSH_INTERVAL", "2s")),
+		ConsumerWorkers:          getEnvInt("CONSUMER_WORKERS", runtime.NumCPU()),
+		ConsumerHealthAddr:       getEnv("CONSUMER_HEALTH_ADDR", ":8081"),
+		CacheInvalidateInterval:  parseDuration(getEnv("API_CACHE_INVALIDATE_INTERVAL", "15s")),
+		ConsumerMaxLag:           int64(getEnvInt("CONSUMER_MAX_LAG", 10000)),
+		HealthCheckTimeout:       parseDuration(getEnv("HEALTH_CHECK_TIMEOUT", "2s")),
+		MetricsAddr:              getEnv("METRICS_ADDR", ":9100"),
+		LogLevel:                 getEnv("LOG_LEVEL", "info"),
+		LogFormat:                getEnv("LOG_FORMAT", "text"),
+		TracingExporter:          getEnv("TRACING_EXPORTER", tracing.ExporterNone),
+		TracingEndpoint:          getEnv("TRACING_ENDPOINT", "localhost:4318"),
+		TracingSampleRatio:       getEnvFloat("TRACING_SAMPLE_RATIO", 1.0),
+		HTTPClientTimeout:        parseDuration(getEnv("HTTP_CLIENT_TIMEOUT", "10s")),
+		HTTPMaxRetries:           getEnvInt("HTTP_MAX_RETRIES", 3),
+		HTTPRetryDelay:           parseDuration(getEnv("HTTP_RETRY_DELAY", "1s")),
 	}
 
 	return cfg, nil
@@ -120,7 +185,35 @@ func getEnvInt(key string, defaultVal int) int {
 	}
 	val, err := strconv.Atoi(valStr)
 	if err != nil {
-		fmt.Printf("⚠️  Invalid int value for %s: %v, using default: %d\n", key, err, defaultVal)
+		slog.Warn("invalid int value, using default", "key", key, "error", err, "default", defaultVal)
+		return defaultVal
+	}
+	return val
+}
+
+// getEnvFloat lấy float environment variable
+func getEnvFloat(key string, defaultVal float64) float64 {
+	valStr := getEnv(key, "")
+	if valStr == "" {
+		return defaultVal
+	}
+	val, err := strconv.ParseFloat(valStr, 64)
+	if err != nil {
+		slog.Warn("invalid float value, using default", "key", key, "error", err, "default", defaultVal)
+		return defaultVal
+	}
+	return val
+}
+
+// getEnvBool lấy bool environment variable
+func getEnvBool(key string, defaultVal bool) bool {
+	valStr := getEnv(key, "")
+	if valStr == "" {
+		return defaultVal
+	}
+	val, err := strconv.ParseBool(valStr)
+	if err != nil {
+		slog.Warn("invalid bool value, using default", "key", key, "error", err, "default", defaultVal)
 		return defaultVal
 	}
 	return val
@@ -130,7 +223,7 @@ func getEnvInt(key string, defaultVal int) int {
 func parseDuration(s string) time.Duration {
 	d, err := time.ParseDuration(s)
 	if err != nil {
-		fmt.Printf("⚠️  Invalid duration: %s, using 5s\n", s)
+		slog.Warn("invalid duration, using default", "value", s, "default", "5s")
 		return 5 * time.Second
 	}
 	return d
@@ -155,22 +248,62 @@ func parseStringSlice(s, separator string) []string {
 	return result
 }
 
-// LogConfig in ra config (với password masked)
+// LogConfig ghi config ra log (không ghi password)
 func (c *Config) LogConfig() {
-	fmt.Println("╔════════════════════════════════════════════════════════════╗")
-	fmt.Println("║              APPLICATION CONFIGURATION                     ║")
-	fmt.Println("╠════════════════════════════════════════════════════════════╣")
-	fmt.Printf("║ Kafka Brokers: %s\n", strings.Join(c.KafkaBrokers, ", "))
-	fmt.Printf("║ Kafka Topic: %s\n", c.KafkaTopic)
-	fmt.Printf("║ Redis: %s\n", c.RedisAddr)
-	fmt.Printf("║ PostgreSQL: %s:%d/%s (user: %s)\n", c.PGHost, c.PGPort, c.PGDBName, c.PGUser)
-	fmt.Printf("║ API Port: %s\n", c.APIPort)
-	fmt.Println("║")
-	fmt.Printf("║ HN Crawler: interval=%v, limit=%d\n", c.HNCrawlInterval, c.HNStoriesLimit)
-	fmt.Printf("║ Devto Crawler: interval=%v, %d posts/tag\n", c.DevtoCrawlInterval, c.DevtoPostsPerTag)
-	fmt.Printf("║ Medium Crawler: interval=%v, %d posts/topic\n", c.MediumCrawlInterval, c.MediumPostsPerTopic)
-	fmt.Printf("║ Consumer: batch=%d, flush=%v\n", c.ConsumerBatchSize, c.ConsumerFlushInterval)
-	fmt.Println("╚════════════════════════════════════════════════════════════╝")
+	slog.Info("configuration loaded",
+		slog.Group("kafka",
+			"brokers", strings.Join(c.KafkaBrokers, ","),
+			"topic", c.KafkaTopic,
+			"dlq_topic", c.DLQTopic,
+			"encoding", c.EventEncoding,
+			"registry", c.SchemaRegistryDir),
+		slog.String("redis", c.RedisAddr),
+		slog.Group("postgres",
+			"host", c.PGHost,
+			"port", c.PGPort,
+			"db", c.PGDBName,
+			"user", c.PGUser),
+		slog.Group("crawlers",
+			"hn_interval", c.HNCrawlInterval,
+			"hn_limit", c.HNStoriesLimit,
+			"devto_interval", c.DevtoCrawlInterval,
+			"devto_per_tag", c.DevtoPostsPerTag,
+			"medium_interval", c.MediumCrawlInterval,
+			"medium_per_topic", c.MediumPostsPerTopic,
+			"metrics_addr", c.MetricsAddr),
+		slog.Group("dedup",
+			"mode", c.DedupHashMode,
+			"bloom_capacity", c.DedupBloomCapacity,
+			"bloom_fp_rate", c.DedupBloomFPRate,
+			"near_dup", c.NearDupEnabled,
+			"near_dup_threshold", c.NearDupThreshold,
+			"near_dup_ttl", c.NearDupTTL),
+		slog.Group("consumer",
+			"batch", c.ConsumerBatchSize,
+			"flush", c.ConsumerFlushInterval,
+			"workers", c.ConsumerWorkers,
+			"health_addr", c.ConsumerHealthAddr,
+			"max_lag", c.ConsumerMaxLag,
+			"cache_invalidate_interval", c.CacheInvalidateInterval,
+			"health_check_timeout", c.HealthCheckTimeout,
+			"taxonomy_file", c.TaxonomyFile),
+		slog.Group("log",
+			"level", c.LogLevel,
+			"format", c.LogFormat),
+		slog.Group("tracing",
+			"exporter", c.TracingExporter,
+			"endpoint", c.TracingEndpoint,
+			"sample_ratio", c.TracingSampleRatio),
+	)
+}
+
+// Tracing trả về config cho tracing.Setup
+func (c *Config) Tracing() tracing.Config {
+	return tracing.Config{
+		Exporter:    c.TracingExporter,
+		Endpoint:    c.TracingEndpoint,
+		SampleRatio: c.TracingSampleRatio,
+	}
 }
 
 // Validate check configuration values
@@ -187,5 +320,23 @@ func (c *Config) Validate() error {
 	if c.PGHost == "" {
 		return fmt.Errorf("postgresql host not configured")
 	}
+	if c.NearDupThreshold < 0 || c.NearDupThreshold > 32 {
+		return fmt.Errorf("near-duplicate threshold must be between 0 and 32")
+	}
+	if c.ConsumerWorkers < 1 {
+		return fmt.Errorf("consumer workers must be at least 1")
+	}
+	if c.HealthCheckTimeout <= 0 {
+		return fmt.Errorf("health check timeout must be positive")
+	}
+	if c.LogFormat != "text" && c.LogFormat != "json" {
+		return fmt.Errorf("log format must be text or json")
+	}
+	if err := c.Tracing().Validate(); err != nil {
+		return err
+	}
+	if c.EventEncoding != "json" && c.EventEncoding != "avro" {
+		return fmt.Errorf("event encoding must be json or avro")
+	}
 	return nil
 }
@@ -2,6 +2,7 @@ package circuitbreaker
 
 import (
 	"fmt"
+	"log/slog"
 	"sync"
 	"time"
 )
@@ -75,7 +76,7 @@ func (cb *CircuitBreaker) onFailure() {
 	cb.lastFailureTime = time.Now()
 	cb.successCount = 0
 
-	fmt.Printf("⚠️  Circuit breaker: failure %d/%d\n", cb.failureCount, cb.maxFailures)
+	slog.Warn("circuit breaker failure", "failures", cb.failureCount, "max_failures", cb.maxFailures)
 
 	if cb.failureCount >= cb.maxFailures {
 		cb.setState(StateOpen)
@@ -99,7 +100,7 @@ func (cb *CircuitBreaker) setState(newState State) {
 	cb.state = newState
 
 	stateNames := []string{"CLOSED", "OPEN", "HALF-OPEN"}
-	fmt.Printf("🔄 Circuit breaker state: %s → %s\n", stateNames[oldState], stateNames[newState])
+	slog.Info("circuit breaker state changed", "from", stateNames[oldState], "to", stateNames[newState])
 
 	if cb.onStateChange != nil {
 		cb.onStateChange(oldState, newState)
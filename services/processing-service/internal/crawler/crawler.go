@@ -8,34 +8,85 @@
 package crawler
 
 import (
+	"context"
 	"crypto/sha256"
 	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"log/slog"
+	"social-insight/internal/dedup"
+	"social-insight/internal/events"
+	httpclient "social-insight/internal/http"
 	"social-insight/internal/kafka"
+	"social-insight/internal/logger"
+	"social-insight/internal/metrics"
 	"social-insight/internal/models"
 	"social-insight/internal/redis"
+	"social-insight/internal/tracing"
 	"social-insight/internal/validation"
+	"strings"
+	"sync/atomic"
 	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Crawler là interface cho all crawlers
 type Crawler interface {
 	// Fetch lấy posts từ source và return
-	Fetch() ([]models.Post, error)
+	// ctx mang run_id của lần crawl (dùng cho log) và span crawler.run
+	Fetch(ctx context.Context) ([]models.Post, error)
 	// Name return tên crawler ("hn", "medium", "devto")
 	Name() string
 }
 
+// FetchTraced gọi c.Fetch trong span crawler.fetch
+// Span con "HTTP GET" của từng request nằm dưới span này
+func FetchTraced(ctx context.Context, c Crawler) (posts []models.Post, err error) {
+	ctx, span := tracing.Start(ctx, "crawler.fetch", trace.WithAttributes(
+		attribute.String("source", c.Name()),
+	))
+	defer func() {
+		span.SetAttributes(attribute.Int("posts", len(posts)))
+		tracing.End(span, &err)
+	}()
+
+	return c.Fetch(ctx)
+}
+
 // BaseCrawler chứa shared logic cho all crawlers
 type BaseCrawler struct {
-	producer   *kafka.Producer
-	redis      *redis.Client
-	source     string
-	kafkaTopic string
-	validator  *validation.Validator
+	producer      *kafka.Producer
+	redis         *redis.Client
+	source        string
+	kafkaTopic    string
+	validator     *validation.Validator
+	contentHashes dedup.HashSet   // Layer 1: exact dedup theo content hash
+	nearDup       *dedup.Detector // nil = tắt near-duplicate detection
+	deadLetter    kafka.DeadLetterSink
+	stats         DedupStats
+}
+
+// Thời gian giữ claim trong lúc chờ Kafka ack
+// Nếu crawler chết giữa chừng, claim tự hết hạn để lần crawl sau gửi lại
+const pendingClaimTTL = 5 * time.Minute
+
+// Thời gian nhớ source ID sau khi broker đã ack
+const seenTTL = 7 * 24 * time.Hour
+
+// DedupStats đếm số posts bị chặn ở từng layer dedup
+type DedupStats struct {
+	Validation  int64 // Bị loại do validation
+	ContentHash int64 // Trùng content hash (cross-source exact duplicate)
+	SourceID    int64 // Trùng ID theo nguồn
+	NearDup     int64 // Near-duplicate: vẫn gửi, gắn canonical_post_id
+	Errors      int64 // Lỗi Redis khi kiểm tra dedup
 }
 
 // NewBaseCrawler tạo BaseCrawler instance
+// Mặc định content hash lưu theo từng key (TTL 365 ngày)
 func NewBaseCrawler(
 	producer *kafka.Producer,
 	redis *redis.Client,
@@ -43,32 +94,93 @@ func NewBaseCrawler(
 	kafkaTopic string,
 ) *BaseCrawler {
 	return &BaseCrawler{
-		producer:   producer,
-		redis:      redis,
-		source:     source,
-		kafkaTopic: kafkaTopic,
-		validator:  validation.New(),
+		producer:      producer,
+		redis:         redis,
+		source:        source,
+		kafkaTopic:    kafkaTopic,
+		validator:     validation.New(),
+		contentHashes: dedup.NewKeyHashSet(redis, 365*24*time.Hour),
+	}
+}
+
+// SetContentHashSet đổi cách lưu content hash (keys, bloom, redisbloom)
+func (b *BaseCrawler) SetContentHashSet(hs dedup.HashSet) {
+	b.contentHashes = hs
+}
+
+// SetNearDuplicateDetector bật near-duplicate detection cho crawler
+func (b *BaseCrawler) SetNearDuplicateDetector(d *dedup.Detector) {
+	b.nearDup = d
+}
+
+// SetDeadLetterSink đặt nơi nhận posts không qua validation
+func (b *BaseCrawler) SetDeadLetterSink(sink kafka.DeadLetterSink) {
+	b.deadLetter = sink
+}
+
+// DedupStats trả về snapshot số lượt dedup theo layer
+func (b *BaseCrawler) DedupStats() DedupStats {
+	return DedupStats{
+		Validation:  atomic.LoadInt64(&b.stats.Validation),
+		ContentHash: atomic.LoadInt64(&b.stats.ContentHash),
+		SourceID:    atomic.LoadInt64(&b.stats.SourceID),
+		NearDup:     atomic.LoadInt64(&b.stats.NearDup),
+		Errors:      atomic.LoadInt64(&b.stats.Errors),
+	}
+}
+
+// nearDupText trả về text dùng để so khớp near-duplicate (ưu tiên title)
+func nearDupText(post models.Post) string {
+	if post.Title != "" {
+		return post.Title
 	}
+	return post.Content
 }
 
 // ProcessAndSend kiểm tra dedup, gửi Kafka, track Redis
 // input: posts từ crawler
 // output: số posts gửi thành công, số skip (duplicate)
-func (b *BaseCrawler) ProcessAndSend(posts []models.Post) (sent, skipped int, err error) {
+//
+// Mỗi layer dedup dùng thao tác check-and-mark atomic để hai crawler
+// replicas không cùng gửi một post; nếu gửi Kafka lỗi thì trả lại claim,
+// nếu hết thời gian chờ ack thì giữ claim tới khi tự hết hạn
+//
+// Mỗi post được gán trace id (đi theo event envelope tới consumer và DB);
+// log của post mang cả run_id (từ ctx) và trace_id
+func (b *BaseCrawler) ProcessAndSend(ctx context.Context, posts []models.Post) (sent, skipped int, err error) {
+	ctx, span := tracing.Start(ctx, "crawler.process_and_send", trace.WithAttributes(
+		attribute.String("source", b.source),
+		attribute.Int("posts", len(posts)),
+	))
+	defer func() {
+		span.SetAttributes(attribute.Int("sent", sent), attribute.Int("skipped", skipped))
+		tracing.End(span, &err)
+	}()
+	rdb := b.redis.WithContext(ctx)
+
 	// Always update last crawl time even if no posts were found
-	if err := b.redis.SetLastCrawl(b.source, time.Now()); err != nil {
-		fmt.Printf("⚠️  Redis set last crawl error for %s: %v\n", b.source, err)
+	if err := rdb.SetLastCrawl(b.source, time.Now()); err != nil {
+		slog.WarnContext(ctx, "redis set last crawl error", "source", b.source, logger.Err(err))
 	}
 	if len(posts) == 0 {
 		return 0, 0, nil
 	}
 
 	for _, post := range posts {
+		if post.TraceID == "" {
+			post.TraceID = events.NewTraceID()
+		}
+		ctx := logger.WithTraceID(ctx, post.TraceID)
+		log := slog.With("source", b.source, "post_id", post.ID)
+
 		// Validate and sanitize post first
 		if b.validator != nil {
 			ok, verrs := b.validator.ValidatePost(&post)
 			if !ok {
-				fmt.Printf("❌ Validation failed for %s: %v\n", post.ID, verrs)
+				log.WarnContext(ctx, "validation failed", "errors", fmt.Sprint(verrs))
+				atomic.AddInt64(&b.stats.Validation, 1)
+				b.countOutcome(metrics.OutcomeInvalid)
+				b.publishDeadLetter(ctx, post, verrs)
 				skipped++
 				continue
 			}
@@ -77,53 +189,162 @@ func (b *BaseCrawler) ProcessAndSend(posts []models.Post) (sent, skipped int, er
 		h := sha256.Sum256([]byte(post.Content + "|" + post.Author))
 		hashStr := hex.EncodeToString(h[:])
 
-		// Check dedup by content hash first
-		seenHash, err := b.redis.CheckIfSeen("content_hash", hashStr)
+		// Layer 1: claim content hash
+		hashClaimed, err := b.contentHashes.Claim(hashStr)
 		if err != nil {
-			fmt.Printf("❌ Redis hash check error for %s: %v\n", hashStr, err)
+			log.ErrorContext(ctx, "redis hash check error", "hash", hashStr, logger.Err(err))
+			atomic.AddInt64(&b.stats.Errors, 1)
+			b.countOutcome(metrics.OutcomeError)
 			// Fall back to ID-based check
-		}
-		if seenHash {
+			hashClaimed = false
+		} else if !hashClaimed {
 			skipped++
-			fmt.Printf("⏭️  Skipped duplicate by content hash [%s]\n", hashStr)
+			atomic.AddInt64(&b.stats.ContentHash, 1)
+			b.countOutcome(metrics.OutcomeContentHash)
+			log.DebugContext(ctx, "skipped duplicate by content hash", "hash", hashStr)
 			continue
 		}
 
-		// Fallback: check dedup by source-specific ID
-		seen, err := b.redis.CheckIfSeen(b.source, post.ID)
+		// Layer 2: check-and-mark source-specific ID (SET NX)
+		// Claim ngắn hạn, chỉ gia hạn thành seenTTL sau khi broker ack
+		firstSeen, err := rdb.CheckAndMark(b.source, post.ID, pendingClaimTTL)
 		if err != nil {
-			fmt.Printf("❌ Redis check error for %s: %v\n", post.ID, err)
+			log.ErrorContext(ctx, "redis check error", logger.Err(err))
+			atomic.AddInt64(&b.stats.Errors, 1)
+			b.countOutcome(metrics.OutcomeError)
+			b.releaseHash(ctx, hashClaimed, hashStr)
 			skipped++
 			continue
 		}
-		if seen {
+		if !firstSeen {
+			// Cùng ID nhưng content đổi (bài được sửa) → không giữ claim hash mới
+			atomic.AddInt64(&b.stats.SourceID, 1)
+			b.countOutcome(metrics.OutcomeSeen)
+			b.releaseHash(ctx, hashClaimed, hashStr)
 			skipped++
 			continue
 		}
 
-		// Send to Kafka
-		if err := b.producer.SendPost(post); err != nil {
-			fmt.Printf("❌ Kafka send error for %s: %v\n", post.ID, err)
+		// Layer 3: near-duplicate, cùng story được cross-post với author/title hơi khác
+		// Không skip mà gắn link tới post gốc để API gom thành một cluster
+		if b.nearDup != nil {
+			match, err := b.nearDup.FindCanonical(nearDupText(post))
+			if err != nil {
+				log.WarnContext(ctx, "near-duplicate check error", logger.Err(err))
+				atomic.AddInt64(&b.stats.Errors, 1)
+				b.countOutcome(metrics.OutcomeError)
+			} else if match != nil && match.CanonicalPostID != post.ID {
+				post.CanonicalPostID = match.CanonicalPostID
+				atomic.AddInt64(&b.stats.NearDup, 1)
+				log.InfoContext(ctx, "near-duplicate detected",
+					"canonical_post_id", match.CanonicalPostID, "distance", match.Distance)
+			}
+		}
+
+		// Send to Kafka, chờ broker ack
+		if err := b.producer.SendPost(ctx, post); errors.Is(err, kafka.ErrAckTimeout) {
+			// Chưa rõ kết quả: broker vẫn có thể ghi message. Giữ claims ngắn hạn
+			// (pendingClaimTTL) thay vì trả lại, để lần crawl kế tiếp không gửi trùng
+			log.WarnContext(ctx, "kafka ack timeout, keeping pending claims", logger.Err(err))
+			b.countOutcome(metrics.OutcomeSendFailed)
+			skipped++
+			continue
+		} else if err != nil {
+			log.ErrorContext(ctx, "kafka send error", logger.Err(err))
+			// Trả lại claims để lần crawl sau gửi lại
+			if err := rdb.UnmarkSeen(b.source, post.ID); err != nil {
+				log.WarnContext(ctx, "redis unmark error", logger.Err(err))
+			}
+			b.releaseHash(ctx, hashClaimed, hashStr)
+			b.countOutcome(metrics.OutcomeSendFailed)
 			skipped++
 			continue
 		}
 
-		// Mark as seen in Redis: both by source ID (short TTL) and by content hash (long TTL)
-		if err := b.redis.MarkAsSeen(b.source, post.ID, 7*24*time.Hour); err != nil {
-			fmt.Printf("⚠️  Redis mark error for %s: %v\n", post.ID, err)
+		// Broker đã ack → đánh dấu seen lâu dài
+		if err := rdb.MarkAsSeen(b.source, post.ID, seenTTL); err != nil {
+			log.WarnContext(ctx, "redis mark error", logger.Err(err))
 		}
-		if err := b.redis.MarkAsSeen("content_hash", hashStr, 365*24*time.Hour); err != nil {
-			fmt.Printf("⚠️  Redis mark error for content hash %s: %v\n", hashStr, err)
+		if hashClaimed {
+			if err := b.contentHashes.Commit(hashStr); err != nil {
+				log.WarnContext(ctx, "redis mark error for content hash", "hash", hashStr, logger.Err(err))
+			}
 		}
 
+		// Chỉ index post gốc để mọi duplicate đều trỏ về cùng một canonical post
+		if b.nearDup != nil && post.CanonicalPostID == "" {
+			if err := b.nearDup.Index(post.ID, nearDupText(post)); err != nil {
+				log.WarnContext(ctx, "near-duplicate index error", logger.Err(err))
+			}
+		}
+
+		if post.CanonicalPostID != "" {
+			b.countOutcome(metrics.OutcomeNearDuplicate)
+		} else {
+			b.countOutcome(metrics.OutcomeNew)
+		}
 		sent++
-		fmt.Printf("✅ [%s] Sent post %s (hash=%s)\n", b.source, post.ID, hashStr)
+		log.DebugContext(ctx, "post sent", "hash", hashStr)
 	}
 
 	// Update last crawl time in Redis
-	if err := b.redis.SetLastCrawl(b.source, time.Now()); err != nil {
-		fmt.Printf("⚠️  Redis set last crawl error for %s: %v\n", b.source, err)
+	if err := rdb.SetLastCrawl(b.source, time.Now()); err != nil {
+		slog.WarnContext(ctx, "redis set last crawl error", "source", b.source, logger.Err(err))
 	}
 
 	return sent, skipped, nil
 }
+
+// countOutcome tăng metric dedup outcome của source
+func (b *BaseCrawler) countOutcome(outcome string) {
+	metrics.DedupOutcomes.WithLabelValues(b.source, outcome).Inc()
+}
+
+// newHTTPClient tạo HTTP client cho crawler, gắn source để đếm metrics
+func newHTTPClient(source string) *httpclient.Client {
+	client := httpclient.NewClient(10 * time.Second)
+	client.SetSource(source)
+	return client
+}
+
+// publishDeadLetter gửi post không hợp lệ sang dead letter topic
+func (b *BaseCrawler) publishDeadLetter(ctx context.Context, post models.Post, verrs []validation.ValidationError) {
+	if b.deadLetter == nil {
+		return
+	}
+
+	payload, err := json.Marshal(post)
+	if err != nil {
+		slog.WarnContext(ctx, "cannot marshal dead letter", "post_id", post.ID, logger.Err(err))
+		return
+	}
+	msgs := make([]string, len(verrs))
+	for i, e := range verrs {
+		msgs[i] = string(e)
+	}
+
+	dl := models.DeadLetter{
+		Payload:     payload,
+		Key:         post.ID,
+		SourceTopic: b.kafkaTopic,
+		Partition:   -1,
+		Offset:      -1,
+		Stage:       models.StageValidation,
+		Error:       strings.Join(msgs, "; "),
+		Producer:    "crawler:" + b.source,
+		FailedAt:    time.Now(),
+	}
+	if err := b.deadLetter.Publish(dl); err != nil {
+		slog.WarnContext(ctx, "dead letter publish error", "post_id", post.ID, logger.Err(err))
+	}
+}
+
+// releaseHash trả lại claim content hash nếu đã claim
+func (b *BaseCrawler) releaseHash(ctx context.Context, claimed bool, hash string) {
+	if !claimed {
+		return
+	}
+	if err := b.contentHashes.Release(hash); err != nil {
+		slog.WarnContext(ctx, "redis release error for content hash", "hash", hash, logger.Err(err))
+	}
+}
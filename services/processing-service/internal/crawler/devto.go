@@ -10,11 +10,14 @@
 package crawler
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"log/slog"
+	"slices"
 	httpclient "social-insight/internal/http"
+	"social-insight/internal/logger"
 	"social-insight/internal/models"
-	"strings"
 	"time"
 )
 
@@ -25,7 +28,8 @@ type DevToArticle struct {
 	Description string `json:"description"`
 	URL         string `json:"url"`
 	Author      struct {
-		Name string `json:"name"`
+		Name     string `json:"name"`
+		Username string `json:"username"`
 	} `json:"user"`
 	PublishedAt string   `json:"published_at"`
 	Tags        []string `json:"tag_list"`
@@ -44,7 +48,7 @@ type DevToCrawler struct {
 func NewDevToCrawler(base *BaseCrawler, limit int) *DevToCrawler {
 	return &DevToCrawler{
 		BaseCrawler: base,
-		client:      httpclient.NewClient(10 * time.Second),
+		client:      newHTTPClient(base.source),
 		tags: []string{
 			"ai",
 			"machine-learning",
@@ -62,17 +66,17 @@ func (d *DevToCrawler) Name() string {
 }
 
 // Fetch lấy posts từ Dev.to
-func (d *DevToCrawler) Fetch() ([]models.Post, error) {
-	fmt.Println("📡 Fetching Dev.to posts...")
+func (d *DevToCrawler) Fetch(ctx context.Context) ([]models.Post, error) {
+	slog.InfoContext(ctx, "fetching posts", "source", "devto", "tags", len(d.tags))
 
 	posts := make([]models.Post, 0)
 
 	for _, tag := range d.tags {
-		fmt.Printf("   📌 Crawling tag: %s\n", tag)
+		slog.DebugContext(ctx, "crawling tag", "source", "devto", "tag", tag)
 
-		tagPosts, err := d.fetchFromTag(tag)
+		tagPosts, err := d.fetchFromTag(ctx, tag)
 		if err != nil {
-			fmt.Printf("   ⚠️  Tag error: %v\n", err)
+			slog.WarnContext(ctx, "tag error", "source", "devto", "tag", tag, logger.Err(err))
 			continue
 		}
 
@@ -82,15 +86,15 @@ func (d *DevToCrawler) Fetch() ([]models.Post, error) {
 		time.Sleep(200 * time.Millisecond)
 	}
 
-	fmt.Printf("✅ Fetched %d posts from Dev.to\n", len(posts))
+	slog.InfoContext(ctx, "fetch complete", "source", "devto", "posts", len(posts))
 	return posts, nil
 }
 
 // fetchFromTag fetch posts từ một tag
-func (d *DevToCrawler) fetchFromTag(tag string) ([]models.Post, error) {
+func (d *DevToCrawler) fetchFromTag(ctx context.Context, tag string) ([]models.Post, error) {
 	url := fmt.Sprintf("https://dev.to/api/articles?tag=%s&per_page=%d", tag, d.limit)
 
-	data, err := d.client.Get(url)
+	data, err := d.client.Get(ctx, url)
 	if err != nil {
 		return nil, fmt.Errorf("fetch articles error: %w", err)
 	}
@@ -120,8 +124,11 @@ func (d *DevToCrawler) parseArticle(article DevToArticle, tag string) *models.Po
 	// Extract ID from URL or use article ID
 	id := fmt.Sprintf("%d", article.ID)
 
-	// Map topic
-	topicName := d.mapTopic(tag)
+	// Tags của bài (kèm tag đang crawl) là tín hiệu để consumer phân loại topic
+	tags := article.Tags
+	if !slices.Contains(tags, tag) {
+		tags = append(tags, tag)
+	}
 
 	// Parse published date
 	createdAt := time.Now()
@@ -132,32 +139,16 @@ func (d *DevToCrawler) parseArticle(article DevToArticle, tag string) *models.Po
 	}
 
 	post := &models.Post{
-		ID:        id,
-		Author:    article.Author.Name,
-		Content:   article.Title,
-		Topic:     topicName,
-		Platform:  "devto",
-		CreatedAt: createdAt,
-		Sentiment: "neutral", // Will be set by ML service
+		ID:           id,
+		Author:       article.Author.Name,
+		AuthorHandle: article.Author.Username,
+		Title:        article.Title,
+		Content:      article.Title,
+		Tags:         tags,
+		Platform:     "devto",
+		URL:          article.URL,
+		CreatedAt:    createdAt,
 	}
 
 	return post
 }
-
-// mapTopic map Dev.to tag to our topics
-func (d *DevToCrawler) mapTopic(tag string) string {
-	tag = strings.ToLower(tag)
-
-	switch tag {
-	case "ai", "machine-learning", "ml", "llm":
-		return "ai"
-	case "cloud", "aws", "gcp", "azure":
-		return "cloud"
-	case "devops", "kubernetes", "docker":
-		return "devops"
-	case "startups", "entrepreneurship":
-		return "startup"
-	default:
-		return "programming"
-	}
-}
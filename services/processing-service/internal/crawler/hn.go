@@ -9,11 +9,13 @@
 package crawler
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"log/slog"
 	httpclient "social-insight/internal/http"
+	"social-insight/internal/logger"
 	"social-insight/internal/models"
-	"strings"
 	"time"
 )
 
@@ -42,7 +44,7 @@ type HackerNewsCrawler struct {
 func NewHackerNewsCrawler(base *BaseCrawler, storiesLimit int) *HackerNewsCrawler {
 	return &HackerNewsCrawler{
 		BaseCrawler:  base,
-		client:       httpclient.NewClient(10 * time.Second),
+		client:       newHTTPClient(base.source),
 		storiesLimit: storiesLimit,
 	}
 }
@@ -53,12 +55,12 @@ func (h *HackerNewsCrawler) Name() string {
 }
 
 // Fetch lấy top stories từ HN
-func (h *HackerNewsCrawler) Fetch() ([]models.Post, error) {
-	fmt.Println("📡 Fetching HackerNews top stories...")
+func (h *HackerNewsCrawler) Fetch(ctx context.Context) ([]models.Post, error) {
+	slog.InfoContext(ctx, "fetching top stories", "source", "hn")
 
 	// Fetch list of top story IDs
 	url := "https://hacker-news.firebaseio.com/v0/topstories.json"
-	data, err := h.client.Get(url)
+	data, err := h.client.Get(ctx, url)
 	if err != nil {
 		return nil, fmt.Errorf("fetch top stories error: %w", err)
 	}
@@ -72,14 +74,14 @@ func (h *HackerNewsCrawler) Fetch() ([]models.Post, error) {
 		storyIDs = storyIDs[:h.storiesLimit]
 	}
 
-	fmt.Printf("✅ Got %d story IDs, fetching details...\n", len(storyIDs))
+	slog.DebugContext(ctx, "fetching story details", "source", "hn", "stories", len(storyIDs))
 
 	// Fetch details cho từng story (parallel)
 	posts := make([]models.Post, 0, len(storyIDs))
 	for i, id := range storyIDs {
-		post, err := h.fetchStory(id)
+		post, err := h.fetchStory(ctx, id)
 		if err != nil {
-			fmt.Printf("⚠️  Skip story %d: %v\n", id, err)
+			slog.WarnContext(ctx, "skip story", "source", "hn", "story_id", id, logger.Err(err))
 			continue
 		}
 
@@ -93,15 +95,15 @@ func (h *HackerNewsCrawler) Fetch() ([]models.Post, error) {
 		}
 	}
 
-	fmt.Printf("✅ Fetched %d stories from HN\n", len(posts))
+	slog.InfoContext(ctx, "fetch complete", "source", "hn", "posts", len(posts))
 	return posts, nil
 }
 
 // fetchStory fetch chi tiết một story
-func (h *HackerNewsCrawler) fetchStory(id int) (*models.Post, error) {
+func (h *HackerNewsCrawler) fetchStory(ctx context.Context, id int) (*models.Post, error) {
 	url := fmt.Sprintf("https://hacker-news.firebaseio.com/v0/item/%d.json", id)
 
-	data, err := h.client.Get(url)
+	data, err := h.client.Get(ctx, url)
 	if err != nil {
 		return nil, fmt.Errorf("fetch story %d error: %w", id, err)
 	}
@@ -121,44 +123,18 @@ func (h *HackerNewsCrawler) fetchStory(id int) (*models.Post, error) {
 		return nil, fmt.Errorf("no title or url")
 	}
 
-	// Detect topic (AI, cloud, devops, programming, startup)
-	topic := h.detectTopic(story.Title)
-
+	// HN không có tag: consumer phân loại topic theo tiêu đề (taxonomy)
 	post := &models.Post{
-		ID:        fmt.Sprintf("%d", story.ID),
-		Author:    story.By,
-		Content:   story.Title,
-		Topic:     topic,
-		Platform:  "hackernews",
-		Likes:     story.Score,
-		CreatedAt: time.Now(),
-		Sentiment: "neutral", // Will be set by ML service
+		ID:           fmt.Sprintf("%d", story.ID),
+		Author:       story.By,
+		AuthorHandle: story.By,
+		Title:        story.Title,
+		Content:      story.Title,
+		Platform:     "hackernews",
+		URL:          story.URL,
+		Likes:        story.Score,
+		CreatedAt:    time.Now(),
 	}
 
 	return post, nil
 }
-
-// detectTopic detect topic từ title
-func (h *HackerNewsCrawler) detectTopic(title string) string {
-	title = strings.ToLower(title)
-
-	if strings.Contains(title, "ai") || strings.Contains(title, "llm") ||
-		strings.Contains(title, "machine learning") || strings.Contains(title, "chatgpt") ||
-		strings.Contains(title, "gpt") || strings.Contains(title, "neural") {
-		return "ai"
-	}
-	if strings.Contains(title, "cloud") || strings.Contains(title, "aws") ||
-		strings.Contains(title, "gcp") || strings.Contains(title, "azure") {
-		return "cloud"
-	}
-	if strings.Contains(title, "devops") || strings.Contains(title, "kubernetes") ||
-		strings.Contains(title, "docker") || strings.Contains(title, "ci/cd") {
-		return "devops"
-	}
-	if strings.Contains(title, "startup") || strings.Contains(title, "funding") ||
-		strings.Contains(title, "venture") || strings.Contains(title, "ipo") {
-		return "startup"
-	}
-
-	return "programming" // default
-}
@@ -10,11 +10,15 @@
 package crawler
 
 import (
+	"context"
 	"encoding/xml"
 	"fmt"
 	"html"
+	"log/slog"
 	"regexp"
+	"slices"
 	httpclient "social-insight/internal/http"
+	"social-insight/internal/logger"
 	"social-insight/internal/models"
 	"strings"
 	"time"
@@ -22,11 +26,12 @@ import (
 
 // MediumItem là item từ RSS feed
 type MediumItem struct {
-	Title       string `xml:"title"`
-	Description string `xml:"description"`
-	Link        string `xml:"link"`
-	Author      string `xml:"creator"`
-	PubDate     string `xml:"pubDate"`
+	Title       string   `xml:"title"`
+	Description string   `xml:"description"`
+	Link        string   `xml:"link"`
+	Author      string   `xml:"creator"`
+	PubDate     string   `xml:"pubDate"`
+	Categories  []string `xml:"category"`
 }
 
 // MediumRSS là RSS feed structure
@@ -52,7 +57,7 @@ type MediumCrawler struct {
 func NewMediumCrawler(base *BaseCrawler, limit int) *MediumCrawler {
 	return &MediumCrawler{
 		BaseCrawler: base,
-		client:      httpclient.NewClient(10 * time.Second),
+		client:      newHTTPClient(base.source),
 		topics: []string{
 			"machine-learning",
 			"artificial-intelligence",
@@ -70,17 +75,17 @@ func (m *MediumCrawler) Name() string {
 }
 
 // Fetch lấy posts từ Medium
-func (m *MediumCrawler) Fetch() ([]models.Post, error) {
-	fmt.Println("📡 Fetching Medium posts...")
+func (m *MediumCrawler) Fetch(ctx context.Context) ([]models.Post, error) {
+	slog.InfoContext(ctx, "fetching posts", "source", "medium", "topics", len(m.topics))
 
 	posts := make([]models.Post, 0)
 
 	for _, topic := range m.topics {
-		fmt.Printf("   📌 Crawling topic: %s\n", topic)
+		slog.DebugContext(ctx, "crawling topic", "source", "medium", "topic", topic)
 
-		topicPosts, err := m.fetchFromTopic(topic)
+		topicPosts, err := m.fetchFromTopic(ctx, topic)
 		if err != nil {
-			fmt.Printf("   ⚠️  Topic error: %v\n", err)
+			slog.WarnContext(ctx, "topic error", "source", "medium", "topic", topic, logger.Err(err))
 			continue
 		}
 
@@ -90,15 +95,15 @@ func (m *MediumCrawler) Fetch() ([]models.Post, error) {
 		time.Sleep(200 * time.Millisecond)
 	}
 
-	fmt.Printf("✅ Fetched %d posts from Medium\n", len(posts))
+	slog.InfoContext(ctx, "fetch complete", "source", "medium", "posts", len(posts))
 	return posts, nil
 }
 
 // fetchFromTopic fetch posts từ một topic
-func (m *MediumCrawler) fetchFromTopic(topic string) ([]models.Post, error) {
+func (m *MediumCrawler) fetchFromTopic(ctx context.Context, topic string) ([]models.Post, error) {
 	url := fmt.Sprintf("https://medium.com/feed/tag/%s", topic)
 
-	data, err := m.client.Get(url)
+	data, err := m.client.Get(ctx, url)
 	if err != nil {
 		return nil, fmt.Errorf("fetch feed error: %w", err)
 	}
@@ -152,18 +157,26 @@ func (m *MediumCrawler) parseItem(item MediumItem, topic string) *models.Post {
 		content = strings.TrimSpace(content)
 	}
 
-	// Map topic
-	topicName := m.mapTopic(topic)
+	// Tag của feed và categories của bài: tín hiệu để consumer phân loại topic
+	tags := []string{topic}
+	for _, c := range item.Categories {
+		if c = strings.TrimSpace(c); c != "" && !slices.Contains(tags, c) {
+			tags = append(tags, c)
+		}
+	}
+
+	// Handle: @username in link (publication links have none)
+	handle := ""
+	authRe := regexp.MustCompile(`@([A-Za-z0-9_.-]+)`)
+	if am := authRe.FindStringSubmatch(link); len(am) > 1 {
+		handle = am[1]
+	}
 
-	// Author extraction fallback: try to extract from link if empty
+	// Author extraction fallback: use handle from link if empty
 	author := strings.TrimSpace(item.Author)
 	if author == "" {
-		// look for @username in link
-		authRe := regexp.MustCompile(`@([A-Za-z0-9_-]+)`)
-		am := authRe.FindStringSubmatch(link)
-		if len(am) > 1 {
-			author = am[1]
-		} else {
+		author = handle
+		if author == "" {
 			author = "Unknown"
 		}
 	}
@@ -187,30 +200,16 @@ func (m *MediumCrawler) parseItem(item MediumItem, topic string) *models.Post {
 	}
 
 	post := &models.Post{
-		ID:        id,
-		Author:    author,
-		Content:   content,
-		Topic:     topicName,
-		Platform:  "medium",
-		CreatedAt: createdAt,
-		Sentiment: "neutral", // Will be set by ML service
+		ID:           id,
+		Author:       author,
+		AuthorHandle: handle,
+		Title:        strings.TrimSpace(html.UnescapeString(item.Title)),
+		Content:      content,
+		Tags:         tags,
+		Platform:     "medium",
+		URL:          link,
+		CreatedAt:    createdAt,
 	}
 
 	return post
 }
-
-// mapTopic map Medium topic to our topics
-func (m *MediumCrawler) mapTopic(topic string) string {
-	switch topic {
-	case "machine-learning", "artificial-intelligence":
-		return "ai"
-	case "cloud-computing":
-		return "cloud"
-	case "devops":
-		return "devops"
-	case "startups":
-		return "startup"
-	default:
-		return "programming"
-	}
-}
@@ -8,19 +8,30 @@
 package database
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
+	"social-insight/internal/metrics"
 	"social-insight/internal/models"
+	"social-insight/internal/taxonomy"
+	"social-insight/internal/tracing"
+	"sort"
 	"strings"
 	"time"
 
-	_ "github.com/lib/pq" // PostgreSQL driver
+	"github.com/lib/pq" // PostgreSQL driver
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // DB là wrapper cho database connection
 type DB struct {
 	// conn là SQL connection
 	conn *sql.DB
+
+	// ctx là context của các thao tác ghi (span cha, xem WithContext)
+	ctx context.Context
 }
 
 // Config chứa cấu hình kết nối database
@@ -56,55 +67,118 @@ func NewDB(cfg Config) (*DB, error) {
 		return nil, fmt.Errorf("không thể kết nối database: %w", err)
 	}
 
-	return &DB{conn: conn}, nil
+	return NewDBFromConn(conn), nil
 }
 
-// InsertPost chèn một post vào database
-func (db *DB) InsertPost(post models.Post) error {
-	query := `
-		INSERT INTO posts (id, author, content, topic, sentiment, likes, comments, shares, platform, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
-		ON CONFLICT (id) DO NOTHING
-	`
+// NewDBFromConn bọc connection đã mở (pool do caller cấu hình)
+func NewDBFromConn(conn *sql.DB) *DB {
+	return &DB{conn: conn, ctx: context.Background()}
+}
 
-	_, err := db.conn.Exec(query,
-		post.ID,
-		post.Author,
-		post.Content,
-		post.Topic,
-		post.Sentiment,
-		post.Likes,
-		post.Comments,
-		post.Shares,
-		post.Platform,
-		post.CreatedAt,
+// WithContext trả về bản sao DB dùng ctx cho các thao tác ghi
+// (dùng chung connection pool); span của thao tác là con của span trong ctx
+func (db *DB) WithContext(ctx context.Context) *DB {
+	clone := *db
+	clone.ctx = ctx
+	return &clone
+}
+
+// observe đo thời gian thao tác (metrics) và mở span "postgres <operation>"
+// Dùng: ctx, end := db.observe("insert_posts"); defer end(&err)
+func (db *DB) observe(operation string) (context.Context, func(*error)) {
+	start := time.Now()
+	ctx, span := tracing.Start(db.ctx, "postgres "+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "postgresql"),
+			attribute.String("db.operation", operation),
+		),
 	)
+	return ctx, func(err *error) {
+		metrics.ObserveDB(operation, start, err)
+		tracing.End(span, err)
+	}
+}
 
+// InsertPost chèn một post vào database
+func (db *DB) InsertPost(post models.Post) error {
+	_, err := db.InsertPosts([]models.Post{post})
 	return err
 }
 
+// insertPostCols là số placeholder của mỗi post trong câu INSERT
+const insertPostCols = 18
+
+// maxInsertRows là số posts tối đa mỗi câu INSERT: Postgres giới hạn 65535 params
+const maxInsertRows = 65535 / insertPostCols
+
 // InsertPosts chèn nhiều posts cùng lúc (batch insert)
-// Tối ưu performance với bulk insert
-func (db *DB) InsertPosts(posts []models.Post) error {
+// Tối ưu performance với bulk insert; batch lớn hơn maxInsertRows được
+// chia thành nhiều câu INSERT trong cùng transaction
+// Posts có canonical URL được gom vào bảng stories; topics của post
+// (post_topics) và thống kê tác giả trong bảng authors được ghi trong
+// cùng transaction
+// inserted là id các posts thực sự được thêm: post đã có (message bị
+// redeliver, replay) bị ON CONFLICT bỏ qua và không nằm trong inserted
+func (db *DB) InsertPosts(posts []models.Post) (inserted []string, err error) {
 	if len(posts) == 0 {
-		return nil
+		return nil, nil
+	}
+	ctx, end := db.observe("insert_posts")
+	defer end(&err)
+
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction error: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Upsert stories trước để posts có thể tham chiếu story_id
+	if err := upsertStories(ctx, tx, posts); err != nil {
+		return nil, err
 	}
 
+	for start := 0; start < len(posts); start += maxInsertRows {
+		chunk := posts[start:min(start+maxInsertRows, len(posts))]
+		ids, err := insertPostRows(ctx, tx, chunk)
+		if err != nil {
+			return nil, err
+		}
+		inserted = append(inserted, ids...)
+	}
+	if err := replacePostTopics(ctx, tx, posts); err != nil {
+		return nil, err
+	}
+	if err := refreshAuthors(ctx, tx, postIDs(posts)); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return inserted, nil
+}
+
+// insertPostRows chèn posts (tối đa maxInsertRows) bằng một câu INSERT
+// Trả về id các posts được thêm (RETURNING bỏ qua các dòng conflict)
+func insertPostRows(ctx context.Context, tx *sql.Tx, posts []models.Post) ([]string, error) {
 	// Xây dựng query với nhiều VALUES
 	// INSERT INTO posts VALUES ($1...), ($2...), ...
+	const cols = insertPostCols
 	valueStrings := make([]string, 0, len(posts))
-	valueArgs := make([]interface{}, 0, len(posts)*10)
+	valueArgs := make([]interface{}, 0, len(posts)*cols)
 
 	for i, post := range posts {
-		offset := i * 10
+		o := i * cols
 		valueStrings = append(valueStrings, fmt.Sprintf(
-			"($%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d)",
-			offset+1, offset+2, offset+3, offset+4, offset+5,
-			offset+6, offset+7, offset+8, offset+9, offset+10,
+			"($%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, $%d, NULLIF($%d, ''), NULLIF($%d, ''), NULLIF($%d, ''), "+
+				"(SELECT id FROM stories WHERE canonical_url = NULLIF($%d, '')), NULLIF($%d, ''), $%d, $%d, $%d)",
+			o+1, o+2, o+3, o+4, o+5, o+6, o+7,
+			o+8, o+9, o+10, o+11, o+12, o+13, o+14, o+14, o+15, o+16, o+17, o+18,
 		))
 		valueArgs = append(valueArgs,
 			post.ID,
 			post.Author,
+			post.Title,
 			post.Content,
 			post.Topic,
 			post.Sentiment,
@@ -113,17 +187,188 @@ func (db *DB) InsertPosts(posts []models.Post) error {
 			post.Shares,
 			post.Platform,
 			post.CreatedAt,
+			post.CanonicalPostID,
+			post.URL,
+			post.CanonicalURL,
+			post.TraceID,
+			post.Handle(),
+			pq.Array(post.Keywords),
+			pq.Array(post.Tags),
 		)
 	}
 
 	query := fmt.Sprintf(`
-		INSERT INTO posts (id, author, content, topic, sentiment, likes, comments, shares, platform, created_at)
+		INSERT INTO posts (id, author, title, content, topic, sentiment, likes, comments, shares, platform, created_at,
+			canonical_post_id, url, canonical_url, story_id, trace_id, author_handle, keywords, tags)
 		VALUES %s
 		ON CONFLICT (id) DO NOTHING
+		RETURNING id
 	`, strings.Join(valueStrings, ","))
 
-	_, err := db.conn.Exec(query, valueArgs...)
-	return err
+	rows, err := tx.QueryContext(ctx, query, valueArgs...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	inserted := make([]string, 0, len(posts))
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		inserted = append(inserted, id)
+	}
+	return inserted, rows.Err()
+}
+
+// affectedAuthors là các tác giả có post trong $1 (ids)
+const affectedAuthors = `(SELECT platform, author_handle FROM posts WHERE id = ANY($1) AND author_handle IS NOT NULL)`
+
+// authorRefreshQueries tính lại bảng authors rồi các cạnh author_topics,
+// author_keywords của affectedAuthors. ORDER BY ở câu đầu: các transaction
+// song song lock rows authors theo cùng thứ tự (tránh deadlock), các câu
+// sau chỉ chạm tác giả đã lock
+var authorRefreshQueries = []string{`
+		INSERT INTO authors (platform, handle, display_name, first_seen_at, last_seen_at,
+			post_count, total_likes, total_comments, total_shares, topic_counts, avg_sentiment, active_weeks, updated_at)
+		SELECT p.platform, p.author_handle,
+			(array_agg(p.author ORDER BY p.created_at DESC))[1],
+			MIN(p.created_at), MAX(p.created_at), COUNT(*),
+			COALESCE(SUM(p.likes), 0), COALESCE(SUM(p.comments), 0), COALESCE(SUM(p.shares), 0),
+			(SELECT jsonb_object_agg(t.topic, t.n) FROM (
+				SELECT topic, COUNT(*) AS n FROM posts
+				WHERE platform = p.platform AND author_handle = p.author_handle
+				GROUP BY topic) t),
+			AVG(CASE p.sentiment WHEN 'positive' THEN 1 WHEN 'negative' THEN -1 ELSE 0 END),
+			COUNT(DISTINCT date_trunc('week', p.created_at)),
+			NOW()
+		FROM posts p
+		WHERE (p.platform, p.author_handle) IN ` + affectedAuthors + `
+		GROUP BY p.platform, p.author_handle
+		ORDER BY p.platform, p.author_handle
+		ON CONFLICT (platform, handle) DO UPDATE SET
+			display_name = EXCLUDED.display_name,
+			first_seen_at = EXCLUDED.first_seen_at,
+			last_seen_at = EXCLUDED.last_seen_at,
+			post_count = EXCLUDED.post_count,
+			total_likes = EXCLUDED.total_likes,
+			total_comments = EXCLUDED.total_comments,
+			total_shares = EXCLUDED.total_shares,
+			topic_counts = EXCLUDED.topic_counts,
+			avg_sentiment = EXCLUDED.avg_sentiment,
+			active_weeks = EXCLUDED.active_weeks,
+			updated_at = NOW()
+	`, `
+		DELETE FROM author_topics WHERE (platform, handle) IN ` + affectedAuthors + `
+	`, `
+		INSERT INTO author_topics (platform, handle, topic, post_count, engagement, last_post_at)
+		SELECT platform, author_handle, topic, COUNT(*),
+			COALESCE(SUM(likes + comments + shares), 0), MAX(created_at)
+		FROM posts
+		WHERE (platform, author_handle) IN ` + affectedAuthors + `
+		GROUP BY platform, author_handle, topic
+	`, `
+		DELETE FROM author_keywords WHERE (platform, handle) IN ` + affectedAuthors + `
+	`, `
+		INSERT INTO author_keywords (platform, handle, topic, keyword, post_count)
+		SELECT p.platform, p.author_handle, p.topic, k.keyword, COUNT(*)
+		FROM posts p CROSS JOIN LATERAL unnest(p.keywords) AS k(keyword)
+		WHERE (p.platform, p.author_handle) IN ` + affectedAuthors + `
+		GROUP BY p.platform, p.author_handle, p.topic, k.keyword
+	`}
+
+// refreshAuthors tính lại thống kê trong bảng authors và cạnh của đồ thị
+// tác giả – topic/keyword của các tác giả có post trong ids, từ toàn bộ
+// posts của họ (không cộng dồn delta nên retry, replay hay update lặp lại
+// không làm lệch số liệu)
+func refreshAuthors(ctx context.Context, exec execer, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	for _, query := range authorRefreshQueries {
+		if _, err := exec.ExecContext(ctx, query, pq.Array(ids)); err != nil {
+			return fmt.Errorf("refresh authors error: %w", err)
+		}
+	}
+	return nil
+}
+
+// postIDs trả về id của posts
+func postIDs(posts []models.Post) []string {
+	ids := make([]string, len(posts))
+	for i, p := range posts {
+		ids[i] = p.ID
+	}
+	return ids
+}
+
+// replacePostTopics ghi lại post_topics của posts có Topics (xóa nhãn cũ
+// rồi insert, nên enrich lại với taxonomy mới không để sót nhãn cũ)
+// Topic đầu tiên của mỗi post là topic chính
+func replacePostTopics(ctx context.Context, exec execer, posts []models.Post) error {
+	var ids, rowIDs, topics, parents []string
+	var scores []float64
+	var primary []bool
+	for _, p := range posts {
+		if len(p.Topics) == 0 {
+			continue
+		}
+		ids = append(ids, p.ID)
+		for i, t := range p.Topics {
+			rowIDs = append(rowIDs, p.ID)
+			topics = append(topics, t.Topic)
+			parents = append(parents, t.Parent)
+			scores = append(scores, t.Score)
+			primary = append(primary, i == 0)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	if _, err := exec.ExecContext(ctx, `DELETE FROM post_topics WHERE post_id = ANY($1)`, pq.Array(ids)); err != nil {
+		return fmt.Errorf("delete post topics error: %w", err)
+	}
+	_, err := exec.ExecContext(ctx, `
+		INSERT INTO post_topics (post_id, topic, parent, score, is_primary)
+		SELECT id, topic, NULLIF(parent, ''), score, is_primary
+		FROM unnest($1::text[], $2::text[], $3::text[], $4::real[], $5::boolean[]) AS u(id, topic, parent, score, is_primary)
+		ON CONFLICT (post_id, topic) DO NOTHING
+	`, pq.Array(rowIDs), pq.Array(topics), pq.Array(parents), pq.Array(scores), pq.Array(primary))
+	if err != nil {
+		return fmt.Errorf("insert post topics error: %w", err)
+	}
+	return nil
+}
+
+// upsertStories tạo story cho các canonical URL chưa có, cập nhật last_seen_at cho URL đã có
+func upsertStories(ctx context.Context, tx *sql.Tx, posts []models.Post) error {
+	urls := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, p := range posts {
+		if p.CanonicalURL == "" || seen[p.CanonicalURL] {
+			continue
+		}
+		seen[p.CanonicalURL] = true
+		urls = append(urls, p.CanonicalURL)
+	}
+	if len(urls) == 0 {
+		return nil
+	}
+
+	// Sắp xếp để các transaction song song lock rows theo cùng thứ tự (tránh deadlock)
+	sort.Strings(urls)
+
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO stories (canonical_url)
+		SELECT unnest($1::text[])
+		ON CONFLICT (canonical_url) DO UPDATE SET last_seen_at = NOW()
+	`, pq.Array(urls))
+	if err != nil {
+		return fmt.Errorf("upsert stories error: %w", err)
+	}
+	return nil
 }
 
 // GetPostCount trả về tổng số posts trong database
@@ -133,11 +378,13 @@ func (db *DB) GetPostCount() (int64, error) {
 	return count, err
 }
 
-// GetStatsByTopic trả về thống kê theo topic
+// GetStatsByTopic trả về số posts của mỗi topic cấp 1
+// (post nhiều topic được đếm ở mỗi topic của nó)
 func (db *DB) GetStatsByTopic() (map[string]int64, error) {
 	query := `
 		SELECT topic, COUNT(*) as count
-		FROM posts
+		FROM post_topics
+		WHERE parent IS NULL
 		GROUP BY topic
 	`
 
@@ -160,6 +407,52 @@ func (db *DB) GetStatsByTopic() (map[string]int64, error) {
 	return stats, nil
 }
 
+// SyncTopics ghi cây topic của tax vào bảng topics (API đọc để trả về
+// taxonomy); topic không còn trong taxonomy được đánh dấu inactive
+func (db *DB) SyncTopics(tax *taxonomy.Taxonomy) (err error) {
+	ctx, end := db.observe("sync_topics")
+	defer end(&err)
+
+	nodes := tax.Nodes()
+	ids := make([]string, len(nodes))
+	parents := make([]string, len(nodes))
+	names := make([]string, len(nodes))
+	positions := make([]int64, len(nodes))
+	for i, n := range nodes {
+		ids[i], parents[i], names[i], positions[i] = n.ID, n.Parent, n.Name, int64(n.Position)
+	}
+
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction error: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO topics (id, parent, name, position, taxonomy_version, active, updated_at)
+		SELECT id, NULLIF(parent, ''), name, position, $5, TRUE, NOW()
+		FROM unnest($1::text[], $2::text[], $3::text[], $4::int[]) AS u(id, parent, name, position)
+		ON CONFLICT (id) DO UPDATE SET
+			parent = EXCLUDED.parent,
+			name = EXCLUDED.name,
+			position = EXCLUDED.position,
+			taxonomy_version = EXCLUDED.taxonomy_version,
+			active = TRUE,
+			updated_at = NOW()
+	`, pq.Array(ids), pq.Array(parents), pq.Array(names), pq.Array(positions), tax.Version)
+	if err != nil {
+		return fmt.Errorf("upsert topics error: %w", err)
+	}
+	_, err = tx.ExecContext(ctx, `
+		UPDATE topics SET active = FALSE, updated_at = NOW()
+		WHERE active AND NOT (id = ANY($1))
+	`, pq.Array(ids))
+	if err != nil {
+		return fmt.Errorf("deactivate topics error: %w", err)
+	}
+	return tx.Commit()
+}
+
 // GetStatsBySentiment trả về thống kê theo sentiment
 func (db *DB) GetStatsBySentiment() (map[string]int64, error) {
 	query := `
@@ -220,6 +513,286 @@ func (db *DB) GetTopAuthors(limit int) ([]map[string]interface{}, error) {
 	return results, nil
 }
 
+// =====================================================
+// DEAD LETTERS
+// =====================================================
+
+// DeadLetterFilter chọn dead letters để replay
+type DeadLetterFilter struct {
+	IDs             []int64   // Chỉ lấy các ID này (rỗng = không lọc)
+	Stage           string    // Lọc theo stage (rỗng = tất cả)
+	Since           time.Time // Chỉ lấy lỗi từ thời điểm này
+	IncludeReplayed bool      // Lấy cả dead letters đã replay
+	Limit           int
+}
+
+// InsertDeadLetter lưu dead letter
+// Cùng vị trí Kafka (topic, partition, offset) chỉ được lưu một lần
+func (db *DB) InsertDeadLetter(dl models.DeadLetter) error {
+	query := `
+		INSERT INTO dead_letters (payload, message_key, source_topic, source_partition, source_offset,
+			stage, error, producer, failed_at)
+		VALUES ($1, NULLIF($2, ''), $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (source_topic, source_partition, source_offset) WHERE source_offset >= 0 DO NOTHING
+	`
+
+	_, err := db.conn.Exec(query,
+		dl.Payload,
+		dl.Key,
+		dl.SourceTopic,
+		dl.Partition,
+		dl.Offset,
+		dl.Stage,
+		dl.Error,
+		dl.Producer,
+		dl.FailedAt,
+	)
+	return err
+}
+
+// GetDeadLetters trả về dead letters theo filter, cũ nhất trước
+func (db *DB) GetDeadLetters(f DeadLetterFilter) ([]models.DeadLetter, error) {
+	conds := []string{"1=1"}
+	args := make([]interface{}, 0)
+	addArg := func(v interface{}) string {
+		args = append(args, v)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	if len(f.IDs) > 0 {
+		conds = append(conds, "id = ANY("+addArg(pq.Array(f.IDs))+")")
+	}
+	if f.Stage != "" {
+		conds = append(conds, "stage = "+addArg(f.Stage))
+	}
+	if !f.Since.IsZero() {
+		conds = append(conds, "failed_at >= "+addArg(f.Since))
+	}
+	if !f.IncludeReplayed {
+		conds = append(conds, "replayed_at IS NULL")
+	}
+	limit := f.Limit
+	if limit <= 0 {
+		limit = 100
+	}
+
+	query := fmt.Sprintf(`
+		SELECT id, payload, COALESCE(message_key, ''), source_topic, source_partition, source_offset,
+			stage, error, COALESCE(producer, ''), failed_at, replayed_at, replay_count
+		FROM dead_letters
+		WHERE %s
+		ORDER BY id ASC
+		LIMIT %s
+	`, strings.Join(conds, " AND "), addArg(limit))
+
+	rows, err := db.conn.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	results := make([]models.DeadLetter, 0)
+	for rows.Next() {
+		var dl models.DeadLetter
+		if err := rows.Scan(
+			&dl.ID, &dl.Payload, &dl.Key, &dl.SourceTopic, &dl.Partition, &dl.Offset,
+			&dl.Stage, &dl.Error, &dl.Producer, &dl.FailedAt, &dl.ReplayedAt, &dl.ReplayCount,
+		); err != nil {
+			return nil, err
+		}
+		results = append(results, dl)
+	}
+	return results, rows.Err()
+}
+
+// MarkDeadLetterReplayed đánh dấu dead letter đã được replay
+func (db *DB) MarkDeadLetterReplayed(id int64) error {
+	_, err := db.conn.Exec(`
+		UPDATE dead_letters
+		SET replayed_at = NOW(), replay_count = replay_count + 1
+		WHERE id = $1
+	`, id)
+	return err
+}
+
+// =====================================================
+// REPROCESSING (replay / backfill)
+// =====================================================
+
+// Checkpoint là vị trí đã xử lý của một job reprocess
+type Checkpoint struct {
+	JobName           string
+	Cursor            string // Post ID cuối đã xử lý
+	Processed         int64
+	Updated           int64
+	EnrichmentVersion int
+	CompletedAt       *time.Time
+}
+
+// execer là *sql.DB hoặc *sql.Tx
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// GetCheckpoint đọc checkpoint của job (nil nếu chưa có)
+func (db *DB) GetCheckpoint(jobName string) (*Checkpoint, error) {
+	cp := Checkpoint{JobName: jobName}
+	var completedAt sql.NullTime
+	err := db.conn.QueryRow(`
+		SELECT cursor, processed, updated, enrichment_version, completed_at
+		FROM job_checkpoints WHERE job_name = $1
+	`, jobName).Scan(&cp.Cursor, &cp.Processed, &cp.Updated, &cp.EnrichmentVersion, &completedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if completedAt.Valid {
+		cp.CompletedAt = &completedAt.Time
+	}
+	return &cp, nil
+}
+
+// DeleteCheckpoint xóa checkpoint để job chạy lại từ đầu
+func (db *DB) DeleteCheckpoint(jobName string) error {
+	_, err := db.conn.Exec("DELETE FROM job_checkpoints WHERE job_name = $1", jobName)
+	return err
+}
+
+// GetPostsAfter đọc posts có id > afterID theo thứ tự id (keyset pagination)
+// Topics theo thứ tự ghi: topic chính trước, rồi điểm giảm dần như Classify
+func (db *DB) GetPostsAfter(afterID string, limit int) ([]models.Post, error) {
+	rows, err := db.conn.Query(`
+		SELECT id, COALESCE(title, ''), content, topic, sentiment, keywords, tags, platform,
+			COALESCE((
+				SELECT json_agg(json_build_object('topic', t.topic, 'parent', COALESCE(t.parent, ''), 'score', t.score)
+					ORDER BY t.is_primary DESC, (t.parent IS NOT NULL), t.score DESC)
+				FROM post_topics t WHERE t.post_id = posts.id
+			), '[]')
+		FROM posts
+		WHERE id > $1
+		ORDER BY id
+		LIMIT $2
+	`, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	posts := make([]models.Post, 0, limit)
+	for rows.Next() {
+		var p models.Post
+		var topics []byte
+		if err := rows.Scan(&p.ID, &p.Title, &p.Content, &p.Topic, &p.Sentiment, pq.Array(&p.Keywords),
+			pq.Array(&p.Tags), &p.Platform, &topics); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(topics, &p.Topics); err != nil {
+			return nil, fmt.Errorf("invalid topics of post %s: %w", p.ID, err)
+		}
+		posts = append(posts, p)
+	}
+	return posts, rows.Err()
+}
+
+// CountPostsAfter đếm posts có id > afterID (dùng để tính tiến độ)
+func (db *DB) CountPostsAfter(afterID string) (int64, error) {
+	var count int64
+	err := db.conn.QueryRow("SELECT COUNT(*) FROM posts WHERE id > $1", afterID).Scan(&count)
+	return count, err
+}
+
+// UpdateEnrichment cập nhật topic, topics, sentiment và keywords của posts đã lưu
+func (db *DB) UpdateEnrichment(posts []models.Post) (err error) {
+	ctx, end := db.observe("update_enrichment")
+	defer end(&err)
+
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction error: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := updateEnrichment(ctx, tx, posts); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// SaveEnrichmentBatch cập nhật posts và lưu checkpoint trong cùng transaction
+// Job bị dừng giữa chừng sẽ chạy tiếp từ checkpoint mà không sót hay lặp batch
+func (db *DB) SaveEnrichmentBatch(posts []models.Post, cp Checkpoint) (err error) {
+	ctx, end := db.observe("save_enrichment_batch")
+	defer end(&err)
+
+	tx, err := db.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction error: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := updateEnrichment(ctx, tx, posts); err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO job_checkpoints (job_name, cursor, processed, updated, enrichment_version, completed_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (job_name) DO UPDATE SET
+			cursor = EXCLUDED.cursor,
+			processed = EXCLUDED.processed,
+			updated = EXCLUDED.updated,
+			enrichment_version = EXCLUDED.enrichment_version,
+			completed_at = EXCLUDED.completed_at,
+			updated_at = NOW()
+	`, cp.JobName, cp.Cursor, cp.Processed, cp.Updated, cp.EnrichmentVersion, cp.CompletedAt)
+	if err != nil {
+		return fmt.Errorf("save checkpoint error: %w", err)
+	}
+	return tx.Commit()
+}
+
+// updateEnrichment UPDATE topic/sentiment/keywords bằng unnest (một câu lệnh
+// cho cả batch), ghi lại post_topics rồi tính lại thống kê của các tác giả
+// liên quan
+func updateEnrichment(ctx context.Context, exec execer, posts []models.Post) error {
+	if len(posts) == 0 {
+		return nil
+	}
+
+	ids := make([]string, len(posts))
+	topics := make([]string, len(posts))
+	sentiments := make([]string, len(posts))
+	// unnest không nhận mảng lồng nhau: keywords nối bằng dấu cách (keyword không chứa khoảng trắng)
+	keywords := make([]string, len(posts))
+	for i, p := range posts {
+		ids[i] = p.ID
+		topics[i] = p.Topic
+		sentiments[i] = p.Sentiment
+		keywords[i] = strings.Join(p.Keywords, " ")
+	}
+
+	_, err := exec.ExecContext(ctx, `
+		UPDATE posts p
+		SET topic = u.topic, sentiment = u.sentiment, keywords = string_to_array(u.keywords, ' ')
+		FROM unnest($1::text[], $2::text[], $3::text[], $4::text[]) AS u(id, topic, sentiment, keywords)
+		WHERE p.id = u.id
+	`, pq.Array(ids), pq.Array(topics), pq.Array(sentiments), pq.Array(keywords))
+	if err != nil {
+		return fmt.Errorf("update enrichment error: %w", err)
+	}
+	if err := replacePostTopics(ctx, exec, posts); err != nil {
+		return err
+	}
+	return refreshAuthors(ctx, exec, ids)
+}
+
+// Ping kiểm tra kết nối PostgreSQL (health check)
+func (db *DB) Ping(ctx context.Context) error {
+	return db.conn.PingContext(ctx)
+}
+
 // Close đóng database connection
 func (db *DB) Close() error {
 	return db.conn.Close()
@@ -0,0 +1,96 @@
+package database
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"social-insight/internal/models"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func newMockDB(t *testing.T) (*DB, sqlmock.Sqlmock) {
+	t.Helper()
+	conn, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return NewDBFromConn(conn), mock
+}
+
+// anyArgs khớp đúng n tham số bất kỳ
+func anyArgs(n int) []driver.Value {
+	args := make([]driver.Value, n)
+	for i := range args {
+		args[i] = sqlmock.AnyArg()
+	}
+	return args
+}
+
+// expectRefreshAuthors khớp các câu tính lại bảng authors
+func expectRefreshAuthors(mock sqlmock.Sqlmock) {
+	for range authorRefreshQueries {
+		mock.ExpectExec("author").WillReturnResult(sqlmock.NewResult(0, 0))
+	}
+}
+
+func TestInsertPostsChunks(t *testing.T) {
+	db, mock := newMockDB(t)
+
+	posts := make([]models.Post, maxInsertRows+2)
+	for i := range posts {
+		posts[i] = models.Post{ID: fmt.Sprintf("hn_%d", i), Author: "a", Platform: "hackernews"}
+	}
+
+	// Mỗi câu INSERT không vượt giới hạn 65535 params của Postgres;
+	// hn_1 đã có (redeliver) nên RETURNING không trả về
+	mock.ExpectBegin()
+	mock.ExpectQuery("INSERT INTO posts .* ON CONFLICT \\(id\\) DO NOTHING\\s+RETURNING id").
+		WithArgs(anyArgs(maxInsertRows * insertPostCols)...).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("hn_0").AddRow("hn_2"))
+	mock.ExpectQuery("INSERT INTO posts").
+		WithArgs(anyArgs(2 * insertPostCols)...).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(posts[maxInsertRows+1].ID))
+	expectRefreshAuthors(mock)
+	mock.ExpectCommit()
+
+	inserted, err := db.InsertPosts(posts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"hn_0", "hn_2", posts[maxInsertRows+1].ID}
+	if !reflect.DeepEqual(inserted, want) {
+		t.Errorf("inserted = %v, want %v", inserted, want)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestInsertPostsRedelivered(t *testing.T) {
+	db, mock := newMockDB(t)
+
+	// Cả batch đã có trong DB: không post nào được tính là mới
+	mock.ExpectBegin()
+	mock.ExpectQuery("INSERT INTO posts").WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	expectRefreshAuthors(mock)
+	mock.ExpectCommit()
+
+	inserted, err := db.InsertPosts([]models.Post{{ID: "hn_1"}, {ID: "hn_2"}})
+	if err != nil || len(inserted) != 0 {
+		t.Errorf("inserted = %v, %v; want none", inserted, err)
+	}
+
+	// Lỗi giữa chừng: rollback, không trả về id nào
+	mock.ExpectBegin()
+	mock.ExpectQuery("INSERT INTO posts").WillReturnError(fmt.Errorf("connection reset"))
+	mock.ExpectRollback()
+	if inserted, err := db.InsertPosts([]models.Post{{ID: "hn_3"}}); err == nil || inserted != nil {
+		t.Errorf("failed insert = %v, %v", inserted, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,99 @@
+// =====================================================
+// DEAD LETTER QUEUE - Publish và archive message lỗi
+// =====================================================
+// Mô tả: Publisher đẩy message lỗi sang dead letter topic
+// Archiver đọc dead letter topic và lưu vào bảng dead_letters
+// để lệnh replay-dlq có thể chọn và gửi lại vào raw_posts
+// =====================================================
+
+package deadletter
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"social-insight/internal/database"
+	"social-insight/internal/kafka"
+	"social-insight/internal/logger"
+	"social-insight/internal/models"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+// Publisher gửi dead letters vào Kafka topic
+type Publisher struct {
+	producer *kafka.Producer
+	topic    string
+
+	// fallback ghi thẳng vào PostgreSQL nếu Kafka lỗi (nil = không có)
+	fallback *database.DB
+}
+
+// NewPublisher tạo dead letter publisher
+// fallback: database để ghi trực tiếp khi không gửi được Kafka (có thể nil)
+func NewPublisher(producer *kafka.Producer, topic string, fallback *database.DB) *Publisher {
+	return &Publisher{
+		producer: producer,
+		topic:    topic,
+		fallback: fallback,
+	}
+}
+
+// Publish gửi dead letter vào topic
+func (p *Publisher) Publish(dl models.DeadLetter) error {
+	if dl.FailedAt.IsZero() {
+		dl.FailedAt = time.Now()
+	}
+
+	data, err := json.Marshal(dl)
+	if err != nil {
+		return fmt.Errorf("không thể marshal dead letter: %w", err)
+	}
+
+	err = p.producer.SendRaw(p.topic, dl.Key, data)
+	if err == nil {
+		return nil
+	}
+	if p.fallback == nil {
+		return err
+	}
+
+	slog.Warn("dead letter topic unavailable, writing to PostgreSQL", "topic", p.topic, logger.Err(err))
+	return p.fallback.InsertDeadLetter(dl)
+}
+
+// Archiver lưu dead letters từ Kafka vào PostgreSQL
+// Implement kafka.MessageHandler
+type Archiver struct {
+	db *database.DB
+}
+
+// NewArchiver tạo archiver
+func NewArchiver(db *database.DB) *Archiver {
+	return &Archiver{db: db}
+}
+
+// HandleMessage parse dead letter và lưu vào bảng dead_letters
+// Message không parse được vẫn được lưu dạng thô để không mất dấu
+func (a *Archiver) HandleMessage(message *sarama.ConsumerMessage) error {
+	var dl models.DeadLetter
+	if err := json.Unmarshal(message.Value, &dl); err != nil {
+		dl = models.DeadLetter{
+			Payload:     message.Value,
+			Key:         string(message.Key),
+			SourceTopic: message.Topic,
+			Partition:   message.Partition,
+			Offset:      message.Offset,
+			Stage:       models.StageUnmarshal,
+			Error:       fmt.Sprintf("invalid dead letter envelope: %v", err),
+			Producer:    "dlq-archiver",
+			FailedAt:    time.Now(),
+		}
+	}
+
+	if err := a.db.InsertDeadLetter(dl); err != nil {
+		return fmt.Errorf("archive dead letter error: %w", err)
+	}
+	return nil
+}
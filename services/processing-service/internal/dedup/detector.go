@@ -0,0 +1,80 @@
+// =====================================================
+// NEAR-DUPLICATE DETECTOR
+// =====================================================
+// Mô tả: Phát hiện bài cross-post giữa các nguồn bằng SimHash
+// Index lưu trên Redis để nhiều crawler dùng chung
+// =====================================================
+
+package dedup
+
+import (
+	"fmt"
+	"social-insight/internal/redis"
+	"time"
+)
+
+// Detector tìm post gốc có title gần giống với post mới
+type Detector struct {
+	redis     *redis.Client
+	threshold int           // Hamming distance tối đa để coi là duplicate
+	ttl       time.Duration // Thời gian giữ fingerprint trong index
+}
+
+// Match là kết quả khi tìm thấy near-duplicate
+type Match struct {
+	CanonicalPostID string
+	Distance        int
+}
+
+// NewDetector tạo near-duplicate detector
+// threshold: Hamming distance tối đa (khuyến nghị 3 cho fingerprint 64-bit)
+func NewDetector(redis *redis.Client, threshold int, ttl time.Duration) *Detector {
+	if threshold < 0 {
+		threshold = 0
+	}
+	if ttl == 0 {
+		ttl = 30 * 24 * time.Hour // Default 30 days
+	}
+	return &Detector{
+		redis:     redis,
+		threshold: threshold,
+		ttl:       ttl,
+	}
+}
+
+// FindCanonical tìm post gốc gần nhất với text
+// Trả về nil nếu không có candidate nào trong threshold
+func (d *Detector) FindCanonical(text string) (*Match, error) {
+	fp := SimHash(text)
+	if fp == 0 {
+		return nil, nil
+	}
+
+	candidates, err := d.redis.GetSimHashCandidates(Bands(fp, d.threshold))
+	if err != nil {
+		return nil, fmt.Errorf("simhash lookup error: %w", err)
+	}
+
+	var best *Match
+	for id, candidate := range candidates {
+		dist := HammingDistance(fp, candidate)
+		if dist > d.threshold {
+			continue
+		}
+		// Chọn candidate gần nhất, hòa thì lấy ID nhỏ hơn để kết quả ổn định
+		if best == nil || dist < best.Distance || (dist == best.Distance && id < best.CanonicalPostID) {
+			best = &Match{CanonicalPostID: id, Distance: dist}
+		}
+	}
+	return best, nil
+}
+
+// Index thêm post vào index để các bài sau có thể match
+// Chỉ nên index post gốc, không index duplicate (để cluster luôn trỏ về một gốc)
+func (d *Detector) Index(postID, text string) error {
+	fp := SimHash(text)
+	if fp == 0 {
+		return nil
+	}
+	return d.redis.IndexSimHash(postID, fp, Bands(fp, d.threshold), d.ttl)
+}
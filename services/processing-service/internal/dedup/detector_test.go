@@ -0,0 +1,64 @@
+package dedup
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"social-insight/internal/redis"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func TestFindCanonical(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client, err := redis.NewClient(mr.Addr())
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := NewDetector(client, testThreshold, time.Hour)
+
+	if err := d.Index("hn_1", original); err != nil {
+		t.Fatal(err)
+	}
+	for i, text := range unrelated {
+		if err := d.Index(fmt.Sprintf("hn_%d", i+10), text); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, text := range crossPosts {
+		match, err := d.FindCanonical(text)
+		if err != nil || match == nil || match.CanonicalPostID != "hn_1" ||
+			match.Distance != HammingDistance(SimHash(original), SimHash(text)) {
+			t.Errorf("FindCanonical(%q) = %+v, %v; want hn_1", text, match, err)
+		}
+	}
+
+	if match, err := d.FindCanonical("Postgres 17 adds incremental backups"); match != nil || err != nil {
+		t.Errorf("distinct text matched %+v, %v", match, err)
+	}
+
+	// Bản sao y hệt được index sau (ID lớn hơn): vẫn trỏ về bài gốc
+	d.Index("hn_2", original)
+	if match, _ := d.FindCanonical(original); match == nil || match.CanonicalPostID != "hn_1" || match.Distance != 0 {
+		t.Errorf("tie: match = %+v, want hn_1", match)
+	}
+
+	// Text không còn token nào thì không được index
+	d.Index("hn_3", "the and of")
+	if mr.Exists("simhash:fp:hn_3") {
+		t.Error("empty text was indexed")
+	}
+
+	// Fingerprint hết TTL: không còn là candidate
+	mr.FastForward(2 * time.Hour)
+	if match, err := d.FindCanonical(crossPosts[0]); match != nil || err != nil {
+		t.Errorf("expired index matched %+v, %v", match, err)
+	}
+
+	mr.Close()
+	if _, err := d.FindCanonical(original); err == nil {
+		t.Error("expected lookup error with Redis down")
+	}
+}
@@ -0,0 +1,214 @@
+// =====================================================
+// CONTENT HASH SET - Exact dedup theo content hash
+// =====================================================
+// Mô tả: Các cách lưu tập content hash đã thấy
+//   - keys:       mỗi hash một key SET NX (atomic, tốn bộ nhớ)
+//   - bloom:      Bloom filter tính trong process, bitmap trên Redis (Lua SETBIT)
+//   - redisbloom: Bloom filter của module RedisBloom (BF.ADD)
+// =====================================================
+
+package dedup
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"social-insight/internal/redis"
+	"time"
+)
+
+// Các chế độ lưu content hash
+const (
+	ModeKeys       = "keys"
+	ModeBloom      = "bloom"
+	ModeRedisBloom = "redisbloom"
+)
+
+// HashSet là tập các content hash đã gửi đi
+//
+// Quy trình cho mỗi post: Claim → gửi Kafka → Commit (broker ack) hoặc Release (thất bại)
+type HashSet interface {
+	// Claim trả về true nếu hash chưa thấy và caller được phép xử lý post
+	Claim(hash string) (bool, error)
+	// Commit ghi nhận hash đã được gửi thành công
+	Commit(hash string) error
+	// Release trả lại claim khi gửi thất bại
+	Release(hash string) error
+	// Mode trả về tên chế độ (dùng cho log)
+	Mode() string
+}
+
+// =====================================================
+// KEYS MODE
+// =====================================================
+
+// KeyHashSet lưu mỗi hash một key seen_posts:content_hash:{hash}
+// Claim dùng SET NX nên atomic giữa nhiều replicas
+type KeyHashSet struct {
+	redis      *redis.Client
+	ttl        time.Duration
+	pendingTTL time.Duration // TTL của claim khi chưa Commit
+}
+
+// NewKeyHashSet tạo KeyHashSet với TTL cho mỗi hash
+// Claim chỉ giữ 5 phút; Commit gia hạn thành ttl
+func NewKeyHashSet(redis *redis.Client, ttl time.Duration) *KeyHashSet {
+	return &KeyHashSet{redis: redis, ttl: ttl, pendingTTL: 5 * time.Minute}
+}
+
+// Claim đánh dấu hash bằng SET NX với TTL ngắn
+// Claim không được Commit (crawler chết trước khi Kafka ack) sẽ tự hết hạn
+func (s *KeyHashSet) Claim(hash string) (bool, error) {
+	return s.redis.CheckAndMark("content_hash", hash, s.pendingTTL)
+}
+
+// Commit gia hạn đánh dấu thành TTL đầy đủ sau khi gửi thành công
+func (s *KeyHashSet) Commit(hash string) error {
+	return s.redis.MarkAsSeen("content_hash", hash, s.ttl)
+}
+
+// Release xóa đánh dấu để lần crawl sau gửi lại
+func (s *KeyHashSet) Release(hash string) error {
+	return s.redis.UnmarkSeen("content_hash", hash)
+}
+
+// Mode trả về "keys"
+func (s *KeyHashSet) Mode() string {
+	return ModeKeys
+}
+
+// =====================================================
+// BLOOM MODE (bitmap trên Redis)
+// =====================================================
+
+// BloomHashSet là Bloom filter: vị trí bit tính trong process,
+// bitmap lưu trên Redis để các replicas dùng chung
+//
+// Claim bật cả k bit trong một Lua script nên hai replicas claim cùng hash
+// chỉ có một bên thắng. Bloom filter không xóa được nên Release đặt marker
+// {key}:released:{hash}; lần Claim kế tiếp tiêu thụ marker và được gửi lại.
+type BloomHashSet struct {
+	redis       *redis.Client
+	key         string
+	m           uint64        // Số bit
+	k           int           // Số hash functions
+	releasedTTL time.Duration // TTL của marker Release
+}
+
+// NewBloomHashSet tạo Bloom filter cho capacity phần tử với false positive rate fpRate
+func NewBloomHashSet(redis *redis.Client, key string, capacity int64, fpRate float64) *BloomHashSet {
+	m, k := BloomParams(capacity, fpRate)
+	return &BloomHashSet{redis: redis, key: key, m: m, k: k, releasedTTL: 7 * 24 * time.Hour}
+}
+
+// Claim thêm hash vào filter, trả về true nếu hash chắc chắn chưa có
+func (s *BloomHashSet) Claim(hash string) (bool, error) {
+	return s.redis.BloomClaim(s.key, releasedKey(s.key, hash), bloomOffsets(hash, s.m, s.k))
+}
+
+// Commit không cần làm gì vì Claim đã thêm hash
+func (s *BloomHashSet) Commit(hash string) error {
+	return nil
+}
+
+// Release cho phép lần Claim sau của hash thắng lại
+func (s *BloomHashSet) Release(hash string) error {
+	return s.redis.MarkReleased(releasedKey(s.key, hash), s.releasedTTL)
+}
+
+// Mode trả về "bloom"
+func (s *BloomHashSet) Mode() string {
+	return ModeBloom
+}
+
+// releasedKey là key marker Release của hash trong filter key
+func releasedKey(key, hash string) string {
+	return key + ":released:" + hash
+}
+
+// BloomParams tính số bit m và số hash k tối ưu
+// m = -n·ln(p) / (ln2)², k = m/n · ln2
+func BloomParams(capacity int64, fpRate float64) (uint64, int) {
+	if capacity <= 0 {
+		capacity = 1_000_000
+	}
+	if fpRate <= 0 || fpRate >= 1 {
+		fpRate = 0.001
+	}
+	n := float64(capacity)
+	m := math.Ceil(-n * math.Log(fpRate) / (math.Ln2 * math.Ln2))
+	k := int(math.Round(m / n * math.Ln2))
+	if k < 1 {
+		k = 1
+	}
+	return uint64(m), k
+}
+
+// bloomOffsets tính k vị trí bit bằng double hashing: h1 + i·h2 (mod m)
+func bloomOffsets(item string, m uint64, k int) []uint64 {
+	sum := sha256.Sum256([]byte(item))
+	h1 := binary.BigEndian.Uint64(sum[0:8])
+	h2 := binary.BigEndian.Uint64(sum[8:16]) | 1 // h2 lẻ để không lặp vị trí
+
+	offsets := make([]uint64, k)
+	for i := 0; i < k; i++ {
+		offsets[i] = (h1 + uint64(i)*h2) % m
+	}
+	return offsets
+}
+
+// =====================================================
+// REDISBLOOM MODE (module BF.*)
+// =====================================================
+
+// RedisBloomHashSet dùng module RedisBloom (redis-stack)
+// Ngữ nghĩa Claim/Commit/Release giống BloomHashSet, Claim dựa vào kết quả BF.ADD
+type RedisBloomHashSet struct {
+	redis       *redis.Client
+	key         string
+	releasedTTL time.Duration
+}
+
+// NewRedisBloomHashSet tạo filter bằng BF.RESERVE nếu chưa có
+func NewRedisBloomHashSet(redis *redis.Client, key string, capacity int64, fpRate float64) (*RedisBloomHashSet, error) {
+	if err := redis.BFReserve(key, fpRate, capacity); err != nil {
+		return nil, fmt.Errorf("BF.RESERVE error (RedisBloom module required): %w", err)
+	}
+	return &RedisBloomHashSet{redis: redis, key: key, releasedTTL: 7 * 24 * time.Hour}, nil
+}
+
+// Claim thêm hash vào filter, trả về true nếu BF.ADD báo hash mới
+func (s *RedisBloomHashSet) Claim(hash string) (bool, error) {
+	return s.redis.BFClaim(s.key, releasedKey(s.key, hash), hash)
+}
+
+// Commit không cần làm gì vì Claim đã thêm hash
+func (s *RedisBloomHashSet) Commit(hash string) error {
+	return nil
+}
+
+// Release cho phép lần Claim sau của hash thắng lại
+func (s *RedisBloomHashSet) Release(hash string) error {
+	return s.redis.MarkReleased(releasedKey(s.key, hash), s.releasedTTL)
+}
+
+// Mode trả về "redisbloom"
+func (s *RedisBloomHashSet) Mode() string {
+	return ModeRedisBloom
+}
+
+// NewHashSet tạo HashSet theo mode cấu hình
+// capacity/fpRate chỉ dùng cho bloom và redisbloom
+func NewHashSet(mode string, redis *redis.Client, capacity int64, fpRate float64) (HashSet, error) {
+	switch mode {
+	case "", ModeKeys:
+		return NewKeyHashSet(redis, 365*24*time.Hour), nil
+	case ModeBloom:
+		return NewBloomHashSet(redis, "dedup:bloom:content_hash", capacity, fpRate), nil
+	case ModeRedisBloom:
+		return NewRedisBloomHashSet(redis, "dedup:bf:content_hash", capacity, fpRate)
+	default:
+		return nil, fmt.Errorf("unknown dedup mode: %q", mode)
+	}
+}
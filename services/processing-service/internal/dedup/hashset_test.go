@@ -0,0 +1,59 @@
+package dedup
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"social-insight/internal/redis"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func TestClaimOneWinner(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client, err := redis.NewClient(mr.Addr())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sets := map[string]HashSet{
+		"keys":  NewKeyHashSet(client, 0),
+		"bloom": NewBloomHashSet(client, "dedup:bloom:test", 1000, 0.001),
+	}
+	for name, set := range sets {
+		// Nhiều replicas claim cùng một hash cùng lúc: đúng một bên thắng
+		var wins int64
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				ok, err := set.Claim("hash-" + name)
+				if err != nil {
+					t.Error(err)
+				}
+				if ok {
+					atomic.AddInt64(&wins, 1)
+				}
+			}()
+		}
+		wg.Wait()
+		if wins != 1 {
+			t.Fatalf("%s: %d concurrent claims won, want 1", name, wins)
+		}
+
+		// Release sau khi gửi thất bại: lần Claim kế tiếp thắng lại đúng một lần
+		if err := set.Release("hash-" + name); err != nil {
+			t.Fatal(err)
+		}
+		for i, want := range []bool{true, false} {
+			if ok, err := set.Claim("hash-" + name); ok != want || err != nil {
+				t.Errorf("%s: claim %d after release = %v, %v; want %v", name, i, ok, err, want)
+			}
+		}
+		if ok, _ := set.Claim("other-" + name); !ok {
+			t.Errorf("%s: distinct hash not claimed", name)
+		}
+	}
+}
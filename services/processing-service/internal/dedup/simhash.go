@@ -0,0 +1,118 @@
+// =====================================================
+// SIMHASH - Near-duplicate fingerprint
+// =====================================================
+// Mô tả: Tính SimHash 64-bit trên title đã chuẩn hóa
+// Hai title gần giống nhau cho fingerprint có Hamming distance nhỏ
+// =====================================================
+
+package dedup
+
+import (
+	"hash/fnv"
+	"math/bits"
+	"strings"
+	"unicode"
+)
+
+// stopWords là các từ phổ biến bị bỏ qua khi tính fingerprint
+var stopWords = map[string]bool{
+	"a": true, "an": true, "the": true, "and": true, "or": true,
+	"of": true, "to": true, "in": true, "on": true, "for": true,
+	"with": true, "is": true, "are": true, "how": true, "why": true,
+	"what": true, "your": true, "you": true, "i": true, "my": true,
+}
+
+// Normalize chuẩn hóa text: lowercase, bỏ dấu câu, bỏ stop words
+// Trả về danh sách tokens theo thứ tự xuất hiện
+func Normalize(text string) []string {
+	text = strings.ToLower(text)
+	words := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	tokens := make([]string, 0, len(words))
+	for _, w := range words {
+		if stopWords[w] {
+			continue
+		}
+		tokens = append(tokens, w)
+	}
+	return tokens
+}
+
+// features sinh features từ tokens: unigram + bigram
+// Bigram giúp phân biệt thứ tự từ, unigram giữ độ bền khi title bị sửa nhẹ
+func features(tokens []string) []string {
+	feats := make([]string, 0, len(tokens)*2)
+	feats = append(feats, tokens...)
+	for i := 0; i+1 < len(tokens); i++ {
+		feats = append(feats, tokens[i]+" "+tokens[i+1])
+	}
+	return feats
+}
+
+// SimHash tính fingerprint 64-bit của text
+// Trả về 0 nếu text không có token nào sau khi chuẩn hóa
+func SimHash(text string) uint64 {
+	feats := features(Normalize(text))
+	if len(feats) == 0 {
+		return 0
+	}
+
+	var weights [64]int
+	for _, f := range feats {
+		h := fnv.New64a()
+		h.Write([]byte(f))
+		sum := h.Sum64()
+		for i := 0; i < 64; i++ {
+			if sum&(1<<uint(i)) != 0 {
+				weights[i]++
+			} else {
+				weights[i]--
+			}
+		}
+	}
+
+	var fp uint64
+	for i := 0; i < 64; i++ {
+		if weights[i] > 0 {
+			fp |= 1 << uint(i)
+		}
+	}
+	return fp
+}
+
+// HammingDistance đếm số bit khác nhau giữa hai fingerprint
+func HammingDistance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// Bands chia fingerprint thành threshold+1 đoạn bit liên tiếp
+// Theo nguyên lý Dirichlet: nếu distance <= threshold thì ít nhất
+// một đoạn phải trùng khớp hoàn toàn → dùng làm khóa index
+func Bands(fp uint64, threshold int) []uint64 {
+	n := threshold + 1
+	if n < 1 {
+		n = 1
+	}
+	if n > 64 {
+		n = 64
+	}
+
+	bands := make([]uint64, n)
+	width := 64 / n
+	for i := 0; i < n; i++ {
+		start := i * width
+		end := start + width
+		if i == n-1 {
+			end = 64 // đoạn cuối lấy phần dư
+		}
+		size := end - start
+		mask := uint64(1)<<uint(size) - 1
+		if size == 64 {
+			mask = ^uint64(0)
+		}
+		bands[i] = (fp >> uint(start)) & mask
+	}
+	return bands
+}
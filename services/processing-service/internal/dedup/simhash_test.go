@@ -0,0 +1,106 @@
+package dedup
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+const testThreshold = 3
+
+const original = "Why we moved our monolith to Kubernetes and what we learned along the way"
+
+// crossPosts là cùng bài đăng lại trên nguồn khác (khác hoa thường, dấu câu, stop words, hậu tố)
+var crossPosts = []string{
+	"Why We Moved Our Monolith to Kubernetes, and What We Learned Along the Way!",
+	"We moved our monolith to Kubernetes and what we learned along the way",
+	"Why we moved our monolith to Kubernetes and what we learned along the way - DEV Community",
+}
+
+// unrelated là các bài khác hẳn
+var unrelated = []string{
+	"Rust 1.80 released with lazy statics and exclusive ranges",
+	"Show HN: A tiny SQLite clone written in Zig",
+	"The state of JavaScript frameworks in 2024",
+}
+
+func TestSimHash(t *testing.T) {
+	fp := SimHash(original)
+	if fp == 0 || SimHash(original) != fp {
+		t.Fatalf("SimHash(%q) = %x", original, fp)
+	}
+	for _, text := range crossPosts {
+		if d := HammingDistance(fp, SimHash(text)); d > testThreshold {
+			t.Errorf("near-identical %q: distance %d > %d", text, d, testThreshold)
+		}
+	}
+	for _, text := range unrelated {
+		if d := HammingDistance(fp, SimHash(text)); d <= testThreshold {
+			t.Errorf("distinct %q: distance %d <= %d", text, d, testThreshold)
+		}
+	}
+	for _, text := range []string{"", "   ", "the and of", "!!! ???"} {
+		if fp := SimHash(text); fp != 0 {
+			t.Errorf("SimHash(%q) = %x, want 0", text, fp)
+		}
+	}
+}
+
+func TestBands(t *testing.T) {
+	fp := uint64(0x0123456789abcdef)
+	tests := []struct {
+		threshold int
+		want      []uint64
+	}{
+		{0, []uint64{fp}},
+		{-1, []uint64{fp}},
+		{1, []uint64{0x89abcdef, 0x01234567}},
+		{3, []uint64{0xcdef, 0x89ab, 0x4567, 0x0123}},
+		// 64/5 = 12 bit mỗi đoạn, đoạn cuối lấy 16 bit còn lại
+		{4, []uint64{0xdef, 0xabc, 0x789, 0x456, 0x0123}},
+	}
+	for _, tt := range tests {
+		got := Bands(fp, tt.threshold)
+		if fmt.Sprintf("%x", got) != fmt.Sprintf("%x", tt.want) {
+			t.Errorf("Bands(threshold %d) = %x, want %x", tt.threshold, got, tt.want)
+		}
+	}
+	if n := len(Bands(fp, 100)); n != 64 {
+		t.Errorf("Bands(threshold 100) has %d bands, want 64", n)
+	}
+}
+
+func TestBandsCollide(t *testing.T) {
+	// Lật tối đa threshold bit bất kỳ: luôn còn ít nhất một band trùng
+	rng := rand.New(rand.NewSource(1))
+	for threshold := 0; threshold <= 8; threshold++ {
+		for i := 0; i < 1000; i++ {
+			fp := rng.Uint64()
+			other := fp
+			for j := 0; j < threshold; j++ {
+				other ^= 1 << uint(rng.Intn(64))
+			}
+			if !shareBand(Bands(fp, threshold), Bands(other, threshold)) {
+				t.Fatalf("threshold %d: %x and %x (distance %d) share no band",
+					threshold, fp, other, HammingDistance(fp, other))
+			}
+		}
+	}
+
+	fp := SimHash(original)
+	for _, text := range crossPosts {
+		if !shareBand(Bands(fp, testThreshold), Bands(SimHash(text), testThreshold)) {
+			t.Errorf("cross-post %q shares no band with the original", text)
+		}
+	}
+}
+
+// shareBand kiểm tra hai fingerprint có trùng band cùng vị trí
+func shareBand(a, b []uint64) bool {
+	for i := range a {
+		if a[i] == b[i] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,208 @@
+// =====================================================
+// ENRICHMENT - Phân loại topic, sentiment và keywords cho posts
+// =====================================================
+// Mô tả: Consumer chỉ điền các trường producer chưa gửi (Fill);
+// lệnh replay tính lại toàn bộ (Enrich) khi đổi luật detect hoặc taxonomy
+// =====================================================
+
+package enrichment
+
+import (
+	"slices"
+	"social-insight/internal/models"
+	"social-insight/internal/taxonomy"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Version tăng mỗi khi luật topic/sentiment/keywords thay đổi
+// Lệnh replay ghi version vào checkpoint để biết dữ liệu đã enrich theo luật nào
+// v2: keywords
+// v3: topics theo taxonomy (nhiều topic có điểm mỗi post)
+const Version = 3
+
+// MaxKeywords là số keywords tối đa của một post
+const MaxKeywords = 5
+
+// Từ khóa mang cảm xúc tích cực/tiêu cực (lexicon đơn giản cho tin công nghệ)
+var (
+	positiveWords = toSet(
+		"amazing", "awesome", "best", "better", "breakthrough", "easy", "excellent",
+		"fast", "faster", "good", "great", "improve", "improved", "improves", "innovative",
+		"launch", "launches", "love", "win", "wins", "success", "successful", "powerful",
+		"secure", "simple", "open-source", "free", "record", "growth", "raises",
+	)
+	negativeWords = toSet(
+		"bad", "breach", "broken", "bug", "bugs", "crash", "crashes", "dead", "decline",
+		"fail", "failed", "failure", "fraud", "hack", "hacked", "layoff", "layoffs",
+		"leak", "leaked", "lawsuit", "outage", "problem", "shutdown", "slow", "vulnerability",
+		"vulnerable", "worse", "worst", "attack", "ban", "banned", "fined",
+	)
+
+	// Từ không mang nội dung, không dùng làm keyword
+	stopWords = toSet(
+		"about", "after", "again", "all", "also", "and", "any", "are", "ask", "based", "been",
+		"before", "being", "best", "better", "but", "can", "could", "did", "does", "doing", "don't",
+		"each", "even", "every", "first", "for", "from", "get", "gets", "getting", "good", "got",
+		"going", "had", "has", "have", "here", "how", "i'm", "into", "its", "just", "know", "last",
+		"like", "make", "makes", "many", "matter", "matters", "may", "might", "more", "most", "much",
+		"need", "new", "not", "now", "off", "old", "one", "only", "other", "our", "out", "over", "own",
+		"part", "really", "same", "should", "show", "some", "still", "such", "than", "that",
+		"the", "their", "them", "then", "there", "these", "they", "thing", "things", "think", "this",
+		"those", "through", "time", "too", "two", "use", "used", "uses", "using", "very", "via", "want", "was", "way",
+		"ways", "were", "what", "when", "where", "which", "while", "who", "why", "will", "with",
+		"without", "would", "year", "years", "you", "your",
+	)
+)
+
+// Enricher tính topics, sentiment và keywords từ nội dung post
+type Enricher struct {
+	taxonomy *taxonomy.Taxonomy
+}
+
+// New tạo Enricher phân loại topic theo tax
+func New(tax *taxonomy.Taxonomy) *Enricher {
+	return &Enricher{taxonomy: tax}
+}
+
+// Fill điền Topic, Topics, Sentiment và Keywords còn trống của post mới
+// (đường consume). Giá trị crawler đã gán được giữ nguyên: Topic của
+// crawler là topic chính, các topic phân loại được xếp sau.
+// Sentiment "neutral" là placeholder của crawler bản cũ (message cũ vẫn
+// còn trên topic) nên được tính lại như khi còn trống
+func (e *Enricher) Fill(post *models.Post) {
+	text := post.Title + " " + post.Content
+
+	if len(post.Keywords) == 0 {
+		post.Keywords = Keywords(headline(post))
+	}
+	if len(post.Topics) == 0 {
+		post.Topics = e.taxonomy.Classify(text, post.Tags)
+	}
+	if post.Topic == "" {
+		post.Topic = post.Topics[0].Topic
+	} else {
+		post.Topics = e.withPrimary(post.Topics, post.Topic)
+	}
+	if post.Sentiment == "" || post.Sentiment == "neutral" {
+		post.Sentiment = Sentiment(text)
+	}
+}
+
+// withPrimary đưa primary lên đầu topics (thêm mới nếu chưa có);
+// Fallback điểm 0 bị bỏ vì post đã có topic
+func (e *Enricher) withPrimary(topics []models.TopicScore, primary string) []models.TopicScore {
+	if topics[0].Topic == primary {
+		return topics
+	}
+	first := models.TopicScore{Topic: primary}
+	if node, ok := e.taxonomy.Node(primary); ok {
+		first.Parent = node.Parent
+	}
+	result := []models.TopicScore{first}
+	for _, t := range topics {
+		switch {
+		case t.Topic == primary:
+			result[0] = t
+		case t.Topic == e.taxonomy.Fallback && t.Score == 0:
+		default:
+			result = append(result, t)
+		}
+	}
+	return result
+}
+
+// Enrich tính lại Topic, Topics, Sentiment và Keywords của post theo luật
+// hiện tại, ghi đè giá trị cũ (chỉ dùng khi replay/reprocess)
+// Topic là topic chính (phần tử đầu của Topics); tag của nền tảng chỉ là
+// một tín hiệu khi phân loại. Trả về true nếu post thay đổi
+func (e *Enricher) Enrich(post *models.Post) bool {
+	text := post.Title + " " + post.Content
+	changed := false
+
+	if keywords := Keywords(headline(post)); !slices.Equal(keywords, post.Keywords) {
+		post.Keywords = keywords
+		changed = true
+	}
+
+	if topics := e.taxonomy.Classify(text, post.Tags); !slices.Equal(topics, post.Topics) {
+		post.Topics = topics
+		changed = true
+	}
+	if primary := post.Topics[0].Topic; primary != post.Topic {
+		post.Topic = primary
+		changed = true
+	}
+	if sentiment := Sentiment(text); sentiment != post.Sentiment {
+		post.Sentiment = sentiment
+		changed = true
+	}
+	return changed
+}
+
+// headline là text dùng để lấy keywords (tiêu đề, không có thì nội dung)
+func headline(post *models.Post) string {
+	if post.Title != "" {
+		return post.Title
+	}
+	return post.Content
+}
+
+// Sentiment tính cảm xúc bằng cách đếm từ tích cực/tiêu cực
+// Trả về "positive", "negative" hoặc "neutral"
+func Sentiment(text string) string {
+	score := 0
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-'
+	})
+	for _, w := range words {
+		if positiveWords[w] {
+			score++
+		}
+		if negativeWords[w] {
+			score--
+		}
+	}
+
+	switch {
+	case score > 0:
+		return "positive"
+	case score < 0:
+		return "negative"
+	default:
+		return "neutral"
+	}
+}
+
+// Keywords trả về tối đa MaxKeywords từ khóa của text theo thứ tự xuất hiện:
+// chữ thường, bỏ 's sở hữu, dài ít nhất 3 ký tự, không phải số hay stop word
+func Keywords(text string) []string {
+	keywords := make([]string, 0, MaxKeywords)
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-' && r != '\'' && r != '+' && r != '#'
+	})
+	for _, w := range words {
+		w = strings.TrimSuffix(strings.Trim(w, "-'"), "'s")
+		if utf8.RuneCountInString(w) < 3 || stopWords[w] || slices.Contains(keywords, w) {
+			continue
+		}
+		if strings.IndexFunc(w, unicode.IsLetter) < 0 {
+			continue
+		}
+		keywords = append(keywords, w)
+		if len(keywords) == MaxKeywords {
+			break
+		}
+	}
+	return keywords
+}
+
+// toSet tạo set từ danh sách từ
+func toSet(words ...string) map[string]bool {
+	set := make(map[string]bool, len(words))
+	for _, w := range words {
+		set[w] = true
+	}
+	return set
+}
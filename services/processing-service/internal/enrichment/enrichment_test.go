@@ -0,0 +1,136 @@
+package enrichment
+
+import (
+	"slices"
+	"testing"
+
+	"social-insight/internal/models"
+	"social-insight/internal/taxonomy"
+)
+
+// testTaxonomy là taxonomy nhỏ cho các test enrich
+const testTaxonomy = `{
+	"version": 1, "fallback": "other", "min_score": 2, "max_topics": 2, "tag_weight": 3,
+	"topics": [
+		{"id": "ai", "rules": [{"pattern": "ai", "weight": 2}],
+			"subtopics": [{"id": "llm", "rules": [{"pattern": "llm*", "weight": 3}]}]},
+		{"id": "devops", "rules": [{"pattern": "kubernetes", "weight": 3}]},
+		{"id": "security", "rules": [{"pattern": "breach", "weight": 3}]},
+		{"id": "other"}
+	]
+}`
+
+func newTestEnricher(t *testing.T) *Enricher {
+	t.Helper()
+	tax, err := taxonomy.Parse([]byte(testTaxonomy))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return New(tax)
+}
+
+func TestSentiment(t *testing.T) {
+	tests := map[string]string{
+		"Awesome new release, faster and simple":    "positive",
+		"Massive data breach after the outage":      "negative",
+		"Great launch marred by a crash and a bug":  "neutral",
+		"Kubernetes 1.30 released":                  "neutral",
+		"":                                          "neutral",
+		"Open-source tool WINS award":               "positive",
+		"Breach? No: a breakthrough, a win, growth": "positive",
+	}
+	for text, want := range tests {
+		if got := Sentiment(text); got != want {
+			t.Errorf("Sentiment(%q) = %s, want %s", text, got, want)
+		}
+	}
+}
+
+func TestKeywords(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"Why Rust's borrow checker matters for C++ devs", []string{"rust", "borrow", "checker", "c++", "devs"}},
+		{"The AI AI ai of 2024: GPT-4 vs Claude", []string{"gpt-4", "claude"}},
+		{"Go go go", []string{}},
+		{"one two three four five six seven eight", []string{"three", "four", "five", "six", "seven"}},
+	}
+	for _, tt := range tests {
+		if got := Keywords(tt.text); !slices.Equal(got, tt.want) {
+			t.Errorf("Keywords(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestEnrichOverwrites(t *testing.T) {
+	e := newTestEnricher(t)
+	post := models.Post{
+		Title:     "LLM agents on Kubernetes fail",
+		Topic:     "startup",
+		Sentiment: "positive",
+		Keywords:  []string{"stale"},
+	}
+	if !e.Enrich(&post) {
+		t.Fatal("Enrich reported no change")
+	}
+	if post.Topic != "ai" || post.Topics[0].Topic != "ai" || post.Sentiment != "negative" ||
+		!slices.Equal(post.Keywords, []string{"llm", "agents", "kubernetes", "fail"}) {
+		t.Errorf("Enrich = %+v", post)
+	}
+
+	// Chạy lại với cùng luật: không có gì thay đổi
+	if e.Enrich(&post) {
+		t.Error("second Enrich reported a change")
+	}
+}
+
+func TestFill(t *testing.T) {
+	e := newTestEnricher(t)
+	tests := []struct {
+		name      string
+		post      models.Post
+		topics    []string
+		sentiment string
+	}{
+		{"empty", models.Post{Title: "LLM agents on Kubernetes"}, []string{"ai", "devops", "llm"}, "neutral"},
+		{"crawler topic classified", models.Post{Title: "LLM agents on Kubernetes", Topic: "devops"},
+			[]string{"devops", "ai", "llm"}, "neutral"},
+		{"crawler topic not classified", models.Post{Title: "LLM agents", Topic: "security"},
+			[]string{"security", "ai", "llm"}, "neutral"},
+		{"crawler subtopic", models.Post{Title: "Hello world", Topic: "llm"}, []string{"llm"}, "neutral"},
+		{"sentiment scored", models.Post{Title: "Massive breach"}, []string{"security"}, "negative"},
+		{"crawler placeholder sentiment", models.Post{Title: "Massive breach", Sentiment: "neutral"}, []string{"security"}, "negative"},
+	}
+	for _, tt := range tests {
+		post := tt.post
+		e.Fill(&post)
+
+		var topics []string
+		for _, s := range post.Topics {
+			topics = append(topics, s.Topic)
+		}
+		want := tt.post.Topic
+		if want == "" {
+			want = tt.topics[0]
+		}
+		if post.Topic != want || !slices.Equal(topics, tt.topics) || post.Sentiment != tt.sentiment {
+			t.Errorf("%s: topic %s topics %v sentiment %s; want %s %v %s",
+				tt.name, post.Topic, topics, post.Sentiment, want, tt.topics, tt.sentiment)
+		}
+	}
+
+	// Subtopic của crawler giữ Parent theo taxonomy
+	post := models.Post{Title: "Hello world", Topic: "llm"}
+	e.Fill(&post)
+	if post.Topics[0].Parent != "ai" {
+		t.Errorf("crawler subtopic parent = %q, want ai", post.Topics[0].Parent)
+	}
+
+	// Keywords crawler gửi được giữ
+	post = models.Post{Title: "LLM agents", Keywords: []string{"agents"}}
+	e.Fill(&post)
+	if !slices.Equal(post.Keywords, []string{"agents"}) {
+		t.Errorf("keywords = %q", post.Keywords)
+	}
+}
@@ -0,0 +1,65 @@
+// =====================================================
+// ENRICHMENT POOL - Worker pool dùng chung giữa các partitions
+// =====================================================
+// Mô tả: Giới hạn số goroutine enrich đồng thời trong một consumer
+// process, bất kể có bao nhiêu partitions được assign
+// =====================================================
+
+package enrichment
+
+import (
+	"social-insight/internal/models"
+	"sync"
+)
+
+// Pool chạy Fill trên nhiều workers
+type Pool struct {
+	enricher *Enricher
+	jobs     chan poolJob
+	wg       sync.WaitGroup
+}
+
+// poolJob là một post cần enrich
+type poolJob struct {
+	post *models.Post
+	done *sync.WaitGroup
+}
+
+// NewPool tạo pool với số workers cố định (tối thiểu 1)
+func NewPool(enricher *Enricher, workers int) *Pool {
+	if workers < 1 {
+		workers = 1
+	}
+	p := &Pool{
+		enricher: enricher,
+		jobs:     make(chan poolJob, workers*4),
+	}
+	for i := 0; i < workers; i++ {
+		p.wg.Add(1)
+		go func() {
+			defer p.wg.Done()
+			for j := range p.jobs {
+				p.enricher.Fill(j.post)
+				j.done.Done()
+			}
+		}()
+	}
+	return p
+}
+
+// FillAll điền các trường còn trống của posts (sửa tại chỗ), chờ xong mới trả về
+// An toàn khi gọi đồng thời từ nhiều partitions
+func (p *Pool) FillAll(posts []models.Post) {
+	var done sync.WaitGroup
+	done.Add(len(posts))
+	for i := range posts {
+		p.jobs <- poolJob{post: &posts[i], done: &done}
+	}
+	done.Wait()
+}
+
+// Close dừng workers (không được gọi FillAll sau khi Close)
+func (p *Pool) Close() {
+	close(p.jobs)
+	p.wg.Wait()
+}
@@ -0,0 +1,203 @@
+// =====================================================
+// EVENT CODEC - Encode/decode envelope (JSON hoặc Avro)
+// =====================================================
+// Mô tả: JSON: envelope JSON thuần (bắt đầu bằng '{')
+// Avro: byte 0x00 + 4 bytes version schema envelope + Avro binary,
+// payload là Avro binary theo schema (event_type, schema_version)
+// Decode tự nhận dạng theo byte đầu nên không phụ thuộc Kafka headers
+// =====================================================
+
+package events
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Các kiểu encoding hỗ trợ
+const (
+	EncodingJSON = "json"
+	EncodingAvro = "avro"
+)
+
+// Content type ghi vào header content_type
+const (
+	ContentTypeJSON = "application/json"
+	ContentTypeAvro = "application/avro"
+)
+
+// avroMagic là byte đầu của message Avro
+const avroMagic byte = 0x00
+
+// Codec encode envelope theo encoding đã chọn và decode mọi encoding
+type Codec struct {
+	encoding string
+	registry *Registry
+}
+
+// NewCodec tạo codec; registry bắt buộc khi encoding = avro
+func NewCodec(encoding string, registry *Registry) (*Codec, error) {
+	switch encoding {
+	case "", EncodingJSON:
+		encoding = EncodingJSON
+	case EncodingAvro:
+		if registry == nil {
+			return nil, fmt.Errorf("avro encoding requires a schema registry")
+		}
+	default:
+		return nil, fmt.Errorf("unknown event encoding: %q", encoding)
+	}
+	return &Codec{encoding: encoding, registry: registry}, nil
+}
+
+// Encoding trả về encoding dùng khi encode
+func (c *Codec) Encoding() string {
+	return c.encoding
+}
+
+// ContentType trả về content type của message do codec này encode
+func (c *Codec) ContentType() string {
+	if c.encoding == EncodingAvro {
+		return ContentTypeAvro
+	}
+	return ContentTypeJSON
+}
+
+// Encode chuyển envelope thành bytes
+func (c *Codec) Encode(env Envelope) ([]byte, error) {
+	if c.encoding == EncodingAvro {
+		return c.encodeAvro(env)
+	}
+	return json.Marshal(env)
+}
+
+// Decode nhận dạng encoding theo byte đầu và parse thành envelope
+// JSON không có event_type được coi là message cũ (models.Post trần)
+func (c *Codec) Decode(value []byte) (Envelope, error) {
+	if len(value) > 0 && value[0] == avroMagic {
+		return c.decodeAvro(value)
+	}
+
+	var env Envelope
+	if err := json.Unmarshal(value, &env); err != nil {
+		return env, fmt.Errorf("invalid json event: %w", err)
+	}
+	if env.EventType == "" {
+		return Legacy(value), nil
+	}
+	return env, nil
+}
+
+// encodeAvro encode payload theo schema của event rồi bọc trong envelope
+func (c *Codec) encodeAvro(env Envelope) ([]byte, error) {
+	envSchema, err := c.registry.Latest(EnvelopeType)
+	if err != nil {
+		return nil, err
+	}
+	payloadSchema, err := c.registry.Lookup(env.EventType, env.SchemaVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	payload, err := payloadSchema.binaryFromJSON(env.Payload)
+	if err != nil {
+		return nil, err
+	}
+
+	native := map[string]interface{}{
+		"schema_version": int32(env.SchemaVersion),
+		"event_type":     env.EventType,
+		"producer":       env.Producer,
+		"produced_at":    env.ProducedAt.UnixMilli(),
+		"trace_id":       env.TraceID,
+		"payload":        payload,
+	}
+
+	buf := make([]byte, 5, 5+len(payload)+64)
+	buf[0] = avroMagic
+	binary.BigEndian.PutUint32(buf[1:5], uint32(envSchema.Version))
+	return envSchema.Codec.BinaryFromNative(buf, native)
+}
+
+// decodeAvro decode envelope Avro, payload được chuyển lại thành JSON
+func (c *Codec) decodeAvro(value []byte) (Envelope, error) {
+	var env Envelope
+	if c.registry == nil {
+		return env, fmt.Errorf("avro event received but no schema registry configured")
+	}
+	if len(value) < 5 {
+		return env, fmt.Errorf("avro event too short (%d bytes)", len(value))
+	}
+
+	envSchema, err := c.registry.Lookup(EnvelopeType, int(binary.BigEndian.Uint32(value[1:5])))
+	if err != nil {
+		return env, err
+	}
+	native, _, err := envSchema.Codec.NativeFromBinary(value[5:])
+	if err != nil {
+		return env, fmt.Errorf("invalid avro envelope: %w", err)
+	}
+	record, ok := native.(map[string]interface{})
+	if !ok {
+		return env, fmt.Errorf("invalid avro envelope: not a record")
+	}
+
+	version, _ := record["schema_version"].(int32)
+	env.SchemaVersion = int(version)
+	env.EventType, _ = record["event_type"].(string)
+	env.Producer, _ = record["producer"].(string)
+	env.TraceID, _ = record["trace_id"].(string)
+	if ms, ok := record["produced_at"].(int64); ok {
+		env.ProducedAt = time.UnixMilli(ms).UTC()
+	}
+
+	payloadSchema, err := c.registry.Lookup(env.EventType, env.SchemaVersion)
+	if err != nil {
+		return env, err
+	}
+	payload, _ := record["payload"].([]byte)
+	payloadNative, _, err := payloadSchema.Codec.NativeFromBinary(payload)
+	if err != nil {
+		return env, fmt.Errorf("invalid avro payload %s v%d: %w", env.EventType, env.SchemaVersion, err)
+	}
+	env.Payload, err = payloadSchema.Codec.TextualFromNative(nil, payloadNative)
+	if err != nil {
+		return env, err
+	}
+	return env, nil
+}
+
+// binaryFromJSON encode payload JSON sang Avro binary
+// Field không có trong schema bị bỏ qua để producer mới vẫn encode được theo schema cũ
+func (s *Schema) binaryFromJSON(payload json.RawMessage) ([]byte, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(payload, &fields); err != nil {
+		return nil, fmt.Errorf("payload %s is not a JSON object: %w", s.EventType, err)
+	}
+	for name := range fields {
+		if !s.fields[name] {
+			delete(fields, name)
+		}
+	}
+
+	textual, err := json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+	native, _, err := s.Codec.NativeFromTextual(textual)
+	if err != nil {
+		return nil, fmt.Errorf("payload does not match schema %s v%d: %w", s.EventType, s.Version, err)
+	}
+	return s.Codec.BinaryFromNative(nil, native)
+}
+
+// Open load registry (embedded + registryDir) và tạo codec cho encoding
+func Open(encoding, registryDir string) (*Codec, error) {
+	registry, err := NewRegistry(registryDir)
+	if err != nil {
+		return nil, err
+	}
+	return NewCodec(encoding, registry)
+}
@@ -0,0 +1,185 @@
+package events
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"social-insight/internal/models"
+)
+
+var producedAt = time.Date(2026, 1, 31, 10, 0, 0, 123000000, time.UTC)
+
+// testPost có mọi field mà một version nào đó của post.created mang theo
+func testPost() models.Post {
+	return models.Post{
+		ID:              "hn_42",
+		Author:          "Jane Doe",
+		AuthorHandle:    "jdoe",
+		Title:           "Show HN: a tool",
+		Content:         "Body",
+		Topic:           "devops",
+		Tags:            []string{"go", "kubernetes"},
+		Sentiment:       "positive",
+		Likes:           10,
+		Comments:        3,
+		Shares:          1,
+		Platform:        "hackernews",
+		URL:             "https://example.com/tool",
+		CanonicalURL:    "https://example.com/tool",
+		CreatedAt:       time.Date(2026, 1, 30, 8, 0, 0, 0, time.UTC),
+		CanonicalPostID: "devto_7",
+	}
+}
+
+func newTestCodecs(t *testing.T) map[string]*Codec {
+	t.Helper()
+	codecs := make(map[string]*Codec)
+	for _, encoding := range []string{EncodingJSON, EncodingAvro} {
+		codec, err := Open(encoding, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		codecs[encoding] = codec
+	}
+	return codecs
+}
+
+// roundTrip encode env rồi decode lại bằng cùng codec
+func roundTrip(t *testing.T, codec *Codec, env Envelope) Envelope {
+	t.Helper()
+	data, err := codec.Encode(env)
+	if err != nil {
+		t.Fatalf("%s encode %s v%d: %v", codec.Encoding(), env.EventType, env.SchemaVersion, err)
+	}
+	if codec.Encoding() == EncodingAvro && data[0] != avroMagic {
+		t.Fatalf("avro message starts with %#x", data[0])
+	}
+	got, err := codec.Decode(data)
+	if err != nil {
+		t.Fatalf("%s decode %s v%d: %v", codec.Encoding(), env.EventType, env.SchemaVersion, err)
+	}
+	if got.SchemaVersion != env.SchemaVersion || got.EventType != env.EventType || got.Producer != env.Producer ||
+		got.TraceID != env.TraceID || !got.ProducedAt.Equal(env.ProducedAt) {
+		t.Errorf("%s envelope = %+v, want %+v", codec.Encoding(), got, env)
+	}
+	return got
+}
+
+func TestPostCreatedRoundTrip(t *testing.T) {
+	codecs := newTestCodecs(t)
+	for version := 1; version <= CurrentVersion(EventPostCreated); version++ {
+		env, err := NewPostCreated(testPost(), "crawler:hn")
+		if err != nil {
+			t.Fatal(err)
+		}
+		env.SchemaVersion = version
+		env.ProducedAt = producedAt
+
+		// Avro chỉ giữ các field có trong schema của version
+		want := testPost()
+		if version < 2 {
+			want.AuthorHandle = ""
+		}
+		if version < 3 {
+			want.Tags = nil
+		}
+
+		for encoding, codec := range codecs {
+			post, err := roundTrip(t, codec, env).Post()
+			if err != nil {
+				t.Fatalf("%s v%d: %v", encoding, version, err)
+			}
+			post.TraceID = ""
+			expected := want
+			if encoding == EncodingJSON {
+				expected = testPost()
+			}
+			if !reflect.DeepEqual(post, expected) {
+				t.Errorf("%s v%d: post = %+v\nwant %+v", encoding, version, post, expected)
+			}
+		}
+	}
+}
+
+func TestPostCreatedKeepsTraceID(t *testing.T) {
+	post := testPost()
+	post.TraceID = "abc123"
+	env, _ := NewPostCreated(post, "crawler:hn")
+	if env.TraceID != "abc123" || env.SchemaVersion != CurrentVersion(EventPostCreated) {
+		t.Errorf("envelope = %+v", env)
+	}
+	if got, _ := env.Post(); got.TraceID != "abc123" {
+		t.Errorf("post trace id = %q", got.TraceID)
+	}
+}
+
+func TestDecodeLegacy(t *testing.T) {
+	// Message cũ: models.Post trần, không có envelope
+	value, _ := json.Marshal(testPost())
+	for encoding, codec := range newTestCodecs(t) {
+		env, err := codec.Decode(value)
+		if err != nil {
+			t.Fatalf("%s: %v", encoding, err)
+		}
+		if env.SchemaVersion != 0 || env.EventType != EventPostCreated || env.Producer != "legacy" {
+			t.Errorf("%s: legacy envelope = %+v", encoding, env)
+		}
+		if err := env.CheckVersion(); err != nil {
+			t.Errorf("%s: legacy version rejected: %v", encoding, err)
+		}
+		if post, err := env.Post(); err != nil || !reflect.DeepEqual(post, testPost()) {
+			t.Errorf("%s: legacy post = %+v, %v", encoding, post, err)
+		}
+	}
+}
+
+func TestDecodeInvalid(t *testing.T) {
+	codec, _ := Open(EncodingJSON, "")
+	for name, value := range map[string][]byte{
+		"empty":         nil,
+		"not json":      []byte("hello"),
+		"short avro":    {avroMagic, 0, 0},
+		"unknown avro":  {avroMagic, 0, 0, 0, 9, 1, 2},
+		"garbage avro":  {avroMagic, 0, 0, 0, 1, 0xff, 0xff, 0xff},
+		"json array":    []byte(`[1, 2]`),
+		"json envelope": []byte(`{"event_type": "post.created", "schema_version": "two"}`),
+	} {
+		if env, err := codec.Decode(value); err == nil {
+			t.Errorf("%s: decoded %+v", name, env)
+		}
+	}
+}
+
+func TestCheckVersion(t *testing.T) {
+	tests := []struct {
+		eventType string
+		version   int
+		ok        bool
+	}{
+		{EventPostCreated, 1, true},
+		{EventPostCreated, CurrentVersion(EventPostCreated), true},
+		{EventPostCreated, CurrentVersion(EventPostCreated) + 1, false},
+		{"post.deleted", 1, false},
+	}
+	for _, tt := range tests {
+		err := Envelope{EventType: tt.eventType, SchemaVersion: tt.version}.CheckVersion()
+		if (err == nil) != tt.ok {
+			t.Errorf("CheckVersion(%s v%d) = %v", tt.eventType, tt.version, err)
+		}
+	}
+}
+
+func TestNewCodec(t *testing.T) {
+	if _, err := NewCodec(EncodingAvro, nil); err == nil {
+		t.Error("avro without registry: expected error")
+	}
+	if _, err := NewCodec("protobuf", nil); err == nil {
+		t.Error("unknown encoding: expected error")
+	}
+	codec, err := NewCodec("", nil)
+	if err != nil || codec.Encoding() != EncodingJSON || codec.ContentType() != ContentTypeJSON {
+		t.Errorf("default codec = %v, %v", codec, err)
+	}
+}
@@ -0,0 +1,141 @@
+// =====================================================
+// EVENT ENVELOPE - Vỏ có version cho messages raw_posts
+// =====================================================
+// Mô tả: Mỗi message là một Envelope gồm schema version, event type,
+// producer, produced_at, trace id và payload. Consumer dispatch theo
+// event type nên có thể thêm loại event mới mà không phá consumer cũ
+// =====================================================
+
+package events
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"social-insight/internal/models"
+	"time"
+)
+
+// Các loại event trên raw_posts
+const (
+	EventPostCreated = "post.created" // Post mới (models.Post)
+)
+
+// Schema version hiện tại của từng event type
+// Consumer từ chối (dead letter) event có version mới hơn version nó hiểu
+var currentVersions = map[string]int{
+	EventPostCreated: 3, // v2: author_handle, v3: tags
+}
+
+// Tên Kafka headers đi kèm mỗi message
+// Cho phép lọc/route message mà không cần decode value
+const (
+	HeaderEventType     = "event_type"
+	HeaderSchemaVersion = "schema_version"
+	HeaderContentType   = "content_type"
+	HeaderProducer      = "producer"
+	HeaderTraceID       = "trace_id"
+)
+
+// Envelope là vỏ chung của mọi event
+type Envelope struct {
+	// SchemaVersion là version schema của payload (0 = message cũ không có envelope)
+	SchemaVersion int `json:"schema_version"`
+
+	// EventType là loại event (post.created)
+	EventType string `json:"event_type"`
+
+	// Producer là thành phần gửi event ("crawler:hn", "replay", ...)
+	Producer string `json:"producer"`
+
+	// ProducedAt là thời điểm tạo event
+	ProducedAt time.Time `json:"produced_at"`
+
+	// TraceID để lần theo một post qua crawler → Kafka → consumer
+	TraceID string `json:"trace_id"`
+
+	// Payload là nội dung event dạng JSON
+	Payload json.RawMessage `json:"payload"`
+}
+
+// CurrentVersion trả về schema version hiện tại của event type (0 nếu không biết)
+func CurrentVersion(eventType string) int {
+	return currentVersions[eventType]
+}
+
+// New tạo envelope với version hiện tại và trace id mới
+func New(eventType, producer string, payload interface{}) (Envelope, error) {
+	version := CurrentVersion(eventType)
+	if version == 0 {
+		return Envelope{}, fmt.Errorf("unknown event type %q", eventType)
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return Envelope{}, fmt.Errorf("không thể marshal payload %s: %w", eventType, err)
+	}
+
+	return Envelope{
+		SchemaVersion: version,
+		EventType:     eventType,
+		Producer:      producer,
+		ProducedAt:    time.Now().UTC(),
+		TraceID:       NewTraceID(),
+		Payload:       data,
+	}, nil
+}
+
+// NewPostCreated tạo event post.created
+// Dùng trace id của post nếu crawler đã gán, để log hai phía nối được với nhau
+func NewPostCreated(post models.Post, producer string) (Envelope, error) {
+	env, err := New(EventPostCreated, producer, post)
+	if err == nil && post.TraceID != "" {
+		env.TraceID = post.TraceID
+	}
+	return env, err
+}
+
+// Legacy bọc message cũ (JSON models.Post không có envelope) thành post.created version 0
+func Legacy(value []byte) Envelope {
+	return Envelope{
+		SchemaVersion: 0,
+		EventType:     EventPostCreated,
+		Producer:      "legacy",
+		Payload:       json.RawMessage(value),
+	}
+}
+
+// CheckVersion trả về lỗi nếu event type lạ hoặc version mới hơn version hiện tại
+func (e Envelope) CheckVersion() error {
+	current := CurrentVersion(e.EventType)
+	if current == 0 {
+		return fmt.Errorf("unknown event type %q", e.EventType)
+	}
+	if e.SchemaVersion > current {
+		return fmt.Errorf("%s schema version %d not supported (max %d)", e.EventType, e.SchemaVersion, current)
+	}
+	return nil
+}
+
+// Post decode payload của event post.created
+func (e Envelope) Post() (models.Post, error) {
+	var post models.Post
+	if e.EventType != EventPostCreated {
+		return post, fmt.Errorf("event %s is not %s", e.EventType, EventPostCreated)
+	}
+	if err := json.Unmarshal(e.Payload, &post); err != nil {
+		return post, fmt.Errorf("invalid %s payload: %w", e.EventType, err)
+	}
+	post.TraceID = e.TraceID
+	return post, nil
+}
+
+// NewTraceID sinh trace id ngẫu nhiên 16 bytes (hex)
+func NewTraceID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%032x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,209 @@
+// =====================================================
+// SCHEMA REGISTRY - Registry dạng file (thay cho Confluent)
+// =====================================================
+// Mô tả: Mỗi schema Avro là một file {event_type}.v{version}.avsc
+// Schemas mặc định được embed vào binary; SCHEMA_REGISTRY_DIR cho phép
+// thêm/ghi đè schema mà không cần build lại
+// =====================================================
+
+package events
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+
+	"github.com/linkedin/goavro/v2"
+)
+
+// EnvelopeType là tên dùng cho schema của chính envelope
+const EnvelopeType = "envelope"
+
+//go:embed schemas/*.avsc
+var embeddedSchemas embed.FS
+
+// schemaFileName khớp tên file "{event_type}.v{version}.avsc"
+var schemaFileName = regexp.MustCompile(`^(.+)\.v(\d+)\.avsc$`)
+
+// Schema là một schema Avro đã compile
+type Schema struct {
+	EventType string
+	Version   int
+	Codec     *goavro.Codec
+
+	// fields là tên các field trong record (dùng để bỏ field lạ khi encode)
+	fields map[string]bool
+
+	// required là các field không có default
+	required []string
+}
+
+type schemaKey struct {
+	eventType string
+	version   int
+}
+
+// Registry lưu schemas theo (event type, version)
+type Registry struct {
+	mu      sync.RWMutex
+	dir     string // Thư mục schemas ngoài (rỗng = chỉ dùng embedded)
+	schemas map[schemaKey]*Schema
+	latest  map[string]int
+}
+
+// NewRegistry load schemas embedded rồi tới schemas trong dir (nếu có)
+// Schema trong dir ghi đè schema embedded cùng event type và version
+func NewRegistry(dir string) (*Registry, error) {
+	r := &Registry{
+		dir:     dir,
+		schemas: make(map[schemaKey]*Schema),
+		latest:  make(map[string]int),
+	}
+
+	sub, err := fs.Sub(embeddedSchemas, "schemas")
+	if err != nil {
+		return nil, err
+	}
+	if err := r.loadFS(sub); err != nil {
+		return nil, fmt.Errorf("embedded schemas: %w", err)
+	}
+
+	if dir != "" {
+		if err := r.loadFS(os.DirFS(dir)); err != nil {
+			return nil, fmt.Errorf("schema registry dir %s: %w", dir, err)
+		}
+	}
+	return r, nil
+}
+
+// loadFS đọc tất cả file .avsc trong fsys
+func (r *Registry) loadFS(fsys fs.FS) error {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		m := schemaFileName.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || m == nil {
+			continue
+		}
+		var version int
+		fmt.Sscanf(m[2], "%d", &version)
+
+		data, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return err
+		}
+		if err := r.add(m[1], version, string(data)); err != nil {
+			return fmt.Errorf("%s: %w", entry.Name(), err)
+		}
+	}
+	return nil
+}
+
+// add compile schema và thêm vào registry
+func (r *Registry) add(eventType string, version int, schemaJSON string) error {
+	schema, err := compileSchema(eventType, version, schemaJSON)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.schemas[schemaKey{eventType, version}] = schema
+	if version > r.latest[eventType] {
+		r.latest[eventType] = version
+	}
+	return nil
+}
+
+// Lookup trả về schema theo event type và version
+func (r *Registry) Lookup(eventType string, version int) (*Schema, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	schema, ok := r.schemas[schemaKey{eventType, version}]
+	if !ok {
+		return nil, fmt.Errorf("schema %s v%d not registered", eventType, version)
+	}
+	return schema, nil
+}
+
+// Latest trả về schema version mới nhất của event type
+func (r *Registry) Latest(eventType string) (*Schema, error) {
+	r.mu.RLock()
+	version := r.latest[eventType]
+	r.mu.RUnlock()
+	if version == 0 {
+		return nil, fmt.Errorf("no schema registered for %s", eventType)
+	}
+	return r.Lookup(eventType, version)
+}
+
+// Register thêm schema version mới và ghi file vào dir
+// Version mới phải backward compatible: field thêm mới phải có default
+func (r *Registry) Register(eventType string, version int, schemaJSON string) error {
+	if r.dir == "" {
+		return fmt.Errorf("schema registry dir not configured")
+	}
+	schema, err := compileSchema(eventType, version, schemaJSON)
+	if err != nil {
+		return err
+	}
+
+	if existing, err := r.Lookup(eventType, version); err == nil {
+		if existing.Codec.CanonicalSchema() != schema.Codec.CanonicalSchema() {
+			return fmt.Errorf("schema %s v%d already registered with different content", eventType, version)
+		}
+		return nil
+	}
+	if prev, err := r.Lookup(eventType, version-1); err == nil {
+		for _, name := range schema.required {
+			if !prev.fields[name] {
+				return fmt.Errorf("field %q added in %s v%d must have a default", name, eventType, version)
+			}
+		}
+	}
+
+	path := filepath.Join(r.dir, fmt.Sprintf("%s.v%d.avsc", eventType, version))
+	if err := os.WriteFile(path, []byte(schemaJSON), 0o644); err != nil {
+		return err
+	}
+	return r.add(eventType, version, schemaJSON)
+}
+
+// compileSchema compile schema Avro và đọc danh sách fields
+func compileSchema(eventType string, version int, schemaJSON string) (*Schema, error) {
+	codec, err := goavro.NewCodec(schemaJSON)
+	if err != nil {
+		return nil, fmt.Errorf("invalid avro schema %s v%d: %w", eventType, version, err)
+	}
+
+	var record struct {
+		Fields []struct {
+			Name    string          `json:"name"`
+			Default json.RawMessage `json:"default"`
+		} `json:"fields"`
+	}
+	if err := json.Unmarshal([]byte(schemaJSON), &record); err != nil {
+		return nil, err
+	}
+
+	schema := &Schema{
+		EventType: eventType,
+		Version:   version,
+		Codec:     codec,
+		fields:    make(map[string]bool, len(record.Fields)),
+	}
+	for _, f := range record.Fields {
+		schema.fields[f.Name] = true
+		if f.Default == nil {
+			schema.required = append(schema.required, f.Name)
+		}
+	}
+	return schema, nil
+}
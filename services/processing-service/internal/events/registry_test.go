@@ -0,0 +1,95 @@
+package events
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// postCreatedV4 là post.created v3 thêm field extra
+func postCreatedV4(t *testing.T, extra string) string {
+	t.Helper()
+	data, err := embeddedSchemas.ReadFile("schemas/post.created.v3.avsc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	schema := string(data)
+	i := strings.LastIndex(schema, "]")
+	return schema[:i] + ", " + extra + schema[i:]
+}
+
+func TestEmbeddedSchemas(t *testing.T) {
+	r, err := NewRegistry("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for eventType, version := range currentVersions {
+		if schema, err := r.Latest(eventType); err != nil || schema.Version != version {
+			t.Errorf("Latest(%s) = %v, %v; want v%d", eventType, schema, err, version)
+		}
+	}
+	if _, err := r.Latest(EnvelopeType); err != nil {
+		t.Error(err)
+	}
+	if _, err := r.Lookup(EventPostCreated, 99); err == nil {
+		t.Error("Lookup of unknown version: expected error")
+	}
+}
+
+func TestRegisterCompatibility(t *testing.T) {
+	dir := t.TempDir()
+	r, err := NewRegistry(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Field mới không có default: consumer mới không đọc được event cũ
+	incompatible := postCreatedV4(t, `{"name": "language", "type": "string"}`)
+	if err := r.Register(EventPostCreated, 4, incompatible); err == nil || !strings.Contains(err.Error(), "language") {
+		t.Fatalf("incompatible schema: err = %v", err)
+	}
+	if _, err := r.Lookup(EventPostCreated, 4); err == nil {
+		t.Fatal("incompatible schema was registered")
+	}
+
+	compatible := postCreatedV4(t, `{"name": "language", "type": "string", "default": ""}`)
+	if err := r.Register(EventPostCreated, 4, compatible); err != nil {
+		t.Fatal(err)
+	}
+	if schema, err := r.Latest(EventPostCreated); err != nil || schema.Version != 4 {
+		t.Errorf("Latest = %v, %v", schema, err)
+	}
+
+	// Đăng ký lại cùng nội dung là no-op; nội dung khác bị từ chối
+	if err := r.Register(EventPostCreated, 4, compatible); err != nil {
+		t.Errorf("re-register same schema: %v", err)
+	}
+	other := postCreatedV4(t, `{"name": "region", "type": "string", "default": ""}`)
+	if err := r.Register(EventPostCreated, 4, other); err == nil {
+		t.Error("re-register different schema: expected error")
+	}
+
+	if err := r.Register(EventPostCreated, 5, "{not avro"); err == nil {
+		t.Error("invalid schema: expected error")
+	}
+
+	// Schema đã ghi vào dir được registry mới load lại
+	if _, err := os.Stat(filepath.Join(dir, "post.created.v4.avsc")); err != nil {
+		t.Fatal(err)
+	}
+	reloaded, err := NewRegistry(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if schema, err := reloaded.Latest(EventPostCreated); err != nil || schema.Version != 4 {
+		t.Errorf("reloaded Latest = %v, %v", schema, err)
+	}
+}
+
+func TestRegisterWithoutDir(t *testing.T) {
+	r, _ := NewRegistry("")
+	if err := r.Register(EventPostCreated, 4, postCreatedV4(t, `{"name": "x", "type": "string", "default": ""}`)); err == nil {
+		t.Error("expected error without registry dir")
+	}
+}
@@ -0,0 +1,88 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func ok(context.Context) error { return nil }
+
+func failing(context.Context) error { return errors.New("connection refused") }
+
+func TestRunStatus(t *testing.T) {
+	tests := []struct {
+		name       string
+		optional   CheckFunc
+		required   CheckFunc
+		wantStatus string
+		wantCode   int
+	}{
+		{"all ok", ok, ok, StatusOK, http.StatusOK},
+		{"optional down", failing, ok, StatusDegraded, http.StatusOK},
+		{"required down", ok, failing, StatusDown, http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewChecker(time.Second)
+			c.Add("redis", false, tt.optional)
+			c.Add("postgres", true, tt.required)
+
+			report := c.Run(context.Background())
+			if report.Status != tt.wantStatus {
+				t.Fatalf("status = %s, want %s", report.Status, tt.wantStatus)
+			}
+			if report.HTTPStatus() != tt.wantCode {
+				t.Fatalf("http status = %d, want %d", report.HTTPStatus(), tt.wantCode)
+			}
+			if len(report.Dependencies) != 2 {
+				t.Fatalf("dependencies = %d, want 2", len(report.Dependencies))
+			}
+		})
+	}
+}
+
+func TestRunTimeout(t *testing.T) {
+	c := NewChecker(20 * time.Millisecond)
+	c.Add("kafka", true, func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+
+	start := time.Now()
+	report := c.Run(context.Background())
+	if time.Since(start) > time.Second {
+		t.Fatal("check was not cancelled by timeout")
+	}
+	dep := report.Dependencies["kafka"]
+	if dep.Status != StatusDown || dep.Error == "" {
+		t.Fatalf("kafka = %+v, want down with error", dep)
+	}
+}
+
+func TestUpTracksLastRun(t *testing.T) {
+	c := NewChecker(time.Second)
+	healthy := true
+	c.Add("redis", false, func(context.Context) error {
+		if healthy {
+			return nil
+		}
+		return errors.New("down")
+	})
+
+	if !c.Up("redis") {
+		t.Fatal("dependency not yet checked should count as up")
+	}
+	healthy = false
+	c.Run(context.Background())
+	if c.Up("redis") {
+		t.Fatal("redis should be down after failed check")
+	}
+	healthy = true
+	c.Run(context.Background())
+	if !c.Up("redis") {
+		t.Fatal("redis should be up after recovery")
+	}
+}
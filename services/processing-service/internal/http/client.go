@@ -8,12 +8,20 @@
 package http
 
 import (
+	"context"
 	"fmt"
 	"io"
+	"log/slog"
 	"math/rand"
 	nethttp "net/http"
+	"social-insight/internal/logger"
+	"social-insight/internal/metrics"
+	"social-insight/internal/tracing"
 	"strconv"
 	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Client là HTTP client reusable với retry logic
@@ -25,6 +33,9 @@ type Client struct {
 	timeout    time.Duration
 	baseDelay  time.Duration
 	maxDelay   time.Duration
+
+	// source là label metrics của crawler dùng client này
+	source string
 }
 
 // NewClient tạo HTTP client mới
@@ -46,13 +57,19 @@ func NewClient(timeout time.Duration) *Client {
 }
 
 // Get fetch dữ liệu từ URL với retry
-func (c *Client) Get(url string) ([]byte, error) {
+// ctx hủy request đang chạy và mang run_id/trace_id cho log
+func (c *Client) Get(ctx context.Context, url string) ([]byte, error) {
 	var lastErr error
 	for attempt := 0; attempt < c.maxRetries; attempt++ {
-		resp, err := c.doRequest("GET", url, nil)
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		resp, err := c.doRequest(ctx, "GET", url, nil)
 		if err != nil {
 			lastErr = err
-			fmt.Printf("⚠️  Attempt %d failed: %v, retrying...\n", attempt+1, err)
+			slog.WarnContext(ctx, "http request failed, retrying",
+				"source", c.source, "url", url, "attempt", attempt+1, logger.Err(err))
 			time.Sleep(c.calculateBackoff(attempt))
 			continue
 		}
@@ -66,11 +83,12 @@ func (c *Client) Get(url string) ([]byte, error) {
 		if resp.StatusCode == nethttp.StatusTooManyRequests {
 			// Try to honor Retry-After header if present
 			if ra := c.getRetryAfter(resp); ra > 0 {
-				fmt.Printf("⚠️  HTTP 429 received, honoring Retry-After: %v\n", ra)
+				slog.WarnContext(ctx, "http rate limited, honoring Retry-After",
+					"source", c.source, "url", url, "retry_after", ra)
 				time.Sleep(ra)
 				continue
 			}
-			fmt.Printf("⚠️  HTTP 429 received, backing off...\n")
+			slog.WarnContext(ctx, "http rate limited, backing off", "source", c.source, "url", url)
 			time.Sleep(c.calculateBackoff(attempt))
 			continue
 		}
@@ -78,7 +96,8 @@ func (c *Client) Get(url string) ([]byte, error) {
 		// Non-200 responses
 		if resp.StatusCode != nethttp.StatusOK {
 			lastErr = fmt.Errorf("HTTP %d: %s", resp.StatusCode, resp.Status)
-			fmt.Printf("⚠️  HTTP %d, retrying...\n", resp.StatusCode)
+			slog.WarnContext(ctx, "http error status, retrying",
+				"source", c.source, "url", url, "status", resp.StatusCode, "attempt", attempt+1)
 			time.Sleep(c.calculateBackoff(attempt))
 			continue
 		}
@@ -143,9 +162,25 @@ func (c *Client) getRetryAfter(resp *nethttp.Response) time.Duration {
 	return 0
 }
 
-// doRequest thực hiện HTTP request
-func (c *Client) doRequest(method, url string, body io.Reader) (*nethttp.Response, error) {
-	req, err := nethttp.NewRequest(method, url, body)
+// doRequest thực hiện HTTP request trong span "HTTP <method>"
+// Không gửi traceparent sang site ngoài, chỉ ghi span phía client
+func (c *Client) doRequest(ctx context.Context, method, url string, body io.Reader) (resp *nethttp.Response, err error) {
+	ctx, span := tracing.Start(ctx, "HTTP "+method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("http.request.method", method),
+			attribute.String("url.full", url),
+			attribute.String("source", c.source),
+		),
+	)
+	defer func() {
+		if resp != nil {
+			span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+		}
+		tracing.End(span, &err)
+	}()
+
+	req, err := nethttp.NewRequestWithContext(ctx, method, url, body)
 	if err != nil {
 		return nil, fmt.Errorf("create request error: %w", err)
 	}
@@ -156,10 +191,14 @@ func (c *Client) doRequest(method, url string, body io.Reader) (*nethttp.Respons
 	req.Header.Set("Accept-Charset", "utf-8")
 
 	// Send request
-	resp, err := c.client.Do(req)
+	start := time.Now()
+	resp, err = c.client.Do(req)
+	metrics.HTTPFetchDuration.WithLabelValues(c.source).Observe(time.Since(start).Seconds())
 	if err != nil {
+		metrics.HTTPFetches.WithLabelValues(c.source, metrics.StatusLabel(0)).Inc()
 		return nil, fmt.Errorf("request error: %w", err)
 	}
+	metrics.HTTPFetches.WithLabelValues(c.source, metrics.StatusLabel(resp.StatusCode)).Inc()
 
 	return resp, nil
 }
@@ -174,6 +213,11 @@ func (c *Client) SetRetryDelay(delay time.Duration) {
 	c.retryDelay = delay
 }
 
+// SetSource đặt tên source (hn, devto, medium) dùng làm label metrics
+func (c *Client) SetSource(source string) {
+	c.source = source
+}
+
 // SetUserAgent set custom user agent
 func (c *Client) SetUserAgent(ua string) {
 	c.userAgent = ua
@@ -0,0 +1,269 @@
+// =====================================================
+// KAFKA BATCH CONSUMER - Gom batch theo partition
+// =====================================================
+// Mô tả: Mỗi partition có buffer riêng trong goroutine ConsumeClaim
+// Offset chỉ được mark sau khi batch chứa nó đã ghi xong
+// (at-least-once: crash giữa chừng thì message được đọc lại)
+// =====================================================
+
+package kafka
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"social-insight/internal/events"
+	"social-insight/internal/logger"
+	"social-insight/internal/models"
+	"social-insight/internal/tracing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// BatchHandler xử lý một batch posts
+// Trả về nil chỉ khi cả batch đã được lưu bền vững (PostgreSQL)
+// ctx mang span consumer.flush của batch
+type BatchHandler interface {
+	HandleBatch(ctx context.Context, posts []models.Post) error
+}
+
+// HandlerFactory tạo BatchHandler riêng cho mỗi partition được assign
+// Handler có thể implement io.Closer để dọn dẹp khi partition bị thu hồi
+type HandlerFactory func(topic string, partition int32) BatchHandler
+
+// BatchConfig cấu hình gom batch
+type BatchConfig struct {
+	// Size là số messages tối đa trong một batch
+	Size int
+
+	// Interval là thời gian tối đa giữ một batch chưa đầy
+	Interval time.Duration
+
+	// Retries là số lần thử lại cả batch trước khi chuyển sang ghi từng post
+	Retries int
+
+	// RetryBackoff là thời gian chờ giữa các lần thử lại
+	RetryBackoff time.Duration
+}
+
+// batchProcessor gom messages của một partition thành batch
+type batchProcessor struct {
+	factory    HandlerFactory
+	handler    BatchHandler // Handler của partition hiện tại (tạo từ factory)
+	deadLetter DeadLetterSink
+	config     BatchConfig
+	codec      *events.Codec
+}
+
+// pendingBatch là batch đang gom của một partition
+// Chỉ goroutine ConsumeClaim của partition đó truy cập nên không cần lock
+type pendingBatch struct {
+	posts    []models.Post
+	messages []*sarama.ConsumerMessage // Message gốc của từng post (dùng cho dead letter)
+
+	// last là message có offset lớn nhất trong batch (kể cả message đã dead-letter)
+	last *sarama.ConsumerMessage
+
+	// spans là span process của từng message trong batch, kết thúc khi flush xong
+	spans []trace.Span
+}
+
+// NewBatchConsumer tạo consumer gom batch theo partition
+// factory: tạo handler lưu batch vào DB cho từng partition
+// deadLetter: nơi nhận message lỗi (nil = không có)
+func NewBatchConsumer(brokers []string, groupID, topic string, factory HandlerFactory, deadLetter DeadLetterSink, batch BatchConfig) (*Consumer, error) {
+	if batch.Size <= 0 {
+		batch.Size = 500
+	}
+	if batch.Interval <= 0 {
+		batch.Interval = 2 * time.Second
+	}
+	if batch.Retries < 0 {
+		batch.Retries = 0
+	}
+	if batch.RetryBackoff <= 0 {
+		batch.RetryBackoff = 500 * time.Millisecond
+	}
+
+	c, err := NewMessageConsumer(brokers, groupID, topic, nil)
+	if err != nil {
+		return nil, err
+	}
+	c.batch = &batchProcessor{
+		factory:    factory,
+		deadLetter: deadLetter,
+		config:     batch,
+		codec:      c.codec,
+	}
+	return c, nil
+}
+
+// consume đọc messages của một partition, flush theo size hoặc interval
+// Trả về lỗi nếu một batch không lưu được và cũng không dead-letter được;
+// khi đó offset không được mark và session sẽ đọc lại từ offset đã commit
+func (p *batchProcessor) consume(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim, c *Consumer) error {
+	// Mỗi partition có handler riêng, không chia sẻ state với partition khác
+	if p.factory != nil {
+		local := *p
+		local.handler = p.factory(claim.Topic(), claim.Partition())
+		p = &local
+	}
+	if closer, ok := p.handler.(io.Closer); ok {
+		defer closer.Close()
+	}
+	defer c.forgetLag(claim.Topic(), claim.Partition())
+
+	ticker := time.NewTicker(p.config.Interval)
+	defer ticker.Stop()
+
+	// committed là offset tiếp theo sẽ commit (dùng để tính lag)
+	committed := claim.InitialOffset()
+
+	batch := &pendingBatch{
+		posts:    make([]models.Post, 0, p.config.Size),
+		messages: make([]*sarama.ConsumerMessage, 0, p.config.Size),
+	}
+
+	flush := func() error {
+		if batch.last == nil {
+			c.recordLag(claim.Topic(), claim.Partition(), committed, claim.HighWaterMarkOffset())
+			return nil
+		}
+		start := time.Now()
+		if err := p.flushTraced(claim.Partition(), batch); err != nil {
+			c.metrics.recordFailure(time.Since(start))
+			return err
+		}
+		c.metrics.recordFlush(len(batch.posts), time.Since(start), batch.messages)
+
+		// Batch đã ghi xong → mark offset cuối (commit luôn các offset trước)
+		session.MarkMessage(batch.last, "")
+		committed = batch.last.Offset + 1
+		c.addProcessed(len(batch.posts))
+		c.recordLag(claim.Topic(), claim.Partition(), committed, claim.HighWaterMarkOffset())
+
+		batch.posts = batch.posts[:0]
+		batch.messages = batch.messages[:0]
+		batch.last = nil
+		return nil
+	}
+
+	for {
+		select {
+		case message, ok := <-claim.Messages():
+			if !ok {
+				// Partition bị thu hồi (rebalance) hoặc consumer đóng
+				return flush()
+			}
+
+			// Message lỗi được chuyển sang dead letter nhưng vẫn tính vào batch
+			// để offset của nó được mark cùng batch
+			span := startConsumerSpan(message)
+			if err := p.dispatch(batch, message); err != nil {
+				tracing.End(span, &err)
+				return err
+			}
+			batch.spans = append(batch.spans, span)
+			batch.last = message
+
+			if len(batch.posts) >= p.config.Size {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+
+		case <-ticker.C:
+			if err := flush(); err != nil {
+				return err
+			}
+
+		case <-session.Context().Done():
+			return flush()
+		}
+	}
+}
+
+// dispatch decode envelope và đưa event vào batch theo event type
+// Trả về lỗi chỉ khi message hỏng mà không chuyển được sang dead letter
+func (p *batchProcessor) dispatch(batch *pendingBatch, message *sarama.ConsumerMessage) error {
+	env, err := p.codec.Decode(message.Value)
+	if err != nil {
+		return publishDeadLetter(p.deadLetter, message, models.StageUnmarshal, err)
+	}
+	if err := env.CheckVersion(); err != nil {
+		return publishDeadLetter(p.deadLetter, message, models.StageDispatch, err)
+	}
+
+	if env.EventType != events.EventPostCreated {
+		return nil
+	}
+
+	post, err := env.Post()
+	if err != nil {
+		return publishDeadLetter(p.deadLetter, message, models.StageUnmarshal, err)
+	}
+	batch.posts = append(batch.posts, post)
+	batch.messages = append(batch.messages, message)
+	return nil
+}
+
+// flushTraced chạy flush trong span consumer.flush (link tới span của
+// từng message) rồi kết thúc span process của các message trong batch
+func (p *batchProcessor) flushTraced(partition int32, batch *pendingBatch) (err error) {
+	links := make([]trace.Link, 0, len(batch.spans))
+	for _, span := range batch.spans {
+		links = append(links, trace.Link{SpanContext: span.SpanContext()})
+	}
+	ctx, span := tracing.Start(context.Background(), "consumer.flush",
+		trace.WithLinks(links...),
+		trace.WithAttributes(
+			attribute.Int("messaging.kafka.destination.partition", int(partition)),
+			attribute.Int("batch.posts", len(batch.posts)),
+		),
+	)
+	defer func() {
+		tracing.End(span, &err)
+		for _, s := range batch.spans {
+			tracing.End(s, &err)
+		}
+		batch.spans = batch.spans[:0]
+	}()
+
+	return p.flush(ctx, batch)
+}
+
+// flush ghi batch với retry; nếu vẫn lỗi thì ghi từng post,
+// post không ghi được sẽ chuyển sang dead letter
+func (p *batchProcessor) flush(ctx context.Context, batch *pendingBatch) error {
+	if len(batch.posts) == 0 {
+		return nil
+	}
+
+	var err error
+	for attempt := 0; attempt <= p.config.Retries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(p.config.RetryBackoff)
+		}
+		if err = p.handler.HandleBatch(ctx, batch.posts); err == nil {
+			return nil
+		}
+		slog.Warn("batch write error", "attempt", attempt+1, "max_attempts", p.config.Retries+1,
+			"posts", len(batch.posts), logger.Err(err))
+	}
+
+	// Cả batch lỗi: tách từng post để một post hỏng không chặn cả batch
+	slog.Warn("falling back to per-post writes", "posts", len(batch.posts))
+	for i, post := range batch.posts {
+		postErr := p.handler.HandleBatch(ctx, []models.Post{post})
+		if postErr == nil {
+			continue
+		}
+		if err := publishDeadLetter(p.deadLetter, batch.messages[i], models.StageHandle, postErr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,466 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"social-insight/internal/events"
+	"social-insight/internal/models"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+// fakeSession ghi lại các offset được mark
+type fakeSession struct {
+	ctx context.Context
+
+	mu     sync.Mutex
+	marked []int64
+}
+
+func (s *fakeSession) Claims() map[string][]int32               { return nil }
+func (s *fakeSession) MemberID() string                         { return "test" }
+func (s *fakeSession) GenerationID() int32                      { return 1 }
+func (s *fakeSession) MarkOffset(string, int32, int64, string)  {}
+func (s *fakeSession) Commit()                                  {}
+func (s *fakeSession) ResetOffset(string, int32, int64, string) {}
+func (s *fakeSession) Context() context.Context                 { return s.ctx }
+func (s *fakeSession) MarkMessage(msg *sarama.ConsumerMessage, metadata string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.marked = append(s.marked, msg.Offset)
+}
+
+func (s *fakeSession) lastMarked() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.marked) == 0 {
+		return -1
+	}
+	return s.marked[len(s.marked)-1]
+}
+
+// fakeClaim phát messages qua channel
+type fakeClaim struct {
+	partition     int32
+	highWaterMark int64
+	messages      chan *sarama.ConsumerMessage
+}
+
+func (c *fakeClaim) Topic() string                            { return "raw_posts" }
+func (c *fakeClaim) Partition() int32                         { return c.partition }
+func (c *fakeClaim) InitialOffset() int64                     { return 0 }
+func (c *fakeClaim) HighWaterMarkOffset() int64               { return c.highWaterMark }
+func (c *fakeClaim) Messages() <-chan *sarama.ConsumerMessage { return c.messages }
+
+// recordingHandler lưu các batch đã ghi; fail khiến các lần ghi đầu lỗi
+type recordingHandler struct {
+	mu      sync.Mutex
+	written []string
+	fail    func(posts []models.Post) error
+}
+
+func (h *recordingHandler) HandleBatch(_ context.Context, posts []models.Post) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.fail != nil {
+		if err := h.fail(posts); err != nil {
+			return err
+		}
+	}
+	for _, p := range posts {
+		h.written = append(h.written, p.ID)
+	}
+	return nil
+}
+
+func (h *recordingHandler) count() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.written)
+}
+
+// recordingSink lưu dead letters
+type recordingSink struct {
+	mu      sync.Mutex
+	letters []models.DeadLetter
+}
+
+func (s *recordingSink) Publish(dl models.DeadLetter) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.letters = append(s.letters, dl)
+	return nil
+}
+
+func postMessage(t *testing.T, partition int32, offset int64) *sarama.ConsumerMessage {
+	t.Helper()
+	data, err := json.Marshal(models.Post{ID: fmt.Sprintf("p%d-%d", partition, offset)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &sarama.ConsumerMessage{Topic: "raw_posts", Partition: partition, Offset: offset, Value: data}
+}
+
+func newProcessor(h BatchHandler, sink DeadLetterSink, size int) *batchProcessor {
+	codec, _ := events.NewCodec(events.EncodingJSON, nil)
+	return &batchProcessor{
+		codec:      codec,
+		handler:    h,
+		deadLetter: sink,
+		config:     BatchConfig{Size: size, Interval: time.Hour, Retries: 1, RetryBackoff: time.Millisecond},
+	}
+}
+
+func TestBatchMarksOnlyAfterWrite(t *testing.T) {
+	handler := &recordingHandler{}
+	p := newProcessor(handler, nil, 3)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	session := &fakeSession{ctx: ctx}
+	claim := &fakeClaim{messages: make(chan *sarama.ConsumerMessage)}
+
+	done := make(chan error, 1)
+	go func() { done <- p.consume(session, claim, &Consumer{metrics: newConsumerMetrics()}) }()
+
+	// Hai messages chưa đủ batch → chưa ghi, chưa mark
+	claim.messages <- postMessage(t, 0, 0)
+	claim.messages <- postMessage(t, 0, 1)
+	if got := session.lastMarked(); got != -1 {
+		t.Fatalf("offset marked before batch written: %d", got)
+	}
+	if handler.count() != 0 {
+		t.Fatalf("batch written before full")
+	}
+
+	// Message thứ ba đủ batch → ghi rồi mark offset 2
+	claim.messages <- postMessage(t, 0, 2)
+	claim.messages <- postMessage(t, 0, 3)
+	if got := session.lastMarked(); got != 2 {
+		t.Fatalf("last marked = %d, want 2", got)
+	}
+
+	// Đóng partition → flush phần còn lại
+	close(claim.messages)
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if got := session.lastMarked(); got != 3 {
+		t.Fatalf("last marked = %d, want 3", got)
+	}
+	if handler.count() != 4 {
+		t.Fatalf("written = %d, want 4", handler.count())
+	}
+}
+
+func TestBatchFlushOnInterval(t *testing.T) {
+	handler := &recordingHandler{}
+	p := newProcessor(handler, nil, 100)
+	p.config.Interval = 10 * time.Millisecond
+
+	ctx, cancel := context.WithCancel(context.Background())
+	session := &fakeSession{ctx: ctx}
+	claim := &fakeClaim{messages: make(chan *sarama.ConsumerMessage)}
+
+	done := make(chan error, 1)
+	go func() { done <- p.consume(session, claim, &Consumer{metrics: newConsumerMetrics()}) }()
+
+	claim.messages <- postMessage(t, 0, 7)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for session.lastMarked() != 7 {
+		if time.Now().After(deadline) {
+			t.Fatal("batch not flushed by interval")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBatchWriteFailureNotMarked(t *testing.T) {
+	handler := &recordingHandler{fail: func([]models.Post) error { return errors.New("db down") }}
+	p := newProcessor(handler, nil, 2)
+
+	session := &fakeSession{ctx: context.Background()}
+	claim := &fakeClaim{messages: make(chan *sarama.ConsumerMessage, 2)}
+	claim.messages <- postMessage(t, 0, 0)
+	claim.messages <- postMessage(t, 0, 1)
+
+	// Không có dead letter sink → lỗi trả về, offset không được mark
+	if err := p.consume(session, claim, &Consumer{metrics: newConsumerMetrics()}); err == nil {
+		t.Fatal("expected error when batch cannot be written")
+	}
+	if got := session.lastMarked(); got != -1 {
+		t.Fatalf("offset marked after failed write: %d", got)
+	}
+}
+
+func TestBatchFallbackDeadLettersBadPost(t *testing.T) {
+	// Batch chứa p0-1 luôn lỗi; ghi riêng từng post thì chỉ p0-1 lỗi
+	handler := &recordingHandler{fail: func(posts []models.Post) error {
+		for _, p := range posts {
+			if p.ID == "p0-1" {
+				return errors.New("bad row")
+			}
+		}
+		return nil
+	}}
+	sink := &recordingSink{}
+	p := newProcessor(handler, sink, 3)
+
+	session := &fakeSession{ctx: context.Background()}
+	claim := &fakeClaim{messages: make(chan *sarama.ConsumerMessage, 4)}
+	for i := int64(0); i < 3; i++ {
+		claim.messages <- postMessage(t, 0, i)
+	}
+	// Message không parse được → dead letter, offset vẫn được mark cùng batch
+	claim.messages <- &sarama.ConsumerMessage{Topic: "raw_posts", Offset: 3, Value: []byte("{")}
+	close(claim.messages)
+
+	if err := p.consume(session, claim, &Consumer{metrics: newConsumerMetrics()}); err != nil {
+		t.Fatal(err)
+	}
+	if handler.count() != 2 {
+		t.Fatalf("written = %d, want 2", handler.count())
+	}
+	if len(sink.letters) != 2 {
+		t.Fatalf("dead letters = %d, want 2", len(sink.letters))
+	}
+	if sink.letters[0].Stage != models.StageHandle || sink.letters[1].Stage != models.StageUnmarshal {
+		t.Fatalf("unexpected stages: %s, %s", sink.letters[0].Stage, sink.letters[1].Stage)
+	}
+	if got := session.lastMarked(); got != 3 {
+		t.Fatalf("last marked = %d, want 3", got)
+	}
+}
+
+func TestBatchPartitionsConcurrent(t *testing.T) {
+	handler := &recordingHandler{}
+	p := newProcessor(handler, nil, 5)
+	consumer := &Consumer{metrics: newConsumerMetrics()}
+
+	const partitions, perPartition = 4, 50
+	var wg sync.WaitGroup
+	sessions := make([]*fakeSession, partitions)
+	for i := 0; i < partitions; i++ {
+		sessions[i] = &fakeSession{ctx: context.Background()}
+		claim := &fakeClaim{partition: int32(i), messages: make(chan *sarama.ConsumerMessage)}
+
+		wg.Add(1)
+		go func(session *fakeSession, claim *fakeClaim) {
+			defer wg.Done()
+			if err := p.consume(session, claim, consumer); err != nil {
+				t.Error(err)
+			}
+		}(sessions[i], claim)
+
+		go func(claim *fakeClaim) {
+			for off := int64(0); off < perPartition; off++ {
+				claim.messages <- postMessage(t, claim.partition, off)
+			}
+			close(claim.messages)
+		}(claim)
+	}
+	wg.Wait()
+
+	if handler.count() != partitions*perPartition {
+		t.Fatalf("written = %d, want %d", handler.count(), partitions*perPartition)
+	}
+	if consumer.GetMessageCount() != partitions*perPartition {
+		t.Fatalf("processed = %d", consumer.GetMessageCount())
+	}
+	m := consumer.Metrics()
+	if m.Batches != partitions*perPartition/5 || m.BatchSize.Max != 5 || m.FlushMs.Count != m.Batches {
+		t.Fatalf("metrics = %+v", m)
+	}
+	for i, s := range sessions {
+		if got := s.lastMarked(); got != perPartition-1 {
+			t.Fatalf("partition %d last marked = %d", i, got)
+		}
+	}
+}
+
+// partitionHandler là handler riêng của một partition, ghi nhận khi bị đóng
+type partitionHandler struct {
+	recordingHandler
+	closed bool
+}
+
+func (h *partitionHandler) Close() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closed = true
+	return nil
+}
+
+// lagRecorder lưu các lag report theo thứ tự
+type lagRecorder struct {
+	mu      sync.Mutex
+	reports []PartitionLag
+}
+
+func (r *lagRecorder) ReportLag(l PartitionLag) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.reports = append(r.reports, l)
+}
+
+func TestBatchPartitionHandlersReportLag(t *testing.T) {
+	p := newProcessor(nil, nil, 2)
+	var mu sync.Mutex
+	handlers := map[int32]*partitionHandler{}
+	p.factory = func(topic string, partition int32) BatchHandler {
+		mu.Lock()
+		defer mu.Unlock()
+		handlers[partition] = &partitionHandler{}
+		return handlers[partition]
+	}
+	lags := &lagRecorder{}
+	consumer := &Consumer{metrics: newConsumerMetrics()}
+	consumer.SetLagReporter(lags)
+
+	claims := []*fakeClaim{
+		{partition: 0, highWaterMark: 10, messages: make(chan *sarama.ConsumerMessage)},
+		{partition: 1, highWaterMark: 5, messages: make(chan *sarama.ConsumerMessage)},
+	}
+	done := make([]chan error, len(claims))
+	for i, claim := range claims {
+		done[i] = make(chan error, 1)
+		go func(claim *fakeClaim, done chan error) {
+			done <- p.consume(&fakeSession{ctx: context.Background()}, claim, consumer)
+		}(claim, done[i])
+
+		// Channel không buffer: gửi được offset 2 nghĩa là batch 0-1 đã flush
+		for off := int64(0); off < 3; off++ {
+			claim.messages <- postMessage(t, claim.partition, off)
+		}
+	}
+
+	want := []PartitionLag{
+		{Topic: "raw_posts", Partition: 0, Offset: 2, HighWaterMark: 10, Lag: 8},
+		{Topic: "raw_posts", Partition: 1, Offset: 2, HighWaterMark: 5, Lag: 3},
+	}
+	got := consumer.Lag()
+	if len(got) != len(want) || consumer.TotalLag() != 11 {
+		t.Fatalf("lag = %+v, total %d", got, consumer.TotalLag())
+	}
+	for i := range want {
+		got[i].UpdatedAt = time.Time{}
+		if got[i] != want[i] {
+			t.Errorf("partition %d lag = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	// Thu hồi partition 0: flush phần còn lại, đóng handler, bỏ lag của nó
+	close(claims[0].messages)
+	if err := <-done[0]; err != nil {
+		t.Fatal(err)
+	}
+	if got := consumer.Lag(); len(got) != 1 || got[0].Partition != 1 {
+		t.Fatalf("lag after revoke = %+v", got)
+	}
+	close(claims[1].messages)
+	if err := <-done[1]; err != nil {
+		t.Fatal(err)
+	}
+
+	// Mỗi partition một handler, chỉ nhận posts của partition đó
+	for partition, h := range handlers {
+		if !h.closed || h.count() != 3 {
+			t.Errorf("partition %d handler: closed %v, written %v", partition, h.closed, h.written)
+		}
+		for _, id := range h.written {
+			if id[:2] != fmt.Sprintf("p%d", partition) {
+				t.Errorf("partition %d handler wrote %s", partition, id)
+			}
+		}
+	}
+	last := lags.reports[len(lags.reports)-1]
+	if last.Partition != 1 || last.Offset != 3 || last.Lag != 2 || len(consumer.Lag()) != 0 {
+		t.Errorf("last report = %+v, remaining lag %+v", last, consumer.Lag())
+	}
+}
+
+func TestBatchDispatchByEventType(t *testing.T) {
+	handler := &recordingHandler{}
+	sink := &recordingSink{}
+	p := newProcessor(handler, sink, 10)
+
+	encode := func(env events.Envelope, err error) []byte {
+		t.Helper()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := p.codec.Encode(env)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+
+	future, _ := events.NewPostCreated(models.Post{ID: "future"}, "test")
+	future.SchemaVersion = events.CurrentVersion(events.EventPostCreated) + 1
+
+	values := [][]byte{
+		encode(events.NewPostCreated(models.Post{ID: "created"}, "test")),
+		// Event type consumer không biết
+		[]byte(`{"schema_version":1,"event_type":"post.deleted","producer":"test","payload":{"id":"created"}}`),
+		[]byte(`{"id":"legacy","author":"a"}`), // Message cũ không có envelope
+		encode(future, nil),
+	}
+
+	session := &fakeSession{ctx: context.Background()}
+	claim := &fakeClaim{messages: make(chan *sarama.ConsumerMessage, len(values))}
+	for i, v := range values {
+		claim.messages <- &sarama.ConsumerMessage{Topic: "raw_posts", Offset: int64(i), Value: v}
+	}
+	close(claim.messages)
+
+	if err := p.consume(session, claim, &Consumer{metrics: newConsumerMetrics()}); err != nil {
+		t.Fatal(err)
+	}
+	if len(handler.written) != 2 || handler.written[0] != "created" || handler.written[1] != "legacy" {
+		t.Fatalf("written = %v", handler.written)
+	}
+	if len(sink.letters) != 2 || sink.letters[0].Stage != models.StageDispatch || sink.letters[1].Stage != models.StageDispatch {
+		t.Fatalf("dead letters = %+v", sink.letters)
+	}
+	if got := session.lastMarked(); got != 3 {
+		t.Fatalf("last marked = %d, want 3", got)
+	}
+}
+
+func TestConsumerMetricsSummary(t *testing.T) {
+	m := newConsumerMetrics()
+	produced := time.Now().Add(-time.Second)
+	for i := 1; i <= 100; i++ {
+		msg := &sarama.ConsumerMessage{Timestamp: produced}
+		m.recordFlush(i, time.Duration(i)*time.Millisecond, []*sarama.ConsumerMessage{msg, {}})
+	}
+	m.recordFailure(time.Second)
+
+	c := &Consumer{metrics: m}
+	snap := c.Metrics()
+	if snap.Batches != 100 || snap.FailedFlushes != 1 {
+		t.Fatalf("batches = %d, failed = %d", snap.Batches, snap.FailedFlushes)
+	}
+	if s := snap.BatchSize; s.Min != 1 || s.Max != 100 || s.P50 != 50 || s.P95 != 95 || s.Avg != 50.5 {
+		t.Fatalf("batch size = %+v", s)
+	}
+	// Message không có timestamp không tính vào latency
+	if s := snap.LatencyMs; s.Count != 100 || s.Min < 1000 {
+		t.Fatalf("latency = %+v", s)
+	}
+	if snap.Throughput <= 0 {
+		t.Fatalf("throughput = %v", snap.Throughput)
+	}
+}
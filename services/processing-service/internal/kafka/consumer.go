@@ -9,9 +9,15 @@ package kafka
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
+	"log/slog"
+	"social-insight/internal/events"
+	"social-insight/internal/logger"
 	"social-insight/internal/models"
+	"social-insight/internal/tracing"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/IBM/sarama"
 )
@@ -22,6 +28,25 @@ type PostHandler interface {
 	HandlePost(post models.Post) error
 }
 
+// MessageHandler xử lý message Kafka thô (không parse thành Post)
+// Dùng cho các topic khác raw_posts, ví dụ dead letter topic
+type MessageHandler interface {
+	HandleMessage(message *sarama.ConsumerMessage) error
+}
+
+// DeadLetterSink nhận các message không xử lý được
+type DeadLetterSink interface {
+	Publish(dl models.DeadLetter) error
+}
+
+// postMessageHandler chuyển message thành Post rồi gọi PostHandler
+// Message lỗi được đẩy sang dead letter sink (nếu có)
+type postMessageHandler struct {
+	handler    PostHandler
+	deadLetter DeadLetterSink
+	codec      *events.Codec
+}
+
 // Consumer là struct wrapper cho Kafka consumer group
 type Consumer struct {
 	// consumerGroup là Sarama consumer group
@@ -30,11 +55,25 @@ type Consumer struct {
 	// topic là tên topic để đọc messages
 	topic string
 
-	// handler xử lý posts nhận được
-	handler PostHandler
+	// handler xử lý từng message nhận được (nil khi dùng batch)
+	handler MessageHandler
+
+	// batch gom posts theo partition (nil = xử lý từng message)
+	batch *batchProcessor
+
+	// codec decode event envelope (JSON, Avro, message cũ)
+	codec *events.Codec
 
-	// messageCount đếm số messages đã xử lý
+	// messageCount đếm số messages đã xử lý (atomic, nhiều partitions cùng cập nhật)
 	messageCount int64
+
+	// lags là lag của từng partition đang được assign
+	lags        map[int32]PartitionLag
+	lagMu       sync.Mutex
+	lagReporter LagReporter
+
+	// metrics thu thập batch size, flush duration, latency
+	metrics *consumerMetrics
 }
 
 // consumerGroupHandler implement sarama.ConsumerGroupHandler
@@ -47,10 +86,26 @@ type consumerGroupHandler struct {
 // groupID: ID của consumer group
 // topic: tên topic để subscribe
 // handler: interface xử lý posts
-func NewConsumer(brokers []string, groupID, topic string, handler PostHandler) (*Consumer, error) {
+// deadLetter: nơi nhận message lỗi (nil = chỉ log và bỏ qua)
+func NewConsumer(brokers []string, groupID, topic string, handler PostHandler, deadLetter DeadLetterSink) (*Consumer, error) {
+	h := &postMessageHandler{
+		handler:    handler,
+		deadLetter: deadLetter,
+	}
+	c, err := NewMessageConsumer(brokers, groupID, topic, h)
+	if err != nil {
+		return nil, err
+	}
+	h.codec = c.codec
+	return c, nil
+}
+
+// NewMessageConsumer tạo Kafka consumer xử lý message thô
+func NewMessageConsumer(brokers []string, groupID, topic string, handler MessageHandler) (*Consumer, error) {
 	// Cấu hình consumer
 	config := sarama.NewConfig()
-	config.Consumer.Group.Rebalance.Strategy = sarama.NewBalanceStrategyRoundRobin()
+	// Sticky: khi thêm/bớt replica chỉ chuyển ít partitions nhất có thể
+	config.Consumer.Group.Rebalance.GroupStrategies = []sarama.BalanceStrategy{sarama.NewBalanceStrategySticky()}
 	config.Consumer.Offsets.Initial = sarama.OffsetOldest // Đọc từ message cũ nhất (từ đầu)
 
 	// Tạo consumer group
@@ -59,13 +114,29 @@ func NewConsumer(brokers []string, groupID, topic string, handler PostHandler) (
 		return nil, fmt.Errorf("không thể tạo consumer group: %w", err)
 	}
 
+	// Mặc định chỉ decode JSON; SetEventCodec để đọc được Avro
+	codec, _ := events.NewCodec(events.EncodingJSON, nil)
+
 	return &Consumer{
 		consumerGroup: consumerGroup,
 		topic:         topic,
 		handler:       handler,
+		codec:         codec,
+		metrics:       newConsumerMetrics(),
 	}, nil
 }
 
+// SetEventCodec đặt codec decode event (cần registry để đọc Avro)
+func (c *Consumer) SetEventCodec(codec *events.Codec) {
+	c.codec = codec
+	if c.batch != nil {
+		c.batch.codec = codec
+	}
+	if h, ok := c.handler.(*postMessageHandler); ok {
+		h.codec = codec
+	}
+}
+
 // Start bắt đầu consume messages
 // Chạy trong goroutine riêng, dừng khi context bị cancel
 func (c *Consumer) Start(ctx context.Context) error {
@@ -82,14 +153,28 @@ func (c *Consumer) Start(ctx context.Context) error {
 		// Consume messages
 		err := c.consumerGroup.Consume(ctx, []string{c.topic}, handler)
 		if err != nil {
-			fmt.Printf("❌ Consumer error: %v\n", err)
+			slog.ErrorContext(ctx, "consumer error", "topic", c.topic, logger.Err(err))
 		}
 	}
 }
 
 // GetMessageCount trả về số messages đã xử lý
 func (c *Consumer) GetMessageCount() int64 {
-	return c.messageCount
+	return atomic.LoadInt64(&c.messageCount)
+}
+
+// addProcessed cộng số messages đã xử lý và log progress
+func (c *Consumer) addProcessed(n int) {
+	if n <= 0 {
+		return
+	}
+	before := atomic.AddInt64(&c.messageCount, int64(n)) - int64(n)
+	after := before + int64(n)
+
+	// Log progress mỗi 10000 messages
+	if after/10000 > before/10000 {
+		slog.Info("consumer progress", "topic", c.topic, "processed", after)
+	}
 }
 
 // Close đóng consumer
@@ -102,42 +187,109 @@ func (c *Consumer) Close() error {
 // =====================================================
 
 // Setup được gọi khi consumer group session bắt đầu
-func (h *consumerGroupHandler) Setup(sarama.ConsumerGroupSession) error {
-	fmt.Println("✅ Consumer group session started")
+func (h *consumerGroupHandler) Setup(session sarama.ConsumerGroupSession) error {
+	slog.Info("consumer group session started",
+		"member_id", session.MemberID(), "generation", session.GenerationID(), "claims", session.Claims())
 	return nil
 }
 
 // Cleanup được gọi khi consumer group session kết thúc
-func (h *consumerGroupHandler) Cleanup(sarama.ConsumerGroupSession) error {
-	fmt.Println("🔄 Consumer group session ended")
+func (h *consumerGroupHandler) Cleanup(session sarama.ConsumerGroupSession) error {
+	slog.Info("consumer group session ended", "generation", session.GenerationID())
 	return nil
 }
 
 // ConsumeClaim xử lý messages từ partition
+// Sarama gọi ConsumeClaim trong một goroutine riêng cho mỗi partition
 func (h *consumerGroupHandler) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
-	for message := range claim.Messages() {
-		// Parse JSON thành Post
-		var post models.Post
-		if err := json.Unmarshal(message.Value, &post); err != nil {
-			fmt.Printf("❌ Cannot unmarshal message: %v\n", err)
-			continue
-		}
+	if h.consumer.batch != nil {
+		return h.consumer.batch.consume(session, claim, h.consumer)
+	}
 
-		// Xử lý post (lưu vào DB, cache, etc.)
-		if err := h.consumer.handler.HandlePost(post); err != nil {
-			fmt.Printf("❌ Cannot handle post %s: %v\n", post.ID, err)
+	for message := range claim.Messages() {
+		span := startConsumerSpan(message)
+		err := h.consumer.handler.HandleMessage(message)
+		tracing.End(span, &err)
+		if err != nil {
+			slog.Error("cannot handle message", append(messageAttrs(message), logger.Err(err))...)
 			continue
 		}
 
 		// Đánh dấu message đã xử lý
 		session.MarkMessage(message, "")
-		h.consumer.messageCount++
+		h.consumer.addProcessed(1)
+	}
 
-		// Log progress mỗi 10000 messages
-		if h.consumer.messageCount%10000 == 0 {
-			fmt.Printf("📊 Processed %d messages\n", h.consumer.messageCount)
-		}
+	return nil
+}
+
+// =====================================================
+// POST MESSAGE HANDLER
+// =====================================================
+
+// HandleMessage decode event post.created và gọi PostHandler
+// Các event type khác được bỏ qua (PostHandler chỉ hiểu posts)
+// Lỗi unmarshal/handle được đẩy sang dead letter; trả về nil nếu đã
+// chuyển thành công sang dead letter để message được mark
+func (h *postMessageHandler) HandleMessage(message *sarama.ConsumerMessage) error {
+	env, err := h.codec.Decode(message.Value)
+	if err != nil {
+		return publishDeadLetter(h.deadLetter, message, models.StageUnmarshal, err)
+	}
+	if err := env.CheckVersion(); err != nil {
+		return publishDeadLetter(h.deadLetter, message, models.StageDispatch, err)
+	}
+	if env.EventType != events.EventPostCreated {
+		return nil
 	}
 
+	post, err := env.Post()
+	if err != nil {
+		return publishDeadLetter(h.deadLetter, message, models.StageUnmarshal, err)
+	}
+
+	// Xử lý post (lưu vào DB, cache, etc.)
+	if err := h.handler.HandlePost(post); err != nil {
+		return publishDeadLetter(h.deadLetter, message, models.StageHandle, err)
+	}
+	return nil
+}
+
+// publishDeadLetter đẩy message lỗi sang dead letter sink
+// Trả về nil nếu đã chuyển thành công để message được mark
+func publishDeadLetter(sink DeadLetterSink, message *sarama.ConsumerMessage, stage string, cause error) error {
+	if sink == nil {
+		return fmt.Errorf("%s error: %w", stage, cause)
+	}
+
+	dl := models.DeadLetter{
+		Payload:     message.Value,
+		Key:         string(message.Key),
+		SourceTopic: message.Topic,
+		Partition:   message.Partition,
+		Offset:      message.Offset,
+		Stage:       stage,
+		Error:       cause.Error(),
+		Producer:    "consumer",
+		FailedAt:    time.Now(),
+	}
+	if err := sink.Publish(dl); err != nil {
+		return fmt.Errorf("%s error: %v (dead letter failed: %w)", stage, cause, err)
+	}
+
+	slog.Warn("message dead-lettered", append(messageAttrs(message), "stage", stage, logger.Err(cause))...)
 	return nil
 }
+
+// messageAttrs là các attribute log chung cho một message Kafka
+// Kèm trace_id từ header để nối log consumer với log crawler
+func messageAttrs(message *sarama.ConsumerMessage) []any {
+	attrs := []any{"topic", message.Topic, "partition", message.Partition, "offset", message.Offset}
+	for _, h := range message.Headers {
+		if h != nil && string(h.Key) == events.HeaderTraceID && len(h.Value) > 0 {
+			attrs = append(attrs, logger.KeyTraceID, string(h.Value))
+			break
+		}
+	}
+	return attrs
+}
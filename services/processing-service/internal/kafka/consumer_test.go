@@ -0,0 +1,84 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"social-insight/internal/events"
+	"social-insight/internal/models"
+	"testing"
+
+	"github.com/IBM/sarama"
+)
+
+// postHandlerFunc cho phép dùng function làm PostHandler
+type postHandlerFunc func(post models.Post) error
+
+func (f postHandlerFunc) HandlePost(post models.Post) error { return f(post) }
+
+// failingSink luôn lỗi khi publish dead letter
+type failingSink struct{}
+
+func (failingSink) Publish(models.DeadLetter) error { return errors.New("dlq down") }
+
+// consumeMessages chạy ConsumeClaim (không batch) trên các messages và trả về session
+func consumeMessages(t *testing.T, handler PostHandler, sink DeadLetterSink, messages ...*sarama.ConsumerMessage) *fakeSession {
+	t.Helper()
+	codec, _ := events.NewCodec(events.EncodingJSON, nil)
+	c := &Consumer{
+		handler: &postMessageHandler{handler: handler, deadLetter: sink, codec: codec},
+		metrics: newConsumerMetrics(),
+	}
+
+	session := &fakeSession{ctx: context.Background()}
+	claim := &fakeClaim{partition: 2, messages: make(chan *sarama.ConsumerMessage, len(messages))}
+	for _, m := range messages {
+		claim.messages <- m
+	}
+	close(claim.messages)
+
+	if err := (&consumerGroupHandler{consumer: c}).ConsumeClaim(session, claim); err != nil {
+		t.Fatal(err)
+	}
+	return session
+}
+
+func TestConsumeDeadLettersPoisonMessages(t *testing.T) {
+	handler := postHandlerFunc(func(post models.Post) error {
+		if post.ID == "p2-1" {
+			return errors.New("insert failed")
+		}
+		return nil
+	})
+	sink := &recordingSink{}
+
+	poison := &sarama.ConsumerMessage{Topic: "raw_posts", Partition: 2, Offset: 2, Key: []byte("k"), Value: []byte("{")}
+	session := consumeMessages(t, handler, sink, postMessage(t, 2, 0), postMessage(t, 2, 1), poison)
+
+	// Message lỗi đã vào dead letter nên vẫn được mark, partition không bị kẹt
+	if len(session.marked) != 3 {
+		t.Fatalf("marked = %v, want all 3 offsets", session.marked)
+	}
+	if len(sink.letters) != 2 {
+		t.Fatalf("dead letters = %+v", sink.letters)
+	}
+	handled, unmarshal := sink.letters[0], sink.letters[1]
+	if handled.Stage != models.StageHandle || handled.Offset != 1 || handled.Error != "insert failed" {
+		t.Errorf("handle dead letter = %+v", handled)
+	}
+	if unmarshal.Stage != models.StageUnmarshal || unmarshal.SourceTopic != "raw_posts" ||
+		unmarshal.Partition != 2 || unmarshal.Offset != 2 || unmarshal.Key != "k" || string(unmarshal.Payload) != "{" {
+		t.Errorf("unmarshal dead letter = %+v", unmarshal)
+	}
+}
+
+func TestConsumeKeepsUnmarkedWhenDeadLetterFails(t *testing.T) {
+	handler := postHandlerFunc(func(models.Post) error { return errors.New("insert failed") })
+
+	// Không có sink hoặc sink lỗi: message không được mark để xử lý lại
+	for name, sink := range map[string]DeadLetterSink{"no sink": nil, "sink down": failingSink{}} {
+		session := consumeMessages(t, handler, sink, postMessage(t, 2, 0))
+		if len(session.marked) != 0 {
+			t.Errorf("%s: marked = %v, want none", name, session.marked)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+// =====================================================
+// CONSUMER LAG - Theo dõi lag từng partition
+// =====================================================
+// Mô tả: Mỗi partition tự báo lag (high watermark - offset đã commit)
+// sau mỗi lần flush, để biết khi nào cần thêm consumer replicas
+// =====================================================
+
+package kafka
+
+import (
+	"social-insight/internal/metrics"
+	"sort"
+	"time"
+)
+
+// PartitionLag là lag của một partition do consumer này đang giữ
+type PartitionLag struct {
+	Topic         string    `json:"topic"`
+	Partition     int32     `json:"partition"`
+	Offset        int64     `json:"offset"`          // Offset tiếp theo sẽ commit
+	HighWaterMark int64     `json:"high_water_mark"` // Offset tiếp theo broker sẽ ghi
+	Lag           int64     `json:"lag"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// LagReporter nhận lag mỗi khi partition flush (ví dụ ghi vào Redis)
+type LagReporter interface {
+	ReportLag(lag PartitionLag)
+}
+
+// SetLagReporter đặt nơi nhận lag report (nil = chỉ lưu trong process)
+func (c *Consumer) SetLagReporter(r LagReporter) {
+	c.lagReporter = r
+}
+
+// Lag trả về lag các partitions đang được assign, sắp xếp theo partition
+func (c *Consumer) Lag() []PartitionLag {
+	c.lagMu.Lock()
+	defer c.lagMu.Unlock()
+
+	result := make([]PartitionLag, 0, len(c.lags))
+	for _, l := range c.lags {
+		result = append(result, l)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Partition < result[j].Partition })
+	return result
+}
+
+// TotalLag trả về tổng lag các partitions đang được assign
+func (c *Consumer) TotalLag() int64 {
+	c.lagMu.Lock()
+	defer c.lagMu.Unlock()
+
+	var total int64
+	for _, l := range c.lags {
+		total += l.Lag
+	}
+	return total
+}
+
+// recordLag cập nhật lag của partition và chuyển cho reporter
+func (c *Consumer) recordLag(topic string, partition int32, offset, highWaterMark int64) {
+	lag := highWaterMark - offset
+	if lag < 0 {
+		lag = 0
+	}
+	l := PartitionLag{
+		Topic:         topic,
+		Partition:     partition,
+		Offset:        offset,
+		HighWaterMark: highWaterMark,
+		Lag:           lag,
+		UpdatedAt:     time.Now(),
+	}
+
+	c.lagMu.Lock()
+	if c.lags == nil {
+		c.lags = make(map[int32]PartitionLag)
+	}
+	c.lags[partition] = l
+	c.lagMu.Unlock()
+	metrics.ConsumerLag.WithLabelValues(topic, metrics.PartitionLabel(partition)).Set(float64(lag))
+
+	if c.lagReporter != nil {
+		c.lagReporter.ReportLag(l)
+	}
+}
+
+// forgetLag xóa partition khi bị thu hồi (rebalance)
+// Replica mới nhận partition sẽ báo lag của nó
+func (c *Consumer) forgetLag(topic string, partition int32) {
+	c.lagMu.Lock()
+	delete(c.lags, partition)
+	c.lagMu.Unlock()
+	metrics.ConsumerLag.DeleteLabelValues(topic, metrics.PartitionLabel(partition))
+}
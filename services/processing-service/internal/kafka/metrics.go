@@ -0,0 +1,231 @@
+// =====================================================
+// CONSUMER METRICS - Throughput, latency, batch size
+// =====================================================
+// Mô tả: Thu thập số liệu mỗi lần flush batch:
+//   - batch size và thời gian flush (ghi DB)
+//   - latency từ lúc produce (timestamp Kafka message) đến lúc ghi DB
+//   - throughput (messages/giây) trong phút gần nhất
+// Các giá trị giữ trong cửa sổ mẫu gần nhất để tính percentile
+// =====================================================
+
+package kafka
+
+import (
+	"math"
+	"social-insight/internal/metrics"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+const (
+	// metricsWindow là số mẫu gần nhất giữ lại cho mỗi chỉ số
+	metricsWindow = 1024
+
+	// throughputWindow là khoảng thời gian tính throughput
+	throughputWindow = time.Minute
+)
+
+// Summary tóm tắt một chỉ số trong cửa sổ mẫu gần nhất
+type Summary struct {
+	Count int64   `json:"count"` // Tổng số mẫu từ khi khởi động
+	Min   float64 `json:"min"`
+	Max   float64 `json:"max"`
+	Avg   float64 `json:"avg"`
+	P50   float64 `json:"p50"`
+	P95   float64 `json:"p95"`
+	P99   float64 `json:"p99"`
+}
+
+// MetricsSnapshot là trạng thái consumer tại một thời điểm
+type MetricsSnapshot struct {
+	Processed     int64          `json:"processed"`
+	Batches       int64          `json:"batches"`
+	FailedFlushes int64          `json:"failed_flushes"`
+	Throughput    float64        `json:"throughput_per_sec"`
+	BatchSize     Summary        `json:"batch_size"`
+	FlushMs       Summary        `json:"flush_ms"`
+	LatencyMs     Summary        `json:"latency_ms"`
+	LastFlushAt   time.Time      `json:"last_flush_at,omitempty"`
+	TotalLag      int64          `json:"total_lag"`
+	Partitions    []PartitionLag `json:"partitions"`
+	StartedAt     time.Time      `json:"started_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+}
+
+// sampleWindow là ring buffer các mẫu gần nhất
+type sampleWindow struct {
+	values []float64
+	next   int
+	count  int64
+}
+
+// add thêm một mẫu, ghi đè mẫu cũ nhất khi đầy
+func (w *sampleWindow) add(v float64) {
+	if len(w.values) < metricsWindow {
+		w.values = append(w.values, v)
+	} else {
+		w.values[w.next] = v
+		w.next = (w.next + 1) % metricsWindow
+	}
+	w.count++
+}
+
+// summary tính min/max/avg/percentile trên các mẫu hiện có
+func (w *sampleWindow) summary() Summary {
+	s := Summary{Count: w.count}
+	if len(w.values) == 0 {
+		return s
+	}
+
+	sorted := append([]float64(nil), w.values...)
+	sort.Float64s(sorted)
+
+	var sum float64
+	for _, v := range sorted {
+		sum += v
+	}
+	s.Min = sorted[0]
+	s.Max = sorted[len(sorted)-1]
+	s.Avg = round(sum / float64(len(sorted)))
+	s.P50 = percentile(sorted, 0.50)
+	s.P95 = percentile(sorted, 0.95)
+	s.P99 = percentile(sorted, 0.99)
+	return s
+}
+
+// percentile lấy giá trị tại phân vị q của mảng đã sắp xếp (nearest-rank)
+func percentile(sorted []float64, q float64) float64 {
+	idx := int(math.Ceil(q*float64(len(sorted)))) - 1
+	if idx < 0 {
+		idx = 0
+	}
+	return sorted[idx]
+}
+
+// round làm tròn 2 chữ số thập phân cho dễ đọc
+func round(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// consumerMetrics thu thập số liệu từ tất cả partitions (thread-safe)
+type consumerMetrics struct {
+	mu sync.Mutex
+
+	batchSize sampleWindow
+	flushMs   sampleWindow
+	latencyMs sampleWindow
+
+	batches       int64
+	failedFlushes int64
+	lastFlushAt   time.Time
+	startedAt     time.Time
+
+	// flushes là các lần flush trong throughputWindow (để tính throughput)
+	flushes []flushSample
+}
+
+// flushSample là số messages của một lần flush
+type flushSample struct {
+	at time.Time
+	n  int
+}
+
+// newConsumerMetrics tạo bộ thu thập metrics rỗng
+func newConsumerMetrics() *consumerMetrics {
+	return &consumerMetrics{startedAt: time.Now()}
+}
+
+// recordFlush ghi nhận một batch đã ghi xong
+// messages là các message của batch; latency tính theo timestamp produce,
+// message không có timestamp (broker cũ) được bỏ qua
+func (m *consumerMetrics) recordFlush(size int, duration time.Duration, messages []*sarama.ConsumerMessage) {
+	now := time.Now()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	metrics.ConsumerBatchSize.Observe(float64(size))
+	metrics.ConsumerFlushDuration.WithLabelValues("ok").Observe(duration.Seconds())
+
+	m.batches++
+	m.lastFlushAt = now
+	m.batchSize.add(float64(size))
+	m.flushMs.add(float64(duration.Microseconds()) / 1000)
+	m.flushes = append(m.flushes, flushSample{at: now, n: size})
+	m.pruneFlushes(now)
+	for _, message := range messages {
+		if message.Timestamp.IsZero() {
+			continue
+		}
+		latency := now.Sub(message.Timestamp)
+		metrics.ConsumerLatency.Observe(latency.Seconds())
+		m.latencyMs.add(float64(latency.Milliseconds()))
+	}
+}
+
+// pruneFlushes bỏ các lần flush đã ra khỏi throughputWindow (cần giữ lock)
+func (m *consumerMetrics) pruneFlushes(now time.Time) {
+	cutoff := now.Add(-throughputWindow)
+	i := 0
+	for i < len(m.flushes) && m.flushes[i].at.Before(cutoff) {
+		i++
+	}
+	m.flushes = m.flushes[i:]
+}
+
+// recordFailure ghi nhận một lần flush lỗi (batch sẽ được đọc lại)
+func (m *consumerMetrics) recordFailure(duration time.Duration) {
+	metrics.ConsumerFlushDuration.WithLabelValues("error").Observe(duration.Seconds())
+
+	m.mu.Lock()
+	m.failedFlushes++
+	m.mu.Unlock()
+}
+
+// Metrics trả về snapshot metrics hiện tại của consumer
+func (c *Consumer) Metrics() MetricsSnapshot {
+	partitions := c.Lag()
+	var totalLag int64
+	for _, l := range partitions {
+		totalLag += l.Lag
+	}
+
+	m := c.metrics
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	m.pruneFlushes(now)
+	var recent int
+	for _, f := range m.flushes {
+		recent += f.n
+	}
+
+	// Mới khởi động chưa đủ một phút thì chia cho thời gian đã chạy
+	window := throughputWindow
+	if uptime := now.Sub(m.startedAt); uptime < window {
+		window = uptime
+	}
+	var throughput float64
+	if window > 0 {
+		throughput = round(float64(recent) / window.Seconds())
+	}
+
+	return MetricsSnapshot{
+		Processed:     c.GetMessageCount(),
+		Batches:       m.batches,
+		FailedFlushes: m.failedFlushes,
+		Throughput:    throughput,
+		BatchSize:     m.batchSize.summary(),
+		FlushMs:       m.flushMs.summary(),
+		LatencyMs:     m.latencyMs.summary(),
+		LastFlushAt:   m.lastFlushAt,
+		TotalLag:      totalLag,
+		Partitions:    partitions,
+		StartedAt:     m.startedAt,
+		UpdatedAt:     now,
+	}
+}
@@ -8,15 +8,26 @@
 package kafka
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"social-insight/internal/events"
+	"social-insight/internal/logger"
+	"social-insight/internal/metrics"
 	"social-insight/internal/models"
+	"social-insight/internal/tracing"
+	"strconv"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/IBM/sarama"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Producer là struct wrapper cho Kafka producer
+// Mọi lần gửi đều chờ broker ack (hoặc lỗi) trước khi trả về
 type Producer struct {
 	// producer là Sarama async producer
 	producer sarama.AsyncProducer
@@ -24,31 +35,77 @@ type Producer struct {
 	// topic là tên topic để gửi messages
 	topic string
 
-	// successCount đếm số messages gửi thành công
+	// sendTimeout là thời gian tối đa chờ ack cho một message
+	sendTimeout time.Duration
+
+	// codec encode event envelope (mặc định JSON)
+	codec *events.Codec
+
+	// name là tên producer ghi vào envelope ("crawler:hn", ...)
+	name string
+
+	// inflight đếm messages đã gửi nhưng chưa nhận ack/lỗi
+	inflight sync.WaitGroup
+
+	// closed chặn gửi mới sau Flush; mu giữ inflight.Add không chạy song song với inflight.Wait
+	mu     sync.Mutex
+	closed bool
+
+	// successCount đếm số messages gửi thành công (atomic)
 	successCount int64
 
-	// errorCount đếm số messages gửi thất bại
+	// errorCount đếm số messages gửi thất bại (atomic)
 	errorCount int64
 }
 
+// ErrAckTimeout báo hết thời gian chờ ack; message vẫn có thể được broker ghi sau đó
+var ErrAckTimeout = errors.New("timeout waiting for kafka ack")
+
+// ErrProducerClosed báo producer đã Flush, không nhận message mới
+var ErrProducerClosed = errors.New("kafka producer is closed")
+
+// Delivery là kết quả gửi một message, có được sau khi broker ack
+type Delivery struct {
+	result chan error
+
+	// span là span publish của message (nil với SendRaw), kết thúc khi có ack/lỗi
+	span trace.Span
+}
+
+// Wait chờ kết quả gửi (nil = broker đã ack)
+func (d *Delivery) Wait(timeout time.Duration) error {
+	select {
+	case err := <-d.result:
+		return err
+	case <-time.After(timeout):
+		return fmt.Errorf("%w after %v", ErrAckTimeout, timeout)
+	}
+}
+
 // NewProducer tạo một Kafka producer mới
 // brokers: danh sách Kafka brokers (ví dụ: ["localhost:9092"])
 // topic: tên topic để gửi messages
 func NewProducer(brokers []string, topic string) (*Producer, error) {
 	// Cấu hình producer
 	config := sarama.NewConfig()
+	config.Version = sarama.V2_1_0_0 // Idempotent producer cần Kafka >= 0.11
 
-	// Bật async producer (gửi không đợi response)
+	// Async producer nhưng luôn nhận Successes/Errors để xác nhận từng message
 	config.Producer.Return.Successes = true
 	config.Producer.Return.Errors = true
 
+	// Idempotent producer: retry không sinh message trùng trên broker
+	config.Producer.Idempotent = true
+	config.Producer.RequiredAcks = sarama.WaitForAll
+	config.Net.MaxOpenRequests = 1
+
 	// Cấu hình retry
-	config.Producer.Retry.Max = 3
+	config.Producer.Retry.Max = 5
 	config.Producer.Retry.Backoff = 100 * time.Millisecond
 
-	// Cấu hình batch để tăng throughput
-	config.Producer.Flush.Frequency = 100 * time.Millisecond // Flush mỗi 100ms
-	config.Producer.Flush.Messages = 1000                    // Hoặc khi đủ 1000 messages
+	// Cấu hình batch: caller chờ ack nên flush nhanh để giảm latency
+	config.Producer.Flush.Frequency = 10 * time.Millisecond // Flush mỗi 10ms
+	config.Producer.Flush.Messages = 1000                   // Hoặc khi đủ 1000 messages
 
 	// Tạo async producer
 	producer, err := sarama.NewAsyncProducer(brokers, config)
@@ -56,66 +113,198 @@ func NewProducer(brokers []string, topic string) (*Producer, error) {
 		return nil, fmt.Errorf("không thể tạo Kafka producer: %w", err)
 	}
 
+	return newProducer(producer, topic), nil
+}
+
+// newProducer bọc AsyncProducer và chạy goroutines nhận ack/lỗi
+// config của producer phải bật Return.Successes và Return.Errors
+func newProducer(producer sarama.AsyncProducer, topic string) *Producer {
+	codec, _ := events.NewCodec(events.EncodingJSON, nil)
 	p := &Producer{
-		producer: producer,
-		topic:    topic,
+		producer:    producer,
+		topic:       topic,
+		sendTimeout: 30 * time.Second,
+		codec:       codec,
+		name:        "unknown",
 	}
 
-	// Goroutine xử lý success responses
+	// Goroutine xử lý success responses: báo ack cho message tương ứng
 	go func() {
-		for range producer.Successes() {
-			p.successCount++
+		for msg := range producer.Successes() {
+			atomic.AddInt64(&p.successCount, 1)
+			metrics.KafkaMessages.WithLabelValues(msg.Topic, "acked").Inc()
+			p.complete(msg, nil)
 		}
 	}()
 
-	// Goroutine xử lý errors
+	// Goroutine xử lý errors: báo lỗi cho message tương ứng
 	go func() {
-		for err := range producer.Errors() {
-			p.errorCount++
-			fmt.Printf("❌ Kafka error: %v\n", err)
+		for perr := range producer.Errors() {
+			atomic.AddInt64(&p.errorCount, 1)
+			metrics.KafkaMessages.WithLabelValues(perr.Msg.Topic, "error").Inc()
+			slog.Error("kafka produce error", "topic", perr.Msg.Topic, logger.Err(perr.Err))
+			p.complete(perr.Msg, perr.Err)
 		}
 	}()
 
-	return p, nil
+	return p
+}
+
+// complete gửi kết quả về Delivery gắn trong message metadata
+func (p *Producer) complete(msg *sarama.ProducerMessage, err error) {
+	if d, ok := msg.Metadata.(*Delivery); ok {
+		if d.span != nil {
+			tracing.End(d.span, &err)
+		}
+		d.result <- err
+	}
+	p.inflight.Done()
+}
+
+// enqueue đưa message vào producer, trả về Delivery để chờ ack
+// span (có thể nil) được kết thúc khi message nhận ack/lỗi
+// Trả về ErrProducerClosed nếu producer đã Flush
+func (p *Producer) enqueue(msg *sarama.ProducerMessage, span trace.Span) (*Delivery, error) {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		err := ErrProducerClosed
+		if span != nil {
+			tracing.End(span, &err)
+		}
+		return nil, err
+	}
+	p.inflight.Add(1)
+	p.mu.Unlock()
+
+	d := &Delivery{result: make(chan error, 1), span: span}
+	msg.Metadata = d
+	metrics.KafkaMessages.WithLabelValues(msg.Topic, "sent").Inc()
+	p.producer.Input() <- msg
+	return d, nil
+}
+
+// SetEventCodec đặt codec (JSON/Avro) và tên producer ghi vào envelope
+func (p *Producer) SetEventCodec(codec *events.Codec, name string) {
+	p.codec = codec
+	p.name = name
 }
 
-// SendPost gửi một post vào Kafka
-func (p *Producer) SendPost(post models.Post) error {
-	// Chuyển post thành JSON
-	data, err := json.Marshal(post)
+// sendEvent encode envelope thành message kèm Kafka headers rồi đưa vào producer
+// Trace context của ctx được ghi vào header traceparent
+func (p *Producer) sendEvent(ctx context.Context, key string, env events.Envelope) (*Delivery, error) {
+	data, err := p.codec.Encode(env)
 	if err != nil {
-		return fmt.Errorf("không thể marshal post: %w", err)
+		return nil, fmt.Errorf("không thể encode event %s: %w", env.EventType, err)
 	}
 
-	// Tạo message
 	msg := &sarama.ProducerMessage{
 		Topic: p.topic,
-		Key:   sarama.StringEncoder(post.ID), // Dùng post ID làm key
+		Key:   sarama.StringEncoder(key),
 		Value: sarama.ByteEncoder(data),
+		Headers: []sarama.RecordHeader{
+			{Key: []byte(events.HeaderEventType), Value: []byte(env.EventType)},
+			{Key: []byte(events.HeaderSchemaVersion), Value: []byte(strconv.Itoa(env.SchemaVersion))},
+			{Key: []byte(events.HeaderContentType), Value: []byte(p.codec.ContentType())},
+			{Key: []byte(events.HeaderProducer), Value: []byte(env.Producer)},
+			{Key: []byte(events.HeaderTraceID), Value: []byte(env.TraceID)},
+		},
 	}
+	span := startProducerSpan(ctx, msg, env.TraceID)
+	return p.enqueue(msg, span)
+}
 
-	// Gửi message (async)
-	p.producer.Input() <- msg
+// SendPost gửi một post vào Kafka và chờ broker ack
+// Trả về nil chỉ khi message đã được ghi thành công; lỗi ErrAckTimeout
+// nghĩa là chưa rõ kết quả (message có thể vẫn được ghi sau đó)
+func (p *Producer) SendPost(ctx context.Context, post models.Post) error {
+	d, err := p.SendPostAsync(ctx, post)
+	if err != nil {
+		return err
+	}
+	return d.Wait(p.sendTimeout)
+}
 
-	return nil
+// SendPostAsync gửi post không chờ; dùng Delivery.Wait để lấy kết quả
+func (p *Producer) SendPostAsync(ctx context.Context, post models.Post) (*Delivery, error) {
+	env, err := events.NewPostCreated(post, p.name)
+	if err != nil {
+		return nil, err
+	}
+	return p.sendEvent(ctx, post.ID, env) // Dùng post ID làm key
 }
 
-// SendPosts gửi nhiều posts cùng lúc
-func (p *Producer) SendPosts(posts []models.Post) error {
+// SendRaw gửi message đã encode sẵn vào topic bất kỳ và chờ broker ack
+// Dùng cho dead letter topic và replay
+func (p *Producer) SendRaw(topic, key string, value []byte) error {
+	msg := &sarama.ProducerMessage{
+		Topic: topic,
+		Value: sarama.ByteEncoder(value),
+	}
+	if key != "" {
+		msg.Key = sarama.StringEncoder(key)
+	}
+
+	d, err := p.enqueue(msg, nil)
+	if err != nil {
+		return err
+	}
+	return d.Wait(p.sendTimeout)
+}
+
+// SendPosts gửi nhiều posts cùng lúc, chờ ack tất cả
+// Trả về lỗi đầu tiên gặp phải
+func (p *Producer) SendPosts(ctx context.Context, posts []models.Post) error {
+	deliveries := make([]*Delivery, 0, len(posts))
 	for _, post := range posts {
-		if err := p.SendPost(post); err != nil {
+		d, err := p.SendPostAsync(ctx, post)
+		if err != nil {
 			return err
 		}
+		deliveries = append(deliveries, d)
+	}
+
+	var firstErr error
+	for _, d := range deliveries {
+		if err := d.Wait(p.sendTimeout); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// SetSendTimeout đặt thời gian chờ ack tối đa cho mỗi message
+func (p *Producer) SetSendTimeout(timeout time.Duration) {
+	p.sendTimeout = timeout
+}
+
+// Flush chặn gửi mới rồi chờ tất cả messages đang gửi nhận ack/lỗi
+// Dùng trước khi shutdown để không mất messages; sau Flush mọi lần gửi trả về ErrProducerClosed
+func (p *Producer) Flush(ctx context.Context) error {
+	p.mu.Lock()
+	p.closed = true
+	p.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		p.inflight.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("flush kafka producer: %w", ctx.Err())
 	}
-	return nil
 }
 
 // GetStats trả về thống kê
 func (p *Producer) GetStats() (success int64, errors int64) {
-	return p.successCount, p.errorCount
+	return atomic.LoadInt64(&p.successCount), atomic.LoadInt64(&p.errorCount)
 }
 
-// Close đóng producer
+// Close đóng producer (sarama flush các messages còn lại trước khi đóng)
 func (p *Producer) Close() error {
 	return p.producer.Close()
 }
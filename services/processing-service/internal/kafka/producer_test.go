@@ -0,0 +1,109 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"social-insight/internal/models"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/IBM/sarama/mocks"
+)
+
+// newMockProducer tạo Producer trên Sarama mock producer
+func newMockProducer(t *testing.T) (*Producer, *mocks.AsyncProducer) {
+	t.Helper()
+	config := mocks.NewTestConfig()
+	config.Producer.Return.Successes = true
+	config.Producer.Return.Errors = true
+	mock := mocks.NewAsyncProducer(t, config)
+	t.Cleanup(func() { mock.Close() })
+	return newProducer(mock, "raw_posts"), mock
+}
+
+// holdAck chặn broker mock ack message cho tới khi release được đóng
+func holdAck(release <-chan struct{}) mocks.MessageChecker {
+	return func(*sarama.ProducerMessage) error {
+		<-release
+		return nil
+	}
+}
+
+func TestProducerCorrelatesAcks(t *testing.T) {
+	p, mock := newMockProducer(t)
+	brokerErr := errors.New("not leader for partition")
+	mock.ExpectInputAndSucceed()
+	mock.ExpectInputAndFail(brokerErr)
+	mock.ExpectInputAndSucceed()
+
+	// Ack/lỗi về theo từng message, kể cả khi gửi async xen kẽ
+	var deliveries []*Delivery
+	for _, id := range []string{"hn_1", "hn_2", "hn_3"} {
+		d, err := p.SendPostAsync(context.Background(), models.Post{ID: id})
+		if err != nil {
+			t.Fatal(err)
+		}
+		deliveries = append(deliveries, d)
+	}
+	for i, want := range []error{nil, brokerErr, nil} {
+		if err := deliveries[i].Wait(time.Second); !errors.Is(err, want) {
+			t.Errorf("delivery %d = %v, want %v", i, err, want)
+		}
+	}
+	if success, failed := p.GetStats(); success != 2 || failed != 1 {
+		t.Errorf("stats = %d acked, %d failed", success, failed)
+	}
+}
+
+func TestProducerAckTimeout(t *testing.T) {
+	p, mock := newMockProducer(t)
+	p.SetSendTimeout(20 * time.Millisecond)
+	release := make(chan struct{})
+	mock.ExpectInputWithMessageCheckerFunctionAndSucceed(holdAck(release))
+
+	if err := p.SendPost(context.Background(), models.Post{ID: "hn_1"}); !errors.Is(err, ErrAckTimeout) {
+		t.Fatalf("SendPost = %v, want ErrAckTimeout", err)
+	}
+
+	// Ack đến muộn vẫn được ghi nhận, Flush không bị treo
+	close(release)
+	if err := p.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if success, _ := p.GetStats(); success != 1 {
+		t.Errorf("late ack not counted: %d", success)
+	}
+}
+
+func TestProducerFlush(t *testing.T) {
+	p, mock := newMockProducer(t)
+	release := make(chan struct{})
+	mock.ExpectInputWithMessageCheckerFunctionAndSucceed(holdAck(release))
+
+	d, err := p.SendPostAsync(context.Background(), models.Post{ID: "hn_1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Message chưa ack: Flush hết hạn nhưng vẫn chặn gửi mới
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := p.Flush(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Flush with pending ack = %v", err)
+	}
+	if err := p.SendPost(context.Background(), models.Post{ID: "hn_2"}); !errors.Is(err, ErrProducerClosed) {
+		t.Fatalf("SendPost after Flush = %v, want ErrProducerClosed", err)
+	}
+	if err := p.SendRaw("dead_letters", "hn_2", []byte("{}")); !errors.Is(err, ErrProducerClosed) {
+		t.Fatalf("SendRaw after Flush = %v, want ErrProducerClosed", err)
+	}
+
+	close(release)
+	if err := p.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Wait(time.Second); err != nil {
+		t.Errorf("pending delivery = %v", err)
+	}
+}
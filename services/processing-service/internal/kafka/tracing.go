@@ -0,0 +1,120 @@
+// =====================================================
+// KAFKA TRACING - Chuyển trace context qua message headers
+// =====================================================
+// Mô tả: Producer ghi header traceparent (W3C) vào message,
+// consumer đọc lại để span xử lý là con của span gửi
+// =====================================================
+
+package kafka
+
+import (
+	"context"
+	"social-insight/internal/events"
+	"social-insight/internal/tracing"
+
+	"github.com/IBM/sarama"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// producerHeaders là TextMapCarrier trên headers của ProducerMessage
+type producerHeaders struct {
+	msg *sarama.ProducerMessage
+}
+
+// Get implement propagation.TextMapCarrier
+func (c producerHeaders) Get(key string) string {
+	for _, h := range c.msg.Headers {
+		if string(h.Key) == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+// Set implement propagation.TextMapCarrier (ghi đè nếu đã có)
+func (c producerHeaders) Set(key, value string) {
+	for i, h := range c.msg.Headers {
+		if string(h.Key) == key {
+			c.msg.Headers[i].Value = []byte(value)
+			return
+		}
+	}
+	c.msg.Headers = append(c.msg.Headers, sarama.RecordHeader{Key: []byte(key), Value: []byte(value)})
+}
+
+// Keys implement propagation.TextMapCarrier
+func (c producerHeaders) Keys() []string {
+	keys := make([]string, 0, len(c.msg.Headers))
+	for _, h := range c.msg.Headers {
+		keys = append(keys, string(h.Key))
+	}
+	return keys
+}
+
+// consumerHeaders là TextMapCarrier (chỉ đọc) trên headers của ConsumerMessage
+type consumerHeaders struct {
+	msg *sarama.ConsumerMessage
+}
+
+// Get implement propagation.TextMapCarrier
+func (c consumerHeaders) Get(key string) string {
+	for _, h := range c.msg.Headers {
+		if h != nil && string(h.Key) == key {
+			return string(h.Value)
+		}
+	}
+	return ""
+}
+
+// Set implement propagation.TextMapCarrier (message đã nhận không sửa được)
+func (c consumerHeaders) Set(string, string) {}
+
+// Keys implement propagation.TextMapCarrier
+func (c consumerHeaders) Keys() []string {
+	keys := make([]string, 0, len(c.msg.Headers))
+	for _, h := range c.msg.Headers {
+		if h != nil {
+			keys = append(keys, string(h.Key))
+		}
+	}
+	return keys
+}
+
+// startProducerSpan mở span gửi message và ghi trace context vào headers
+// Span kết thúc khi broker ack/lỗi (xem Producer.complete)
+func startProducerSpan(ctx context.Context, msg *sarama.ProducerMessage, eventTraceID string) trace.Span {
+	ctx, span := tracing.Start(ctx, msg.Topic+" publish",
+		trace.WithSpanKind(trace.SpanKindProducer),
+		trace.WithAttributes(
+			attribute.String("messaging.system", "kafka"),
+			attribute.String("messaging.operation", "publish"),
+			attribute.String("messaging.destination.name", msg.Topic),
+			attribute.String(events.HeaderTraceID, eventTraceID),
+		),
+	)
+	tracing.Inject(ctx, producerHeaders{msg: msg})
+	return span
+}
+
+// startConsumerSpan mở span xử lý message, là con của span producer
+// (lấy từ header traceparent); span kết thúc khi batch chứa message đã ghi
+func startConsumerSpan(message *sarama.ConsumerMessage) trace.Span {
+	attrs := []attribute.KeyValue{
+		attribute.String("messaging.system", "kafka"),
+		attribute.String("messaging.operation", "process"),
+		attribute.String("messaging.destination.name", message.Topic),
+		attribute.Int("messaging.kafka.destination.partition", int(message.Partition)),
+		attribute.Int64("messaging.kafka.message.offset", message.Offset),
+	}
+	if id := (consumerHeaders{msg: message}).Get(events.HeaderTraceID); id != "" {
+		attrs = append(attrs, attribute.String(events.HeaderTraceID, id))
+	}
+
+	ctx := tracing.Extract(context.Background(), consumerHeaders{msg: message})
+	_, span := tracing.Start(ctx, message.Topic+" process",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(attrs...),
+	)
+	return span
+}
@@ -0,0 +1,137 @@
+package kafka
+
+import (
+	"context"
+	"social-insight/internal/events"
+	"social-insight/internal/tracing"
+	"testing"
+
+	"github.com/IBM/sarama"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// keepSpansExporter giữ span sau Shutdown (InMemoryExporter tự xóa khi shutdown)
+type keepSpansExporter struct {
+	*tracetest.InMemoryExporter
+}
+
+func (keepSpansExporter) Shutdown(context.Context) error { return nil }
+
+// setupTestTracing ghi span vào exporter in-memory, trả về hàm flush
+func setupTestTracing(t *testing.T) (*tracetest.InMemoryExporter, func()) {
+	t.Helper()
+	exporter := tracetest.NewInMemoryExporter()
+	shutdown := tracing.SetupWithExporter("test", keepSpansExporter{exporter}, 1)
+	return exporter, func() {
+		if err := shutdown(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// findSpan trả về span đầu tiên có tên name
+func findSpan(t *testing.T, spans tracetest.SpanStubs, name string) tracetest.SpanStub {
+	t.Helper()
+	for _, s := range spans {
+		if s.Name == name {
+			return s
+		}
+	}
+	t.Fatalf("span %q not found", name)
+	return tracetest.SpanStub{}
+}
+
+// toConsumerMessage chuyển headers của message đã gửi sang message nhận được
+func toConsumerMessage(msg *sarama.ProducerMessage, offset int64, value []byte) *sarama.ConsumerMessage {
+	headers := make([]*sarama.RecordHeader, len(msg.Headers))
+	for i := range msg.Headers {
+		headers[i] = &msg.Headers[i]
+	}
+	return &sarama.ConsumerMessage{Topic: msg.Topic, Offset: offset, Value: value, Headers: headers}
+}
+
+func TestTraceContextThroughHeaders(t *testing.T) {
+	exporter, flush := setupTestTracing(t)
+
+	ctx, run := tracing.Start(context.Background(), "crawler.run")
+	msg := &sarama.ProducerMessage{
+		Topic:   "raw_posts",
+		Headers: []sarama.RecordHeader{{Key: []byte(events.HeaderTraceID), Value: []byte("abc")}},
+	}
+	publish := startProducerSpan(ctx, msg, "abc")
+	publish.End()
+	run.End()
+
+	process := startConsumerSpan(toConsumerMessage(msg, 0, nil))
+	process.End()
+	flush()
+
+	spans := exporter.GetSpans()
+	runSpan := findSpan(t, spans, "crawler.run")
+	publishSpan := findSpan(t, spans, "raw_posts publish")
+	processSpan := findSpan(t, spans, "raw_posts process")
+
+	if publishSpan.Parent.SpanID() != runSpan.SpanContext.SpanID() {
+		t.Fatalf("publish span parent = %s, want crawler.run", publishSpan.Parent.SpanID())
+	}
+	if processSpan.Parent.SpanID() != publishSpan.SpanContext.SpanID() {
+		t.Fatalf("process span parent = %s, want publish span", processSpan.Parent.SpanID())
+	}
+	if processSpan.SpanContext.TraceID() != runSpan.SpanContext.TraceID() {
+		t.Fatal("process span is not in the crawl trace")
+	}
+
+	found := false
+	for _, attr := range processSpan.Attributes {
+		if string(attr.Key) == events.HeaderTraceID && attr.Value.AsString() == "abc" {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatal("process span missing trace_id attribute")
+	}
+}
+
+func TestBatchFlushSpanLinksMessages(t *testing.T) {
+	exporter, flush := setupTestTracing(t)
+
+	ctx, run := tracing.Start(context.Background(), "crawler.run")
+	messages := make([]*sarama.ConsumerMessage, 2)
+	for i := range messages {
+		msg := &sarama.ProducerMessage{Topic: "raw_posts"}
+		startProducerSpan(ctx, msg, "").End()
+		messages[i] = toConsumerMessage(msg, int64(i), postMessage(t, 0, int64(i)).Value)
+	}
+	run.End()
+
+	handler := &recordingHandler{}
+	p := newProcessor(handler, nil, 2)
+	session := &fakeSession{ctx: context.Background()}
+	claim := &fakeClaim{messages: make(chan *sarama.ConsumerMessage, len(messages))}
+	for _, m := range messages {
+		claim.messages <- m
+	}
+	close(claim.messages)
+	if err := p.consume(session, claim, &Consumer{metrics: newConsumerMetrics()}); err != nil {
+		t.Fatal(err)
+	}
+	flush()
+
+	spans := exporter.GetSpans()
+	flushSpan := findSpan(t, spans, "consumer.flush")
+	if len(flushSpan.Links) != 2 {
+		t.Fatalf("flush span links = %d, want 2", len(flushSpan.Links))
+	}
+	processed := 0
+	for _, s := range spans {
+		if s.Name == "raw_posts process" {
+			processed++
+			if s.EndTime.Before(flushSpan.StartTime) {
+				t.Fatal("message span ended before batch was written")
+			}
+		}
+	}
+	if processed != 2 {
+		t.Fatalf("process spans = %d, want 2", processed)
+	}
+}
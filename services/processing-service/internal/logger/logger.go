@@ -1,98 +1,131 @@
+// =====================================================
+// LOGGER - Structured logging trên log/slog
+// =====================================================
+// Mô tả: Cấu hình slog mặc định cho mọi binary:
+//   - LOG_FORMAT: text (dễ đọc khi dev) | json (máy parse được)
+//   - LOG_LEVEL: debug | info | warn | error
+//   - Tự gắn service, run_id, trace_id từ context vào mỗi dòng log
+//
+// Dùng:
+//   logger.Setup("consumer", cfg.LogLevel, cfg.LogFormat)
+//   slog.Info("batch saved", "posts", n)
+//   ctx = logger.WithTraceID(ctx, id); slog.InfoContext(ctx, "post sent")
+// =====================================================
+
 package logger
 
 import (
-	"fmt"
-	"time"
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
 )
 
-// Level định nghĩa mức độ logging
-type Level int
-
+// Tên các attribute dùng chung, giữ thống nhất giữa các binaries
 const (
-	LevelDebug Level = iota
-	LevelInfo
-	LevelWarn
-	LevelError
+	KeyService = "service"
+	KeyRunID   = "run_id"
+	KeyTraceID = "trace_id"
+	KeyError   = "error"
 )
 
-var levelNames = []string{"DEBUG", "INFO", "WARN", "ERROR"}
+// contextKey là kiểu khóa riêng để không trùng với package khác
+type contextKey int
+
+const (
+	runIDKey contextKey = iota
+	traceIDKey
+)
 
-// Logger là wrapper cho structured logging
-type Logger struct {
-	name     string
-	minLevel Level
+// Setup tạo logger theo level/format và đặt làm slog default
+// Log của package "log" chuẩn cũng đi qua handler này
+func Setup(service, level, format string) *slog.Logger {
+	return SetupWriter(os.Stdout, service, level, format)
 }
 
-// New tạo logger mới
-func New(name string) *Logger {
-	return &Logger{
-		name:     name,
-		minLevel: LevelDebug,
+// SetupWriter giống Setup nhưng ghi ra w thay vì stdout
+func SetupWriter(w io.Writer, service, level, format string) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: ParseLevel(level)}
+
+	var handler slog.Handler
+	if strings.EqualFold(format, "json") {
+		handler = slog.NewJSONHandler(w, opts)
+	} else {
+		handler = slog.NewTextHandler(w, opts)
 	}
-}
 
-// SetLevel đặt mức độ minimum logging
-func (l *Logger) SetLevel(level Level) {
-	l.minLevel = level
+	l := slog.New(&contextHandler{Handler: handler}).With(KeyService, service)
+	slog.SetDefault(l)
+	return l
 }
 
-// log ghi log với context
-func (l *Logger) log(level Level, msg string, fields map[string]interface{}) {
-	if level < l.minLevel {
-		return
+// ParseLevel chuyển chuỗi level thành slog.Level (mặc định info)
+func ParseLevel(level string) slog.Level {
+	switch strings.ToLower(strings.TrimSpace(level)) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn", "warning":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
 	}
+}
 
-	timestamp := time.Now().Format("15:04:05.000")
-	fmt.Printf("[%s] %s [%s] %s", timestamp, levelNames[level], l.name, msg)
-
-	if len(fields) > 0 {
-		fmt.Print(" {")
-		first := true
-		for k, v := range fields {
-			if !first {
-				fmt.Print(", ")
-			}
-			fmt.Printf("%s=%v", k, v)
-			first = false
-		}
-		fmt.Print("}")
+// Err là attribute chuẩn cho lỗi
+func Err(err error) slog.Attr {
+	if err == nil {
+		return slog.String(KeyError, "")
 	}
-	fmt.Println()
+	return slog.String(KeyError, err.Error())
 }
 
-// Debug logs at DEBUG level
-func (l *Logger) Debug(msg string, fields map[string]interface{}) {
-	l.log(LevelDebug, msg, fields)
+// WithRunID gắn id của một lần crawl vào context
+func WithRunID(ctx context.Context, runID string) context.Context {
+	return context.WithValue(ctx, runIDKey, runID)
 }
 
-// Info logs at INFO level
-func (l *Logger) Info(msg string, fields map[string]interface{}) {
-	l.log(LevelInfo, msg, fields)
+// RunID lấy run id từ context ("" nếu không có)
+func RunID(ctx context.Context) string {
+	id, _ := ctx.Value(runIDKey).(string)
+	return id
 }
 
-// Warn logs at WARN level
-func (l *Logger) Warn(msg string, fields map[string]interface{}) {
-	l.log(LevelWarn, msg, fields)
+// WithTraceID gắn trace id của một post/event vào context
+func WithTraceID(ctx context.Context, traceID string) context.Context {
+	return context.WithValue(ctx, traceIDKey, traceID)
 }
 
-// Error logs at ERROR level
-func (l *Logger) Error(msg string, fields map[string]interface{}) {
-	l.log(LevelError, msg, fields)
+// TraceID lấy trace id từ context ("" nếu không có)
+func TraceID(ctx context.Context) string {
+	id, _ := ctx.Value(traceIDKey).(string)
+	return id
 }
 
-// Shorthand methods
-func (l *Logger) Debugf(msg string) {
-	l.log(LevelDebug, msg, nil)
+// contextHandler thêm run_id/trace_id từ context vào record
+type contextHandler struct {
+	slog.Handler
 }
 
-func (l *Logger) Infof(msg string) {
-	l.log(LevelInfo, msg, nil)
+// Handle implement slog.Handler
+func (h *contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := RunID(ctx); id != "" {
+		r.AddAttrs(slog.String(KeyRunID, id))
+	}
+	if id := TraceID(ctx); id != "" {
+		r.AddAttrs(slog.String(KeyTraceID, id))
+	}
+	return h.Handler.Handle(ctx, r)
 }
 
-func (l *Logger) Warnf(msg string) {
-	l.log(LevelWarn, msg, nil)
+// WithAttrs implement slog.Handler (giữ wrapper)
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs)}
 }
 
-func (l *Logger) Errorf(msg string) {
-	l.log(LevelError, msg, nil)
+// WithGroup implement slog.Handler (giữ wrapper)
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name)}
 }
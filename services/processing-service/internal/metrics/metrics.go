@@ -0,0 +1,150 @@
+// =====================================================
+// PROMETHEUS METRICS - Metrics dùng chung cho crawlers và consumer
+// =====================================================
+// Mô tả: Khai báo tất cả collectors ở một chỗ để tên và labels
+// thống nhất giữa các binaries. Quy ước labels:
+//   source    - hn | devto | medium
+//   status    - HTTP status code ("200", "429", ...) hoặc "error"
+//   outcome   - kết quả dedup của một post
+//   result    - ok | error (Kafka: sent | acked | error)
+//   operation - tên thao tác DB/Redis
+//   topic, partition - Kafka
+// Expose qua Handler() tại /metrics
+// =====================================================
+
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "social_insight"
+
+// Các giá trị label outcome của dedup
+const (
+	OutcomeNew           = "new"            // Post mới, đã gửi Kafka
+	OutcomeInvalid       = "invalid"        // Không qua validation
+	OutcomeSeen          = "seen"           // Đã gửi trước đó (source ID)
+	OutcomeContentHash   = "content_hash"   // Trùng content hash
+	OutcomeNearDuplicate = "near_duplicate" // Gửi kèm link tới post gốc
+	OutcomeSendFailed    = "send_failed"    // Gửi Kafka lỗi, claim được trả lại
+	OutcomeError         = "error"          // Lỗi Redis khi kiểm tra
+)
+
+var (
+	// HTTPFetches đếm HTTP requests của crawlers theo source và status
+	HTTPFetches = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_fetches_total",
+		Help:      "HTTP requests sent by crawlers, by source and response status.",
+	}, []string{"source", "status"})
+
+	// HTTPFetchDuration đo thời gian một HTTP request của crawler
+	HTTPFetchDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_fetch_duration_seconds",
+		Help:      "Duration of crawler HTTP requests.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"source"})
+
+	// DedupOutcomes đếm kết quả dedup của từng post
+	DedupOutcomes = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "dedup_outcomes_total",
+		Help:      "Crawled posts by dedup outcome.",
+	}, []string{"source", "outcome"})
+
+	// CrawlRuns đếm số lần crawl theo kết quả
+	CrawlRuns = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "crawl_runs_total",
+		Help:      "Crawl runs by source and result.",
+	}, []string{"source", "result"})
+
+	// KafkaMessages đếm messages gửi Kafka: sent (enqueue), acked, error
+	KafkaMessages = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "kafka_messages_total",
+		Help:      "Kafka messages produced, by topic and result (sent, acked, error).",
+	}, []string{"topic", "result"})
+
+	// DBBatchDuration đo thời gian các thao tác ghi batch vào PostgreSQL
+	DBBatchDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "db_batch_duration_seconds",
+		Help:      "Duration of PostgreSQL batch writes, by operation and result.",
+		Buckets:   []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+	}, []string{"operation", "result"})
+
+	// RedisErrors đếm lỗi Redis theo command (không tính key không tồn tại)
+	RedisErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "redis_errors_total",
+		Help:      "Redis command errors, by operation.",
+	}, []string{"operation"})
+
+	// ConsumerBatchSize là số messages mỗi lần consumer flush
+	ConsumerBatchSize = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "consumer_batch_size",
+		Help:      "Messages per consumer flush.",
+		Buckets:   prometheus.ExponentialBuckets(1, 2, 11),
+	})
+
+	// ConsumerFlushDuration đo thời gian consumer ghi một batch
+	ConsumerFlushDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "consumer_flush_duration_seconds",
+		Help:      "Time spent writing one consumer batch, by result.",
+		Buckets:   []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+	}, []string{"result"})
+
+	// ConsumerLatency đo thời gian từ lúc produce đến lúc ghi PostgreSQL
+	ConsumerLatency = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "consumer_latency_seconds",
+		Help:      "Time from Kafka produce timestamp to PostgreSQL write.",
+		Buckets:   []float64{.05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60, 300},
+	})
+
+	// ConsumerLag là lag của từng partition consumer đang giữ
+	ConsumerLag = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "consumer_lag",
+		Help:      "High-water mark minus committed offset, by topic and partition.",
+	}, []string{"topic", "partition"})
+)
+
+// Handler trả về HTTP handler cho /metrics
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// ObserveDB ghi thời gian một thao tác DB bắt đầu từ start
+// Dùng: defer metrics.ObserveDB("insert_posts", time.Now(), &err)
+func ObserveDB(operation string, start time.Time, err *error) {
+	result := "ok"
+	if err != nil && *err != nil {
+		result = "error"
+	}
+	DBBatchDuration.WithLabelValues(operation, result).Observe(time.Since(start).Seconds())
+}
+
+// StatusLabel chuyển HTTP status code thành giá trị label status
+func StatusLabel(code int) string {
+	if code <= 0 {
+		return "error"
+	}
+	return strconv.Itoa(code)
+}
+
+// PartitionLabel chuyển partition thành giá trị label
+func PartitionLabel(partition int32) string {
+	return strconv.FormatInt(int64(partition), 10)
+}
@@ -0,0 +1,56 @@
+// =====================================================
+// DEAD LETTER MODEL - Message xử lý thất bại
+// =====================================================
+// Mô tả: Lưu message gốc cùng vị trí Kafka, lỗi và stage
+// để điều tra và replay lại vào raw_posts sau khi sửa lỗi
+// =====================================================
+
+package models
+
+import (
+	"time"
+)
+
+// Các stage có thể sinh dead letter
+const (
+	StageValidation = "validation" // Crawler: post không qua validator
+	StageUnmarshal  = "unmarshal"  // Consumer: message không parse được
+	StageDispatch   = "dispatch"   // Consumer: event type lạ hoặc schema version mới hơn
+	StageHandle     = "handle"     // Consumer: lưu DB/cache thất bại
+)
+
+// DeadLetter là một message không xử lý được
+type DeadLetter struct {
+	// ID do PostgreSQL sinh (0 khi chưa lưu)
+	ID int64 `json:"id,omitempty"`
+
+	// Payload là nội dung message gốc (bytes, base64 khi encode JSON)
+	Payload []byte `json:"payload"`
+
+	// Key là message key gốc (post ID)
+	Key string `json:"key,omitempty"`
+
+	// SourceTopic/Partition/Offset là vị trí message gốc trên Kafka
+	// Partition và Offset = -1 nếu message chưa từng lên Kafka (ví dụ lỗi validation ở crawler)
+	SourceTopic string `json:"source_topic"`
+	Partition   int32  `json:"partition"`
+	Offset      int64  `json:"offset"`
+
+	// Stage là bước xử lý bị lỗi (validation, unmarshal, handle)
+	Stage string `json:"stage"`
+
+	// Error là thông báo lỗi
+	Error string `json:"error"`
+
+	// Producer là thành phần sinh dead letter ("consumer", "crawler:hn", ...)
+	Producer string `json:"producer"`
+
+	// FailedAt là thời điểm lỗi
+	FailedAt time.Time `json:"failed_at"`
+
+	// ReplayedAt là lần replay gần nhất (nil nếu chưa replay)
+	ReplayedAt *time.Time `json:"replayed_at,omitempty"`
+
+	// ReplayCount là số lần đã replay
+	ReplayCount int `json:"replay_count,omitempty"`
+}
@@ -8,6 +8,7 @@
 package models
 
 import (
+	"strings"
 	"time"
 )
 
@@ -21,18 +22,38 @@ type Post struct {
 	// Author là tên người đăng bài
 	Author string `json:"author"`
 
+	// AuthorHandle là username ổn định của tác giả trên nền tảng
+	// (HN: by, Dev.to: username, Medium: @username trong link).
+	// Rỗng khi crawler không biết; consumer điền bằng Handle()
+	AuthorHandle string `json:"author_handle,omitempty"`
+
+	// Title là tiêu đề gốc của bài viết (dùng cho near-duplicate detection)
+	Title string `json:"title,omitempty"`
+
 	// Content là nội dung bài viết
 	// Chủ đề xoay quanh công nghệ và AI
 	Content string `json:"content"`
 
-	// Topic là chủ đề của bài viết
-	// Giá trị: "ai", "cloud", "devops", "programming", "startup"
+	// Topic là chủ đề chính của bài viết (topic cấp 1 điểm cao nhất trong
+	// Topics); các giá trị có thể có nằm trong file taxonomy
 	Topic string `json:"topic"`
 
+	// Topics là mọi topic/subtopic được gán kèm điểm (bảng post_topics)
+	// Được consumer tính khi enrich, crawler không gửi
+	Topics []TopicScore `json:"topics,omitempty"`
+
+	// Tags là tag của bài trên nền tảng (Dev.to tag_list, tag/feed Medium
+	// đã crawl), dùng làm tín hiệu phân loại topic
+	Tags []string `json:"tags,omitempty"`
+
 	// Sentiment là cảm xúc của bài viết
 	// Giá trị: "positive", "negative", "neutral"
 	Sentiment string `json:"sentiment"`
 
+	// Keywords là các từ khóa của tiêu đề (đồ thị tác giả – keyword)
+	// Được consumer tính khi enrich, crawler không gửi
+	Keywords []string `json:"keywords,omitempty"`
+
 	// Likes là số lượt thích (0-10000)
 	Likes int `json:"likes"`
 
@@ -46,17 +67,46 @@ type Post struct {
 	// Giá trị: "twitter", "linkedin", "reddit", "hackernews"
 	Platform string `json:"platform"`
 
+	// URL là link bài viết (HN: link bài được share, Dev.to/Medium: link bài gốc)
+	URL string `json:"url,omitempty"`
+
+	// CanonicalURL là URL đã chuẩn hóa, dùng để gom các bài cùng link thành story
+	// Được consumer tính khi lưu, rỗng nếu post không có URL hợp lệ
+	CanonicalURL string `json:"canonical_url,omitempty"`
+
 	// CreatedAt là thời điểm tạo bài viết
 	CreatedAt time.Time `json:"created_at"`
+
+	// CanonicalPostID trỏ tới post gốc nếu bài này là near-duplicate
+	// (cùng story được cross-post sang nền tảng khác). Rỗng nếu là bài gốc.
+	CanonicalPostID string `json:"canonical_post_id,omitempty"`
+
+	// TraceID nối log của post từ crawl run → Kafka → consumer → PostgreSQL
+	// Đi trong event envelope (không nằm trong payload)
+	TraceID string `json:"-"`
+}
+
+// Handle trả về handle đã chuẩn hóa của tác giả (chữ thường, bỏ @),
+// lấy từ Author khi không có AuthorHandle
+// (khớp backfill của migration 008_create_authors.sql)
+func (p Post) Handle() string {
+	handle := p.AuthorHandle
+	if handle == "" {
+		handle = p.Author
+	}
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(handle), "@"))
 }
 
-// Topics là danh sách các chủ đề công nghệ
-var Topics = []string{
-	"ai",          // Trí tuệ nhân tạo, Machine Learning
-	"cloud",       // Cloud Computing (AWS, GCP, Azure)
-	"devops",      // DevOps, Kubernetes, Docker
-	"programming", // Ngôn ngữ lập trình, frameworks
-	"startup",     // Startup, tech news, funding
+// TopicScore là một topic (hoặc subtopic) được gán cho post
+type TopicScore struct {
+	Topic string `json:"topic"`
+
+	// Parent là topic cấp 1 chứa subtopic (rỗng nếu Topic là topic cấp 1)
+	Parent string `json:"parent,omitempty"`
+
+	// Score là tổng trọng số các luật/tag khớp (topic cấp 1 gồm cả điểm
+	// của subtopics)
+	Score float64 `json:"score"`
 }
 
 // Platforms là danh sách các nền tảng mạng xã hội
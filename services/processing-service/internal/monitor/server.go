@@ -0,0 +1,69 @@
+// =====================================================
+// MONITOR SERVER - HTTP endpoint cho health/metrics
+// =====================================================
+// Mô tả: HTTP server nhỏ chạy kèm consumer/crawler để
+// kiểm tra trạng thái từ bên ngoài (docker, load balancer, curl)
+// =====================================================
+
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"social-insight/internal/logger"
+)
+
+// StatusFunc trả về body JSON và HTTP status code
+type StatusFunc func() (interface{}, int)
+
+// Server là HTTP server monitor
+type Server struct {
+	mux    *http.ServeMux
+	server *http.Server
+}
+
+// NewServer tạo monitor server lắng nghe tại addr (ví dụ ":8081")
+func NewServer(addr string) *Server {
+	mux := http.NewServeMux()
+	return &Server{
+		mux: mux,
+		server: &http.Server{
+			Addr:              addr,
+			Handler:           mux,
+			ReadHeaderTimeout: 5 * time.Second,
+		},
+	}
+}
+
+// Handle đăng ký handler tùy ý
+func (s *Server) Handle(path string, handler http.Handler) {
+	s.mux.Handle(path, handler)
+}
+
+// HandleJSON đăng ký endpoint trả về JSON từ fn
+func (s *Server) HandleJSON(path string, fn StatusFunc) {
+	s.mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+		body, code := fn()
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(code)
+		json.NewEncoder(w).Encode(body)
+	})
+}
+
+// Start chạy server trong goroutine riêng
+func (s *Server) Start() {
+	go func() {
+		if err := s.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			slog.Error("monitor server error", "addr", s.server.Addr, logger.Err(err))
+		}
+	}()
+}
+
+// Shutdown dừng server, chờ các request đang chạy
+func (s *Server) Shutdown(ctx context.Context) error {
+	return s.server.Shutdown(ctx)
+}
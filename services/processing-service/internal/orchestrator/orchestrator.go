@@ -1,11 +1,13 @@
 package orchestrator
 
 import (
-	"fmt"
+	"context"
+	"log/slog"
 	"sync"
 	"time"
 
 	"social-insight/internal/crawler"
+	"social-insight/internal/logger"
 )
 
 // CrawlerOrchestrator quản lý việc chạy đồng thời các crawlers
@@ -37,7 +39,7 @@ func (o *CrawlerOrchestrator) Register(name string, c crawler.Crawler) {
 
 // RunParallel chạy tất cả crawlers đồng thời
 // Trả về kết quả từ mỗi crawler
-func (o *CrawlerOrchestrator) RunParallel() map[string]CrawlResult {
+func (o *CrawlerOrchestrator) RunParallel(ctx context.Context) map[string]CrawlResult {
 	o.mu.RLock()
 	crawlers := o.crawlers
 	o.mu.RUnlock()
@@ -53,7 +55,7 @@ func (o *CrawlerOrchestrator) RunParallel() map[string]CrawlResult {
 			defer wg.Done()
 
 			startTime := time.Now()
-			posts, err := crawler.Fetch()
+			posts, err := crawler.Fetch(ctx)
 			duration := time.Since(startTime)
 
 			result := CrawlResult{
@@ -68,9 +70,9 @@ func (o *CrawlerOrchestrator) RunParallel() map[string]CrawlResult {
 				mu.Lock()
 				o.failures[crawlerName]++
 				mu.Unlock()
-				fmt.Printf("❌ [%s] Error: %v (took %v)\n", crawlerName, err, duration)
+				slog.ErrorContext(ctx, "crawler fetch failed", "crawler", crawlerName, "duration", duration, logger.Err(err))
 			} else {
-				fmt.Printf("✅ [%s] Fetched %d posts (took %v)\n", crawlerName, len(posts), duration)
+				slog.InfoContext(ctx, "crawler fetched", "crawler", crawlerName, "posts", len(posts), "duration", duration)
 			}
 
 			mu.Lock()
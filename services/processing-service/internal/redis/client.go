@@ -12,6 +12,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"social-insight/internal/models"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-redis/redis/v8"
@@ -37,6 +39,8 @@ func NewClient(addr string) (*Client, error) {
 		DB:       0,   // Database mặc định
 		PoolSize: 100, // Connection pool size
 	})
+	rdb.AddHook(metricsHook{})
+	rdb.AddHook(tracingHook{})
 
 	// Test connection
 	if err := rdb.Ping(ctx).Err(); err != nil {
@@ -49,6 +53,19 @@ func NewClient(addr string) (*Client, error) {
 	}, nil
 }
 
+// WithContext trả về bản sao Client dùng ctx cho mọi command
+// (dùng chung connection pool); command có span nếu ctx mang span
+func (c *Client) WithContext(ctx context.Context) *Client {
+	clone := *c
+	clone.ctx = ctx
+	return &clone
+}
+
+// Ping kiểm tra kết nối Redis (health check)
+func (c *Client) Ping(ctx context.Context) error {
+	return c.rdb.Ping(ctx).Err()
+}
+
 // CachePost lưu post vào cache với TTL
 // key format: post:{id}
 func (c *Client) CachePost(post models.Post, ttl time.Duration) error {
@@ -83,11 +100,54 @@ func (c *Client) GetPost(id string) (*models.Post, error) {
 }
 
 // IncrementCounter tăng counter (dùng cho thống kê realtime)
-// Ví dụ: posts:total, posts:ai, sentiment:positive
+// Ví dụ: posts:total, sentiment:positive
 func (c *Client) IncrementCounter(key string) error {
 	return c.rdb.Incr(c.ctx, key).Err()
 }
 
+// IncrementCounters tăng nhiều counters trong một round-trip (pipeline INCRBY)
+// Dùng cho cả batch để nhiều partitions không gửi hàng nghìn lệnh INCR riêng lẻ
+func (c *Client) IncrementCounters(counts map[string]int64) error {
+	if len(counts) == 0 {
+		return nil
+	}
+	pipe := c.rdb.Pipeline()
+	for key, n := range counts {
+		pipe.IncrBy(c.ctx, key, n)
+	}
+	_, err := pipe.Exec(c.ctx)
+	return err
+}
+
+// TopicCountersKey là hash số posts theo topic cấp 1 (field = id topic
+// trong taxonomy); post nhiều topic được đếm ở mỗi topic
+// (cùng tên với api-service/internal/redis)
+const TopicCountersKey = "stats:topics"
+
+// IncrementTopicCounters tăng các field của hash TopicCountersKey trong
+// một round-trip (pipeline HINCRBY)
+func (c *Client) IncrementTopicCounters(counts map[string]int64) error {
+	if len(counts) == 0 {
+		return nil
+	}
+	pipe := c.rdb.Pipeline()
+	for topic, n := range counts {
+		pipe.HIncrBy(c.ctx, TopicCountersKey, topic, n)
+	}
+	_, err := pipe.Exec(c.ctx)
+	return err
+}
+
+// CacheGenerationKey là generation của response cache trong API
+// (cùng tên với api-service/internal/redis)
+const CacheGenerationKey = "api:cache:generation"
+
+// InvalidateAPICache tăng generation: mọi response API đã cache trước đó
+// hết hiệu lực (gọi sau khi ghi dữ liệu posts mới xuống PostgreSQL)
+func (c *Client) InvalidateAPICache() error {
+	return c.rdb.Incr(c.ctx, CacheGenerationKey).Err()
+}
+
 // GetCounter lấy giá trị counter
 func (c *Client) GetCounter(key string) (int64, error) {
 	return c.rdb.Get(c.ctx, key).Int64()
@@ -135,13 +195,13 @@ func (c *Client) GetRecentPosts(count int64) ([]models.Post, error) {
 }
 
 // GetStats lấy thống kê từ cache
+// Số posts theo topic (hash TopicCountersKey) trả về dạng posts:{topic}
 func (c *Client) GetStats() (map[string]int64, error) {
 	stats := make(map[string]int64)
 
 	// Danh sách các keys cần lấy
 	keys := []string{
 		"posts:total",
-		"posts:ai", "posts:cloud", "posts:devops", "posts:programming", "posts:startup",
 		"sentiment:positive", "sentiment:negative", "sentiment:neutral",
 	}
 
@@ -153,6 +213,16 @@ func (c *Client) GetStats() (map[string]int64, error) {
 		stats[key] = val
 	}
 
+	topics, err := c.rdb.HGetAll(c.ctx, TopicCountersKey).Result()
+	if err != nil {
+		return stats, err
+	}
+	for topic, val := range topics {
+		if n, err := strconv.ParseInt(val, 10, 64); err == nil {
+			stats["posts:"+topic] = n
+		}
+	}
+
 	return stats, nil
 }
 
@@ -203,6 +273,170 @@ func (c *Client) MarkAsSeen(source, postID string, ttl time.Duration) error {
 	return c.rdb.SetEX(c.ctx, key, "1", ttl).Err()
 }
 
+// CheckAndMark kiểm tra và đánh dấu post trong một round-trip (SET NX)
+// Trả về true nếu post chưa từng thấy và vừa được đánh dấu bởi lời gọi này
+// An toàn khi nhiều crawler replicas chạy song song
+func (c *Client) CheckAndMark(source, postID string, ttl time.Duration) (bool, error) {
+	if ttl == 0 {
+		ttl = 7 * 24 * time.Hour // Default 7 days
+	}
+
+	key := fmt.Sprintf("seen_posts:%s:%s", source, postID)
+	return c.rdb.SetNX(c.ctx, key, "1", ttl).Result()
+}
+
+// UnmarkSeen xóa đánh dấu (dùng khi gửi Kafka thất bại sau CheckAndMark)
+func (c *Client) UnmarkSeen(source, postID string) error {
+	key := fmt.Sprintf("seen_posts:%s:%s", source, postID)
+	return c.rdb.Del(c.ctx, key).Err()
+}
+
+// bloomClaimScript bật k bit của item trong bitmap KEYS[1] trong một bước atomic
+// Trả về 1 nếu có bit nào trước đó là 0 (item mới) hoặc item vừa được Release (KEYS[2])
+var bloomClaimScript = redis.NewScript(`
+if redis.call('DEL', KEYS[2]) == 1 then
+	return 1
+end
+local added = 0
+for i = 1, #ARGV do
+	if redis.call('SETBIT', KEYS[1], ARGV[i], 1) == 0 then
+		added = 1
+	end
+end
+return added
+`)
+
+// BloomClaim thêm item (các bit offsets) vào bitmap key, trả về true nếu item chưa có
+// Dùng cho Bloom filter tự quản lý (không cần module RedisBloom)
+// releasedKey là marker do MarkReleased đặt khi claim trước đó bị trả lại
+func (c *Client) BloomClaim(key, releasedKey string, offsets []uint64) (bool, error) {
+	args := make([]interface{}, len(offsets))
+	for i, off := range offsets {
+		args[i] = off
+	}
+	n, err := bloomClaimScript.Run(c.ctx, c.rdb, []string{key, releasedKey}, args...).Int64()
+	return n == 1, err
+}
+
+// MarkReleased đặt marker cho phép claim lại một item đã nằm trong Bloom filter
+// Bloom filter không xóa được phần tử nên Release dùng marker thay vì xóa bit
+func (c *Client) MarkReleased(releasedKey string, ttl time.Duration) error {
+	return c.rdb.Set(c.ctx, releasedKey, "1", ttl).Err()
+}
+
+// BFReserve tạo RedisBloom filter (bỏ qua lỗi nếu filter đã tồn tại)
+// Yêu cầu Redis có module RedisBloom (redis-stack)
+func (c *Client) BFReserve(key string, errorRate float64, capacity int64) error {
+	err := c.rdb.Do(c.ctx, "BF.RESERVE", key, errorRate, capacity).Err()
+	if err != nil && !strings.Contains(err.Error(), "exists") {
+		return err
+	}
+	return nil
+}
+
+// bfClaimScript giống bloomClaimScript nhưng dùng BF.ADD của RedisBloom
+var bfClaimScript = redis.NewScript(`
+if redis.call('DEL', KEYS[2]) == 1 then
+	return 1
+end
+return redis.call('BF.ADD', KEYS[1], ARGV[1])
+`)
+
+// BFClaim thêm item vào RedisBloom filter, trả về true nếu item chưa có
+// (BF.ADD trả về 1 khi item mới) hoặc item vừa được Release (releasedKey)
+func (c *Client) BFClaim(key, releasedKey, item string) (bool, error) {
+	n, err := bfClaimScript.Run(c.ctx, c.rdb, []string{key, releasedKey}, item).Int64()
+	return n == 1, err
+}
+
+// simHashBandKey tạo key cho một band bucket
+// Số lượng bands nằm trong key để index cũ không lẫn khi đổi threshold
+// key format: simhash:b{n}:{i}:{value}
+func simHashBandKey(n, i int, band uint64) string {
+	return fmt.Sprintf("simhash:b%d:%d:%x", n, i, band)
+}
+
+// IndexSimHash lưu fingerprint của post và thêm post vào các band buckets
+// key format: simhash:fp:{postID} => fingerprint (hex)
+func (c *Client) IndexSimHash(postID string, fingerprint uint64, bands []uint64, ttl time.Duration) error {
+	pipe := c.rdb.TxPipeline()
+	pipe.Set(c.ctx, fmt.Sprintf("simhash:fp:%s", postID), strconv.FormatUint(fingerprint, 16), ttl)
+	for i, band := range bands {
+		key := simHashBandKey(len(bands), i, band)
+		pipe.SAdd(c.ctx, key, postID)
+		pipe.Expire(c.ctx, key, ttl)
+	}
+	_, err := pipe.Exec(c.ctx)
+	return err
+}
+
+// GetSimHashCandidates lấy các post nằm chung ít nhất một band bucket
+// Trả về map postID → fingerprint, bỏ qua post có fingerprint đã hết hạn
+func (c *Client) GetSimHashCandidates(bands []uint64) (map[string]uint64, error) {
+	keys := make([]string, 0, len(bands))
+	for i, band := range bands {
+		keys = append(keys, simHashBandKey(len(bands), i, band))
+	}
+
+	ids, err := c.rdb.SUnion(c.ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+	candidates := make(map[string]uint64, len(ids))
+	if len(ids) == 0 {
+		return candidates, nil
+	}
+
+	fpKeys := make([]string, len(ids))
+	for i, id := range ids {
+		fpKeys[i] = fmt.Sprintf("simhash:fp:%s", id)
+	}
+	values, err := c.rdb.MGet(c.ctx, fpKeys...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	for i, v := range values {
+		s, ok := v.(string)
+		if !ok {
+			continue // Fingerprint đã hết hạn
+		}
+		fp, err := strconv.ParseUint(s, 16, 64)
+		if err != nil {
+			continue
+		}
+		candidates[ids[i]] = fp
+	}
+	return candidates, nil
+}
+
+// SetConsumerLag ghi lag của một partition vào hash consumer:lag:{group}
+// Field là partition, value là JSON; key hết hạn nếu consumer ngừng báo
+func (c *Client) SetConsumerLag(group string, partition int32, data []byte, ttl time.Duration) error {
+	key := fmt.Sprintf("consumer:lag:%s", group)
+	pipe := c.rdb.TxPipeline()
+	pipe.HSet(c.ctx, key, fmt.Sprintf("%d", partition), data)
+	pipe.Expire(c.ctx, key, ttl)
+	_, err := pipe.Exec(c.ctx)
+	return err
+}
+
+// GetConsumerLag đọc lag các partitions của group (partition → JSON)
+func (c *Client) GetConsumerLag(group string) (map[string]string, error) {
+	return c.rdb.HGetAll(c.ctx, fmt.Sprintf("consumer:lag:%s", group)).Result()
+}
+
+// SetConsumerMetrics ghi metrics snapshot của một replica vào hash consumer:metrics:{group}
+// Field là hostname của replica, value là JSON
+func (c *Client) SetConsumerMetrics(group, host string, data []byte, ttl time.Duration) error {
+	key := fmt.Sprintf("consumer:metrics:%s", group)
+	pipe := c.rdb.TxPipeline()
+	pipe.HSet(c.ctx, key, host, data)
+	pipe.Expire(c.ctx, key, ttl)
+	_, err := pipe.Exec(c.ctx)
+	return err
+}
+
 // Close đóng Redis connection
 func (c *Client) Close() error {
 	return c.rdb.Close()
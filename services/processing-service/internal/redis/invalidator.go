@@ -0,0 +1,54 @@
+// =====================================================
+// API CACHE INVALIDATOR - Gộp các lần tăng generation
+// =====================================================
+// Mô tả: Consumer ghi batch vài giây một lần; nếu tăng generation sau
+// mỗi batch thì response cache của API (TTL 30-60s) luôn bị bỏ trước
+// khi hết hạn. Invalidator chỉ tăng generation tối đa một lần mỗi
+// interval cho mỗi consumer: batch đầu tiên sau interval tăng ngay,
+// các batch khác trong cửa sổ được bỏ qua. Dữ liệu ghi trong cửa sổ hiện
+// ra ở lần tăng kế tiếp (batch sau interval) hoặc khi entry hết TTL
+// =====================================================
+
+package redis
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// Invalidator gộp các lần InvalidateAPICache, an toàn khi nhiều
+// partition gọi đồng thời
+type Invalidator struct {
+	bump     func(ctx context.Context) error
+	interval time.Duration
+	now      func() time.Time
+
+	// last là thời điểm (UnixNano) lần tăng generation gần nhất
+	last atomic.Int64
+}
+
+// NewInvalidator tạo Invalidator gọi bump tối đa một lần mỗi interval
+// (interval <= 0: gọi mỗi lần)
+func NewInvalidator(bump func(ctx context.Context) error, interval time.Duration) *Invalidator {
+	return &Invalidator{bump: bump, interval: interval, now: time.Now}
+}
+
+// Invalidate tăng generation nếu lần tăng trước đã cách ít nhất interval;
+// trả về true khi đã tăng. Bump lỗi thì lần gọi sau thử lại ngay
+func (i *Invalidator) Invalidate(ctx context.Context) (bool, error) {
+	now := i.now().UnixNano()
+	last := i.last.Load()
+	if last != 0 && now-last < int64(i.interval) {
+		return false, nil
+	}
+	// Chỉ một goroutine giành được lượt tăng trong cửa sổ
+	if !i.last.CompareAndSwap(last, now) {
+		return false, nil
+	}
+	if err := i.bump(ctx); err != nil {
+		i.last.CompareAndSwap(now, last)
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,89 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// fakeClock là đồng hồ chỉnh tay cho Invalidator
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) now() time.Time { return c.t }
+
+func newTestInvalidator(interval time.Duration, bump func(context.Context) error) (*Invalidator, *fakeClock) {
+	clock := &fakeClock{t: time.Date(2026, 1, 31, 10, 0, 0, 0, time.UTC)}
+	inv := NewInvalidator(bump, interval)
+	inv.now = clock.now
+	return inv, clock
+}
+
+func TestCachedEntrySurvivesBurst(t *testing.T) {
+	// generation giống api:cache:generation; entry hợp lệ khi cùng generation
+	var generation int64
+	inv, clock := newTestInvalidator(10*time.Second, func(context.Context) error {
+		generation++
+		return nil
+	})
+	ctx := context.Background()
+
+	// Batch đầu tiên tăng generation ngay
+	if bumped, _ := inv.Invalidate(ctx); !bumped || generation != 1 {
+		t.Fatalf("first batch: bumped=%v generation=%d", bumped, generation)
+	}
+	cached := generation // API cache một response sau batch đầu
+
+	// Consumer ghi batch liên tục (9 batch trong 9.9s): entry vẫn hợp lệ
+	for i := 0; i < 9; i++ {
+		clock.t = clock.t.Add(1100 * time.Millisecond)
+		if bumped, _ := inv.Invalidate(ctx); bumped {
+			t.Fatalf("batch %d inside the window bumped the generation", i+2)
+		}
+	}
+	if generation != cached {
+		t.Fatalf("cached entry invalidated during burst: generation %d, entry %d", generation, cached)
+	}
+
+	// Hết cửa sổ: batch kế tiếp làm entry hết hiệu lực
+	clock.t = clock.t.Add(time.Second)
+	if bumped, _ := inv.Invalidate(ctx); !bumped || generation != cached+1 {
+		t.Errorf("batch after the window: bumped=%v generation=%d", bumped, generation)
+	}
+}
+
+func TestInvalidateRetriesAfterError(t *testing.T) {
+	calls := 0
+	fail := true
+	inv, _ := newTestInvalidator(time.Minute, func(context.Context) error {
+		calls++
+		if fail {
+			return errors.New("redis down")
+		}
+		return nil
+	})
+	ctx := context.Background()
+
+	if _, err := inv.Invalidate(ctx); err == nil {
+		t.Fatal("expected error")
+	}
+	// Lần lỗi không tính là đã tăng: lần sau thử lại dù còn trong cửa sổ
+	fail = false
+	if bumped, err := inv.Invalidate(ctx); !bumped || err != nil || calls != 2 {
+		t.Errorf("retry: bumped=%v err=%v calls=%d", bumped, err, calls)
+	}
+}
+
+func TestInvalidateWithoutInterval(t *testing.T) {
+	calls := 0
+	inv, _ := newTestInvalidator(0, func(context.Context) error {
+		calls++
+		return nil
+	})
+	for i := 0; i < 3; i++ {
+		inv.Invalidate(context.Background())
+	}
+	if calls != 3 {
+		t.Errorf("calls = %d, want 3", calls)
+	}
+}
@@ -0,0 +1,49 @@
+// =====================================================
+// REDIS METRICS HOOK - Đếm lỗi Redis theo command
+// =====================================================
+// Mô tả: go-redis hook tăng social_insight_redis_errors_total
+// cho mọi command lỗi, kể cả trong pipeline (redis.Nil không tính)
+// =====================================================
+
+package redis
+
+import (
+	"context"
+	"social-insight/internal/metrics"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// metricsHook implement redis.Hook
+type metricsHook struct{}
+
+// BeforeProcess không làm gì
+func (metricsHook) BeforeProcess(ctx context.Context, _ redis.Cmder) (context.Context, error) {
+	return ctx, nil
+}
+
+// AfterProcess đếm lỗi của command
+func (metricsHook) AfterProcess(_ context.Context, cmd redis.Cmder) error {
+	countError(cmd)
+	return nil
+}
+
+// BeforeProcessPipeline không làm gì
+func (metricsHook) BeforeProcessPipeline(ctx context.Context, _ []redis.Cmder) (context.Context, error) {
+	return ctx, nil
+}
+
+// AfterProcessPipeline đếm lỗi của từng command trong pipeline
+func (metricsHook) AfterProcessPipeline(_ context.Context, cmds []redis.Cmder) error {
+	for _, cmd := range cmds {
+		countError(cmd)
+	}
+	return nil
+}
+
+// countError tăng counter nếu command lỗi thật sự
+func countError(cmd redis.Cmder) {
+	if err := cmd.Err(); err != nil && err != redis.Nil {
+		metrics.RedisErrors.WithLabelValues(cmd.Name()).Inc()
+	}
+}
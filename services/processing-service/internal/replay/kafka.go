@@ -0,0 +1,361 @@
+// =====================================================
+// KAFKA REPLAY - Đọc lại raw_posts theo khoảng thời gian/offset
+// =====================================================
+// Mô tả: Đọc trực tiếp từng partition trong khoảng [start, end),
+// offset đã xử lý được commit vào consumer group riêng của replay
+// nên không ảnh hưởng consumer chính và có thể chạy tiếp khi bị dừng
+// =====================================================
+
+package replay
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"social-insight/internal/events"
+	"social-insight/internal/kafka"
+	"social-insight/internal/logger"
+	"social-insight/internal/models"
+	"social-insight/internal/tracing"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/IBM/sarama"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// KafkaRange là khoảng messages cần replay
+// From/FromOffset chọn điểm bắt đầu (ưu tiên offset), To/ToOffset chọn điểm dừng;
+// không đặt điểm dừng thì dừng ở high watermark lúc bắt đầu replay
+type KafkaRange struct {
+	From       time.Time
+	To         time.Time
+	FromOffset int64 // < 0 = không dùng
+	ToOffset   int64 // < 0 = không dùng
+	Partitions []int32
+}
+
+// KafkaReplayer replay messages của một topic vào handler
+type KafkaReplayer struct {
+	client   sarama.Client
+	consumer sarama.Consumer
+	offsets  sarama.OffsetManager
+	topic    string
+	codec    *events.Codec
+	handler  kafka.BatchHandler
+	poms     []sarama.PartitionOffsetManager
+
+	batchSize int
+	processed int64 // atomic
+	total     int64
+}
+
+// partitionRange là khoảng offset [start, end) của một partition
+type partitionRange struct {
+	partition int32
+	start     int64
+	end       int64
+	pom       sarama.PartitionOffsetManager
+}
+
+// NewKafkaReplayer tạo replayer; group là consumer group riêng để lưu tiến độ
+func NewKafkaReplayer(brokers []string, topic, group string, codec *events.Codec, handler kafka.BatchHandler, batchSize int) (*KafkaReplayer, error) {
+	config := sarama.NewConfig()
+	config.Version = sarama.V2_1_0_0 // Cần >= 0.10.1 để tìm offset theo timestamp
+	config.Consumer.Offsets.Initial = sarama.OffsetOldest
+	config.Consumer.Offsets.AutoCommit.Interval = time.Second
+
+	client, err := sarama.NewClient(brokers, config)
+	if err != nil {
+		return nil, fmt.Errorf("không thể kết nối Kafka: %w", err)
+	}
+	consumer, err := sarama.NewConsumerFromClient(client)
+	if err != nil {
+		client.Close()
+		return nil, err
+	}
+	offsets, err := sarama.NewOffsetManagerFromClient(group, client)
+	if err != nil {
+		consumer.Close()
+		client.Close()
+		return nil, err
+	}
+
+	if batchSize <= 0 {
+		batchSize = 500
+	}
+	return &KafkaReplayer{
+		client:    client,
+		consumer:  consumer,
+		offsets:   offsets,
+		topic:     topic,
+		codec:     codec,
+		handler:   handler,
+		batchSize: batchSize,
+	}, nil
+}
+
+// Run replay khoảng rng; resume = true thì bắt đầu từ offset đã commit của group
+// Trả về số messages đã xử lý
+func (r *KafkaReplayer) Run(ctx context.Context, rng KafkaRange, resume bool) (int64, error) {
+	ranges, err := r.plan(rng, resume)
+	if err != nil {
+		return 0, err
+	}
+	for _, pr := range ranges {
+		slog.InfoContext(ctx, "replay plan", "topic", r.topic, "partition", pr.partition,
+			"start_offset", pr.start, "end_offset", pr.end, "messages", pr.end-pr.start)
+		r.total += pr.end - pr.start
+	}
+	if r.total == 0 {
+		slog.InfoContext(ctx, "nothing to replay", "topic", r.topic)
+		return 0, nil
+	}
+
+	stopProgress := startProgress(fmt.Sprintf("kafka %s", r.topic), r.total, &r.processed)
+	defer stopProgress()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, len(ranges))
+	for _, pr := range ranges {
+		if pr.start >= pr.end {
+			continue
+		}
+		wg.Add(1)
+		go func(pr partitionRange) {
+			defer wg.Done()
+			if err := r.replayPartition(ctx, pr); err != nil {
+				errs <- fmt.Errorf("partition %d: %w", pr.partition, err)
+			}
+		}(pr)
+	}
+	wg.Wait()
+	close(errs)
+
+	if err, ok := <-errs; ok {
+		return atomic.LoadInt64(&r.processed), err
+	}
+	return atomic.LoadInt64(&r.processed), ctx.Err()
+}
+
+// plan tính khoảng offset của từng partition
+func (r *KafkaReplayer) plan(rng KafkaRange, resume bool) ([]partitionRange, error) {
+	partitions := rng.Partitions
+	if len(partitions) == 0 {
+		var err error
+		if partitions, err = r.client.Partitions(r.topic); err != nil {
+			return nil, err
+		}
+	}
+
+	ranges := make([]partitionRange, 0, len(partitions))
+	for _, p := range partitions {
+		oldest, err := r.client.GetOffset(r.topic, p, sarama.OffsetOldest)
+		if err != nil {
+			return nil, err
+		}
+		newest, err := r.client.GetOffset(r.topic, p, sarama.OffsetNewest)
+		if err != nil {
+			return nil, err
+		}
+
+		pom, err := r.offsets.ManagePartition(r.topic, p)
+		if err != nil {
+			return nil, err
+		}
+		r.poms = append(r.poms, pom)
+		committed := int64(-1)
+		if resume {
+			committed, _ = pom.NextOffset()
+		}
+
+		start, end, err := resolveRange(rng, oldest, newest, committed, func(t time.Time) (int64, error) {
+			return r.offsetForTime(p, t, newest)
+		})
+		if err != nil {
+			return nil, err
+		}
+		ranges = append(ranges, partitionRange{partition: p, start: start, end: end, pom: pom})
+	}
+	return ranges, nil
+}
+
+// resolveRange tính khoảng [start, end) của một partition có offset trong
+// [oldest, newest); committed là offset kế tiếp đã commit của group
+// (< 0 = không resume), at tìm offset đầu tiên có timestamp >= t
+func resolveRange(rng KafkaRange, oldest, newest, committed int64, at func(time.Time) (int64, error)) (start, end int64, err error) {
+	start = oldest
+	switch {
+	case rng.FromOffset >= 0:
+		start = rng.FromOffset
+	case !rng.From.IsZero():
+		if start, err = at(rng.From); err != nil {
+			return 0, 0, err
+		}
+	}
+
+	end = newest
+	switch {
+	case rng.ToOffset >= 0:
+		end = rng.ToOffset
+	case !rng.To.IsZero():
+		if end, err = at(rng.To); err != nil {
+			return 0, 0, err
+		}
+	}
+
+	if committed > start {
+		start = committed
+	}
+
+	// Offset cũ đã bị Kafka xóa theo retention
+	if start < oldest {
+		start = oldest
+	}
+	if end > newest {
+		end = newest
+	}
+	if start > end {
+		start = end
+	}
+	return start, end, nil
+}
+
+// ParseTime parse thời điểm RFC3339 hoặc khoảng lùi so với now (ví dụ 72h)
+// Chuỗi rỗng trả về zero time (không giới hạn)
+func ParseTime(s string, now time.Time) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		if d < 0 {
+			return time.Time{}, fmt.Errorf("negative duration %q", s)
+		}
+		return now.Add(-d), nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
+
+// ParsePartitions parse "0,1,2" thành []int32 (rỗng = tất cả partitions)
+func ParsePartitions(s string) ([]int32, error) {
+	var result []int32
+	for _, p := range strings.Split(s, ",") {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		n, err := strconv.ParseInt(p, 10, 32)
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 {
+			return nil, fmt.Errorf("invalid partition %d", n)
+		}
+		result = append(result, int32(n))
+	}
+	return result, nil
+}
+
+// offsetForTime trả về offset đầu tiên có timestamp >= t (newest nếu không có)
+func (r *KafkaReplayer) offsetForTime(partition int32, t time.Time, newest int64) (int64, error) {
+	offset, err := r.client.GetOffset(r.topic, partition, t.UnixMilli())
+	if err != nil {
+		return 0, err
+	}
+	if offset < 0 {
+		return newest, nil
+	}
+	return offset, nil
+}
+
+// replayPartition đọc [start, end) của một partition, xử lý theo batch
+// và commit offset vào group sau mỗi batch
+func (r *KafkaReplayer) replayPartition(ctx context.Context, pr partitionRange) error {
+	pc, err := r.consumer.ConsumePartition(r.topic, pr.partition, pr.start)
+	if err != nil {
+		return err
+	}
+	defer pc.Close()
+
+	posts := make([]models.Post, 0, r.batchSize)
+	var pending int64
+	var next int64
+
+	flush := func() (err error) {
+		if pending == 0 {
+			return nil
+		}
+		// flush cuối chạy sau khi ctx bị hủy nên không được kế thừa cancel
+		fctx, span := tracing.Start(context.WithoutCancel(ctx), "replay.flush", trace.WithAttributes(
+			attribute.Int("messaging.kafka.destination.partition", int(pr.partition)),
+			attribute.Int("batch.posts", len(posts)),
+		))
+		defer tracing.End(span, &err)
+
+		if len(posts) > 0 {
+			if err := r.handler.HandleBatch(fctx, posts); err != nil {
+				return err
+			}
+		}
+		pr.pom.MarkOffset(next, "")
+		atomic.AddInt64(&r.processed, pending)
+
+		posts = posts[:0]
+		pending = 0
+		return nil
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return flush()
+
+		case err := <-pc.Errors():
+			return err
+
+		case msg := <-pc.Messages():
+			if msg.Offset >= pr.end {
+				return flush()
+			}
+			next = msg.Offset + 1
+			pending++
+
+			env, err := r.codec.Decode(msg.Value)
+			if err == nil {
+				err = env.CheckVersion()
+			}
+			if err != nil {
+				slog.WarnContext(ctx, "skip message", "partition", msg.Partition, "offset", msg.Offset, logger.Err(err))
+			} else if env.EventType == events.EventPostCreated {
+				if post, err := env.Post(); err == nil {
+					posts = append(posts, post)
+				}
+			}
+
+			if pending >= int64(r.batchSize) {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+			if next >= pr.end {
+				return flush()
+			}
+		}
+	}
+}
+
+// Close commit offsets còn lại và đóng kết nối
+func (r *KafkaReplayer) Close() error {
+	for _, pom := range r.poms {
+		pom.Close()
+	}
+	if err := r.offsets.Close(); err != nil {
+		slog.Warn("offset manager close error", logger.Err(err))
+	}
+	r.consumer.Close()
+	return r.client.Close()
+}
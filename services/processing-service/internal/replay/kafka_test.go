@@ -0,0 +1,95 @@
+package replay
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestResolveRange(t *testing.T) {
+	t0 := time.Date(2026, 1, 31, 10, 0, 0, 0, time.UTC)
+	// Partition có offset [100, 200), message i có timestamp t0 + i phút
+	at := func(ts time.Time) (int64, error) {
+		offset := 100 + int64(ts.Sub(t0)/time.Minute)
+		if offset >= 200 {
+			return 200, nil
+		}
+		return offset, nil
+	}
+	tests := []struct {
+		name       string
+		rng        KafkaRange
+		committed  int64
+		start, end int64
+	}{
+		{"all", KafkaRange{FromOffset: -1, ToOffset: -1}, -1, 100, 200},
+		{"offsets", KafkaRange{FromOffset: 120, ToOffset: 150}, -1, 120, 150},
+		{"times", KafkaRange{From: t0.Add(10 * time.Minute), To: t0.Add(20 * time.Minute), FromOffset: -1, ToOffset: -1}, -1, 110, 120},
+		{"offset wins over time", KafkaRange{From: t0.Add(10 * time.Minute), FromOffset: 130, ToOffset: -1}, -1, 130, 200},
+		{"from after newest", KafkaRange{From: t0.Add(time.Hour * 24), FromOffset: -1, ToOffset: -1}, -1, 200, 200},
+		// Offset cũ đã bị xóa theo retention, điểm dừng vượt high watermark
+		{"clamped", KafkaRange{FromOffset: 10, ToOffset: 500}, -1, 100, 200},
+		{"end before start", KafkaRange{FromOffset: 150, ToOffset: 120}, -1, 120, 120},
+		// Resume: tiếp tục từ offset đã commit, không lùi về trước điểm bắt đầu
+		{"resume", KafkaRange{FromOffset: 120, ToOffset: 150}, 135, 135, 150},
+		{"resume before start", KafkaRange{FromOffset: 120, ToOffset: 150}, 110, 120, 150},
+		{"resume finished", KafkaRange{FromOffset: 120, ToOffset: 150}, 150, 150, 150},
+		{"resume past end", KafkaRange{FromOffset: 120, ToOffset: 150}, 180, 150, 150},
+	}
+	for _, tt := range tests {
+		start, end, err := resolveRange(tt.rng, 100, 200, tt.committed, at)
+		if err != nil || start != tt.start || end != tt.end {
+			t.Errorf("%s: [%d, %d) err %v, want [%d, %d)", tt.name, start, end, err, tt.start, tt.end)
+		}
+	}
+
+	failing := func(time.Time) (int64, error) { return 0, errors.New("broker down") }
+	if _, _, err := resolveRange(KafkaRange{To: t0, FromOffset: -1, ToOffset: -1}, 100, 200, -1, failing); err == nil {
+		t.Error("expected offset lookup error")
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	now := time.Date(2026, 1, 31, 10, 0, 0, 0, time.UTC)
+	tests := []struct {
+		in      string
+		want    time.Time
+		wantErr bool
+	}{
+		{"", time.Time{}, false},
+		{"72h", now.Add(-72 * time.Hour), false},
+		{"90m", now.Add(-90 * time.Minute), false},
+		{"2026-01-30T08:00:00Z", time.Date(2026, 1, 30, 8, 0, 0, 0, time.UTC), false},
+		{"-1h", time.Time{}, true},
+		{"yesterday", time.Time{}, true},
+		{"2026-01-30", time.Time{}, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseTime(tt.in, now)
+		if (err != nil) != tt.wantErr || !got.Equal(tt.want) {
+			t.Errorf("ParseTime(%q) = %v, %v; want %v, error %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestParsePartitions(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    []int32
+		wantErr bool
+	}{
+		{"", nil, false},
+		{"0,1,2", []int32{0, 1, 2}, false},
+		{" 3 , ,5 ", []int32{3, 5}, false},
+		{"1,x", nil, true},
+		{"-1", nil, true},
+		{"4294967296", nil, true},
+	}
+	for _, tt := range tests {
+		got, err := ParsePartitions(tt.in)
+		if (err != nil) != tt.wantErr || !slices.Equal(got, tt.want) {
+			t.Errorf("ParsePartitions(%q) = %v, %v; want %v, error %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
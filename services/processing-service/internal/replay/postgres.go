@@ -0,0 +1,133 @@
+// =====================================================
+// POSTGRES REPROCESS - Chạy lại enrichment trên posts đã lưu
+// =====================================================
+// Mô tả: Đọc posts theo thứ tự id (keyset pagination), chạy lại
+// topic detection/sentiment và cập nhật các rows thay đổi theo batch.
+// Checkpoint lưu cùng transaction với UPDATE nên job dừng giữa chừng
+// có thể chạy tiếp đúng vị trí
+// =====================================================
+
+package replay
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"social-insight/internal/database"
+	"social-insight/internal/enrichment"
+	"social-insight/internal/models"
+	"sync/atomic"
+	"time"
+)
+
+// Reprocessor chạy lại enrichment trên bảng posts
+type Reprocessor struct {
+	db        *database.DB
+	enricher  *enrichment.Enricher
+	job       string
+	batchSize int
+	dryRun    bool
+}
+
+// NewReprocessor tạo reprocessor cho job (tên job dùng làm khóa checkpoint)
+func NewReprocessor(db *database.DB, enricher *enrichment.Enricher, job string, batchSize int) *Reprocessor {
+	if batchSize <= 0 {
+		batchSize = 500
+	}
+	return &Reprocessor{db: db, enricher: enricher, job: job, batchSize: batchSize}
+}
+
+// SetDryRun chỉ tính số rows sẽ thay đổi, không UPDATE và không lưu checkpoint
+func (r *Reprocessor) SetDryRun(dryRun bool) {
+	r.dryRun = dryRun
+}
+
+// Run xử lý tất cả posts sau checkpoint; restart = true thì bỏ checkpoint cũ
+// Dừng sau batch hiện tại khi ctx bị cancel (checkpoint đã lưu)
+func (r *Reprocessor) Run(ctx context.Context, restart bool) (*database.Checkpoint, error) {
+	if restart && !r.dryRun {
+		if err := r.db.DeleteCheckpoint(r.job); err != nil {
+			return nil, err
+		}
+	}
+
+	cp, err := r.db.GetCheckpoint(r.job)
+	if err != nil {
+		return nil, fmt.Errorf("load checkpoint error: %w", err)
+	}
+	if cp == nil || restart {
+		cp = &database.Checkpoint{JobName: r.job}
+	}
+	if cp.CompletedAt != nil {
+		slog.InfoContext(ctx, "job already completed, use -restart to run again",
+			"job", r.job, "completed_at", cp.CompletedAt.Format(time.RFC3339))
+		return cp, nil
+	}
+	if cp.Cursor != "" {
+		slog.InfoContext(ctx, "resuming job", "job", r.job, "cursor", cp.Cursor,
+			"processed", cp.Processed, "updated", cp.Updated)
+	}
+	cp.EnrichmentVersion = enrichment.Version
+
+	remaining, err := r.db.CountPostsAfter(cp.Cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	var done int64
+	stopProgress := startProgress("postgres posts", remaining, &done)
+	defer stopProgress()
+
+	for {
+		if err := ctx.Err(); err != nil {
+			slog.WarnContext(ctx, "job stopped, run again to resume", "job", r.job, "cursor", cp.Cursor)
+			return cp, err
+		}
+
+		posts, err := r.db.GetPostsAfter(cp.Cursor, r.batchSize)
+		if err != nil {
+			return cp, fmt.Errorf("read posts error: %w", err)
+		}
+		if len(posts) == 0 {
+			// Batch trước vừa đủ batchSize: đánh dấu hoàn thành ở đây
+			now := time.Now()
+			cp.CompletedAt = &now
+			if !r.dryRun {
+				if err := r.db.SaveEnrichmentBatch(nil, *cp); err != nil {
+					return cp, err
+				}
+			}
+			break
+		}
+
+		changed := make([]models.Post, 0, len(posts))
+		for i := range posts {
+			if r.enricher.Enrich(&posts[i]) {
+				changed = append(changed, posts[i])
+			}
+		}
+
+		next := *cp
+		next.Cursor = posts[len(posts)-1].ID
+		next.Processed += int64(len(posts))
+		next.Updated += int64(len(changed))
+		if len(posts) < r.batchSize {
+			now := time.Now()
+			next.CompletedAt = &now
+		}
+
+		if !r.dryRun {
+			if err := r.db.SaveEnrichmentBatch(changed, next); err != nil {
+				return cp, err
+			}
+		}
+		*cp = next
+		atomic.AddInt64(&done, int64(len(posts)))
+
+		if cp.CompletedAt != nil {
+			break
+		}
+	}
+
+	return cp, nil
+}
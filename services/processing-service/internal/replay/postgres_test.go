@@ -0,0 +1,179 @@
+package replay
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"social-insight/internal/database"
+	"social-insight/internal/enrichment"
+	"social-insight/internal/models"
+	"social-insight/internal/taxonomy"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+)
+
+var checkpointColumns = []string{"cursor", "processed", "updated", "enrichment_version", "completed_at"}
+
+// completedArg khớp completed_at của checkpoint: có giá trị hay NULL
+type completedArg bool
+
+func (c completedArg) Match(v driver.Value) bool {
+	return (v != nil) == bool(c)
+}
+
+func newTestReprocessor(t *testing.T, batchSize int) (*Reprocessor, *enrichment.Enricher, sqlmock.Sqlmock) {
+	t.Helper()
+	tax, err := taxonomy.Load("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	enricher := enrichment.New(tax)
+	return NewReprocessor(database.NewDBFromConn(conn), enricher, "test-job", batchSize), enricher, mock
+}
+
+// postRows trả về rows của GetPostsAfter cho posts p{from}..p{to-1};
+// enriched = true thì posts đã được enrich theo luật hiện tại
+func postRows(t *testing.T, enricher *enrichment.Enricher, from, to int, enriched bool) *sqlmock.Rows {
+	t.Helper()
+	rows := sqlmock.NewRows([]string{"id", "title", "content", "topic", "sentiment", "keywords", "tags", "platform", "topics"})
+	for i := from; i < to; i++ {
+		p := models.Post{ID: fmt.Sprintf("p%02d", i), Title: "Kubernetes outage", Platform: "hackernews"}
+		if enriched {
+			enricher.Enrich(&p)
+		}
+		keywords, err := pq.StringArray(p.Keywords).Value()
+		if err != nil {
+			t.Fatal(err)
+		}
+		topics, err := json.Marshal(p.Topics)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p.Topics == nil {
+			topics = []byte("[]")
+		}
+		rows.AddRow(p.ID, p.Title, p.Content, p.Topic, p.Sentiment, keywords, nil, p.Platform, topics)
+	}
+	return rows
+}
+
+// expectPostsAfter khớp một trang keyset sau cursor
+func expectPostsAfter(mock sqlmock.Sqlmock, cursor string, limit int, rows *sqlmock.Rows) {
+	mock.ExpectQuery("FROM posts\\s+WHERE id > \\$1").WithArgs(cursor, limit).WillReturnRows(rows)
+}
+
+// expectSave khớp SaveEnrichmentBatch: UPDATE posts thay đổi (kèm post_topics
+// và thống kê tác giả) rồi checkpoint, cùng một transaction
+func expectSave(mock sqlmock.Sqlmock, changed bool, cursor string, processed, updated int, completed bool) {
+	mock.ExpectBegin()
+	if changed {
+		for _, query := range []string{"UPDATE posts p", "DELETE FROM post_topics", "INSERT INTO post_topics",
+			"INSERT INTO authors", "DELETE FROM author_topics", "INSERT INTO author_topics",
+			"DELETE FROM author_keywords", "INSERT INTO author_keywords"} {
+			mock.ExpectExec(query).WillReturnResult(sqlmock.NewResult(0, 1))
+		}
+	}
+	mock.ExpectExec("INSERT INTO job_checkpoints").
+		WithArgs("test-job", cursor, processed, updated, enrichment.Version, completedArg(completed)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+}
+
+func TestReprocessResume(t *testing.T) {
+	r, enricher, mock := newTestReprocessor(t, 4)
+
+	// Lần đầu: batch 2 lỗi giữa transaction, checkpoint vẫn ở cuối batch 1
+	mock.ExpectQuery("FROM job_checkpoints").WithArgs("test-job").WillReturnRows(sqlmock.NewRows(checkpointColumns))
+	mock.ExpectQuery("SELECT COUNT").WithArgs("").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(10))
+	expectPostsAfter(mock, "", 4, postRows(t, enricher, 0, 4, false))
+	expectSave(mock, true, "p03", 4, 4, false)
+	expectPostsAfter(mock, "p03", 4, postRows(t, enricher, 4, 8, false))
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE posts p").WillReturnError(errors.New("connection reset"))
+	mock.ExpectRollback()
+
+	cp, err := r.Run(context.Background(), false)
+	if err == nil || cp.Cursor != "p03" || cp.Processed != 4 || cp.CompletedAt != nil {
+		t.Fatalf("failed run: cp %+v, err %v", cp, err)
+	}
+
+	// Chạy lại: đọc checkpoint đã commit, tiếp tục sau p03
+	mock.ExpectQuery("FROM job_checkpoints").WithArgs("test-job").
+		WillReturnRows(sqlmock.NewRows(checkpointColumns).AddRow("p03", 4, 4, enrichment.Version, nil))
+	mock.ExpectQuery("SELECT COUNT").WithArgs("p03").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(6))
+	expectPostsAfter(mock, "p03", 4, postRows(t, enricher, 4, 8, false))
+	expectSave(mock, true, "p07", 8, 8, false)
+	expectPostsAfter(mock, "p07", 4, postRows(t, enricher, 8, 10, false))
+	expectSave(mock, true, "p09", 10, 10, true)
+
+	cp, err = r.Run(context.Background(), false)
+	if err != nil || cp.Cursor != "p09" || cp.Processed != 10 || cp.Updated != 10 || cp.CompletedAt == nil {
+		t.Fatalf("resumed run: cp %+v, err %v", cp, err)
+	}
+
+	// Job đã hoàn thành: không đọc posts nếu không -restart
+	mock.ExpectQuery("FROM job_checkpoints").WithArgs("test-job").
+		WillReturnRows(sqlmock.NewRows(checkpointColumns).AddRow("p09", 10, 10, enrichment.Version, time.Now()))
+	if cp, err := r.Run(context.Background(), false); err != nil || cp.Processed != 10 {
+		t.Errorf("completed run: cp %+v, err %v", cp, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestReprocessRestart(t *testing.T) {
+	r, enricher, mock := newTestReprocessor(t, 4)
+
+	// Restart bỏ checkpoint cũ; posts đã đúng luật nên chỉ lưu checkpoint,
+	// batch chẵn nên trang rỗng cuối cùng đánh dấu hoàn thành
+	mock.ExpectExec("DELETE FROM job_checkpoints").WithArgs("test-job").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("FROM job_checkpoints").WithArgs("test-job").WillReturnRows(sqlmock.NewRows(checkpointColumns))
+	mock.ExpectQuery("SELECT COUNT").WithArgs("").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(8))
+	expectPostsAfter(mock, "", 4, postRows(t, enricher, 0, 4, true))
+	expectSave(mock, false, "p03", 4, 0, false)
+	expectPostsAfter(mock, "p03", 4, postRows(t, enricher, 4, 8, true))
+	expectSave(mock, false, "p07", 8, 0, false)
+	expectPostsAfter(mock, "p07", 4, postRows(t, enricher, 0, 0, true))
+	expectSave(mock, false, "p07", 8, 0, true)
+
+	cp, err := r.Run(context.Background(), true)
+	if err != nil || cp.Processed != 8 || cp.Updated != 0 || cp.CompletedAt == nil {
+		t.Errorf("restart: cp %+v, err %v", cp, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestReprocessDryRun(t *testing.T) {
+	r, enricher, mock := newTestReprocessor(t, 2)
+	r.SetDryRun(true)
+
+	// Dry run chỉ đọc: không xóa checkpoint, không UPDATE, không lưu checkpoint
+	mock.ExpectQuery("FROM job_checkpoints").WithArgs("test-job").WillReturnRows(sqlmock.NewRows(checkpointColumns))
+	mock.ExpectQuery("SELECT COUNT").WithArgs("").WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(5))
+	expectPostsAfter(mock, "", 2, postRows(t, enricher, 0, 2, false))
+	expectPostsAfter(mock, "p01", 2, postRows(t, enricher, 2, 4, false))
+	expectPostsAfter(mock, "p03", 2, postRows(t, enricher, 4, 5, false))
+
+	cp, err := r.Run(context.Background(), true)
+	if err != nil || cp.Processed != 5 || cp.Updated != 5 || cp.CompletedAt == nil {
+		t.Fatalf("dry run: cp %+v, err %v", cp, err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,56 @@
+// =====================================================
+// REPLAY PROGRESS - Log tiến độ định kỳ
+// =====================================================
+
+package replay
+
+import (
+	"log/slog"
+	"sync/atomic"
+	"time"
+)
+
+// startProgress log tiến độ mỗi 5 giây, trả về hàm dừng (log dòng cuối)
+func startProgress(label string, total int64, done *int64) func() {
+	start := time.Now()
+	report := func() {
+		n := atomic.LoadInt64(done)
+		elapsed := time.Since(start).Seconds()
+		rate := 0.0
+		if elapsed > 0 {
+			rate = float64(n) / elapsed
+		}
+		pct := 100.0
+		if total > 0 {
+			pct = float64(n) * 100 / float64(total)
+		}
+		eta := "-"
+		if rate > 0 && total > n {
+			eta = (time.Duration(float64(total-n)/rate) * time.Second).Round(time.Second).String()
+		}
+		slog.Info("replay progress", "label", label, "done", n, "total", total,
+			"percent", pct, "rate_per_sec", rate, "eta", eta)
+	}
+
+	stop := make(chan struct{})
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		ticker := time.NewTicker(5 * time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				report()
+			}
+		}
+	}()
+
+	return func() {
+		close(stop)
+		<-finished
+		report()
+	}
+}
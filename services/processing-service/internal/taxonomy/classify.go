@@ -0,0 +1,139 @@
+// =====================================================
+// CLASSIFY - Gán nhiều topic có điểm cho một post
+// =====================================================
+// Mô tả: Text được tách thành từ (chữ thường, giữ + và # cho c++/c#),
+// luật chỉ khớp trọn từ nên "ai" không khớp "said" hay "email".
+// Mỗi luật được tính một lần cho mỗi post dù xuất hiện nhiều lần
+// =====================================================
+
+package taxonomy
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+
+	"social-insight/internal/models"
+)
+
+// Classify chấm điểm text (tiêu đề + nội dung) và tags của post
+//
+// Điểm của subtopic là tổng trọng số luật/tag khớp; điểm của topic cấp 1
+// cộng thêm điểm các subtopic của nó. Trả về tối đa MaxTopics topic cấp 1
+// có điểm ≥ MinScore (điểm cao trước, phần tử đầu là topic chính), tiếp
+// theo là các subtopic đạt MinScore của chúng. Không topic nào đạt thì
+// trả về Fallback với điểm 0
+func (t *Taxonomy) Classify(text string, tags []string) []models.TopicScore {
+	scores := make([]float64, len(t.nodes))
+
+	words := tokenize(text)
+	matched := make([]bool, len(t.rules))
+	match := func(i, at int) {
+		if !matched[i] && t.rules[i].matchAt(words, at) {
+			matched[i] = true
+			scores[t.rules[i].node] += t.rules[i].weight
+		}
+	}
+	for at, w := range words {
+		for _, i := range t.byWord[w] {
+			match(i, at)
+		}
+		for _, i := range t.prefix {
+			match(i, at)
+		}
+	}
+
+	tagged := make([]bool, len(t.nodes))
+	for _, tag := range tags {
+		for _, node := range t.tags[normalizeTag(tag)] {
+			if !tagged[node] {
+				tagged[node] = true
+				scores[node] += t.TagWeight
+			}
+		}
+	}
+
+	totals := append([]float64(nil), scores...)
+	for i, n := range t.nodes {
+		if n.parent >= 0 {
+			totals[n.parent] += scores[i]
+		}
+	}
+
+	var topics, subtopics []int
+	for i, n := range t.nodes {
+		if n.parent < 0 && totals[i] >= t.MinScore {
+			topics = append(topics, i)
+		}
+	}
+	byScore := func(nodes []int) {
+		sort.SliceStable(nodes, func(a, b int) bool { return totals[nodes[a]] > totals[nodes[b]] })
+	}
+	byScore(topics)
+	if len(topics) > t.MaxTopics {
+		topics = topics[:t.MaxTopics]
+	}
+	if len(topics) == 0 {
+		return []models.TopicScore{{Topic: t.Fallback}}
+	}
+
+	selected := make(map[int]bool, len(topics))
+	for _, i := range topics {
+		selected[i] = true
+	}
+	for i, n := range t.nodes {
+		if n.parent >= 0 && selected[n.parent] && totals[i] >= t.MinScore {
+			subtopics = append(subtopics, i)
+		}
+	}
+	byScore(subtopics)
+
+	result := make([]models.TopicScore, 0, len(topics)+len(subtopics))
+	for _, i := range append(topics, subtopics...) {
+		result = append(result, models.TopicScore{
+			Topic:  t.nodes[i].ID,
+			Parent: t.nodes[i].Parent,
+			Score:  math.Round(totals[i]*100) / 100,
+		})
+	}
+	return result
+}
+
+// matchAt kiểm tra các từ của rule khớp words bắt đầu từ vị trí at
+func (r rule) matchAt(words []string, at int) bool {
+	if at+len(r.words) > len(words) {
+		return false
+	}
+	for i, w := range r.words {
+		if r.prefix[i] {
+			if !strings.HasPrefix(words[at+i], w) {
+				return false
+			}
+		} else if words[at+i] != w {
+			return false
+		}
+	}
+	return true
+}
+
+// tokenize tách text thành từ chữ thường; ký tự không phải chữ/số là dấu
+// phân cách (trừ + và # ở cuối từ: c++, c#). "ci/cd", "node.js" và
+// "zero-day" thành nhiều từ, cả trong text lẫn trong pattern
+func tokenize(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '+' && r != '#'
+	})
+	words := fields[:0]
+	for _, f := range fields {
+		if f = strings.TrimLeft(f, "+#"); f != "" {
+			words = append(words, f)
+		}
+	}
+	return words
+}
+
+// normalizeTag chuẩn hóa tag của nền tảng (#AI, Machine-Learning → ai, machine-learning)
+func normalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(tag), "#"))
+}
@@ -0,0 +1,242 @@
+// =====================================================
+// TAXONOMY - Cây topic cấu hình được cho bước phân loại posts
+// =====================================================
+// Mô tả: Topics, subtopics và luật từ khóa (có trọng số) đọc từ file
+// JSON. File mặc định được embed vào binary; TAXONOMY_FILE cho phép
+// thay taxonomy mà không cần build lại (xem taxonomy.json)
+// =====================================================
+
+package taxonomy
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+//go:embed taxonomy.json
+var defaultTaxonomy []byte
+
+// idPattern là dạng hợp lệ của id topic (khớp tham số topic của API)
+var idPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,31}$`)
+
+// Taxonomy là cây topic cùng luật phân loại
+type Taxonomy struct {
+	// Version tăng mỗi khi đổi luật (lệnh replay dùng để đặt tên job)
+	Version int `json:"version"`
+
+	// Fallback là topic gán cho post không khớp topic nào
+	Fallback string `json:"fallback"`
+
+	// MinScore là điểm tối thiểu để post được gán topic/subtopic
+	MinScore float64 `json:"min_score"`
+
+	// MaxTopics là số topic cấp 1 tối đa của một post
+	MaxTopics int `json:"max_topics"`
+
+	// TagWeight là điểm cộng khi tag của post (Dev.to, Medium) nằm trong
+	// Tags của topic
+	TagWeight float64 `json:"tag_weight"`
+
+	Topics []Topic `json:"topics"`
+
+	nodes  []Node
+	rules  []rule
+	byWord map[string][]int // Từ đầu tiên của pattern → index trong rules
+	prefix []int            // Rules có từ đầu tiên dạng prefix (foo*)
+	tags   map[string][]int // Tag → index trong nodes
+}
+
+// Topic là một topic (hoặc subtopic) trong file taxonomy
+type Topic struct {
+	ID        string   `json:"id"`
+	Name      string   `json:"name"`
+	Tags      []string `json:"tags,omitempty"`
+	Rules     []Rule   `json:"rules,omitempty"`
+	Subtopics []Topic  `json:"subtopics,omitempty"`
+}
+
+// Rule là một luật từ khóa: Pattern là một hoặc nhiều từ khớp trọn từ
+// (không phân biệt hoa thường); từ kết thúc bằng * khớp theo prefix
+type Rule struct {
+	Pattern string  `json:"pattern"`
+	Weight  float64 `json:"weight"`
+}
+
+// Node là một topic đã phẳng hóa (topic cấp 1 có Parent rỗng)
+type Node struct {
+	ID       string
+	Parent   string
+	Name     string
+	Position int // Thứ tự trong file (dashboard hiển thị theo thứ tự này)
+
+	parent int // Index của node cha trong nodes, -1 nếu là topic cấp 1
+}
+
+// rule là Rule đã tách từ
+type rule struct {
+	words  []string
+	prefix []bool
+	weight float64
+	node   int
+}
+
+// Load đọc taxonomy từ path ("" = taxonomy mặc định được embed)
+func Load(path string) (*Taxonomy, error) {
+	data := defaultTaxonomy
+	if path != "" {
+		var err error
+		if data, err = os.ReadFile(path); err != nil {
+			return nil, fmt.Errorf("read taxonomy: %w", err)
+		}
+	}
+	t, err := Parse(data)
+	if err != nil {
+		if path == "" {
+			path = "embedded taxonomy"
+		}
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return t, nil
+}
+
+// Parse đọc taxonomy từ JSON và kiểm tra tính hợp lệ
+func Parse(data []byte) (*Taxonomy, error) {
+	var t Taxonomy
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&t); err != nil {
+		return nil, fmt.Errorf("invalid taxonomy json: %w", err)
+	}
+	if err := t.compile(); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// compile kiểm tra taxonomy và dựng index cho Classify
+func (t *Taxonomy) compile() error {
+	if t.Version < 1 {
+		return fmt.Errorf("version must be at least 1")
+	}
+	if t.MinScore <= 0 {
+		return fmt.Errorf("min_score must be positive")
+	}
+	if t.MaxTopics < 1 {
+		return fmt.Errorf("max_topics must be at least 1")
+	}
+	if t.TagWeight < 0 {
+		return fmt.Errorf("tag_weight must not be negative")
+	}
+	if len(t.Topics) == 0 {
+		return fmt.Errorf("no topics")
+	}
+
+	t.byWord = make(map[string][]int)
+	t.tags = make(map[string][]int)
+	seen := make(map[string]bool)
+	var add func(topic Topic, parent int) error
+	add = func(topic Topic, parent int) error {
+		if !idPattern.MatchString(topic.ID) {
+			return fmt.Errorf("topic id %q must match %s", topic.ID, idPattern)
+		}
+		if seen[topic.ID] {
+			return fmt.Errorf("duplicate topic id %q", topic.ID)
+		}
+		seen[topic.ID] = true
+
+		node := len(t.nodes)
+		n := Node{ID: topic.ID, Name: topic.Name, Position: node, parent: parent}
+		if n.Name == "" {
+			n.Name = topic.ID
+		}
+		if parent >= 0 {
+			n.Parent = t.nodes[parent].ID
+		}
+		t.nodes = append(t.nodes, n)
+
+		for _, tag := range topic.Tags {
+			tag = normalizeTag(tag)
+			if !slices.Contains(t.tags[tag], node) {
+				t.tags[tag] = append(t.tags[tag], node)
+			}
+		}
+		for _, r := range topic.Rules {
+			compiled, err := compileRule(r, node)
+			if err != nil {
+				return fmt.Errorf("topic %s: %w", topic.ID, err)
+			}
+			i := len(t.rules)
+			t.rules = append(t.rules, compiled)
+			if compiled.prefix[0] {
+				t.prefix = append(t.prefix, i)
+			} else {
+				t.byWord[compiled.words[0]] = append(t.byWord[compiled.words[0]], i)
+			}
+		}
+
+		for _, sub := range topic.Subtopics {
+			if parent >= 0 {
+				return fmt.Errorf("topic %s: subtopics can only be nested one level", topic.ID)
+			}
+			if err := add(sub, node); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	for _, topic := range t.Topics {
+		if err := add(topic, -1); err != nil {
+			return err
+		}
+	}
+
+	if fallback, ok := t.Node(t.Fallback); !ok || fallback.Parent != "" {
+		return fmt.Errorf("fallback %q must be a top-level topic", t.Fallback)
+	}
+	return nil
+}
+
+// compileRule tách pattern thành từ giống cách tách text của post
+func compileRule(r Rule, node int) (rule, error) {
+	if r.Weight <= 0 {
+		return rule{}, fmt.Errorf("rule %q: weight must be positive", r.Pattern)
+	}
+	compiled := rule{weight: r.Weight, node: node}
+	fields := strings.Fields(r.Pattern)
+	for i, field := range fields {
+		isPrefix := strings.HasSuffix(field, "*")
+		words := tokenize(strings.TrimSuffix(field, "*"))
+		if len(words) == 0 || (isPrefix && i != len(fields)-1) {
+			return rule{}, fmt.Errorf("rule %q: invalid pattern (* is only allowed at the end)", r.Pattern)
+		}
+		for j, w := range words {
+			compiled.words = append(compiled.words, w)
+			compiled.prefix = append(compiled.prefix, isPrefix && j == len(words)-1)
+		}
+	}
+	if len(compiled.words) == 0 {
+		return rule{}, fmt.Errorf("rule %q: empty pattern", r.Pattern)
+	}
+	return compiled, nil
+}
+
+// Nodes trả về mọi topic và subtopic theo thứ tự trong file
+func (t *Taxonomy) Nodes() []Node {
+	return append([]Node(nil), t.nodes...)
+}
+
+// Node tìm topic theo id
+func (t *Taxonomy) Node(id string) (Node, bool) {
+	for _, n := range t.nodes {
+		if n.ID == id {
+			return n, true
+		}
+	}
+	return Node{}, false
+}
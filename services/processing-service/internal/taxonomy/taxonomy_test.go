@@ -0,0 +1,128 @@
+package taxonomy
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"social-insight/internal/models"
+)
+
+// testTaxonomy là taxonomy nhỏ cho các test phân loại
+const testTaxonomy = `{
+	"version": 1, "fallback": "other", "min_score": 2, "max_topics": 2, "tag_weight": 3,
+	"topics": [
+		{"id": "ai", "tags": ["machine-learning"],
+			"rules": [{"pattern": "ai", "weight": 2}, {"pattern": "machine learning", "weight": 3}],
+			"subtopics": [{"id": "llm", "tags": ["llm"], "rules": [{"pattern": "llm*", "weight": 3}, {"pattern": "gpt*", "weight": 3}]}]},
+		{"id": "devops", "rules": [{"pattern": "ci/cd", "weight": 3}, {"pattern": "kubernetes", "weight": 3}, {"pattern": "deploy*", "weight": 1}]},
+		{"id": "programming", "rules": [{"pattern": "c++", "weight": 3}, {"pattern": "rust", "weight": 3}]},
+		{"id": "other"}
+	]
+}`
+
+func mustParse(t *testing.T, data string) *Taxonomy {
+	t.Helper()
+	tax, err := Parse([]byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tax
+}
+
+// format in kết quả dạng "ai=8 llm<ai=3"
+func format(scores []models.TopicScore) string {
+	parts := make([]string, len(scores))
+	for i, s := range scores {
+		name := s.Topic
+		if s.Parent != "" {
+			name += "<" + s.Parent
+		}
+		parts[i] = name + "=" + strconv.FormatFloat(s.Score, 'f', -1, 64)
+	}
+	return strings.Join(parts, " ")
+}
+
+func TestClassify(t *testing.T) {
+	tax := mustParse(t, testTaxonomy)
+
+	tests := []struct {
+		name string
+		text string
+		tags []string
+		want string
+	}{
+		// Luật khớp trọn từ: "ai" không nằm trong said/email/maintain
+		{"word boundaries", "He said the email was hard to maintain", nil, "other=0"},
+		{"single word", "AI is everywhere", nil, "ai=2"},
+		{"phrase and prefix", "Machine learning with LLMs and GPT-4", nil, "ai=9 llm<ai=6"},
+		// Mỗi luật chỉ tính một lần
+		{"repeated word", "AI, AI and more AI", nil, "ai=2"},
+		{"split pattern", "Our CI/CD pipeline on Kubernetes", nil, "devops=6"},
+		{"plus sign", "Why C++ still matters", nil, "programming=3"},
+		// deploy* chỉ có trọng số 1 < min_score
+		{"below min score", "We deployed on Friday", nil, "other=0"},
+		{"tags", "Weekly reading list", []string{"#Machine-Learning", "llm"}, "ai=6 llm<ai=3"},
+		// max_topics = 2: topic điểm thấp nhất bị bỏ
+		{"multi-label", "Rust and C++ for AI on Kubernetes with CI/CD", nil, "devops=6 programming=6"},
+		{"subtopic only", "GPT-5 released", nil, "ai=3 llm<ai=3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := format(tax.Classify(tt.text, tt.tags)); got != tt.want {
+				t.Errorf("Classify(%q, %v) = %s, want %s", tt.text, tt.tags, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRejectsInvalid(t *testing.T) {
+	tests := map[string]string{
+		"missing fallback":  `{"version": 1, "fallback": "none", "min_score": 1, "max_topics": 1, "topics": [{"id": "ai"}]}`,
+		"subtopic fallback": `{"version": 1, "fallback": "llm", "min_score": 1, "max_topics": 1, "topics": [{"id": "ai", "subtopics": [{"id": "llm"}]}]}`,
+		"duplicate id":      `{"version": 1, "fallback": "ai", "min_score": 1, "max_topics": 1, "topics": [{"id": "ai"}, {"id": "ai"}]}`,
+		"invalid id":        `{"version": 1, "fallback": "ai", "min_score": 1, "max_topics": 1, "topics": [{"id": "AI!"}]}`,
+		"deep nesting":      `{"version": 1, "fallback": "ai", "min_score": 1, "max_topics": 1, "topics": [{"id": "ai", "subtopics": [{"id": "llm", "subtopics": [{"id": "gpt"}]}]}]}`,
+		"star in middle":    `{"version": 1, "fallback": "ai", "min_score": 1, "max_topics": 1, "topics": [{"id": "ai", "rules": [{"pattern": "deep* learning", "weight": 1}]}]}`,
+		"zero weight":       `{"version": 1, "fallback": "ai", "min_score": 1, "max_topics": 1, "topics": [{"id": "ai", "rules": [{"pattern": "ai", "weight": 0}]}]}`,
+		"unknown field":     `{"version": 1, "fallback": "ai", "min_score": 1, "max_topics": 1, "topics": [{"id": "ai", "keywords": ["ai"]}]}`,
+		"no min score":      `{"version": 1, "fallback": "ai", "max_topics": 1, "topics": [{"id": "ai"}]}`,
+	}
+	for name, data := range tests {
+		if _, err := Parse([]byte(data)); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestDefaultTaxonomy(t *testing.T) {
+	tax, err := Load("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]string{
+		"Show HN: I said goodbye to email and maintain a paper journal": "other",
+		"OpenAI announces GPT-5":                                  "ai",
+		"Kubernetes 1.30 release notes":                           "devops",
+		"Rewriting our CLI in Rust":                               "programming",
+		"Critical vulnerability found in OpenSSH (CVE-2024-6387)": "security",
+		"Acme raises $20M Series A to build developer tools":      "startup",
+		"Serverless on AWS Lambda: a retrospective":               "cloud",
+	}
+	for text, want := range tests {
+		if got := tax.Classify(text, nil)[0].Topic; got != want {
+			t.Errorf("Classify(%q) primary = %s, want %s", text, got, want)
+		}
+	}
+
+	var topLevel int
+	for _, n := range tax.Nodes() {
+		if n.Parent == "" {
+			topLevel++
+		}
+	}
+	if topLevel < 2 || tax.Fallback == "" {
+		t.Errorf("default taxonomy: %d top-level topics, fallback %q", topLevel, tax.Fallback)
+	}
+}
@@ -0,0 +1,188 @@
+// =====================================================
+// URL NORMALIZER - Canonical URL cho story clustering
+// =====================================================
+// Mô tả: Chuẩn hóa URL để các bài cùng trỏ về một article
+// trên nhiều nền tảng (HN, Dev.to, Medium) có cùng canonical URL
+// Bỏ: tracking params (utm_*, fbclid...), fragment, AMP cache, www.,
+// trailing slash, default port. Params như ref/source/amp, path /amp và
+// host mobile/AMP (m., amp.) chỉ bỏ với các site trong allowlist siteRules
+// =====================================================
+
+package urlnorm
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// trackingParams là các query params chỉ dùng cho tracking trên mọi site
+var trackingParams = map[string]bool{
+	"fbclid": true, "gclid": true, "dclid": true, "msclkid": true, "yclid": true,
+	"mc_cid": true, "mc_eid": true, "igshid": true, "_hsenc": true, "_hsmi": true,
+	"ref_src": true, "ref_url": true, "referrer": true,
+}
+
+// siteRule là chuẩn hóa chỉ đúng trên một site (và các subdomain của nó):
+// trên site khác ?ref=, ?amp=, path /amp hay host m./amp. có thể là trang khác
+type siteRule struct {
+	params      []string // Query params chỉ dùng cho tracking/AMP trên site này
+	mirrorHosts bool     // m./mobile./amp. là bản mobile/AMP của cùng trang
+	ampPath     bool     // /amp ở đầu hoặc cuối path là bản AMP của cùng trang
+}
+
+// siteRules là allowlist theo domain
+var siteRules = map[string]siteRule{
+	"medium.com":         {params: []string{"source", "sk", "ref"}}, // ?source=rss----..., sk = share key
+	"producthunt.com":    {params: []string{"ref"}},
+	"techcrunch.com":     {ampPath: true, params: []string{"amp"}},
+	"wired.com":          {ampPath: true},
+	"washingtonpost.com": {params: []string{"outputtype"}}, // ?outputType=amp
+	"twitter.com":        {mirrorHosts: true},
+	"x.com":              {mirrorHosts: true},
+	"facebook.com":       {mirrorHosts: true},
+	"youtube.com":        {mirrorHosts: true},
+	"theguardian.com":    {mirrorHosts: true},
+	"bbc.co.uk":          {mirrorHosts: true},
+	"reuters.com":        {mirrorHosts: true},
+}
+
+// mirrorHostPrefixes là các subdomain mobile/AMP bỏ đi khi site có mirrorHosts
+var mirrorHostPrefixes = []string{"m.", "mobile.", "amp."}
+
+// Canonicalize trả về canonical URL của raw
+// Trả về "" và error nếu URL không hợp lệ hoặc không phải http(s)
+func Canonicalize(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return "", fmt.Errorf("empty url")
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", fmt.Errorf("parse url error: %w", err)
+	}
+	scheme := strings.ToLower(u.Scheme)
+	if scheme != "http" && scheme != "https" {
+		return "", fmt.Errorf("unsupported scheme: %q", u.Scheme)
+	}
+	if u.Host == "" {
+		return "", fmt.Errorf("missing host")
+	}
+
+	// AMP cache URLs bọc URL gốc → lấy lại URL gốc rồi chuẩn hóa tiếp
+	if inner, ok := unwrapAMP(u); ok {
+		return Canonicalize(inner)
+	}
+
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	rule := siteRuleFor(host)
+	if rule.mirrorHosts {
+		for _, prefix := range mirrorHostPrefixes {
+			host = strings.TrimPrefix(host, prefix)
+		}
+	}
+	if port := u.Port(); port != "" && port != "80" && port != "443" {
+		host = host + ":" + port
+	}
+
+	path := u.EscapedPath()
+	if rule.ampPath {
+		path = strings.TrimSuffix(path, "/amp")
+		path = strings.TrimSuffix(path, "/amp/")
+		path = strings.TrimPrefix(path, "/amp/")
+	}
+	if path != "" && !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	path = strings.TrimRight(path, "/")
+
+	query := cleanQuery(u.Query(), rule)
+
+	// Scheme luôn là https để http/https cùng một story
+	canonical := "https://" + host + path
+	if query != "" {
+		canonical += "?" + query
+	}
+	return canonical, nil
+}
+
+// siteRuleFor trả về rule của host hoặc domain cha gần nhất có trong siteRules
+func siteRuleFor(host string) siteRule {
+	for h := host; h != ""; {
+		if rule, ok := siteRules[h]; ok {
+			return rule
+		}
+		i := strings.IndexByte(h, '.')
+		if i < 0 {
+			break
+		}
+		h = h[i+1:]
+	}
+	return siteRule{}
+}
+
+// cleanQuery bỏ tracking params (chung và của site) và sắp xếp params còn lại
+func cleanQuery(values url.Values, rule siteRule) string {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		lk := strings.ToLower(k)
+		if strings.HasPrefix(lk, "utm_") || trackingParams[lk] || slices.Contains(rule.params, lk) {
+			continue
+		}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		vals := values[k]
+		sort.Strings(vals)
+		for _, v := range vals {
+			if v == "" {
+				parts = append(parts, url.QueryEscape(k))
+				continue
+			}
+			parts = append(parts, url.QueryEscape(k)+"="+url.QueryEscape(v))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// unwrapAMP lấy URL gốc từ các dạng AMP cache:
+//   - https://www-example-com.cdn.ampproject.org/c/s/www.example.com/path
+//   - https://www.google.com/amp/s/www.example.com/path
+func unwrapAMP(u *url.URL) (string, bool) {
+	host := strings.ToLower(u.Hostname())
+	path := u.Path
+
+	var rest string
+	switch {
+	case strings.HasSuffix(host, ".cdn.ampproject.org"):
+		// /c/s/host/path (https) hoặc /c/host/path (http), có thể có /v/ cho video
+		rest = strings.TrimPrefix(path, "/")
+		for _, p := range []string{"c/", "v/", "i/"} {
+			rest = strings.TrimPrefix(rest, p)
+		}
+	case (host == "google.com" || host == "www.google.com") && strings.HasPrefix(path, "/amp/"):
+		rest = strings.TrimPrefix(path, "/amp/")
+	default:
+		return "", false
+	}
+
+	scheme := "http://"
+	if strings.HasPrefix(rest, "s/") {
+		scheme = "https://"
+		rest = strings.TrimPrefix(rest, "s/")
+	}
+	if rest == "" {
+		return "", false
+	}
+	inner := scheme + rest
+	if u.RawQuery != "" {
+		inner += "?" + u.RawQuery
+	}
+	return inner, true
+}
@@ -0,0 +1,59 @@
+package urlnorm
+
+import "testing"
+
+func TestCanonicalize(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"https", "https://example.com/post", "https://example.com/post"},
+		{"http to https", "http://Example.COM/post/", "https://example.com/post"},
+		{"www", "https://www.example.com/post", "https://example.com/post"},
+		{"default port", "https://example.com:443/post", "https://example.com/post"},
+		{"custom port", "http://example.com:8080/post", "https://example.com:8080/post"},
+		{"fragment", "https://example.com/post#comments", "https://example.com/post"},
+		{"utm", "https://example.com/post?utm_source=hn&utm_medium=rss&fbclid=x", "https://example.com/post"},
+		{"sorted params", "https://example.com/search?q=go&page=2", "https://example.com/search?page=2&q=go"},
+		{"amp cache", "https://www-example-com.cdn.ampproject.org/c/s/www.example.com/post", "https://example.com/post"},
+		{"google amp", "https://www.google.com/amp/s/example.com/post", "https://example.com/post"},
+
+		// ref/source chỉ là tracking trên các site trong allowlist
+		{"medium rss source", "https://medium.com/@a/post-123?source=rss----abc---4", "https://medium.com/@a/post-123"},
+		{"medium subdomain", "https://team.medium.com/post-123?source=rss&sk=abc", "https://team.medium.com/post-123"},
+		{"producthunt ref", "https://www.producthunt.com/posts/tool?ref=hn", "https://producthunt.com/posts/tool"},
+		{"ref elsewhere", "https://github.com/org/repo/compare?ref=main", "https://github.com/org/repo/compare?ref=main"},
+		{"source elsewhere", "https://example.com/download?source=linux", "https://example.com/download?source=linux"},
+
+		// Host mobile/AMP chỉ gộp với bản desktop trên các site trong allowlist
+		{"mobile twitter", "https://mobile.twitter.com/user/status/1", "https://twitter.com/user/status/1"},
+		{"mobile youtube", "https://m.youtube.com/watch?v=abc", "https://youtube.com/watch?v=abc"},
+		{"amp guardian", "https://amp.theguardian.com/technology/story", "https://theguardian.com/technology/story"},
+		{"m. elsewhere", "https://m.example.com/post", "https://m.example.com/post"},
+		{"amp. elsewhere", "https://amp.dev/documentation", "https://amp.dev/documentation"},
+		{"lookalike domain", "https://m.notyoutube.com/watch", "https://m.notyoutube.com/watch"},
+
+		// Path /amp và ?amp= chỉ là bản AMP trên các site trong allowlist
+		{"amp path", "https://techcrunch.com/2026/01/30/story/amp/", "https://techcrunch.com/2026/01/30/story"},
+		{"amp prefix", "https://www.wired.com/amp/story/gadget", "https://wired.com/story/gadget"},
+		{"amp param", "https://techcrunch.com/2026/01/30/story?amp=1", "https://techcrunch.com/2026/01/30/story"},
+		{"outputType", "https://www.washingtonpost.com/tech/2026/01/30/story/?outputType=amp", "https://washingtonpost.com/tech/2026/01/30/story"},
+		{"amp path elsewhere", "https://example.com/docs/amp", "https://example.com/docs/amp"},
+		{"amp prefix elsewhere", "https://example.com/amp/components", "https://example.com/amp/components"},
+		{"amp param elsewhere", "https://example.com/search?amp=1&q=x", "https://example.com/search?amp=1&q=x"},
+		{"outputType elsewhere", "https://example.com/report?outputType=pdf", "https://example.com/report?outputType=pdf"},
+	}
+	for _, tt := range tests {
+		got, err := Canonicalize(tt.in)
+		if err != nil || got != tt.want {
+			t.Errorf("%s: Canonicalize(%q) = %q, %v; want %q", tt.name, tt.in, got, err, tt.want)
+		}
+	}
+
+	for _, bad := range []string{"", "  ", "ftp://example.com/file", "mailto:a@example.com", "https://", "://broken"} {
+		if got, err := Canonicalize(bad); err == nil {
+			t.Errorf("Canonicalize(%q) = %q, expected error", bad, got)
+		}
+	}
+}
@@ -35,11 +35,9 @@ func (v *Validator) ValidatePost(p *models.Post) (bool, []ValidationError) {
 		p.Author = "Anonymous"
 	}
 
-	// Topic normalization: lowercase and ensure it's in known topics
+	// Topic normalization: lowercase only; the consumer classifies the post
+	// against the topic taxonomy (empty is fine)
 	p.Topic = strings.ToLower(strings.TrimSpace(p.Topic))
-	if p.Topic == "" {
-		p.Topic = "programming"
-	}
 
 	// CreatedAt: if zero value, leave as-is (caller may set), but flag
 	if p.CreatedAt.IsZero() {